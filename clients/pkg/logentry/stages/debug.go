@@ -0,0 +1,332 @@
+package stages
+
+import (
+	"context"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"go.uber.org/atomic"
+
+	"github.com/grafana/loki/clients/pkg/promtail/api"
+)
+
+// DebugEntry is a snapshot of an entry going through a pipeline.
+type DebugEntry struct {
+	Labels    model.LabelSet         `json:"labels"`
+	Extracted map[string]interface{} `json:"extracted"`
+	Timestamp time.Time              `json:"timestamp"`
+	Line      string                 `json:"line"`
+}
+
+func newDebugEntry(e Entry) *DebugEntry {
+	extracted := make(map[string]interface{}, len(e.Extracted))
+	for k, v := range e.Extracted {
+		extracted[k] = v
+	}
+	return &DebugEntry{
+		Labels:    e.Labels.Clone(),
+		Extracted: extracted,
+		Timestamp: e.Timestamp,
+		Line:      e.Line,
+	}
+}
+
+// StageTrace is an entry before and after a stage of a pipeline.
+type StageTrace struct {
+	Stage string `json:"stage"`
+	// Before is nil when the entry was created by the stage, like the blocks of the multiline stage.
+	Before *DebugEntry `json:"before,omitempty"`
+	// After is nil when the entry did not leave the stage: it was dropped, or merged into another entry.
+	After *DebugEntry `json:"after,omitempty"`
+	// Diff lists the changes made by the stage.
+	Diff string `json:"diff,omitempty"`
+}
+
+// EntryTrace follows an entry through the stages of a pipeline.
+type EntryTrace struct {
+	// Input is nil when the entry was created by one of the stages.
+	Input  *DebugEntry  `json:"input,omitempty"`
+	Stages []StageTrace `json:"stages"`
+	// Output is nil when the entry did not leave the pipeline.
+	Output *DebugEntry `json:"output,omitempty"`
+}
+
+// last returns the entry as it left the last stage it went through.
+func (t *EntryTrace) last() *DebugEntry {
+	if len(t.Stages) == 0 {
+		return t.Input
+	}
+	return t.Stages[len(t.Stages)-1].After
+}
+
+// TracePipeline creates a pipeline from the configuration and processes the entries with it one stage at a time,
+// recording the entries before and after every stage. The pipeline has its own metrics registry so that it does
+// not affect the metrics of the running pipelines, and the state of its stages, like the blocks of the multiline
+// stage or the limits of the limit stage, starts empty.
+func TracePipeline(logger log.Logger, stgs PipelineStages, jobName *string, entries []api.Entry) ([]EntryTrace, error) {
+	p, err := NewPipeline(logger, stgs, jobName, prometheus.NewRegistry())
+	if err != nil {
+		return nil, err
+	}
+	return p.trace(entries), nil
+}
+
+func (p *Pipeline) trace(entries []api.Entry) []EntryTrace {
+	insp := newInspector(ioutil.Discard, true)
+
+	traces := make([]*EntryTrace, 0, len(entries))
+	current := make([]Entry, 0, len(entries))
+	for _, e := range entries {
+		entry := Entry{
+			Extracted: map[string]interface{}{},
+			Entry: api.Entry{
+				Labels: e.Labels.Clone(),
+				Entry:  e.Entry,
+			},
+		}
+		// Same as Run, the extracted map starts with the labels.
+		for labelName, labelValue := range entry.Labels {
+			entry.Extracted[string(labelName)] = string(labelValue)
+		}
+		traces = append(traces, &EntryTrace{Input: newDebugEntry(entry)})
+		entry.traceID = len(traces)
+		current = append(current, entry)
+	}
+
+	for _, stage := range p.stages {
+		out := runStage(stage, current)
+		left := make(map[int]bool, len(out))
+		for i := range out {
+			st := StageTrace{
+				Stage: stage.Name(),
+				After: newDebugEntry(out[i]),
+			}
+			id := out[i].traceID
+			if id == 0 || left[id] {
+				// The entry was created by the stage.
+				traces = append(traces, &EntryTrace{})
+				id = len(traces)
+				out[i].traceID = id
+			} else {
+				st.Before = traces[id-1].last()
+				st.Diff = strings.TrimSpace(insp.diff(*st.Before, *st.After))
+			}
+			left[id] = true
+			traces[id-1].Stages = append(traces[id-1].Stages, st)
+		}
+		for _, e := range current {
+			if !left[e.traceID] {
+				t := traces[e.traceID-1]
+				t.Stages = append(t.Stages, StageTrace{
+					Stage:  stage.Name(),
+					Before: t.last(),
+				})
+			}
+		}
+		current = out
+	}
+
+	for _, e := range current {
+		t := traces[e.traceID-1]
+		t.Output = t.last()
+	}
+	result := make([]EntryTrace, 0, len(traces))
+	for _, t := range traces {
+		result = append(result, *t)
+	}
+	return result
+}
+
+// runStage processes the entries with the stage and returns the entries it sent, once it is done.
+func runStage(stage Stage, entries []Entry) []Entry {
+	in := make(chan Entry)
+	out := stage.Run(in)
+	go func() {
+		defer close(in)
+		for _, e := range entries {
+			in <- e
+		}
+	}()
+	var result []Entry
+	for e := range out {
+		result = append(result, e)
+	}
+	return result
+}
+
+// debugPipelines are the pipelines wrapping the entry handlers of the targets, by job name. A pipeline is listed
+// once while it wraps at least one handler, so that its entries are sampled once however many handlers it wraps.
+var debugPipelines = struct {
+	sync.Mutex
+	byJob map[string][]*Pipeline
+	wraps map[*Pipeline]int
+}{
+	byJob: map[string][]*Pipeline{},
+	wraps: map[*Pipeline]int{},
+}
+
+func registerDebugPipeline(p *Pipeline) {
+	if p.jobName == nil {
+		return
+	}
+	debugPipelines.Lock()
+	defer debugPipelines.Unlock()
+	debugPipelines.wraps[p]++
+	if debugPipelines.wraps[p] > 1 {
+		return
+	}
+	debugPipelines.byJob[*p.jobName] = append(debugPipelines.byJob[*p.jobName], p)
+}
+
+func unregisterDebugPipeline(p *Pipeline) {
+	if p.jobName == nil {
+		return
+	}
+	debugPipelines.Lock()
+	defer debugPipelines.Unlock()
+	debugPipelines.wraps[p]--
+	if debugPipelines.wraps[p] > 0 {
+		return
+	}
+	delete(debugPipelines.wraps, p)
+	pipelines := debugPipelines.byJob[*p.jobName]
+	for i, other := range pipelines {
+		if other == p {
+			pipelines = append(pipelines[:i], pipelines[i+1:]...)
+			break
+		}
+	}
+	if len(pipelines) == 0 {
+		delete(debugPipelines.byJob, *p.jobName)
+		return
+	}
+	debugPipelines.byJob[*p.jobName] = pipelines
+}
+
+// DebugJobs returns the sorted names of the jobs whose pipelines can be sampled with SamplePipeline.
+func DebugJobs() []string {
+	debugPipelines.Lock()
+	defer debugPipelines.Unlock()
+	jobs := make([]string, 0, len(debugPipelines.byJob))
+	for job := range debugPipelines.byJob {
+		jobs = append(jobs, job)
+	}
+	sort.Strings(jobs)
+	return jobs
+}
+
+// SamplePipeline captures the entries entering the pipelines of the job whose labels match the matchers, until
+// limit entries are captured or the context is done, and returns their traces through a copy of the pipeline of
+// the job, see TracePipeline. The running pipelines process the captured entries as usual.
+func SamplePipeline(ctx context.Context, logger log.Logger, job string, matchers []*labels.Matcher, limit int) ([]EntryTrace, error) {
+	debugPipelines.Lock()
+	pipelines := append([]*Pipeline(nil), debugPipelines.byJob[job]...)
+	debugPipelines.Unlock()
+	if len(pipelines) == 0 {
+		return nil, errors.Errorf("no pipeline found for job %q", job)
+	}
+
+	s := &sampler{
+		matchers: matchers,
+		limit:    limit,
+		full:     make(chan struct{}),
+	}
+	for _, p := range pipelines {
+		p.samplers.add(s)
+	}
+	select {
+	case <-s.full:
+	case <-ctx.Done():
+	}
+	for _, p := range pipelines {
+		p.samplers.remove(s)
+	}
+
+	// All the pipelines of a job share the configuration of the job.
+	return TracePipeline(logger, pipelines[0].config, &job, s.captured())
+}
+
+// samplers are the samplers capturing the entries entering a pipeline.
+type samplers struct {
+	// active is the number of samplers, checked before taking the lock so that pipelines that are not being
+	// debugged don't pay for it.
+	active atomic.Int32
+
+	mtx  sync.Mutex
+	list []*sampler
+}
+
+func (s *samplers) add(sp *sampler) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.list = append(s.list, sp)
+	s.active.Inc()
+}
+
+func (s *samplers) remove(sp *sampler) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	for i, other := range s.list {
+		if other == sp {
+			s.list = append(s.list[:i], s.list[i+1:]...)
+			s.active.Dec()
+			return
+		}
+	}
+}
+
+func (s *samplers) offer(e api.Entry) {
+	if s.active.Load() == 0 {
+		return
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	for _, sp := range s.list {
+		sp.offer(e)
+	}
+}
+
+// sampler captures the first entries whose labels match its matchers.
+type sampler struct {
+	matchers []*labels.Matcher
+	limit    int
+	full     chan struct{}
+
+	mtx     sync.Mutex
+	entries []api.Entry
+}
+
+func (s *sampler) offer(e api.Entry) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if len(s.entries) >= s.limit {
+		return
+	}
+	for _, m := range s.matchers {
+		if !m.Matches(string(e.Labels[model.LabelName(m.Name)])) {
+			return
+		}
+	}
+	// The labels are modified by the stages.
+	s.entries = append(s.entries, api.Entry{
+		Labels: e.Labels.Clone(),
+		Entry:  e.Entry,
+	})
+	if len(s.entries) == s.limit {
+		close(s.full)
+	}
+}
+
+func (s *sampler) captured() []api.Entry {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return append([]api.Entry(nil), s.entries...)
+}
@@ -0,0 +1,182 @@
+package stages
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/clients/pkg/promtail/api"
+	"github.com/grafana/loki/clients/pkg/promtail/client/fake"
+
+	"github.com/grafana/loki/pkg/logproto"
+	util_log "github.com/grafana/loki/pkg/util/log"
+)
+
+var testDebugYaml = `
+pipeline_stages:
+- multiline:
+    firstline: "^\\["
+    max_wait_time: 3s
+- regex:
+    expression: "^\\[(?P<level>\\w+)\\] (?P<message>.*)"
+- labels:
+    level:
+- drop:
+    source: level
+    value: debug
+`
+
+func debugEntry(line string) api.Entry {
+	return api.Entry{
+		Labels: model.LabelSet{"job": "test"},
+		Entry: logproto.Entry{
+			Timestamp: time.Unix(1, 0),
+			Line:      line,
+		},
+	}
+}
+
+func TestTracePipeline(t *testing.T) {
+	traces, err := TracePipeline(util_log.Logger, loadConfig(testDebugYaml), nil, []api.Entry{
+		debugEntry("[info] first"),
+		debugEntry("  continued"),
+		debugEntry("[debug] second"),
+	})
+	require.NoError(t, err)
+	require.Len(t, traces, 5)
+
+	// The input entries don't leave the multiline stage, which creates new entries.
+	for i, line := range []string{"[info] first", "  continued", "[debug] second"} {
+		require.Equal(t, line, traces[i].Input.Line)
+		require.Equal(t, map[string]interface{}{"job": "test"}, traces[i].Input.Extracted)
+		require.Len(t, traces[i].Stages, 1)
+		require.Equal(t, StageTypeMultiline, traces[i].Stages[0].Stage)
+		require.NotNil(t, traces[i].Stages[0].Before)
+		require.Nil(t, traces[i].Stages[0].After)
+		require.Nil(t, traces[i].Output)
+	}
+
+	info := traces[3]
+	require.Nil(t, info.Input)
+	require.Len(t, info.Stages, 4)
+	require.Nil(t, info.Stages[0].Before)
+	require.Equal(t, "[info] first\n  continued", info.Stages[0].After.Line)
+	require.Equal(t, StageTypeRegex, info.Stages[1].Stage)
+	require.Equal(t, "info", info.Stages[1].After.Extracted["level"])
+	require.Contains(t, info.Stages[1].Diff, "info")
+	require.Equal(t, StageTypeLabel, info.Stages[2].Stage)
+	require.Equal(t, model.LabelSet{"job": "test", "level": "info"}, info.Stages[2].After.Labels)
+	require.Equal(t, model.LabelSet{"job": "test"}, info.Stages[2].Before.Labels)
+	require.Equal(t, StageTypeDrop, info.Stages[3].Stage)
+	require.Empty(t, info.Stages[3].Diff)
+	require.Equal(t, info.Stages[3].After, info.Output)
+
+	debug := traces[4]
+	require.Len(t, debug.Stages, 4)
+	require.Equal(t, "[debug] second", debug.Stages[0].After.Line)
+	require.NotNil(t, debug.Stages[3].Before)
+	require.Nil(t, debug.Stages[3].After)
+	require.Nil(t, debug.Output)
+}
+
+func TestTracePipeline_InvalidConfig(t *testing.T) {
+	_, err := TracePipeline(util_log.Logger, loadConfig(`
+pipeline_stages:
+- regex: {}
+`), nil, nil)
+	require.Error(t, err)
+}
+
+func TestSamplePipeline(t *testing.T) {
+	job := "sample_pipeline_test"
+	p, err := NewPipeline(util_log.Logger, loadConfig(testDebugYaml), &job, prometheus.NewRegistry())
+	require.NoError(t, err)
+	c := fake.New(func() {})
+	defer c.Stop()
+	handler := p.Wrap(c)
+
+	require.Contains(t, DebugJobs(), job)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	done := make(chan []EntryTrace)
+	go func() {
+		traces, err := SamplePipeline(ctx, util_log.Logger, job, []*labels.Matcher{
+			labels.MustNewMatcher(labels.MatchEqual, "stream", "stdout"),
+		}, 2)
+		require.NoError(t, err)
+		done <- traces
+	}()
+
+	// Send entries until the sampler is registered and full.
+	var traces []EntryTrace
+	for traces == nil {
+		for _, stream := range []string{"stderr", "stdout"} {
+			e := debugEntry("[info] " + stream)
+			e.Labels["stream"] = model.LabelValue(stream)
+			handler.Chan() <- e
+		}
+		select {
+		case traces = <-done:
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	// The multiline stage creates an entry per sampled line, the last one when the sampled entries end.
+	require.Len(t, traces, 4)
+	require.Equal(t, "[info] stdout", traces[2].Output.Line)
+	require.Equal(t, model.LabelValue("stdout"), traces[2].Output.Labels["stream"])
+	require.Equal(t, "[info] stdout", traces[3].Output.Line)
+
+	handler.Stop()
+	require.NotContains(t, DebugJobs(), job)
+
+	_, err = SamplePipeline(ctx, util_log.Logger, job, nil, 1)
+	require.Error(t, err)
+}
+
+func TestSamplePipeline_SeveralWraps(t *testing.T) {
+	job := "sample_pipeline_wraps_test"
+	p, err := NewPipeline(util_log.Logger, loadConfig(testDebugYaml), &job, prometheus.NewRegistry())
+	require.NoError(t, err)
+	c := fake.New(func() {})
+	defer c.Stop()
+
+	// The pipeline wraps a handler per target, like the partitions of the kafka target, and is listed once.
+	first, second := p.Wrap(c), p.Wrap(c)
+	debugPipelines.Lock()
+	require.Equal(t, []*Pipeline{p}, debugPipelines.byJob[job])
+	debugPipelines.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	done := make(chan []EntryTrace)
+	go func() {
+		traces, err := SamplePipeline(ctx, util_log.Logger, job, nil, 2)
+		require.NoError(t, err)
+		done <- traces
+	}()
+
+	// Every entry is captured once, whichever handler it goes through.
+	var traces []EntryTrace
+	for i := 0; traces == nil; i++ {
+		first.Chan() <- debugEntry(fmt.Sprintf("[info] first %d", i))
+		second.Chan() <- debugEntry(fmt.Sprintf("[info] second %d", i))
+		select {
+		case traces = <-done:
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	require.NotEqual(t, traces[0].Input.Line, traces[1].Input.Line)
+
+	first.Stop()
+	require.Contains(t, DebugJobs(), job)
+	second.Stop()
+	require.NotContains(t, DebugJobs(), job)
+}
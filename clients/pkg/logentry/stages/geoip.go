@@ -0,0 +1,346 @@
+package stages
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/mitchellh/mapstructure"
+	"github.com/oschwald/maxminddb-golang"
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+)
+
+const (
+	ErrEmptyGeoIPStageConfig           = "geoip stage config cannot be empty"
+	ErrGeoIPStageEmptyDB               = "geoip stage `db` is required"
+	ErrGeoIPStageEmptySource           = "geoip stage `source` is required"
+	ErrGeoIPStageInvalidReloadInterval = "geoip stage `reload_interval` parse error: %v"
+	ErrGeoIPStageCouldNotOpenDB        = "geoip stage could not open the database: %v"
+)
+
+const defaultGeoIPReloadInterval = time.Minute
+
+// defaultGeoIPFields are the fields extracted when none are configured, mapping the names in the extracted data
+// to the paths of the values in the records of the GeoIP2 and GeoLite2 City, Country and ASN databases. Fields
+// missing from the record of an IP are not extracted.
+var defaultGeoIPFields = map[string]string{
+	"geoip_country_code":                   "country.iso_code",
+	"geoip_country_name":                   "country.names.en",
+	"geoip_continent_code":                 "continent.code",
+	"geoip_city_name":                      "city.names.en",
+	"geoip_subdivision_code":               "subdivisions.0.iso_code",
+	"geoip_location_latitude":              "location.latitude",
+	"geoip_location_longitude":             "location.longitude",
+	"geoip_timezone":                       "location.time_zone",
+	"geoip_postal_code":                    "postal.code",
+	"geoip_autonomous_system_number":       "autonomous_system_number",
+	"geoip_autonomous_system_organization": "autonomous_system_organization",
+}
+
+// GeoIPConfig contains the configuration for a geoipStage
+type GeoIPConfig struct {
+	DB             string            `mapstructure:"db"`
+	Source         *string           `mapstructure:"source"`
+	Fields         map[string]string `mapstructure:"fields"`
+	ReloadInterval *string           `mapstructure:"reload_interval"`
+	reloadInterval time.Duration
+}
+
+// validateGeoIPConfig validates the config and sets the defaults.
+func validateGeoIPConfig(c *GeoIPConfig) error {
+	if c == nil {
+		return errors.New(ErrEmptyGeoIPStageConfig)
+	}
+	if c.DB == "" {
+		return errors.New(ErrGeoIPStageEmptyDB)
+	}
+	if c.Source == nil || *c.Source == "" {
+		return errors.New(ErrGeoIPStageEmptySource)
+	}
+	if len(c.Fields) == 0 {
+		c.Fields = defaultGeoIPFields
+	}
+	c.reloadInterval = defaultGeoIPReloadInterval
+	if c.ReloadInterval != nil {
+		d, err := time.ParseDuration(*c.ReloadInterval)
+		if err != nil {
+			return errors.Errorf(ErrGeoIPStageInvalidReloadInterval, err)
+		}
+		c.reloadInterval = d
+	}
+	return nil
+}
+
+// geoipStage looks up the IP of the extracted data in a MaxMind database and extracts the fields of its record.
+type geoipStage struct {
+	cfg    *GeoIPConfig
+	logger log.Logger
+	// recordType is the struct type the records are decoded into, holding only the configured fields.
+	recordType reflect.Type
+
+	mtx       sync.RWMutex
+	reader    *maxminddb.Reader
+	modTime   time.Time
+	size      int64
+	lastCheck time.Time
+	reloading bool
+}
+
+// newGeoIPStage creates a new geoip stage.
+func newGeoIPStage(logger log.Logger, config interface{}) (Stage, error) {
+	cfg := &GeoIPConfig{}
+	if err := mapstructure.WeakDecode(config, cfg); err != nil {
+		return nil, err
+	}
+	if err := validateGeoIPConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	s := &geoipStage{
+		cfg:        cfg,
+		logger:     log.With(logger, "component", "stage", "type", "geoip"),
+		recordType: geoipRecordType(cfg.Fields),
+	}
+	fi, err := os.Stat(cfg.DB)
+	if err != nil {
+		return nil, errors.Errorf(ErrGeoIPStageCouldNotOpenDB, err)
+	}
+	reader, err := openGeoIPDB(cfg.DB)
+	if err != nil {
+		return nil, errors.Errorf(ErrGeoIPStageCouldNotOpenDB, err)
+	}
+	s.reader, s.modTime, s.size, s.lastCheck = reader, fi.ModTime(), fi.Size(), time.Now()
+
+	return toStage(s), nil
+}
+
+// Process implements Stage
+func (s *geoipStage) Process(labels model.LabelSet, extracted map[string]interface{}, t *time.Time, entry *string) {
+	s.maybeReload()
+
+	value, ok := extracted[*s.cfg.Source]
+	if !ok {
+		if Debug {
+			level.Debug(s.logger).Log("msg", "source does not exist in the extracted data", "source", *s.cfg.Source)
+		}
+		return
+	}
+	str, err := getString(value)
+	if err != nil {
+		if Debug {
+			level.Debug(s.logger).Log("msg", "failed to convert source value to string", "source", *s.cfg.Source, "err", err, "type", reflect.TypeOf(value))
+		}
+		return
+	}
+	ip := net.ParseIP(str)
+	if ip == nil {
+		if Debug {
+			level.Debug(s.logger).Log("msg", "source value is not an ip address", "source", *s.cfg.Source, "value", str)
+		}
+		return
+	}
+
+	s.mtx.RLock()
+	reader := s.reader
+	s.mtx.RUnlock()
+
+	// The fields missing from the record, or of an IP which is not in the database, are left empty.
+	record := reflect.New(s.recordType)
+	if err := reader.Lookup(ip, record.Interface()); err != nil {
+		if Debug {
+			level.Debug(s.logger).Log("msg", "failed to look up ip address", "ip", str, "err", err)
+		}
+		return
+	}
+
+	for name, path := range s.cfg.Fields {
+		if v, ok := geoipValue(record.Elem(), strings.Split(path, ".")); ok {
+			extracted[name] = v
+		}
+	}
+}
+
+// maybeReload checks, at most every reload interval, whether the database file changed, in which case it is
+// reloaded in the background.
+func (s *geoipStage) maybeReload() {
+	if s.cfg.reloadInterval <= 0 {
+		return
+	}
+	now := time.Now()
+
+	s.mtx.Lock()
+	if s.reloading || now.Sub(s.lastCheck) < s.cfg.reloadInterval {
+		s.mtx.Unlock()
+		return
+	}
+	s.lastCheck = now
+	s.mtx.Unlock()
+
+	fi, err := os.Stat(s.cfg.DB)
+	if err != nil {
+		level.Warn(s.logger).Log("msg", "failed to check the geoip database for changes", "db", s.cfg.DB, "err", err)
+		return
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if fi.ModTime().Equal(s.modTime) && fi.Size() == s.size {
+		return
+	}
+	s.reloading = true
+	go s.reload(fi)
+}
+
+func (s *geoipStage) reload(fi os.FileInfo) {
+	reader, err := openGeoIPDB(s.cfg.DB)
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.reloading = false
+	if err != nil {
+		// The database is tried again at the next check, as it may have been in the middle of being written.
+		level.Error(s.logger).Log("msg", "failed to reload the geoip database", "db", s.cfg.DB, "err", err)
+		return
+	}
+	s.reader, s.modTime, s.size = reader, fi.ModTime(), fi.Size()
+	level.Info(s.logger).Log("msg", "reloaded the geoip database", "db", s.cfg.DB)
+}
+
+// Name implements Stage
+func (s *geoipStage) Name() string {
+	return StageTypeGeoIP
+}
+
+// openGeoIPDB reads the database in memory rather than mapping it, so that it can be replaced while being used.
+func openGeoIPDB(path string) (*maxminddb.Reader, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return maxminddb.FromBytes(buf)
+}
+
+// geoipPath is a node of the tree of the paths of the configured fields.
+type geoipPath struct {
+	// keys are the children looked up by name, elem the child looked up by index.
+	keys map[string]*geoipPath
+	elem *geoipPath
+	// leaf is set when a field ends at the node.
+	leaf bool
+}
+
+func (p *geoipPath) add(path []string) {
+	if len(path) == 0 {
+		p.leaf = true
+		return
+	}
+	var child *geoipPath
+	if _, err := strconv.Atoi(path[0]); err == nil {
+		if p.elem == nil {
+			p.elem = &geoipPath{}
+		}
+		child = p.elem
+	} else {
+		if p.keys == nil {
+			p.keys = map[string]*geoipPath{}
+		}
+		if child = p.keys[path[0]]; child == nil {
+			child = &geoipPath{}
+			p.keys[path[0]] = child
+		}
+	}
+	child.add(path[1:])
+}
+
+// decodeType returns the type the value at the node is decoded into: a struct with a field per key, a slice of the
+// element, or an empty interface for the values extracted as they are.
+func (p *geoipPath) decodeType() reflect.Type {
+	switch {
+	case p.leaf || (p.elem != nil && len(p.keys) > 0):
+		return reflect.TypeOf((*interface{})(nil)).Elem()
+	case p.elem != nil:
+		return reflect.SliceOf(p.elem.decodeType())
+	}
+	keys := make([]string, 0, len(p.keys))
+	for k := range p.keys {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	fields := make([]reflect.StructField, 0, len(keys))
+	for i, k := range keys {
+		fields = append(fields, reflect.StructField{
+			Name: fmt.Sprintf("F%d", i),
+			Type: p.keys[k].decodeType(),
+			Tag:  reflect.StructTag(fmt.Sprintf("maxminddb:%q", k)),
+		})
+	}
+	return reflect.StructOf(fields)
+}
+
+// geoipRecordType returns the struct type decoding only the values of the fields from the records, so that the
+// rest of the records is skipped.
+func geoipRecordType(fields map[string]string) reflect.Type {
+	root := &geoipPath{}
+	for _, path := range fields {
+		root.add(strings.Split(path, "."))
+	}
+	if root.leaf || root.elem != nil {
+		// The records are maps, a field can't be the whole record or an index.
+		root.leaf, root.elem = false, nil
+	}
+	return root.decodeType()
+}
+
+// geoipValue returns the scalar value at the path of a decoded record, if any.
+func geoipValue(v reflect.Value, path []string) (interface{}, bool) {
+	for _, key := range path {
+		for v.Kind() == reflect.Interface && !v.IsNil() {
+			v = v.Elem()
+		}
+		switch v.Kind() {
+		case reflect.Struct:
+			found := false
+			for i := 0; i < v.NumField(); i++ {
+				if v.Type().Field(i).Tag.Get("maxminddb") == key {
+					v, found = v.Field(i), true
+					break
+				}
+			}
+			if !found {
+				return nil, false
+			}
+		case reflect.Map:
+			v = v.MapIndex(reflect.ValueOf(key))
+		case reflect.Slice:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= v.Len() {
+				return nil, false
+			}
+			v = v.Index(i)
+		default:
+			return nil, false
+		}
+		if !v.IsValid() {
+			return nil, false
+		}
+	}
+	for v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Invalid, reflect.Interface, reflect.Map, reflect.Slice, reflect.Struct, reflect.Ptr:
+		// Only scalar values can be extracted.
+		return nil, false
+	}
+	return v.Interface(), true
+}
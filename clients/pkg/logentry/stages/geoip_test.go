@@ -0,0 +1,119 @@
+package stages
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	util_log "github.com/grafana/loki/pkg/util/log"
+)
+
+var testGeoIPYaml = `
+pipeline_stages:
+- json:
+    expressions:
+      client_ip: client.ip
+- geoip:
+    db: %s
+    source: client_ip
+- labels:
+    geoip_country_code:
+    geoip_autonomous_system_number:
+`
+
+var testGeoIPLogLine = `{"client":{"ip":"1.2.3.4"},"msg":"hello"}`
+
+// copyGeoIPDB copies the test database of the country, holding a City record for 1.2.3.0/24, to the path.
+func copyGeoIPDB(t *testing.T, path string, country string) {
+	t.Helper()
+	buf, err := ioutil.ReadFile(filepath.Join("testdata", "geoip-"+country+".mmdb"))
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(path, buf, 0644))
+}
+
+func TestGeoIPPipeline(t *testing.T) {
+	db := filepath.Join(t.TempDir(), "GeoLite2-City.mmdb")
+	copyGeoIPDB(t, db, "fr")
+
+	pl, err := NewPipeline(util_log.Logger, loadConfig(fmt.Sprintf(testGeoIPYaml, db)), nil, prometheus.DefaultRegisterer)
+	require.NoError(t, err)
+
+	out := processEntries(pl, newEntry(nil, nil, testGeoIPLogLine, time.Now()))[0]
+	require.Equal(t, model.LabelSet{
+		"geoip_country_code":             "FR",
+		"geoip_autonomous_system_number": "64496",
+	}, out.Labels)
+	require.Equal(t, "France", out.Extracted["geoip_country_name"])
+	require.Equal(t, "Paris", out.Extracted["geoip_city_name"])
+	require.Equal(t, "IDF", out.Extracted["geoip_subdivision_code"])
+	require.Equal(t, 48.85, out.Extracted["geoip_location_latitude"])
+	require.NotContains(t, out.Extracted, "geoip_postal_code")
+}
+
+func TestGeoIPStage_Process(t *testing.T) {
+	db := filepath.Join(t.TempDir(), "GeoLite2-City.mmdb")
+	copyGeoIPDB(t, db, "fr")
+
+	source := "ip"
+	reloadInterval := "1ns"
+	st, err := newGeoIPStage(util_log.Logger, &GeoIPConfig{
+		DB:             db,
+		Source:         &source,
+		Fields:         map[string]string{"country": "country.iso_code", "names": "country.names"},
+		ReloadInterval: &reloadInterval,
+	})
+	require.NoError(t, err)
+	s := st.(*stageProcessor).Processor.(*geoipStage)
+
+	extracted := map[string]interface{}{"ip": "1.2.3.4"}
+	s.Process(model.LabelSet{}, extracted, nil, nil)
+	require.Equal(t, map[string]interface{}{"ip": "1.2.3.4", "country": "FR"}, extracted)
+
+	// unknown and invalid addresses are ignored.
+	for _, ip := range []string{"8.8.8.8", "not an ip"} {
+		extracted = map[string]interface{}{"ip": ip}
+		s.Process(model.LabelSet{}, extracted, nil, nil)
+		require.Equal(t, map[string]interface{}{"ip": ip}, extracted)
+	}
+
+	// the database is reloaded when the file changes.
+	copyGeoIPDB(t, db, "de")
+	require.NoError(t, os.Chtimes(db, time.Now().Add(time.Minute), time.Now().Add(time.Minute)))
+	require.Eventually(t, func() bool {
+		extracted = map[string]interface{}{"ip": "1.2.3.4"}
+		s.Process(model.LabelSet{}, extracted, nil, nil)
+		return extracted["country"] == "DE"
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestGeoIPStage_Validation(t *testing.T) {
+	source := "ip"
+	invalid := "abc"
+	for name, tc := range map[string]struct {
+		config *GeoIPConfig
+		err    string
+	}{
+		"nil config":       {config: nil, err: ErrEmptyGeoIPStageConfig},
+		"missing db":       {config: &GeoIPConfig{Source: &source}, err: ErrGeoIPStageEmptyDB},
+		"missing source":   {config: &GeoIPConfig{DB: "db.mmdb"}, err: ErrGeoIPStageEmptySource},
+		"invalid interval": {config: &GeoIPConfig{DB: "db.mmdb", Source: &source, ReloadInterval: &invalid}, err: "geoip stage `reload_interval` parse error"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := validateGeoIPConfig(tc.config)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.err)
+		})
+	}
+
+	cfg := &GeoIPConfig{DB: "db.mmdb", Source: &source}
+	require.NoError(t, validateGeoIPConfig(cfg))
+	require.Equal(t, defaultGeoIPFields, cfg.Fields)
+	require.Equal(t, defaultGeoIPReloadInterval, cfg.reloadInterval)
+}
@@ -52,13 +52,7 @@ func (i inspector) inspect(stageName string, before *Entry, after Entry) {
 		return
 	}
 
-	r := diffReporter{
-		formatter: i.formatter,
-	}
-
-	cmp.Equal(*before, after, cmp.Reporter(&r))
-
-	diff := r.String()
+	diff := i.diff(*before, after)
 	if strings.TrimSpace(diff) == "" {
 		diff = i.formatter.red.Sprintf("none")
 	}
@@ -66,6 +60,23 @@ func (i inspector) inspect(stageName string, before *Entry, after Entry) {
 	fmt.Fprintf(i.writer, "[inspect: %s stage]: %s\n", i.formatter.bold.Sprintf("%s", stageName), diff)
 }
 
+// ignoreTraceID ignores the unexported field identifying the traced entries.
+var ignoreTraceID = cmp.FilterPath(func(p cmp.Path) bool {
+	sf, ok := p.Last().(cmp.StructField)
+	return ok && sf.Name() == "traceID"
+}, cmp.Ignore())
+
+// diff returns the differences between the values.
+func (i inspector) diff(before, after interface{}) string {
+	r := diffReporter{
+		formatter: i.formatter,
+	}
+
+	cmp.Equal(before, after, cmp.Reporter(&r), ignoreTraceID)
+
+	return r.String()
+}
+
 // diffReporter is a simple custom reporter that only records differences
 // detected during comparison.
 type diffReporter struct {
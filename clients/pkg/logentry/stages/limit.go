@@ -0,0 +1,164 @@
+package stages
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"golang.org/x/time/rate"
+)
+
+const (
+	ErrLimitStageInvalidRate      = "limit stage failed to parse rate, the rate must be greater than 0, received %f"
+	ErrLimitStageInvalidBurst     = "limit stage failed to parse burst, the burst must be greater than 0, received %d"
+	ErrLimitStageConflictingKeys  = "limit stage config error, `by_label_name` and `by_stream` cannot both be defined at the same time"
+	ErrLimitStageInvalidMaxLabels = "limit stage failed to parse max_distinct_labels, the value must be greater than 0, received %d"
+
+	defaultLimitMaxDistinctLabels = 10000
+)
+
+var (
+	defaultLimitDropReason = "ratelimit_drop_stage"
+)
+
+// LimitConfig contains the configuration for a limitStage
+type LimitConfig struct {
+	Rate              float64 `mapstructure:"rate"`
+	Burst             int     `mapstructure:"burst"`
+	Drop              bool    `mapstructure:"drop"`
+	ByLabelName       string  `mapstructure:"by_label_name"`
+	ByStream          bool    `mapstructure:"by_stream"`
+	MaxDistinctLabels int     `mapstructure:"max_distinct_labels"`
+	DropReason        *string `mapstructure:"drop_counter_reason"`
+}
+
+// validateLimitConfig validates the LimitConfig for the limitStage
+func validateLimitConfig(cfg *LimitConfig) error {
+	if cfg.Rate <= 0 {
+		return errors.Errorf(ErrLimitStageInvalidRate, cfg.Rate)
+	}
+	if cfg.Burst <= 0 {
+		return errors.Errorf(ErrLimitStageInvalidBurst, cfg.Burst)
+	}
+	if cfg.ByLabelName != "" && cfg.ByStream {
+		return errors.New(ErrLimitStageConflictingKeys)
+	}
+	if cfg.MaxDistinctLabels < 0 {
+		return errors.Errorf(ErrLimitStageInvalidMaxLabels, cfg.MaxDistinctLabels)
+	}
+	if cfg.MaxDistinctLabels == 0 {
+		cfg.MaxDistinctLabels = defaultLimitMaxDistinctLabels
+	}
+	if cfg.DropReason == nil || *cfg.DropReason == "" {
+		cfg.DropReason = &defaultLimitDropReason
+	}
+	return nil
+}
+
+// newLimitStage creates a LimitStage from config
+func newLimitStage(logger log.Logger, config interface{}, registerer prometheus.Registerer) (Stage, error) {
+	cfg := &LimitConfig{}
+	err := mapstructure.WeakDecode(config, cfg)
+	if err != nil {
+		return nil, err
+	}
+	err = validateLimitConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &limitStage{
+		logger:    log.With(logger, "component", "stage", "type", "limit"),
+		cfg:       cfg,
+		dropCount: getDropCountMetric(registerer),
+		limiters:  map[string]*rate.Limiter{},
+	}, nil
+}
+
+// limitStage rate limits the log lines with a token bucket, either for all the lines, per stream or per value of
+// a label. Lines over the limit are either dropped or wait for the bucket to refill.
+type limitStage struct {
+	logger    log.Logger
+	cfg       *LimitConfig
+	dropCount *prometheus.CounterVec
+	// limiters are only used by the goroutine of Run.
+	limiters map[string]*rate.Limiter
+}
+
+func (m *limitStage) Run(in chan Entry) chan Entry {
+	out := make(chan Entry)
+	go func() {
+		defer close(out)
+		for e := range in {
+			limiter := m.limiter(e)
+			if !m.cfg.Drop {
+				// Wait never fails with a background context, as the burst is positive.
+				_ = limiter.Wait(context.Background())
+				out <- e
+				continue
+			}
+			if limiter.Allow() {
+				out <- e
+				continue
+			}
+			if Debug {
+				level.Debug(m.logger).Log("msg", "line over the rate limit will be dropped")
+			}
+			m.dropCount.WithLabelValues(*m.cfg.DropReason).Inc()
+		}
+	}()
+	return out
+}
+
+// limiter returns the token bucket of the entry.
+func (m *limitStage) limiter(e Entry) *rate.Limiter {
+	key := m.key(e)
+	limiter, ok := m.limiters[key]
+	if ok {
+		return limiter
+	}
+
+	if len(m.limiters) >= m.cfg.MaxDistinctLabels {
+		// Forget all the buckets rather than tracking an unbounded number of them.
+		level.Warn(m.logger).Log("msg", "too many distinct rate limited keys, resetting the rate limits", "max_distinct_labels", m.cfg.MaxDistinctLabels)
+		m.limiters = map[string]*rate.Limiter{}
+	}
+	limiter = rate.NewLimiter(rate.Limit(m.cfg.Rate), m.cfg.Burst)
+	m.limiters[key] = limiter
+	return limiter
+}
+
+// key returns the key of the token bucket of the entry. When limiting by label, the value is read from the labels,
+// then from the extracted data, and entries without the label share a single bucket.
+func (m *limitStage) key(e Entry) string {
+	switch {
+	case m.cfg.ByStream:
+		return e.Labels.String()
+	case m.cfg.ByLabelName != "":
+		if v, ok := e.Labels[model.LabelName(m.cfg.ByLabelName)]; ok {
+			return string(v)
+		}
+		if v, ok := e.Extracted[m.cfg.ByLabelName]; ok {
+			s, err := getString(v)
+			if err == nil {
+				return s
+			}
+			if Debug {
+				level.Debug(m.logger).Log("msg", "failed to convert extracted value to string", "err", err, "type", reflect.TypeOf(v))
+			}
+		}
+		return ""
+	default:
+		return ""
+	}
+}
+
+// Name implements Stage
+func (m *limitStage) Name() string {
+	return StageTypeLimit
+}
@@ -0,0 +1,109 @@
+package stages
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	util_log "github.com/grafana/loki/pkg/util/log"
+)
+
+var testLimitYaml = `
+pipeline_stages:
+- json:
+    expressions:
+      app:
+- limit:
+    rate: 0.001
+    burst: 2
+    by_label_name: app
+    drop: true
+`
+
+func TestLimitPipeline(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testLimitYaml), nil, registry)
+	require.NoError(t, err)
+
+	var entries []Entry
+	for i := 0; i < 5; i++ {
+		entries = append(entries,
+			newEntry(nil, nil, `{"app":"app1"}`, time.Now()),
+			newEntry(nil, nil, `{"app":"app2"}`, time.Now()),
+		)
+	}
+	out := processEntries(pl, entries...)
+
+	// Each app has its own bucket of 2 lines.
+	require.Len(t, out, 4)
+	dropped := testutil.ToFloat64(getDropCountMetric(registry).WithLabelValues(defaultLimitDropReason))
+	require.Equal(t, float64(6), dropped)
+}
+
+func Test_limitStage_ByStream(t *testing.T) {
+	s, err := newLimitStage(util_log.Logger, &LimitConfig{Rate: 0.001, Burst: 1, ByStream: true, Drop: true}, prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	out := processEntries(s,
+		newEntry(nil, model.LabelSet{"stream": "a"}, "1", time.Now()),
+		newEntry(nil, model.LabelSet{"stream": "b"}, "2", time.Now()),
+		newEntry(nil, model.LabelSet{"stream": "a"}, "3", time.Now()),
+	)
+	require.Len(t, out, 2)
+	require.Equal(t, "1", out[0].Line)
+	require.Equal(t, "2", out[1].Line)
+}
+
+func Test_limitStage_Block(t *testing.T) {
+	s, err := newLimitStage(util_log.Logger, &LimitConfig{Rate: 20, Burst: 1}, prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	start := time.Now()
+	out := processEntries(s,
+		newEntry(nil, nil, "1", time.Now()),
+		newEntry(nil, nil, "2", time.Now()),
+		newEntry(nil, nil, "3", time.Now()),
+	)
+
+	// No line is dropped, but the last ones wait for the bucket to refill.
+	require.Len(t, out, 3)
+	require.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+}
+
+func Test_limitStage_MaxDistinctLabels(t *testing.T) {
+	st, err := newLimitStage(util_log.Logger, &LimitConfig{Rate: 1, Burst: 1, ByLabelName: "app", MaxDistinctLabels: 2, Drop: true}, prometheus.NewRegistry())
+	require.NoError(t, err)
+	s := st.(*limitStage)
+
+	for i := 0; i < 5; i++ {
+		s.limiter(newEntry(nil, model.LabelSet{"app": model.LabelValue(fmt.Sprint(i))}, "", time.Now()))
+		require.LessOrEqual(t, len(s.limiters), 2)
+	}
+}
+
+func Test_validateLimitConfig(t *testing.T) {
+	for name, tc := range map[string]struct {
+		config  *LimitConfig
+		wantErr error
+	}{
+		"invalid rate":      {config: &LimitConfig{Burst: 1}, wantErr: fmt.Errorf(ErrLimitStageInvalidRate, 0.0)},
+		"invalid burst":     {config: &LimitConfig{Rate: 1}, wantErr: fmt.Errorf(ErrLimitStageInvalidBurst, 0)},
+		"conflicting keys":  {config: &LimitConfig{Rate: 1, Burst: 1, ByStream: true, ByLabelName: "app"}, wantErr: errors.New(ErrLimitStageConflictingKeys)},
+		"invalid max label": {config: &LimitConfig{Rate: 1, Burst: 1, MaxDistinctLabels: -1}, wantErr: fmt.Errorf(ErrLimitStageInvalidMaxLabels, -1)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.wantErr.Error(), validateLimitConfig(tc.config).Error())
+		})
+	}
+
+	cfg := &LimitConfig{Rate: 1, Burst: 1}
+	require.NoError(t, validateLimitConfig(cfg))
+	require.Equal(t, defaultLimitMaxDistinctLabels, cfg.MaxDistinctLabels)
+	require.Equal(t, defaultLimitDropReason, *cfg.DropReason)
+}
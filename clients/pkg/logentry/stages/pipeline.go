@@ -28,6 +28,8 @@ type Pipeline struct {
 	stages    []Stage
 	jobName   *string
 	dropCount *prometheus.CounterVec
+	config    PipelineStages
+	samplers  samplers
 }
 
 // NewPipeline creates a new log entry pipeline from a configuration
@@ -59,6 +61,7 @@ func NewPipeline(logger log.Logger, stgs PipelineStages, jobName *string, regist
 		stages:    st,
 		jobName:   jobName,
 		dropCount: getDropCountMetric(registerer),
+		config:    stgs,
 	}, nil
 }
 
@@ -103,6 +106,7 @@ func (p *Pipeline) Wrap(next api.EntryHandler) api.EntryHandler {
 	wg, once := sync.WaitGroup{}, sync.Once{}
 	pipelineIn := make(chan Entry)
 	pipelineOut := p.Run(pipelineIn)
+	registerDebugPipeline(p)
 	wg.Add(2)
 	go func() {
 		defer wg.Done()
@@ -124,6 +128,7 @@ func (p *Pipeline) Wrap(next api.EntryHandler) api.EntryHandler {
 		defer wg.Done()
 		defer close(pipelineIn)
 		for e := range handlerIn {
+			p.samplers.offer(e)
 			pipelineIn <- Entry{
 				Extracted: map[string]interface{}{},
 				Entry:     e,
@@ -131,7 +136,10 @@ func (p *Pipeline) Wrap(next api.EntryHandler) api.EntryHandler {
 		}
 	}()
 	return api.NewEntryHandler(handlerIn, func() {
-		once.Do(func() { close(handlerIn) })
+		once.Do(func() {
+			unregisterDebugPipeline(p)
+			close(handlerIn)
+		})
 		wg.Wait()
 	})
 }
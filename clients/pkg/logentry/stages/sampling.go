@@ -0,0 +1,101 @@
+package stages
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	ErrSamplingStageInvalidRate = "sampling stage failed to parse rate, the rate must be between 0.0 and 1.0, received %f"
+)
+
+var (
+	defaultSamplingDropReason = "sampling_stage"
+)
+
+// SamplingConfig contains the configuration for a samplingStage
+type SamplingConfig struct {
+	DropReason   *string `mapstructure:"drop_counter_reason"`
+	SamplingRate float64 `mapstructure:"rate"`
+}
+
+// validateSamplingConfig validates the SamplingConfig for the samplingStage
+func validateSamplingConfig(cfg *SamplingConfig) error {
+	if cfg.DropReason == nil || *cfg.DropReason == "" {
+		cfg.DropReason = &defaultSamplingDropReason
+	}
+	if cfg.SamplingRate < 0.0 || cfg.SamplingRate > 1.0 {
+		return errors.Errorf(ErrSamplingStageInvalidRate, cfg.SamplingRate)
+	}
+	return nil
+}
+
+// newSamplingStage creates a SamplingStage from config
+func newSamplingStage(logger log.Logger, config interface{}, registerer prometheus.Registerer) (Stage, error) {
+	cfg := &SamplingConfig{}
+	err := mapstructure.WeakDecode(config, cfg)
+	if err != nil {
+		return nil, err
+	}
+	err = validateSamplingConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &samplingStage{
+		logger:    log.With(logger, "component", "stage", "type", "sampling"),
+		cfg:       cfg,
+		dropCount: getDropCountMetric(registerer),
+		source:    rand.New(rand.NewSource(time.Now().UnixNano())),
+	}, nil
+}
+
+// samplingStage keeps a random sample of the log lines, dropping the others
+type samplingStage struct {
+	logger    log.Logger
+	cfg       *SamplingConfig
+	dropCount *prometheus.CounterVec
+	// source is only used by the goroutine of Run, as rand.Rand is not safe for concurrent use.
+	source *rand.Rand
+}
+
+func (m *samplingStage) Run(in chan Entry) chan Entry {
+	out := make(chan Entry)
+	go func() {
+		defer close(out)
+		for e := range in {
+			if m.isSampled() {
+				out <- e
+				continue
+			}
+			if Debug {
+				level.Debug(m.logger).Log("msg", "line was not sampled and will be dropped")
+			}
+			m.dropCount.WithLabelValues(*m.cfg.DropReason).Inc()
+		}
+	}()
+	return out
+}
+
+// isSampled returns true if the line is kept.
+func (m *samplingStage) isSampled() bool {
+	switch m.cfg.SamplingRate {
+	case 0:
+		return false
+	case 1:
+		return true
+	default:
+		return m.source.Float64() < m.cfg.SamplingRate
+	}
+}
+
+// Name implements Stage
+func (m *samplingStage) Name() string {
+	return StageTypeSampling
+}
@@ -0,0 +1,65 @@
+package stages
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	util_log "github.com/grafana/loki/pkg/util/log"
+)
+
+var testSamplingYaml = `
+pipeline_stages:
+- sampling:
+    rate: 0.5
+    drop_counter_reason: debug_sampling
+`
+
+func TestSamplingPipeline(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testSamplingYaml), nil, registry)
+	require.NoError(t, err)
+
+	entries := make([]Entry, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		entries = append(entries, newEntry(nil, nil, fmt.Sprintf("line %d", i), time.Now()))
+	}
+	out := processEntries(pl, entries...)
+
+	// The sample is random, but very unlikely to be this far from the rate.
+	require.InDelta(t, 500, len(out), 100)
+	dropped := testutil.ToFloat64(getDropCountMetric(registry).WithLabelValues("debug_sampling"))
+	require.Equal(t, float64(1000-len(out)), dropped)
+}
+
+func Test_samplingStage_Rates(t *testing.T) {
+	for _, tc := range []struct {
+		rate     float64
+		expected int
+	}{
+		{rate: 0, expected: 0},
+		{rate: 1, expected: 100},
+	} {
+		s, err := newSamplingStage(util_log.Logger, map[string]interface{}{"rate": tc.rate}, prometheus.NewRegistry())
+		require.NoError(t, err)
+		entries := make([]Entry, 0, 100)
+		for i := 0; i < 100; i++ {
+			entries = append(entries, newEntry(nil, nil, "line", time.Now()))
+		}
+		require.Len(t, processEntries(s, entries...), tc.expected)
+	}
+}
+
+func Test_validateSamplingConfig(t *testing.T) {
+	err := validateSamplingConfig(&SamplingConfig{SamplingRate: 1.5})
+	require.Equal(t, errors.New(fmt.Sprintf(ErrSamplingStageInvalidRate, 1.5)).Error(), err.Error())
+
+	cfg := &SamplingConfig{SamplingRate: 0.1}
+	require.NoError(t, validateSamplingConfig(cfg))
+	require.Equal(t, defaultSamplingDropReason, *cfg.DropReason)
+}
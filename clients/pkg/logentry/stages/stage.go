@@ -35,6 +35,9 @@ const (
 	StageTypePack         = "pack"
 	StageTypeLabelAllow   = "labelallow"
 	StageTypeStaticLabels = "static_labels"
+	StageTypeGeoIP        = "geoip"
+	StageTypeSampling     = "sampling"
+	StageTypeLimit        = "limit"
 )
 
 // Processor takes an existing set of labels, timestamp and log entry and returns either a possibly mutated
@@ -47,6 +50,9 @@ type Processor interface {
 type Entry struct {
 	Extracted map[string]interface{}
 	api.Entry
+
+	// traceID identifies the entry while a pipeline is traced, see TracePipeline.
+	traceID int
 }
 
 // Stage can receive entries via an inbound channel and forward mutated entries to an outbound channel.
@@ -203,6 +209,21 @@ func New(logger log.Logger, jobName *string, stageType string,
 		if err != nil {
 			return nil, err
 		}
+	case StageTypeGeoIP:
+		s, err = newGeoIPStage(logger, cfg)
+		if err != nil {
+			return nil, err
+		}
+	case StageTypeSampling:
+		s, err = newSamplingStage(logger, cfg, registerer)
+		if err != nil {
+			return nil, err
+		}
+	case StageTypeLimit:
+		s, err = newLimitStage(logger, cfg, registerer)
+		if err != nil {
+			return nil, err
+		}
 	default:
 		return nil, errors.Errorf("Unknown stage type: %s", stageType)
 	}
@@ -11,6 +11,7 @@ import (
 	"github.com/prometheus/common/model"
 
 	"github.com/grafana/loki/clients/pkg/promtail/api"
+	"github.com/grafana/loki/clients/pkg/promtail/wal"
 
 	"github.com/grafana/loki/pkg/logproto"
 )
@@ -23,6 +24,9 @@ type batch struct {
 	streams   map[string]*logproto.Stream
 	bytes     int
 	createdAt time.Time
+
+	// walStart is the position in the write-ahead log of the first entry of the batch.
+	walStart wal.Position
 }
 
 func newBatch(entries ...api.Entry) *batch {
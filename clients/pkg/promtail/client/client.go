@@ -6,6 +6,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"hash/fnv"
 	"io"
 	"net/http"
 	"strconv"
@@ -22,6 +23,7 @@ import (
 
 	"github.com/grafana/loki/clients/pkg/logentry/metric"
 	"github.com/grafana/loki/clients/pkg/promtail/api"
+	"github.com/grafana/loki/clients/pkg/promtail/wal"
 
 	lokiutil "github.com/grafana/loki/pkg/util"
 	"github.com/grafana/loki/pkg/util/build"
@@ -153,6 +155,19 @@ type client struct {
 	// ctx is used in any upstream calls from the `client`.
 	ctx    context.Context
 	cancel context.CancelFunc
+
+	// stopCtx is cancelled when the client is stopped. With a write-ahead log, it ends the retries of the batches,
+	// whose entries are kept in the write-ahead log to be sent again after a restart.
+	stopCtx context.Context
+	stop    context.CancelFunc
+
+	// walReader is set when the entries are read from the write-ahead log instead of the entries channel.
+	walReader *wal.Reader
+	records   chan wal.Record
+	// unsent is the position of the first batch read from the write-ahead log which could not be sent, set when
+	// hasUnsent is: the write-ahead log is not committed past it.
+	unsent    wal.Position
+	hasUnsent bool
 }
 
 // Tripperware can wrap a roundtripper.
@@ -160,15 +175,34 @@ type Tripperware func(http.RoundTripper) http.RoundTripper
 
 // New makes a new Client.
 func New(reg prometheus.Registerer, cfg Config, logger log.Logger) (Client, error) {
-	return newClient(reg, cfg, logger)
+	return newClient(reg, cfg, logger, nil)
 }
 
-func newClient(reg prometheus.Registerer, cfg Config, logger log.Logger) (*client, error) {
+// newWALClient makes a new client sending the entries read from the write-ahead log.
+// The position of the entries in the write-ahead log is committed once they have been sent, or dropped after all retries.
+func newWALClient(reg prometheus.Registerer, cfg Config, logger log.Logger, w *wal.WAL) (*client, error) {
+	walReader, err := w.NewReader(walReaderName(cfg))
+	if err != nil {
+		return nil, err
+	}
+	return newClient(reg, cfg, logger, walReader)
+}
+
+// walReaderName returns the name under which the write-ahead log position of the client is persisted.
+func walReaderName(cfg Config) string {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(cfg.URL.String()))
+	_, _ = h.Write([]byte(cfg.TenantID))
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+func newClient(reg prometheus.Registerer, cfg Config, logger log.Logger, walReader *wal.Reader) (*client, error) {
 	if cfg.URL.URL == nil {
 		return nil, errors.New("client needs target URL")
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
+	stopCtx, stop := context.WithCancel(ctx)
 
 	c := &client{
 		logger:  log.With(logger, "component", "client", "host", cfg.URL.Host),
@@ -179,6 +213,10 @@ func newClient(reg prometheus.Registerer, cfg Config, logger log.Logger) (*clien
 		externalLabels: cfg.ExternalLabels.LabelSet,
 		ctx:            ctx,
 		cancel:         cancel,
+		stopCtx:        stopCtx,
+		stop:           stop,
+
+		walReader: walReader,
 	}
 
 	err := cfg.Client.Validate()
@@ -199,6 +237,12 @@ func newClient(reg prometheus.Registerer, cfg Config, logger log.Logger) (*clien
 		counter.WithLabelValues(c.cfg.URL.Host).Add(0)
 	}
 
+	if c.walReader != nil {
+		c.records = make(chan wal.Record)
+		c.wg.Add(1)
+		go c.readWAL()
+	}
+
 	c.wg.Add(1)
 	go c.run()
 	return c, nil
@@ -206,7 +250,7 @@ func newClient(reg prometheus.Registerer, cfg Config, logger log.Logger) (*clien
 
 // NewWithTripperware creates a new Loki client with a custom tripperware.
 func NewWithTripperware(reg prometheus.Registerer, cfg Config, logger log.Logger, tp Tripperware) (Client, error) {
-	c, err := newClient(reg, cfg, logger)
+	c, err := newClient(reg, cfg, logger, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -235,45 +279,48 @@ func (c *client) run() {
 
 	maxWaitCheck := time.NewTicker(maxWaitCheckFrequency)
 
+	// When reading from the write-ahead log, the entries channel is not used and lastRead is the position
+	// following the last record read.
+	entries := c.entries
+	var lastRead wal.Position
+	if c.walReader != nil {
+		entries = nil
+	}
+
 	defer func() {
 		maxWaitCheck.Stop()
-		// Send all pending batches
+		// Send all pending batches, the ones which could not be sent are kept in the write-ahead log.
+		unsent := map[string]*batch{}
 		for tenantID, batch := range batches {
-			c.sendBatch(tenantID, batch)
+			if c.hasUnsent || !c.sendBatch(tenantID, batch) {
+				unsent[tenantID] = batch
+			}
 		}
+		c.commitWAL(unsent, lastRead)
 
 		c.wg.Done()
 	}()
 
 	for {
 		select {
-		case e, ok := <-c.entries:
+		case e, ok := <-entries:
 			if !ok {
 				return
 			}
-			e, tenantID := c.processEntry(e)
-			batch, ok := batches[tenantID]
+			c.addEntry(batches, e, wal.Position{})
 
-			// If the batch doesn't exist yet, we create a new one with the entry
+		case r, ok := <-c.records:
 			if !ok {
-				batches[tenantID] = newBatch(e)
-				break
+				return
 			}
-
-			// If adding the entry to the batch will increase the size over the max
-			// size allowed, we do send the current batch and then create a new one
-			if batch.sizeBytesAfter(e) > c.cfg.BatchSize {
-				c.sendBatch(tenantID, batch)
-
-				batches[tenantID] = newBatch(e)
-				break
+			lastRead = r.End
+			if c.addEntry(batches, r.Entry, r.Start) {
+				c.commitWAL(batches, lastRead)
 			}
 
-			// The max size of the batch isn't reached, so we can add the entry
-			batch.add(e)
-
 		case <-maxWaitCheck.C:
 			// Send all batches whose max wait time has been reached
+			sent := false
 			for tenantID, batch := range batches {
 				if batch.age() < c.cfg.BatchWait {
 					continue
@@ -281,8 +328,99 @@ func (c *client) run() {
 
 				c.sendBatch(tenantID, batch)
 				delete(batches, tenantID)
+				sent = true
+			}
+			if sent {
+				c.commitWAL(batches, lastRead)
+			}
+		}
+
+		// When stopping while Loki is unavailable, the entries left in the write-ahead log are not read anymore,
+		// they are sent after a restart.
+		if c.hasUnsent && c.stopCtx.Err() != nil {
+			return
+		}
+	}
+}
+
+// addEntry adds the entry to the batch of its tenant, and returns true if a batch has been sent to make room for it.
+// walStart is the position of the entry in the write-ahead log, if any.
+func (c *client) addEntry(batches map[string]*batch, e api.Entry, walStart wal.Position) bool {
+	e, tenantID := c.processEntry(e)
+	batch, ok := batches[tenantID]
+
+	// If the batch doesn't exist yet, we create a new one with the entry
+	if !ok {
+		batches[tenantID] = newBatch(e)
+		batches[tenantID].walStart = walStart
+		return false
+	}
+
+	// If adding the entry to the batch will increase the size over the max
+	// size allowed, we do send the current batch and then create a new one
+	if batch.sizeBytesAfter(e) > c.cfg.BatchSize {
+		c.sendBatch(tenantID, batch)
+
+		batches[tenantID] = newBatch(e)
+		batches[tenantID].walStart = walStart
+		return true
+	}
+
+	// The max size of the batch isn't reached, so we can add the entry
+	batch.add(e)
+	return false
+}
+
+// readWAL feeds the run loop with the records of the write-ahead log, until it is closed or the client is stopped.
+func (c *client) readWAL() {
+	defer func() {
+		c.walReader.Close()
+		close(c.records)
+		c.wg.Done()
+	}()
+
+	for {
+		r, err := c.walReader.Next(c.ctx)
+		switch {
+		case err == wal.ErrClosed || c.ctx.Err() != nil:
+			return
+		case err != nil:
+			level.Error(c.logger).Log("msg", "error reading write-ahead log, will retry", "error", err)
+			select {
+			case <-time.After(time.Second):
+				continue
+			case <-c.ctx.Done():
+				return
 			}
 		}
+
+		select {
+		case c.records <- r:
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+// commitWAL commits the position of the write-ahead log up to which the entries have been sent, which is before
+// the first entry of the pending batches and of the batches which could not be sent.
+func (c *client) commitWAL(pending map[string]*batch, lastRead wal.Position) {
+	// When the client is stopped without retries, the entries which could not be sent are read again after a restart.
+	if c.walReader == nil || c.ctx.Err() != nil {
+		return
+	}
+
+	pos := lastRead
+	if c.hasUnsent && c.unsent.Before(pos) {
+		pos = c.unsent
+	}
+	for _, batch := range pending {
+		if batch.walStart.Before(pos) {
+			pos = batch.walStart
+		}
+	}
+	if err := c.walReader.Commit(pos); err != nil {
+		level.Error(c.logger).Log("msg", "error committing write-ahead log position", "error", err)
 	}
 }
 
@@ -290,16 +428,28 @@ func (c *client) Chan() chan<- api.Entry {
 	return c.entries
 }
 
-func (c *client) sendBatch(tenantID string, batch *batch) {
+// sendBatch sends the batch, retrying according to the backoff config. It returns false if the batch could not be
+// encoded, or sent because Loki is unavailable, in which case its entries are dropped unless they are in the
+// write-ahead log: the write-ahead log is then not committed past them, for them to be sent again after a restart.
+// With a write-ahead log, the batch is retried until Loki is available again, unless the client is stopping.
+func (c *client) sendBatch(tenantID string, batch *batch) bool {
 	buf, entriesCount, err := batch.encode()
 	if err != nil {
 		level.Error(c.logger).Log("msg", "error encoding batch", "error", err)
-		return
+		_, entriesCount := batch.createPushRequest()
+		c.metrics.droppedBytes.WithLabelValues(c.cfg.URL.Host).Add(float64(batch.sizeBytes()))
+		c.metrics.droppedEntries.WithLabelValues(c.cfg.URL.Host).Add(float64(entriesCount))
+		c.keepUnsent(batch)
+		return false
 	}
 	bufBytes := float64(len(buf))
 	c.metrics.encodedBytes.WithLabelValues(c.cfg.URL.Host).Add(bufBytes)
 
-	backoff := backoff.New(c.ctx, c.cfg.BackoffConfig)
+	ctx := c.ctx
+	if c.walReader != nil {
+		ctx = c.stopCtx
+	}
+	backoff := backoff.New(ctx, c.cfg.BackoffConfig)
 	var status int
 	for {
 		start := time.Now()
@@ -316,7 +466,7 @@ func (c *client) sendBatch(tenantID string, batch *batch) {
 				if err != nil {
 					// is this possible?
 					level.Warn(c.logger).Log("msg", "error converting stream label string to label.Labels, cannot update lagging metric", "error", err)
-					return
+					return true
 				}
 				var lblSet model.LabelSet
 				for i := range lbls {
@@ -338,7 +488,7 @@ func (c *client) sendBatch(tenantID string, batch *batch) {
 					c.metrics.streamLag.With(lblSet).Set(time.Since(s.Entries[len(s.Entries)-1].Timestamp).Seconds())
 				}
 			}
-			return
+			return true
 		}
 
 		// Only retry 429s, 500s and connection-level errors.
@@ -352,14 +502,35 @@ func (c *client) sendBatch(tenantID string, batch *batch) {
 
 		// Make sure it sends at least once before checking for retry.
 		if !backoff.Ongoing() {
+			if c.walReader != nil && c.stopCtx.Err() == nil {
+				backoff.Reset()
+				continue
+			}
 			break
 		}
 	}
 
-	if err != nil {
-		level.Error(c.logger).Log("msg", "final error sending batch", "status", status, "error", err)
-		c.metrics.droppedBytes.WithLabelValues(c.cfg.URL.Host).Add(bufBytes)
-		c.metrics.droppedEntries.WithLabelValues(c.cfg.URL.Host).Add(float64(entriesCount))
+	retriable := status <= 0 || status == 429 || status/100 == 5
+	if c.walReader != nil && retriable {
+		level.Error(c.logger).Log("msg", "final error sending batch, its entries are kept in the write-ahead log", "status", status, "error", err)
+		c.keepUnsent(batch)
+		return false
+	}
+
+	level.Error(c.logger).Log("msg", "final error sending batch", "status", status, "error", err)
+	c.metrics.droppedBytes.WithLabelValues(c.cfg.URL.Host).Add(bufBytes)
+	c.metrics.droppedEntries.WithLabelValues(c.cfg.URL.Host).Add(float64(entriesCount))
+	return !retriable
+}
+
+// keepUnsent records that the batch could not be sent, so that the write-ahead log is not committed past it.
+func (c *client) keepUnsent(batch *batch) {
+	if c.walReader == nil {
+		return
+	}
+	if !c.hasUnsent || batch.walStart.Before(c.unsent) {
+		c.unsent = batch.walStart
+		c.hasUnsent = true
 	}
 }
 
@@ -414,8 +585,13 @@ func (c *client) getTenantID(labels model.LabelSet) string {
 }
 
 // Stop the client.
+// When reading from the write-ahead log, the client stops once the write-ahead log is closed and all its entries
+// have been sent, or once a batch could not be sent without retries: the entries left are sent after a restart.
 func (c *client) Stop() {
-	c.once.Do(func() { close(c.entries) })
+	c.once.Do(func() {
+		c.stop()
+		close(c.entries)
+	})
 	c.wg.Wait()
 }
 
@@ -1,12 +1,14 @@
 package client
 
 import (
+	"context"
 	"io"
 	"math"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -21,6 +23,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/loki/clients/pkg/promtail/api"
+	"github.com/grafana/loki/clients/pkg/promtail/wal"
 
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/loki/pkg/util"
@@ -493,3 +496,124 @@ func Test_Tripperware(t *testing.T) {
 	c.Stop()
 	require.True(t, called)
 }
+
+func TestClient_WAL(t *testing.T) {
+	// The server is unavailable for longer than the backoff config allows.
+	var reqs int32
+	receivedReqsChan := make(chan receivedReq, 10)
+	handler := createServerHandler(receivedReqsChan, 200)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(&reqs, 1) <= 5 {
+			rw.WriteHeader(500)
+			return
+		}
+		handler(rw, req)
+	}))
+	defer server.Close()
+
+	serverURL := flagext.URLValue{}
+	require.NoError(t, serverURL.Set(server.URL))
+	cfg := Config{
+		URL:           serverURL,
+		BatchWait:     10 * time.Millisecond,
+		BatchSize:     10 << 20,
+		BackoffConfig: backoff.Config{MinBackoff: 1 * time.Millisecond, MaxBackoff: 2 * time.Millisecond, MaxRetries: 1},
+		Timeout:       1 * time.Second,
+	}
+	walCfg := wal.Config{Enabled: true, Dir: t.TempDir(), MaxSegmentSize: 1 << 20}
+
+	w, err := wal.New(walCfg, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	reg := prometheus.NewRegistry()
+	c, err := NewMultiWithWAL(reg, log.NewNopLogger(), w, cfg)
+	require.NoError(t, err)
+
+	for _, e := range logEntries[:3] {
+		c.Chan() <- e
+	}
+
+	var received []logproto.Entry
+	for len(received) < 3 {
+		select {
+		case req := <-receivedReqsChan:
+			for _, s := range req.pushReq.Streams {
+				received = append(received, s.Entries...)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("expected 3 entries, received %d", len(received))
+		}
+	}
+	c.Stop()
+	require.Equal(t, []logproto.Entry{logEntries[0].Entry, logEntries[1].Entry, logEntries[2].Entry}, received)
+	require.Equal(t, 0.0, testutil.ToFloat64(c.(*MultiClient).clients[0].(*client).metrics.droppedEntries.WithLabelValues(serverURL.Host)))
+
+	// The sent entries have been committed, so they are not read again after a restart.
+	w, err = wal.New(walCfg, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	defer w.Close()
+	r, err := w.NewReader(walReaderName(cfg))
+	require.NoError(t, err)
+	defer r.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = r.Next(ctx)
+	require.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestClient_WALStopWhileUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(503)
+	}))
+	defer server.Close()
+
+	serverURL := flagext.URLValue{}
+	require.NoError(t, serverURL.Set(server.URL))
+	cfg := Config{
+		URL:           serverURL,
+		BatchWait:     10 * time.Millisecond,
+		BatchSize:     10 << 20,
+		BackoffConfig: backoff.Config{MinBackoff: 1 * time.Millisecond, MaxBackoff: 2 * time.Millisecond, MaxRetries: 1},
+		Timeout:       1 * time.Second,
+	}
+	walCfg := wal.Config{Enabled: true, Dir: t.TempDir(), MaxSegmentSize: 1 << 20}
+
+	w, err := wal.New(walCfg, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	c, err := NewMultiWithWAL(prometheus.NewRegistry(), log.NewNopLogger(), w, cfg)
+	require.NoError(t, err)
+	for _, e := range logEntries[:3] {
+		c.Chan() <- e
+	}
+	// Let the batch be retried for a while.
+	time.Sleep(50 * time.Millisecond)
+
+	// The client stops retrying when stopped, and the entries are kept in the write-ahead log.
+	stopped := make(chan struct{})
+	go func() {
+		c.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the client did not stop while Loki is unavailable")
+	}
+	require.Equal(t, 0.0, testutil.ToFloat64(c.(*MultiClient).clients[0].(*client).metrics.droppedEntries.WithLabelValues(serverURL.Host)))
+
+	// The entries are read again after a restart.
+	w, err = wal.New(walCfg, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	defer w.Close()
+	r, err := w.NewReader(walReaderName(cfg))
+	require.NoError(t, err)
+	defer r.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for _, e := range logEntries[:3] {
+		rec, err := r.Next(ctx)
+		require.NoError(t, err)
+		require.Equal(t, e.Line, rec.Entry.Line)
+	}
+}
@@ -5,9 +5,11 @@ import (
 	"sync"
 
 	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/grafana/loki/clients/pkg/promtail/api"
+	"github.com/grafana/loki/clients/pkg/promtail/wal"
 )
 
 // MultiClient is client pushing to one or more loki instances.
@@ -15,19 +17,41 @@ type MultiClient struct {
 	clients []Client
 	entries chan api.Entry
 	wg      sync.WaitGroup
+	logger  log.Logger
+
+	// wal is set when the entries are written to the write-ahead log, from which each client reads them.
+	wal *wal.WAL
 
 	once sync.Once
 }
 
 // NewMulti creates a new client
 func NewMulti(reg prometheus.Registerer, logger log.Logger, cfgs ...Config) (Client, error) {
+	return newMulti(reg, logger, nil, cfgs...)
+}
+
+// NewMultiWithWAL creates a new client writing the entries to the write-ahead log, from which each client reads
+// them. The write-ahead log is closed when the client is stopped.
+func NewMultiWithWAL(reg prometheus.Registerer, logger log.Logger, w *wal.WAL, cfgs ...Config) (Client, error) {
+	return newMulti(reg, logger, w, cfgs...)
+}
+
+func newMulti(reg prometheus.Registerer, logger log.Logger, w *wal.WAL, cfgs ...Config) (Client, error) {
 	if len(cfgs) == 0 {
 		return nil, errors.New("at least one client config should be provided")
 	}
 
 	clients := make([]Client, 0, len(cfgs))
 	for _, cfg := range cfgs {
-		client, err := New(reg, cfg, logger)
+		var (
+			client Client
+			err    error
+		)
+		if w != nil {
+			client, err = newWALClient(reg, cfg, logger, w)
+		} else {
+			client, err = New(reg, cfg, logger)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -36,6 +60,8 @@ func NewMulti(reg prometheus.Registerer, logger log.Logger, cfgs ...Config) (Cli
 	multi := &MultiClient{
 		clients: clients,
 		entries: make(chan api.Entry),
+		logger:  logger,
+		wal:     w,
 	}
 	multi.start()
 	return multi, nil
@@ -46,6 +72,12 @@ func (m *MultiClient) start() {
 	go func() {
 		defer m.wg.Done()
 		for e := range m.entries {
+			if m.wal != nil {
+				if err := m.wal.Write(e); err != nil {
+					level.Error(m.logger).Log("msg", "error writing entry to the write-ahead log", "error", err)
+				}
+				continue
+			}
 			for _, c := range m.clients {
 				c.Chan() <- e
 			}
@@ -61,6 +93,8 @@ func (m *MultiClient) Chan() chan<- api.Entry {
 func (m *MultiClient) Stop() {
 	m.once.Do(func() { close(m.entries) })
 	m.wg.Wait()
+	// the clients stop once they have sent all the entries of the closed write-ahead log.
+	m.closeWAL()
 	for _, c := range m.clients {
 		c.Stop()
 	}
@@ -71,4 +105,14 @@ func (m *MultiClient) StopNow() {
 	for _, c := range m.clients {
 		c.StopNow()
 	}
+	m.closeWAL()
+}
+
+func (m *MultiClient) closeWAL() {
+	if m.wal == nil {
+		return
+	}
+	if err := m.wal.Close(); err != nil {
+		level.Error(m.logger).Log("msg", "error closing the write-ahead log", "error", err)
+	}
 }
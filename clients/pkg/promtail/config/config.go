@@ -12,6 +12,7 @@ import (
 	"github.com/grafana/loki/clients/pkg/promtail/scrapeconfig"
 	"github.com/grafana/loki/clients/pkg/promtail/server"
 	"github.com/grafana/loki/clients/pkg/promtail/targets/file"
+	"github.com/grafana/loki/clients/pkg/promtail/wal"
 
 	"github.com/grafana/loki/pkg/util/flagext"
 )
@@ -26,6 +27,7 @@ type Config struct {
 	ScrapeConfig    []scrapeconfig.Config `yaml:"scrape_configs,omitempty"`
 	TargetConfig    file.Config           `yaml:"target_config,omitempty"`
 	LimitConfig     limit.Config          `yaml:"limit_config,omitempty"`
+	WALConfig       wal.Config            `yaml:"wal,omitempty"`
 }
 
 // RegisterFlags with prefix registers flags where every name is prefixed by
@@ -36,6 +38,7 @@ func (c *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
 	c.PositionsConfig.RegisterFlagsWithPrefix(prefix, f)
 	c.TargetConfig.RegisterFlagsWithPrefix(prefix, f)
 	c.LimitConfig.RegisterFlagsWithPrefix(prefix, f)
+	c.WALConfig.RegisterFlagsWithPrefix(prefix, f)
 }
 
 // RegisterFlags registers flags.
@@ -0,0 +1,329 @@
+package positions
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"go.etcd.io/bbolt"
+)
+
+const (
+	// migratedSuffix is appended to the name of a positions file once it has been migrated to the database.
+	migratedSuffix = ".migrated"
+	// openTimeout is how long to wait for the lock of a database held by another process.
+	openTimeout = 5 * time.Second
+)
+
+var positionsBucket = []byte("positions")
+
+// boltPositions tracks positions in a bbolt database. The positions are kept in memory, and only the ones which
+// changed since the last sync are written to the database, each sync being a single transaction.
+type boltPositions struct {
+	logger log.Logger
+	cfg    Config
+	db     *bbolt.DB
+
+	mtx       sync.Mutex
+	positions map[string]string
+	// dirty holds the positions updated or removed since the last sync.
+	dirty map[string]struct{}
+
+	quit chan struct{}
+	done chan struct{}
+}
+
+// dbFile returns the location of the bbolt positions database.
+func (cfg *Config) dbFile() string {
+	if cfg.DBFile != "" {
+		return filepath.Clean(cfg.DBFile)
+	}
+	file := filepath.Clean(cfg.PositionsFile)
+	return strings.TrimSuffix(file, filepath.Ext(file)) + ".db"
+}
+
+func newBoltPositions(logger log.Logger, cfg Config) (Positions, error) {
+	p := &boltPositions{
+		logger: logger,
+		cfg:    cfg,
+		dirty:  map[string]struct{}{},
+		quit:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	var err error
+	if cfg.ReadOnly {
+		err = p.openReadOnly()
+	} else {
+		err = p.open()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	go p.run()
+	return p, nil
+}
+
+// open opens the database, creating it if needed, and migrates the positions file into it.
+func (p *boltPositions) open() error {
+	dbFile := p.cfg.dbFile()
+	db, err := bbolt.Open(dbFile, positionFileMode, &bbolt.Options{Timeout: openTimeout})
+	if err != nil {
+		return fmt.Errorf("failed to open positions database [%s]: %w", dbFile, err)
+	}
+	p.db = db
+
+	if err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(positionsBucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return err
+	}
+
+	if err := p.migrate(); err != nil {
+		db.Close()
+		return err
+	}
+
+	p.positions, err = p.load()
+	if err != nil {
+		db.Close()
+		return err
+	}
+	return nil
+}
+
+// openReadOnly reads the positions without writing anything. If the database does not exist yet, the positions
+// are read from the positions file, which has not been migrated.
+func (p *boltPositions) openReadOnly() error {
+	dbFile := p.cfg.dbFile()
+	if _, err := os.Stat(dbFile); os.IsNotExist(err) {
+		positions, err := readPositionsFile(p.cfg, p.logger)
+		if err != nil {
+			return err
+		}
+		p.positions = positions
+		return nil
+	}
+
+	db, err := bbolt.Open(dbFile, positionFileMode, &bbolt.Options{Timeout: openTimeout, ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("failed to open positions database [%s]: %w", dbFile, err)
+	}
+	p.db = db
+
+	p.positions, err = p.load()
+	if err != nil {
+		db.Close()
+		return err
+	}
+	return nil
+}
+
+// migrate imports the positions of the YAML positions file, and renames it so it is only imported once. Positions
+// already in the database are kept.
+func (p *boltPositions) migrate() error {
+	file := filepath.Clean(p.cfg.PositionsFile)
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		return nil
+	}
+
+	positions, err := readPositionsFile(p.cfg, p.logger)
+	if err != nil {
+		return err
+	}
+
+	if err := p.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(positionsBucket)
+		for k, v := range positions {
+			if b.Get([]byte(k)) != nil {
+				continue
+			}
+			if err := b.Put([]byte(k), []byte(v)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to migrate positions file [%s]: %w", file, err)
+	}
+
+	if err := os.Rename(file, file+migratedSuffix); err != nil {
+		return fmt.Errorf("failed to rename migrated positions file [%s]: %w", file, err)
+	}
+	level.Info(p.logger).Log("msg", "migrated positions file to database", "file", file, "db", p.cfg.dbFile(), "positions", len(positions))
+	return nil
+}
+
+func (p *boltPositions) load() (map[string]string, error) {
+	positions := map[string]string{}
+	err := p.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(positionsBucket)
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			positions[string(k)] = string(v)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read positions database [%s]: %w", p.cfg.dbFile(), err)
+	}
+	return positions, nil
+}
+
+func (p *boltPositions) Stop() {
+	close(p.quit)
+	<-p.done
+	if p.db != nil {
+		if err := p.db.Close(); err != nil {
+			level.Error(p.logger).Log("msg", "error closing positions database", "error", err)
+		}
+	}
+}
+
+func (p *boltPositions) PutString(path string, pos string) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	if cur, ok := p.positions[path]; ok && cur == pos {
+		return
+	}
+	p.positions[path] = pos
+	p.dirty[path] = struct{}{}
+}
+
+func (p *boltPositions) Put(path string, pos int64) {
+	p.PutString(path, strconv.FormatInt(pos, 10))
+}
+
+func (p *boltPositions) GetString(path string) string {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	return p.positions[path]
+}
+
+func (p *boltPositions) Get(path string) (int64, error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	pos, ok := p.positions[path]
+	if !ok {
+		return 0, nil
+	}
+	return strconv.ParseInt(pos, 10, 64)
+}
+
+func (p *boltPositions) Remove(path string) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.remove(path)
+}
+
+func (p *boltPositions) remove(path string) {
+	delete(p.positions, path)
+	p.dirty[path] = struct{}{}
+}
+
+func (p *boltPositions) SyncPeriod() time.Duration {
+	return p.cfg.SyncPeriod
+}
+
+func (p *boltPositions) run() {
+	defer func() {
+		p.save()
+		level.Debug(p.logger).Log("msg", "positions saved")
+		close(p.done)
+	}()
+
+	ticker := time.NewTicker(p.cfg.SyncPeriod)
+	for {
+		select {
+		case <-p.quit:
+			return
+		case <-ticker.C:
+			p.save()
+			p.cleanup()
+		}
+	}
+}
+
+func (p *boltPositions) save() {
+	if p.cfg.ReadOnly {
+		return
+	}
+	p.mtx.Lock()
+	if len(p.dirty) == 0 {
+		p.mtx.Unlock()
+		return
+	}
+	// A nil value is a removed position.
+	changes := make(map[string]*string, len(p.dirty))
+	for k := range p.dirty {
+		if v, ok := p.positions[k]; ok {
+			changes[k] = &v
+		} else {
+			changes[k] = nil
+		}
+	}
+	p.dirty = map[string]struct{}{}
+	p.mtx.Unlock()
+
+	if p.cfg.BeforeSave != nil {
+		if err := p.cfg.BeforeSave(); err != nil {
+			level.Error(p.logger).Log("msg", "error before writing positions database, positions not saved", "error", err)
+			p.redirty(changes)
+			return
+		}
+	}
+
+	err := p.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(positionsBucket)
+		for k, v := range changes {
+			var err error
+			if v == nil {
+				err = b.Delete([]byte(k))
+			} else {
+				err = b.Put([]byte(k), []byte(*v))
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		level.Error(p.logger).Log("msg", "error writing positions database", "error", err)
+		p.redirty(changes)
+	}
+}
+
+// redirty marks the changes which failed to be saved to be saved again by the next sync.
+func (p *boltPositions) redirty(changes map[string]*string) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	for k := range changes {
+		p.dirty[k] = struct{}{}
+	}
+}
+
+func (p *boltPositions) cleanup() {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	toRemove := []string{}
+	for k := range p.positions {
+		if isStale(p.logger, k) {
+			toRemove = append(toRemove, k)
+		}
+	}
+	for _, tr := range toRemove {
+		p.remove(tr)
+	}
+}
@@ -0,0 +1,116 @@
+package positions
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBoltPositions(t *testing.T) {
+	dir := t.TempDir()
+	cfg := Config{
+		SyncPeriod:    time.Hour,
+		PositionsFile: filepath.Join(dir, "positions.yaml"),
+		Backend:       BackendBBolt,
+	}
+
+	p, err := New(log.NewNopLogger(), cfg)
+	require.NoError(t, err)
+	p.Put("/tmp/a.log", 10)
+	p.PutString(CursorKey("job"), "cursor")
+	p.Put("/tmp/b.log", 20)
+	p.Remove("/tmp/b.log")
+	p.Stop()
+
+	_, err = os.Stat(filepath.Join(dir, "positions.db"))
+	require.NoError(t, err)
+
+	// the positions are read back after a restart.
+	p, err = New(log.NewNopLogger(), cfg)
+	require.NoError(t, err)
+	defer p.Stop()
+	pos, err := p.Get("/tmp/a.log")
+	require.NoError(t, err)
+	require.Equal(t, int64(10), pos)
+	require.Equal(t, "cursor", p.GetString(CursorKey("job")))
+	require.Equal(t, "", p.GetString("/tmp/b.log"))
+}
+
+func TestBoltPositionsMigration(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "positions.yaml")
+	require.NoError(t, ioutil.WriteFile(file, []byte(`positions:
+  /tmp/random.log: "17623"
+`), 0644))
+	cfg := Config{
+		SyncPeriod:    time.Hour,
+		PositionsFile: file,
+		Backend:       BackendBBolt,
+		DBFile:        filepath.Join(dir, "custom.db"),
+	}
+
+	// the database is not written to in read only mode, the positions are read from the file.
+	readOnly := cfg
+	readOnly.ReadOnly = true
+	p, err := New(log.NewNopLogger(), readOnly)
+	require.NoError(t, err)
+	require.Equal(t, "17623", p.GetString("/tmp/random.log"))
+	p.Stop()
+	_, err = os.Stat(cfg.DBFile)
+	require.True(t, os.IsNotExist(err))
+
+	p, err = New(log.NewNopLogger(), cfg)
+	require.NoError(t, err)
+	require.Equal(t, "17623", p.GetString("/tmp/random.log"))
+	p.Put("/tmp/random.log", 20000)
+	p.Stop()
+
+	// the positions file is only migrated once.
+	_, err = os.Stat(file)
+	require.True(t, os.IsNotExist(err))
+	_, err = os.Stat(file + migratedSuffix)
+	require.NoError(t, err)
+
+	p, err = New(log.NewNopLogger(), readOnly)
+	require.NoError(t, err)
+	defer p.Stop()
+	require.Equal(t, "20000", p.GetString("/tmp/random.log"))
+}
+
+func TestBoltPositionsCleanup(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "exists.log")
+	require.NoError(t, ioutil.WriteFile(existing, []byte("hello\n"), 0644))
+	cfg := Config{
+		SyncPeriod:    time.Hour,
+		PositionsFile: filepath.Join(dir, "positions.yaml"),
+		Backend:       BackendBBolt,
+	}
+
+	p, err := New(log.NewNopLogger(), cfg)
+	require.NoError(t, err)
+	p.Put(existing, 6)
+	p.Put(filepath.Join(dir, "deleted.log"), 10)
+	p.PutString(CursorKey("job"), "cursor")
+	p.(*boltPositions).save()
+	p.(*boltPositions).cleanup()
+	p.Stop()
+
+	p, err = New(log.NewNopLogger(), cfg)
+	require.NoError(t, err)
+	defer p.Stop()
+	require.Equal(t, map[string]string{
+		existing:         "6",
+		CursorKey("job"): "cursor",
+	}, p.(*boltPositions).positions)
+}
+
+func TestUnknownBackend(t *testing.T) {
+	_, err := New(log.NewNopLogger(), Config{Backend: "foo"})
+	require.Error(t, err)
+}
@@ -20,6 +20,11 @@ const (
 	positionFileMode = 0600
 	cursorKeyPrefix  = "cursor-"
 	journalKeyPrefix = "journal-"
+
+	// BackendYAML saves the positions in a YAML file, rewritten every sync period.
+	BackendYAML = "yaml"
+	// BackendBBolt saves the positions in an embedded bbolt database, only updating the positions that changed.
+	BackendBBolt = "bbolt"
 )
 
 // Config describes where to get position information from.
@@ -27,7 +32,12 @@ type Config struct {
 	SyncPeriod        time.Duration `yaml:"sync_period"`
 	PositionsFile     string        `yaml:"filename"`
 	IgnoreInvalidYaml bool          `yaml:"ignore_invalid_yaml"`
+	Backend           string        `yaml:"backend"`
+	DBFile            string        `yaml:"db_filename"`
 	ReadOnly          bool          `yaml:"-"`
+	// BeforeSave is called before writing the positions file, which is not written if it fails.
+	// It is used to persist the entries read up to the positions before committing them.
+	BeforeSave func() error `yaml:"-"`
 }
 
 // RegisterFlags with prefix registers flags where every name is prefixed by
@@ -36,6 +46,8 @@ func (cfg *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
 	f.DurationVar(&cfg.SyncPeriod, prefix+"positions.sync-period", 10*time.Second, "Period with this to sync the position file.")
 	f.StringVar(&cfg.PositionsFile, prefix+"positions.file", "/var/log/positions.yaml", "Location to read/write positions from.")
 	f.BoolVar(&cfg.IgnoreInvalidYaml, prefix+"positions.ignore-invalid-yaml", false, "whether to ignore & later overwrite positions files that are corrupted")
+	f.StringVar(&cfg.Backend, prefix+"positions.backend", BackendYAML, "Backend storing the positions, either yaml or bbolt.")
+	f.StringVar(&cfg.DBFile, prefix+"positions.db-file", "", "Location of the bbolt positions database, defaults to the positions file with a .db extension. An existing positions file is migrated to the database.")
 }
 
 // RegisterFlags register flags.
@@ -83,6 +95,17 @@ type Positions interface {
 
 // New makes a new Positions.
 func New(logger log.Logger, cfg Config) (Positions, error) {
+	switch cfg.Backend {
+	case "", BackendYAML:
+		return newYAMLPositions(logger, cfg)
+	case BackendBBolt:
+		return newBoltPositions(logger, cfg)
+	default:
+		return nil, fmt.Errorf("unknown positions backend %q, must be one of %s or %s", cfg.Backend, BackendYAML, BackendBBolt)
+	}
+}
+
+func newYAMLPositions(logger log.Logger, cfg Config) (Positions, error) {
 	positionData, err := readPositionsFile(cfg, logger)
 	if err != nil {
 		return nil, err
@@ -175,6 +198,13 @@ func (p *positions) save() {
 	}
 	p.mtx.Unlock()
 
+	if p.cfg.BeforeSave != nil {
+		if err := p.cfg.BeforeSave(); err != nil {
+			level.Error(p.logger).Log("msg", "error before writing positions file, positions not saved", "error", err)
+			return
+		}
+	}
+
 	if err := writePositionFile(p.cfg.PositionsFile, positions); err != nil {
 		level.Error(p.logger).Log("msg", "error writing positions file", "error", err)
 	}
@@ -190,22 +220,8 @@ func (p *positions) cleanup() {
 	defer p.mtx.Unlock()
 	toRemove := []string{}
 	for k := range p.positions {
-		// If the position file is prefixed with cursor, it's a
-		// cursor and not a file on disk.
-		// We still have to support journal files, so we keep the previous check to avoid breaking change.
-		if strings.HasPrefix(k, cursorKeyPrefix) || strings.HasPrefix(k, journalKeyPrefix) {
-			continue
-		}
-
-		if _, err := os.Stat(k); err != nil {
-			if os.IsNotExist(err) {
-				// File no longer exists.
-				toRemove = append(toRemove, k)
-			} else {
-				// Can't determine if file exists or not, some other error.
-				level.Warn(p.logger).Log("msg", "could not determine if log file "+
-					"still exists while cleaning positions file", "error", err)
-			}
+		if isStale(p.logger, k) {
+			toRemove = append(toRemove, k)
 		}
 	}
 	for _, tr := range toRemove {
@@ -213,6 +229,27 @@ func (p *positions) cleanup() {
 	}
 }
 
+// isStale returns true if the position is the one of a file which no longer exists.
+func isStale(logger log.Logger, key string) bool {
+	// If the position file is prefixed with cursor, it's a
+	// cursor and not a file on disk.
+	// We still have to support journal files, so we keep the previous check to avoid breaking change.
+	if strings.HasPrefix(key, cursorKeyPrefix) || strings.HasPrefix(key, journalKeyPrefix) {
+		return false
+	}
+
+	if _, err := os.Stat(key); err != nil {
+		if os.IsNotExist(err) {
+			// File no longer exists.
+			return true
+		}
+		// Can't determine if file exists or not, some other error.
+		level.Warn(logger).Log("msg", "could not determine if log file "+
+			"still exists while cleaning positions file", "error", err)
+	}
+	return false
+}
+
 func readPositionsFile(cfg Config, logger log.Logger) (map[string]string, error) {
 	cleanfn := filepath.Clean(cfg.PositionsFile)
 	buf, err := ioutil.ReadFile(cleanfn)
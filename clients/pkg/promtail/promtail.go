@@ -11,6 +11,7 @@ import (
 	"github.com/grafana/loki/clients/pkg/promtail/config"
 	"github.com/grafana/loki/clients/pkg/promtail/server"
 	"github.com/grafana/loki/clients/pkg/promtail/targets"
+	"github.com/grafana/loki/clients/pkg/promtail/wal"
 
 	util_log "github.com/grafana/loki/pkg/util/log"
 )
@@ -69,6 +70,20 @@ func New(cfg config.Config, dryRun bool, reg prometheus.Registerer, opts ...Opti
 			return nil, err
 		}
 		cfg.PositionsConfig.ReadOnly = true
+	} else if cfg.WALConfig.Enabled {
+		if err := cfg.WALConfig.Validate(); err != nil {
+			return nil, err
+		}
+		w, err := wal.New(cfg.WALConfig, promtail.logger, prometheus.DefaultRegisterer)
+		if err != nil {
+			return nil, err
+		}
+		promtail.client, err = client.NewMultiWithWAL(prometheus.DefaultRegisterer, promtail.logger, w, cfg.ClientConfigs...)
+		if err != nil {
+			return nil, err
+		}
+		// The entries are persisted before the positions they were read up to are.
+		cfg.PositionsConfig.BeforeSave = w.Sync
 	} else {
 		promtail.client, err = client.NewMulti(prometheus.DefaultRegisterer, promtail.logger, cfg.ClientConfigs...)
 		if err != nil {
@@ -33,17 +33,22 @@ import (
 
 // Config describes a job to scrape.
 type Config struct {
-	JobName          string                     `yaml:"job_name,omitempty"`
-	PipelineStages   stages.PipelineStages      `yaml:"pipeline_stages,omitempty"`
-	JournalConfig    *JournalTargetConfig       `yaml:"journal,omitempty"`
-	SyslogConfig     *SyslogTargetConfig        `yaml:"syslog,omitempty"`
-	GcplogConfig     *GcplogTargetConfig        `yaml:"gcplog,omitempty"`
-	PushConfig       *PushTargetConfig          `yaml:"loki_push_api,omitempty"`
-	WindowsConfig    *WindowsEventsTargetConfig `yaml:"windows_events,omitempty"`
-	KafkaConfig      *KafkaTargetConfig         `yaml:"kafka,omitempty"`
-	GelfConfig       *GelfTargetConfig          `yaml:"gelf,omitempty"`
-	CloudflareConfig *CloudflareConfig          `yaml:"cloudflare,omitempty"`
-	RelabelConfigs   []*relabel.Config          `yaml:"relabel_configs,omitempty"`
+	JobName           string                     `yaml:"job_name,omitempty"`
+	PipelineStages    stages.PipelineStages      `yaml:"pipeline_stages,omitempty"`
+	JournalConfig     *JournalTargetConfig       `yaml:"journal,omitempty"`
+	SyslogConfig      *SyslogTargetConfig        `yaml:"syslog,omitempty"`
+	GcplogConfig      *GcplogTargetConfig        `yaml:"gcplog,omitempty"`
+	PushConfig        *PushTargetConfig          `yaml:"loki_push_api,omitempty"`
+	WindowsConfig     *WindowsEventsTargetConfig `yaml:"windows_events,omitempty"`
+	KafkaConfig       *KafkaTargetConfig         `yaml:"kafka,omitempty"`
+	GelfConfig        *GelfTargetConfig          `yaml:"gelf,omitempty"`
+	CloudflareConfig  *CloudflareConfig          `yaml:"cloudflare,omitempty"`
+	HerokuDrainConfig *HerokuDrainTargetConfig   `yaml:"heroku_drain,omitempty"`
+	// AzureEventHubsConfig consumes the Azure Event Hubs through their Kafka endpoint.
+	AzureEventHubsConfig *AzureEventHubsTargetConfig `yaml:"azure_event_hubs,omitempty"`
+	// KubernetesPodLogsConfig reads the logs of the pods discovered by the kubernetes_sd_configs through the Kubernetes API.
+	KubernetesPodLogsConfig *KubernetesPodLogsTargetConfig `yaml:"kubernetes_pod_logs,omitempty"`
+	RelabelConfigs          []*relabel.Config              `yaml:"relabel_configs,omitempty"`
 	// List of Docker service discovery configurations.
 	DockerSDConfigs        []*moby.DockerSDConfig `yaml:"docker_sd_configs,omitempty"`
 	ServiceDiscoveryConfig ServiceDiscoveryConfig `yaml:",inline"`
@@ -165,6 +170,14 @@ type SyslogTargetConfig struct {
 	// ListenAddress is the address to listen on for syslog messages.
 	ListenAddress string `yaml:"listen_address"`
 
+	// ListenProtocol is the protocol used to receive syslog messages, tcp or udp.
+	// Defaults to tcp.
+	ListenProtocol string `yaml:"listen_protocol"`
+
+	// SyslogFormat is the format of the syslog messages, rfc5424 or the BSD
+	// rfc3164 format. Defaults to rfc5424.
+	SyslogFormat string `yaml:"syslog_format"`
+
 	// IdleTimeout is the idle timeout for tcp connections.
 	IdleTimeout time.Duration `yaml:"idle_timeout"`
 
@@ -299,6 +312,33 @@ type KafkaSASLConfig struct {
 	TLSConfig promconfig.TLSConfig `yaml:",inline"`
 }
 
+// AzureEventHubsTargetConfig describes a scrape config that consumes Azure Event Hubs through their Kafka endpoint.
+type AzureEventHubsTargetConfig struct {
+	// FullyQualifiedNamespace is the host of the Event Hubs namespace, such as my-namespace.servicebus.windows.net,
+	// optionally followed by the port of the Kafka endpoint, 9093 by default (Required).
+	FullyQualifiedNamespace string `yaml:"fully_qualified_namespace"`
+
+	// ConnectionString is the connection string of a shared access policy of the namespace or of the event hubs (Required).
+	ConnectionString flagext.Secret `yaml:"connection_string"`
+
+	// EventHubs are the event hubs to consume (Required).
+	EventHubs []string `yaml:"event_hubs"`
+
+	// The consumer group id.
+	GroupID string `yaml:"group_id"`
+
+	// UseIncomingTimestamp sets the timestamp to the time of the records of the
+	// Azure resource logs, or to the timestamp of the messages for other messages.
+	UseIncomingTimestamp bool `yaml:"use_incoming_timestamp"`
+
+	// DisallowCustomMessages drops the messages that are not Azure resource logs
+	// instead of sending them as they are.
+	DisallowCustomMessages bool `yaml:"disallow_custom_messages"`
+
+	// Labels optionally holds labels to associate with each log line.
+	Labels model.LabelSet `yaml:"labels"`
+}
+
 // GelfTargetConfig describes a scrape config that read GELF messages on UDP.
 type GelfTargetConfig struct {
 	// ListenAddress is the address to listen on UDP for gelf messages. (Default to `:12201`)
@@ -362,6 +402,29 @@ type PushTargetConfig struct {
 	KeepTimestamp bool `yaml:"use_incoming_timestamp"`
 }
 
+// HerokuDrainTargetConfig describes a scrape config that listens for Heroku HTTPS log drains.
+type HerokuDrainTargetConfig struct {
+	// Server is the weaveworks server config for listening connections
+	Server server.Config `yaml:"server"`
+
+	// Labels optionally holds labels to associate with each record received on the drain.
+	Labels model.LabelSet `yaml:"labels"`
+
+	// UseIncomingTimestamp sets the timestamp to the incoming drain messages
+	// timestamp if it's set.
+	UseIncomingTimestamp bool `yaml:"use_incoming_timestamp"`
+}
+
+// KubernetesPodLogsTargetConfig describes a scrape config reading the logs of pods through the Kubernetes API.
+type KubernetesPodLogsTargetConfig struct {
+	// KubeConfig is the path of the kubeconfig file used to connect to the Kubernetes API. When empty, the
+	// service account of the pod promtail runs in is used.
+	KubeConfig string `yaml:"kubeconfig_file"`
+
+	// Labels optionally holds labels to associate with each log line.
+	Labels model.LabelSet `yaml:"labels"`
+}
+
 // DefaultScrapeConfig is the default Config.
 var DefaultScrapeConfig = Config{
 	PipelineStages: stages.PipelineStages{},
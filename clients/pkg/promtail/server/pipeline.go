@@ -0,0 +1,171 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/common/version"
+	"github.com/prometheus/prometheus/model/labels"
+	"gopkg.in/yaml.v2"
+
+	"github.com/grafana/loki/clients/pkg/logentry/stages"
+	"github.com/grafana/loki/clients/pkg/promtail/api"
+
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql"
+)
+
+const (
+	defaultSampleLimit   = 10
+	maxSampleLimit       = 100
+	defaultSampleTimeout = 5 * time.Second
+	maxSampleTimeout     = 30 * time.Second
+	maxDryRunLines       = 1000
+)
+
+// pipelineJob is a job listed in the pipeline page, with the selectors of its active targets.
+type pipelineJob struct {
+	Name      string
+	Selectors []string
+}
+
+// pipeline serves the pipeline debugging page.
+func (s *server) pipeline(rw http.ResponseWriter, req *http.Request) {
+	active := s.tms.ActiveTargets()
+	var jobs []pipelineJob
+	for _, job := range stages.DebugJobs() {
+		selectors := make([]string, 0, len(active[job]))
+		for _, t := range active[job] {
+			if len(t.Labels()) > 0 {
+				selectors = append(selectors, t.Labels().String())
+			}
+		}
+		sort.Strings(selectors)
+		jobs = append(jobs, pipelineJob{Name: job, Selectors: selectors})
+	}
+
+	executeTemplate(req.Context(), rw, templateOptions{
+		Data: struct {
+			Jobs []pipelineJob
+		}{
+			Jobs: jobs,
+		},
+		BuildVersion: version.Info(),
+		Name:         "pipeline.html",
+		PageTitle:    "Pipeline",
+		ExternalURL:  s.externalURL,
+	})
+}
+
+// pipelineSample serves the traces of the entries sampled from the pipeline of a job.
+func (s *server) pipelineSample(rw http.ResponseWriter, req *http.Request) {
+	job := req.FormValue("job")
+	if job == "" {
+		http.Error(rw, "the job parameter is required", http.StatusBadRequest)
+		return
+	}
+	var err error
+	var matchers []*labels.Matcher
+	if selector := req.FormValue("selector"); selector != "" {
+		matchers, err = logql.ParseMatchers(selector)
+		if err != nil {
+			http.Error(rw, errors.Wrap(err, "invalid selector").Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	limit := defaultSampleLimit
+	if v := req.FormValue("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit <= 0 || limit > maxSampleLimit {
+			http.Error(rw, "the limit parameter must be a number between 1 and "+strconv.Itoa(maxSampleLimit), http.StatusBadRequest)
+			return
+		}
+	}
+	timeout := defaultSampleTimeout
+	if v := req.FormValue("timeout"); v != "" {
+		timeout, err = time.ParseDuration(v)
+		if err != nil || timeout <= 0 || timeout > maxSampleTimeout {
+			http.Error(rw, "the timeout parameter must be a duration up to "+maxSampleTimeout.String(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	defer cancel()
+	traces, err := stages.SamplePipeline(ctx, s.log, job, matchers, limit)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.writeTraces(rw, traces)
+}
+
+// dryRunRequest is the body of the pipeline dry-run endpoint.
+type dryRunRequest struct {
+	// Config is the YAML list of the pipeline stages, like the pipeline_stages of a scrape config.
+	Config string         `json:"config"`
+	Labels model.LabelSet `json:"labels"`
+	Lines  []string       `json:"lines"`
+}
+
+// pipelineDryRun serves the traces of the lines of the request through the pipeline of the request.
+func (s *server) pipelineDryRun(rw http.ResponseWriter, req *http.Request) {
+	var dryRun dryRunRequest
+	if err := json.NewDecoder(req.Body).Decode(&dryRun); err != nil {
+		http.Error(rw, errors.Wrap(err, "invalid request").Error(), http.StatusBadRequest)
+		return
+	}
+	if len(dryRun.Lines) > maxDryRunLines {
+		http.Error(rw, "too many lines, the maximum is "+strconv.Itoa(maxDryRunLines), http.StatusBadRequest)
+		return
+	}
+	var stgs stages.PipelineStages
+	if err := yaml.Unmarshal([]byte(dryRun.Config), &stgs); err != nil {
+		http.Error(rw, errors.Wrap(err, "invalid pipeline config").Error(), http.StatusBadRequest)
+		return
+	}
+	if err := dryRun.Labels.Validate(); err != nil {
+		http.Error(rw, errors.Wrap(err, "invalid labels").Error(), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	entries := make([]api.Entry, 0, len(dryRun.Lines))
+	for _, line := range dryRun.Lines {
+		entries = append(entries, api.Entry{
+			Labels: dryRun.Labels.Clone(),
+			Entry: logproto.Entry{
+				Timestamp: now,
+				Line:      line,
+			},
+		})
+	}
+	job := "dry-run"
+	traces, err := stages.TracePipeline(s.log, stgs, &job, entries)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.writeTraces(rw, traces)
+}
+
+func (s *server) writeTraces(rw http.ResponseWriter, traces []stages.EntryTrace) {
+	if traces == nil {
+		traces = []stages.EntryTrace{}
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(rw).Encode(struct {
+		Traces []stages.EntryTrace `json:"traces"`
+	}{
+		Traces: traces,
+	}); err != nil {
+		level.Error(s.log).Log("msg", "error writing pipeline traces", "error", err)
+	}
+}
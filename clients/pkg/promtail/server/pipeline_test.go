@@ -0,0 +1,138 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+
+	"github.com/grafana/loki/clients/pkg/logentry/stages"
+	"github.com/grafana/loki/clients/pkg/promtail/api"
+	"github.com/grafana/loki/clients/pkg/promtail/client/fake"
+
+	"github.com/grafana/loki/pkg/logproto"
+	util_log "github.com/grafana/loki/pkg/util/log"
+)
+
+const testPipelineConfig = `
+- regex:
+    expression: "^(?P<level>\\w+) (?P<message>.*)"
+- labels:
+    level:
+`
+
+func decodeTraces(t *testing.T, rec *httptest.ResponseRecorder) []stages.EntryTrace {
+	t.Helper()
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var res struct {
+		Traces []stages.EntryTrace `json:"traces"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&res))
+	return res.Traces
+}
+
+func Test_pipelineDryRun(t *testing.T) {
+	s := &server{log: util_log.Logger}
+	body, err := json.Marshal(dryRunRequest{
+		Config: testPipelineConfig,
+		Labels: model.LabelSet{"job": "test"},
+		Lines:  []string{"info first", "debug second"},
+	})
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	s.pipelineDryRun(rec, httptest.NewRequest(http.MethodPost, "/api/v1/pipeline/dry-run", strings.NewReader(string(body))))
+	traces := decodeTraces(t, rec)
+	require.Len(t, traces, 2)
+	require.Equal(t, "info first", traces[0].Input.Line)
+	require.Len(t, traces[0].Stages, 2)
+	require.Equal(t, model.LabelSet{"job": "test", "level": "info"}, traces[0].Output.Labels)
+	require.Equal(t, model.LabelSet{"job": "test", "level": "debug"}, traces[1].Output.Labels)
+}
+
+func Test_pipelineDryRun_Errors(t *testing.T) {
+	s := &server{log: util_log.Logger}
+	for name, body := range map[string]string{
+		"invalid json":   `{`,
+		"invalid config": `{"config": "- regex: {}", "lines": ["line"]}`,
+		"invalid yaml":   `{"config": "{", "lines": ["line"]}`,
+		"invalid labels": `{"config": "", "labels": {"0job": "x"}, "lines": ["line"]}`,
+		"too many lines": `{"config": "", "lines": [` + strings.TrimSuffix(strings.Repeat(`"line",`, maxDryRunLines+1), ",") + `]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.pipelineDryRun(rec, httptest.NewRequest(http.MethodPost, "/api/v1/pipeline/dry-run", strings.NewReader(body)))
+			require.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+		})
+	}
+}
+
+func Test_pipelineSample(t *testing.T) {
+	var cfg stages.PipelineStages
+	require.NoError(t, yaml.Unmarshal([]byte(testPipelineConfig), &cfg))
+	job := "server_pipeline_sample_test"
+	p, err := stages.NewPipeline(util_log.Logger, cfg, &job, prometheus.NewRegistry())
+	require.NoError(t, err)
+	c := fake.New(func() {})
+	defer c.Stop()
+	handler := p.Wrap(c)
+	defer handler.Stop()
+
+	// Send entries until the request is done.
+	done := make(chan struct{})
+	go func() {
+		for {
+			for _, stream := range []string{"stderr", "stdout"} {
+				select {
+				case handler.Chan() <- api.Entry{
+					Labels: model.LabelSet{"stream": model.LabelValue(stream)},
+					Entry:  logproto.Entry{Timestamp: time.Now(), Line: "info " + stream},
+				}:
+				case <-done:
+					return
+				}
+			}
+		}
+	}()
+	defer close(done)
+
+	s := &server{log: util_log.Logger}
+	rec := httptest.NewRecorder()
+	s.pipelineSample(rec, httptest.NewRequest(http.MethodGet, "/api/v1/pipeline/sample?job="+job+"&limit=2&selector="+url.QueryEscape(`{stream="stdout"}`), nil))
+	traces := decodeTraces(t, rec)
+	require.Len(t, traces, 2)
+	for _, tr := range traces {
+		require.Equal(t, "info stdout", tr.Input.Line)
+		require.Equal(t, model.LabelSet{"stream": "stdout", "level": "info"}, tr.Output.Labels)
+	}
+}
+
+func Test_pipelineSample_Errors(t *testing.T) {
+	s := &server{log: util_log.Logger}
+	for name, query := range map[string]string{
+		"missing job":       ``,
+		"unknown job":       `job=unknown&timeout=10ms`,
+		"invalid selector":  `job=test&selector=%7B`,
+		"invalid limit":     `job=test&limit=0`,
+		"limit too high":    `job=test&limit=1000`,
+		"invalid timeout":   `job=test&timeout=abc`,
+		"timeout too long":  `job=test&timeout=1h`,
+		"negative timeout":  `job=test&timeout=-1s`,
+		"non-numeric limit": `job=test&limit=abc`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.pipelineSample(rec, httptest.NewRequest(http.MethodGet, "/api/v1/pipeline/sample?"+query, nil))
+			require.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+		})
+	}
+}
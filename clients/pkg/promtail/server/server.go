@@ -103,6 +103,9 @@ func New(cfg Config, log log.Logger, tms *targets.TargetManagers, promtailCfg st
 	serv.HTTP.Path("/service-discovery").Handler(http.HandlerFunc(serv.serviceDiscovery))
 	serv.HTTP.Path("/targets").Handler(http.HandlerFunc(serv.targets))
 	serv.HTTP.Path("/config").Handler(http.HandlerFunc(serv.config))
+	serv.HTTP.Path("/pipeline").Handler(http.HandlerFunc(serv.pipeline))
+	serv.HTTP.Path("/api/v1/pipeline/sample").Methods("GET").Handler(http.HandlerFunc(serv.pipelineSample))
+	serv.HTTP.Path("/api/v1/pipeline/dry-run").Methods("POST").Handler(http.HandlerFunc(serv.pipelineDryRun))
 	serv.HTTP.Path("/debug/fgprof").Handler(fgprof.Handler())
 	return serv, nil
 }
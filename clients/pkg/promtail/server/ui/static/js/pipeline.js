@@ -0,0 +1,114 @@
+function showError(message) {
+  $("#pipelineError").text(message).show();
+  $("#pipelineTraces").empty();
+}
+
+function formatEntry(entry) {
+  if (!entry) { return ""; }
+  return JSON.stringify(entry, null, 2);
+}
+
+function showTraces(data) {
+  var container = $("#pipelineTraces").empty();
+  $("#pipelineError").hide();
+  if (data.traces.length === 0) {
+    container.append($("<p>").text("No entries."));
+    return;
+  }
+  $.each(data.traces, function (i, trace) {
+    var table = $("<table>").addClass("table table-sm table-bordered");
+    table.append($("<thead>").append($("<tr>")
+      .append($("<th>").text("Stage"))
+      .append($("<th>").text("Before"))
+      .append($("<th>").text("After"))
+      .append($("<th>").text("Changes"))));
+    var body = $("<tbody>").appendTo(table);
+    $.each(trace.stages, function (_, stage) {
+      var after = $("<td>");
+      if (stage.after) {
+        after.append($("<pre>").text(formatEntry(stage.after)));
+      } else {
+        after.append($("<span>").addClass("dropped").text("dropped or merged into another entry"));
+      }
+      var before = $("<td>");
+      if (stage.before) {
+        before.append($("<pre>").text(formatEntry(stage.before)));
+      } else {
+        before.append($("<span>").text("created by the stage"));
+      }
+      body.append($("<tr>")
+        .append($("<td>").text(stage.stage))
+        .append(before)
+        .append(after)
+        .append($("<td>").append($("<pre>").text(stage.diff || ""))));
+    });
+    var title = trace.input ? trace.input.line : "entry created by the " + trace.stages[0].stage + " stage";
+    $("<div>").addClass("trace")
+      .append($("<h5>").text("#" + (i + 1) + ": " + title + (trace.output ? "" : " (not sent)")))
+      .append(table)
+      .appendTo(container);
+  });
+}
+
+function request(settings) {
+  $("#pipelineTraces").empty().append($("<p>").text("Waiting for entries..."));
+  $.ajax(settings)
+    .done(showTraces)
+    .fail(function (xhr) { showError(xhr.responseText || xhr.statusText); });
+}
+
+function updateTargets() {
+  var select = $("#sampleTarget").empty().append($("<option>").val("").text("All targets"));
+  $.each(PIPELINE_JOBS[$("#sampleJob").val()] || [], function (_, selector) {
+    select.append($("<option>").val(selector).text(selector));
+  });
+  $("#sampleSelector").val("");
+}
+
+function init() {
+  updateTargets();
+  $("#sampleJob").on("change", updateTargets);
+  $("#sampleTarget").on("change", function () {
+    $("#sampleSelector").val($(this).val());
+  });
+
+  $("#sampleForm").on("submit", function (e) {
+    e.preventDefault();
+    request({
+      url: PATH_PREFIX + "/api/v1/pipeline/sample",
+      data: {
+        job: $("#sampleJob").val(),
+        selector: $("#sampleSelector").val(),
+        limit: $("#sampleLimit").val(),
+        timeout: $("#sampleTimeout").val()
+      }
+    });
+  });
+
+  $("#dryRunForm").on("submit", function (e) {
+    e.preventDefault();
+    var labels = {};
+    if ($("#dryRunLabels").val()) {
+      try {
+        labels = JSON.parse($("#dryRunLabels").val());
+      } catch (err) {
+        showError("invalid labels: " + err.message);
+        return;
+      }
+    }
+    var lines = $("#dryRunLines").val().split("\n");
+    if (lines.length > 0 && lines[lines.length - 1] === "") { lines.pop(); }
+    request({
+      url: PATH_PREFIX + "/api/v1/pipeline/dry-run",
+      method: "POST",
+      contentType: "application/json",
+      data: JSON.stringify({
+        config: $("#dryRunConfig").val(),
+        labels: labels,
+        lines: lines
+      })
+    });
+  });
+}
+
+$(init);
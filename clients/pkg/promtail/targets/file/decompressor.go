@@ -0,0 +1,267 @@
+package file
+
+import (
+	"bufio"
+	"compress/bzip2"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/klauspost/compress/zstd"
+	"github.com/prometheus/common/model"
+	"go.uber.org/atomic"
+
+	"github.com/grafana/loki/clients/pkg/promtail/api"
+	"github.com/grafana/loki/clients/pkg/promtail/positions"
+
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+const (
+	// positionDone is the position recorded once a compressed file has been read to completion.
+	positionDone = "done"
+	// keyPrefixSize is the number of bytes of a compressed file hashed to build its positions key.
+	keyPrefixSize = 64 << 10
+)
+
+// isCompressed returns true if the file is a compressed archive, which is decompressed and read once instead of
+// being tailed.
+func isCompressed(path string) bool {
+	switch filepath.Ext(path) {
+	case ".gz", ".bz2", ".zst":
+		return true
+	default:
+		return false
+	}
+}
+
+// compressedFileKey returns the key under which the position of a compressed file is recorded. It is derived from
+// the content of the file rather than its path, so that the file is not read again when it is renamed by a rotation.
+// It is a cursor key, as it is not a path the positions cleanup could check the existence of.
+func compressedFileKey(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	if _, err := io.CopyN(h, f, keyPrefixSize); err != nil && err != io.EOF {
+		return "", err
+	}
+	return positions.CursorKey(fmt.Sprintf("compressed:%x:%d", h.Sum(nil), fi.Size())), nil
+}
+
+// decompressor reads the lines of a compressed file to completion. The number of decompressed bytes read is
+// recorded in the positions under the content key of the file, so that reading resumes after a restart.
+type decompressor struct {
+	metrics   *Metrics
+	logger    log.Logger
+	handler   api.EntryHandler
+	positions positions.Positions
+
+	path string
+	key  string
+
+	// position is the number of decompressed bytes read.
+	position *atomic.Int64
+	// readBytes is the number of compressed bytes read.
+	readBytes *atomic.Int64
+	running   *atomic.Bool
+	finished  *atomic.Bool
+
+	stopOnce sync.Once
+	quit     chan struct{}
+	done     chan struct{}
+}
+
+func newDecompressor(metrics *Metrics, logger log.Logger, handler api.EntryHandler, positions positions.Positions, path, key string) (*decompressor, error) {
+	d := &decompressor{
+		metrics:   metrics,
+		logger:    log.With(logger, "component", "decompressor"),
+		handler:   api.AddLabelsMiddleware(model.LabelSet{FilenameLabel: model.LabelValue(path)}).Wrap(handler),
+		positions: positions,
+		path:      path,
+		key:       key,
+		position:  atomic.NewInt64(0),
+		readBytes: atomic.NewInt64(0),
+		running:   atomic.NewBool(false),
+		finished:  atomic.NewBool(false),
+		quit:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+
+	pos := positions.GetString(key)
+	if pos == positionDone {
+		level.Debug(d.logger).Log("msg", "compressed file already read", "path", path)
+		d.finished.Store(true)
+		close(d.done)
+		return d, nil
+	}
+	if pos != "" {
+		offset, err := strconv.ParseInt(pos, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		d.position.Store(offset)
+	}
+
+	d.running.Store(true)
+	metrics.filesActive.Add(1.)
+	go d.run()
+	return d, nil
+}
+
+func (d *decompressor) run() {
+	level.Info(d.logger).Log("msg", "decompressing file", "path", d.path, "position", d.position.Load())
+
+	positionWait := time.NewTicker(d.positions.SyncPeriod())
+	defer func() {
+		positionWait.Stop()
+		d.cleanupMetrics()
+		d.running.Store(false)
+		close(d.done)
+	}()
+
+	f, err := os.Open(d.path)
+	if err != nil {
+		level.Error(d.logger).Log("msg", "error opening compressed file", "path", d.path, "error", err)
+		return
+	}
+	defer f.Close()
+	if fi, err := f.Stat(); err == nil {
+		d.metrics.totalBytes.WithLabelValues(d.path).Set(float64(fi.Size()))
+	}
+
+	r, err := d.newReader(&countingReader{r: f, count: d.readBytes})
+	if err != nil {
+		level.Error(d.logger).Log("msg", "error decompressing file", "path", d.path, "error", err)
+		return
+	}
+	defer r.Close()
+
+	// Skip the lines read before a restart.
+	if _, err := io.CopyN(io.Discard, r, d.position.Load()); err != nil {
+		level.Error(d.logger).Log("msg", "error skipping the lines already read", "path", d.path, "error", err)
+		return
+	}
+
+	entries := d.handler.Chan()
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if len(line) > 0 {
+			select {
+			case entries <- api.Entry{
+				Labels: model.LabelSet{},
+				Entry: logproto.Entry{
+					Timestamp: time.Now(),
+					Line:      strings.TrimRight(line, "\r\n"),
+				},
+			}:
+			case <-d.quit:
+				return
+			}
+			d.position.Add(int64(len(line)))
+			d.metrics.readLines.WithLabelValues(d.path).Inc()
+			d.metrics.logLengthHistogram.WithLabelValues(d.path).Observe(float64(len(line)))
+		}
+
+		if err == io.EOF {
+			d.finished.Store(true)
+			d.positions.PutString(d.key, positionDone)
+			level.Info(d.logger).Log("msg", "compressed file read to completion", "path", d.path)
+			return
+		}
+		if err != nil {
+			// The file is read again from the last recorded position by the next sync of the target.
+			level.Error(d.logger).Log("msg", "error reading compressed file", "path", d.path, "error", err)
+			return
+		}
+
+		select {
+		case <-positionWait.C:
+			d.markPosition()
+		case <-d.quit:
+			return
+		default:
+		}
+	}
+}
+
+func (d *decompressor) newReader(r io.Reader) (io.ReadCloser, error) {
+	switch filepath.Ext(d.path) {
+	case ".gz":
+		return gzip.NewReader(r)
+	case ".bz2":
+		return io.NopCloser(bzip2.NewReader(r)), nil
+	case ".zst":
+		dec, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return dec.IOReadCloser(), nil
+	default:
+		return nil, fmt.Errorf("unsupported compressed file %s", d.path)
+	}
+}
+
+func (d *decompressor) markPosition() {
+	if d.finished.Load() {
+		return
+	}
+	d.metrics.readBytes.WithLabelValues(d.path).Set(float64(d.readBytes.Load()))
+	d.positions.Put(d.key, d.position.Load())
+}
+
+func (d *decompressor) stop() {
+	d.stopOnce.Do(func() {
+		close(d.quit)
+		<-d.done
+		d.markPosition()
+		d.handler.Stop()
+	})
+}
+
+func (d *decompressor) isRunning() bool {
+	return d.running.Load()
+}
+
+// isFinished returns true if the file has been read to completion.
+func (d *decompressor) isFinished() bool {
+	return d.finished.Load()
+}
+
+// cleanupMetrics removes all metrics exported by this decompressor.
+func (d *decompressor) cleanupMetrics() {
+	d.metrics.filesActive.Add(-1.)
+	d.metrics.readLines.DeleteLabelValues(d.path)
+	d.metrics.readBytes.DeleteLabelValues(d.path)
+	d.metrics.totalBytes.DeleteLabelValues(d.path)
+	d.metrics.logLengthHistogram.DeleteLabelValues(d.path)
+}
+
+// countingReader counts the bytes read from the underlying reader.
+type countingReader struct {
+	r     io.Reader
+	count *atomic.Int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.count.Add(int64(n))
+	return n, err
+}
@@ -72,6 +72,8 @@ type FileTarget struct {
 	done               chan struct{}
 
 	tails map[string]*tailer
+	// decompressors read the compressed files, they are kept once finished so the files are not read again.
+	decompressors map[string]*decompressor
 
 	targetConfig *Config
 }
@@ -100,6 +102,7 @@ func NewFileTarget(
 		quit:               make(chan struct{}),
 		done:               make(chan struct{}),
 		tails:              map[string]*tailer{},
+		decompressors:      map[string]*decompressor{},
 		targetConfig:       targetConfig,
 		fileEventWatcher:   fileEventWatcher,
 		targetEventHandler: targetEventHandler,
@@ -111,7 +114,7 @@ func NewFileTarget(
 
 // Ready if at least one file is being tailed
 func (t *FileTarget) Ready() bool {
-	return len(t.tails) > 0
+	return len(t.tails) > 0 || len(t.decompressors) > 0
 }
 
 // Stop the target.
@@ -142,6 +145,9 @@ func (t *FileTarget) Details() interface{} {
 	for fileName := range t.tails {
 		files[fileName], _ = t.positions.Get(fileName)
 	}
+	for fileName, d := range t.decompressors {
+		files[fileName] = d.position.Load()
+	}
 	return files
 }
 
@@ -150,6 +156,9 @@ func (t *FileTarget) run() {
 		for _, v := range t.tails {
 			v.stop()
 		}
+		for _, v := range t.decompressors {
+			v.stop()
+		}
 		level.Info(t.logger).Log("msg", "filetarget: watcher closed, tailer stopped, positions saved", "path", t.path)
 		close(t.done)
 	}()
@@ -240,6 +249,7 @@ func (t *FileTarget) sync() error {
 	// Stop tailing any files which no longer exist
 	toStopTailing := toStopTailing(matches, t.tails)
 	t.stopTailingAndRemovePosition(toStopTailing)
+	t.stopDecompressing(matches)
 
 	return nil
 }
@@ -275,6 +285,9 @@ func (t *FileTarget) startTailing(ps []string) {
 		if _, ok := t.tails[p]; ok {
 			continue
 		}
+		if _, ok := t.decompressors[p]; ok {
+			continue
+		}
 		fi, err := os.Stat(p)
 		if err != nil {
 			level.Error(t.logger).Log("msg", "failed to tail file, stat failed", "error", err, "filename", p)
@@ -284,6 +297,10 @@ func (t *FileTarget) startTailing(ps []string) {
 			level.Info(t.logger).Log("msg", "failed to tail file", "error", "file is a directory", "filename", p)
 			continue
 		}
+		if isCompressed(p) {
+			t.startDecompressing(p, fi, ps)
+			continue
+		}
 		level.Debug(t.logger).Log("msg", "tailing new file", "filename", p)
 		tailer, err := newTailer(t.metrics, t.logger, t.handler, t.positions, p)
 		if err != nil {
@@ -294,6 +311,65 @@ func (t *FileTarget) startTailing(ps []string) {
 	}
 }
 
+// startDecompressing starts reading a compressed file, unless it is the result of the rotation of a file which is
+// already being read. matches are the files currently matched by the target.
+func (t *FileTarget) startDecompressing(p string, fi os.FileInfo, matches []string) {
+	// Compressed files are read once, so wait until they are fully written.
+	if time.Since(fi.ModTime()) < t.targetConfig.SyncPeriod {
+		level.Debug(t.logger).Log("msg", "compressed file recently modified, waiting for the next sync to read it", "filename", p)
+		return
+	}
+
+	key, err := compressedFileKey(p)
+	if err != nil {
+		level.Error(t.logger).Log("msg", "failed to read compressed file", "error", err, "filename", p)
+		return
+	}
+
+	for existing, d := range t.decompressors {
+		if d.key != key {
+			continue
+		}
+		if contains(matches, existing) {
+			level.Debug(t.logger).Log("msg", "compressed file has the same content as another file, skipping it", "filename", p, "other", existing)
+			return
+		}
+		// The file has been renamed, keep track of it under its new name.
+		level.Debug(t.logger).Log("msg", "compressed file renamed", "filename", p, "previous", existing)
+		delete(t.decompressors, existing)
+		t.decompressors[p] = d
+		return
+	}
+
+	level.Debug(t.logger).Log("msg", "decompressing new file", "filename", p)
+	d, err := newDecompressor(t.metrics, t.logger, t.handler, t.positions, p, key)
+	if err != nil {
+		level.Error(t.logger).Log("msg", "failed to start decompressor", "error", err, "filename", p)
+		return
+	}
+	t.decompressors[p] = d
+}
+
+// stopDecompressing stops reading the compressed files which no longer exist and removes their position.
+// Decompressors which stopped because of errors are stopped as well, so that they are restarted by the next sync.
+func (t *FileTarget) stopDecompressing(matches []string) {
+	matched := make(map[string]struct{}, len(matches))
+	for _, p := range matches {
+		matched[p] = struct{}{}
+	}
+	for p, d := range t.decompressors {
+		_, exists := matched[p]
+		if exists && (d.isRunning() || d.isFinished()) {
+			continue
+		}
+		d.stop()
+		delete(t.decompressors, p)
+		if !exists {
+			t.positions.Remove(d.key)
+		}
+	}
+}
+
 // stopTailingAndRemovePosition will stop the tailer and remove the positions entry.
 // Call this when a file no longer exists and you want to remove all traces of it.
 func (t *FileTarget) stopTailingAndRemovePosition(ps []string) {
@@ -347,6 +423,10 @@ func toStopTailing(nt []string, et map[string]*tailer) []string {
 
 func (t *FileTarget) reportSize(ms []string) {
 	for _, m := range ms {
+		// Decompressors report the size of the compressed files they read
+		if _, ok := t.decompressors[m]; ok {
+			continue
+		}
 		// Ask the tailer to update the size if a tailer exists, this keeps position and size metrics in sync
 		if tailer, ok := t.tails[m]; ok {
 			err := tailer.markPositionAndSize()
@@ -369,6 +449,15 @@ func (t *FileTarget) reportSize(ms []string) {
 	}
 }
 
+func contains(ps []string, p string) bool {
+	for _, m := range ps {
+		if m == p {
+			return true
+		}
+	}
+	return false
+}
+
 // Returns the elements from set b which are missing from set a
 func missing(as map[string]struct{}, bs map[string]struct{}) map[string]struct{} {
 	c := map[string]struct{}{}
@@ -1,14 +1,17 @@
 package file
 
 import (
+	"compress/gzip"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
 	"testing"
 	"time"
 
+	"github.com/klauspost/compress/zstd"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -230,6 +233,98 @@ func TestHandleFileCreationEvent(t *testing.T) {
 	}, time.Second*10, time.Millisecond*1, "Expected tails to be 1 at this point in the test...")
 }
 
+func TestFileTargetCompressedFiles(t *testing.T) {
+	logger := log.NewNopLogger()
+	dirName := newTestLogDirectories(t)
+	logDir := filepath.Join(dirName, "log")
+	require.NoError(t, os.MkdirAll(logDir, 0750))
+
+	writeCompressed := func(path string, w func(io.Writer) io.WriteCloser, lines ...string) {
+		f, err := os.Create(path)
+		require.NoError(t, err)
+		cw := w(f)
+		for _, line := range lines {
+			_, err = cw.Write([]byte(line + "\n"))
+			require.NoError(t, err)
+		}
+		require.NoError(t, cw.Close())
+		require.NoError(t, f.Close())
+		// compressed files are only read once they are not modified anymore.
+		old := time.Now().Add(-time.Hour)
+		require.NoError(t, os.Chtimes(path, old, old))
+	}
+	writeCompressed(filepath.Join(logDir, "app.log.1.gz"), func(w io.Writer) io.WriteCloser {
+		return gzip.NewWriter(w)
+	}, "gzip 1", "gzip 2")
+	writeCompressed(filepath.Join(logDir, "app.log.2.zst"), func(w io.Writer) io.WriteCloser {
+		zw, err := zstd.NewWriter(w)
+		require.NoError(t, err)
+		return zw
+	}, "zstd 1")
+
+	ps, err := positions.New(logger, positions.Config{
+		SyncPeriod:    10 * time.Minute,
+		PositionsFile: filepath.Join(dirName, "positions.yml"),
+	})
+	require.NoError(t, err)
+	defer ps.Stop()
+
+	client := fake.New(func() {})
+	defer client.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fakeTargetHandler := make(chan fileTargetEvent)
+	go func() {
+		for {
+			select {
+			case <-fakeTargetHandler:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	target, err := NewFileTarget(NewMetrics(nil), logger, client, ps, logDir+"/app.log.*", nil, nil, &Config{
+		SyncPeriod: 10 * time.Minute,
+	}, nil, fakeTargetHandler)
+	require.NoError(t, err)
+	defer target.Stop()
+
+	lines := func() []string {
+		var res []string
+		for _, e := range client.Received() {
+			res = append(res, e.Line)
+		}
+		sort.Strings(res)
+		return res
+	}
+	require.Eventually(t, func() bool {
+		return len(lines()) == 3
+	}, 10*time.Second, 10*time.Millisecond)
+	require.Equal(t, []string{"gzip 1", "gzip 2", "zstd 1"}, lines())
+
+	key, err := compressedFileKey(filepath.Join(logDir, "app.log.1.gz"))
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return ps.GetString(key) == positionDone
+	}, 10*time.Second, 10*time.Millisecond)
+
+	// A rotated file is not read again.
+	require.NoError(t, os.Rename(filepath.Join(logDir, "app.log.1.gz"), filepath.Join(logDir, "app.log.3.gz")))
+	require.NoError(t, target.sync())
+	require.Len(t, target.decompressors, 2)
+	require.Contains(t, target.decompressors, filepath.Join(logDir, "app.log.3.gz"))
+	require.Equal(t, positionDone, ps.GetString(key))
+
+	// The position of a removed file is removed.
+	require.NoError(t, os.Remove(filepath.Join(logDir, "app.log.3.gz")))
+	require.NoError(t, target.sync())
+	require.Len(t, target.decompressors, 1)
+	require.Equal(t, "", ps.GetString(key))
+	require.Len(t, lines(), 3)
+}
+
 func TestToStopTailing(t *testing.T) {
 	nt := []string{"file1", "file2", "file3", "file4", "file5", "file6", "file7", "file11", "file12", "file15"}
 	et := make(map[string]*tailer, 15)
@@ -0,0 +1,38 @@
+package heroku
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Metrics holds a set of Heroku drain metrics.
+type Metrics struct {
+	reg prometheus.Registerer
+
+	herokuEntries *prometheus.CounterVec
+	herokuErrors  *prometheus.CounterVec
+}
+
+// NewMetrics creates a new set of Heroku drain metrics. If reg is non-nil, the
+// metrics will be registered.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	var m Metrics
+	m.reg = reg
+
+	m.herokuEntries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "promtail",
+		Name:      "heroku_drain_target_entries_total",
+		Help:      "Total number of successful entries received by the Heroku drain target",
+	}, []string{"job"})
+	m.herokuErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "promtail",
+		Name:      "heroku_drain_target_parsing_errors_total",
+		Help:      "Total number of parsing errors while receiving Heroku drain messages",
+	}, []string{"job"})
+
+	if reg != nil {
+		reg.MustRegister(
+			m.herokuEntries,
+			m.herokuErrors,
+		)
+	}
+
+	return &m
+}
@@ -0,0 +1,290 @@
+package heroku
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/imdario/mergo"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/weaveworks/common/logging"
+	"github.com/weaveworks/common/server"
+
+	"github.com/grafana/loki/clients/pkg/promtail/api"
+	lokiClient "github.com/grafana/loki/clients/pkg/promtail/client"
+	"github.com/grafana/loki/clients/pkg/promtail/scrapeconfig"
+	"github.com/grafana/loki/clients/pkg/promtail/targets/target"
+
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+const (
+	// DrainPath is the path the Heroku log drains are pushed to.
+	DrainPath = "/heroku/api/v1/drain"
+
+	// tenantIDParam is the query parameter selecting the tenant the drained logs are sent to.
+	tenantIDParam = "tenant_id"
+	// maxFrameLength is the maximum length of a logplex frame.
+	maxFrameLength = 1 << 20
+)
+
+// Target receives the logs of Heroku HTTPS log drains.
+type Target struct {
+	metrics       *Metrics
+	logger        log.Logger
+	handler       api.EntryHandler
+	config        *scrapeconfig.HerokuDrainTargetConfig
+	relabelConfig []*relabel.Config
+	jobName       string
+	server        *server.Server
+}
+
+// NewTarget creates a new Heroku drain target and starts its HTTP server.
+func NewTarget(metrics *Metrics,
+	logger log.Logger,
+	handler api.EntryHandler,
+	jobName string,
+	config *scrapeconfig.HerokuDrainTargetConfig,
+	relabel []*relabel.Config,
+) (*Target, error) {
+
+	t := &Target{
+		metrics:       metrics,
+		logger:        logger,
+		handler:       handler,
+		jobName:       jobName,
+		config:        config,
+		relabelConfig: relabel,
+	}
+
+	// Bit of a chicken and egg problem trying to register the defaults and apply overrides from the loaded config.
+	// First create an empty config and set defaults.
+	defaults := server.Config{}
+	defaults.RegisterFlags(flag.NewFlagSet("empty", flag.ContinueOnError))
+	// Then apply any config values loaded as overrides to the defaults.
+	if err := mergo.Merge(&defaults, config.Server, mergo.WithOverride); err != nil {
+		level.Error(logger).Log("msg", "failed to parse configs and override defaults when configuring heroku drain server", "err", err)
+	}
+	// The merge won't overwrite with a zero value but in the case of ports 0 value
+	// indicates the desire for a random port so reset these to zero if the incoming config val is 0
+	if config.Server.HTTPListenPort == 0 {
+		defaults.HTTPListenPort = 0
+	}
+	if config.Server.GRPCListenPort == 0 {
+		defaults.GRPCListenPort = 0
+	}
+	// Set the config to the new combined config.
+	config.Server = defaults
+
+	if err := t.run(); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (t *Target) run() error {
+	level.Info(t.logger).Log("msg", "starting heroku drain target", "job", t.jobName)
+	// To prevent metric collisions because all metrics are going to be registered in the global Prometheus registry.
+	t.config.Server.MetricsNamespace = "promtail_" + t.jobName
+
+	// We don't want the /debug and /metrics endpoints running
+	t.config.Server.RegisterInstrumentation = false
+
+	t.config.Server.Log = logging.GoKit(t.logger)
+
+	srv, err := server.New(t.config.Server)
+	if err != nil {
+		return err
+	}
+
+	t.server = srv
+	t.server.HTTP.Path(DrainPath).Methods("POST").Handler(http.HandlerFunc(t.drain))
+
+	go func() {
+		err := srv.Run()
+		if err != nil {
+			level.Error(t.logger).Log("msg", "heroku drain target shutdown with error", "err", err)
+		}
+	}()
+
+	return nil
+}
+
+// drain handles a drain request, whose body holds octet counted syslog frames.
+func (t *Target) drain(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	tenantID := r.URL.Query().Get(tenantIDParam)
+	entries := t.handler.Chan()
+
+	err := parseFrames(r.Body, func(frame []byte) {
+		msg, err := parseMessage(frame)
+		if err != nil {
+			t.metrics.herokuErrors.WithLabelValues(t.jobName).Inc()
+			level.Debug(t.logger).Log("msg", "failed to parse heroku drain message", "err", err)
+			return
+		}
+
+		lb := labels.NewBuilder(nil)
+		for k, v := range t.config.Labels {
+			lb.Set(string(k), string(v))
+		}
+		lb.Set("__heroku_drain_host", msg.hostname)
+		lb.Set("__heroku_drain_app", msg.appname)
+		lb.Set("__heroku_drain_proc", msg.procID)
+		lb.Set("__heroku_drain_log_id", msg.msgID)
+		if token := r.Header.Get("Logplex-Drain-Token"); token != "" {
+			lb.Set("__heroku_drain_drain_token", token)
+		}
+
+		processed := relabel.Process(lb.Labels(), t.relabelConfig...)
+		if len(processed) == 0 {
+			return
+		}
+
+		filtered := model.LabelSet{}
+		for _, lbl := range processed {
+			if strings.HasPrefix(lbl.Name, "__") {
+				continue
+			}
+			filtered[model.LabelName(lbl.Name)] = model.LabelValue(lbl.Value)
+		}
+		if tenantID != "" {
+			filtered[lokiClient.ReservedLabelTenantID] = model.LabelValue(tenantID)
+		}
+
+		timestamp := time.Now()
+		if t.config.UseIncomingTimestamp && !msg.timestamp.IsZero() {
+			timestamp = msg.timestamp
+		}
+		entries <- api.Entry{
+			Labels: filtered,
+			Entry: logproto.Entry{
+				Timestamp: timestamp,
+				Line:      msg.message,
+			},
+		}
+		t.metrics.herokuEntries.WithLabelValues(t.jobName).Inc()
+	})
+	if err != nil {
+		t.metrics.herokuErrors.WithLabelValues(t.jobName).Inc()
+		level.Warn(t.logger).Log("msg", "failed to read heroku drain request", "err", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Type returns HerokuDrainTargetType.
+func (t *Target) Type() target.TargetType {
+	return target.HerokuDrainTargetType
+}
+
+// Ready indicates whether or not the target is ready to be read from.
+func (t *Target) Ready() bool {
+	return true
+}
+
+// DiscoveredLabels returns the set of labels discovered by the target, which
+// is always nil. Implements Target.
+func (t *Target) DiscoveredLabels() model.LabelSet {
+	return nil
+}
+
+// Labels returns the set of labels that statically apply to all log entries
+// produced by the target.
+func (t *Target) Labels() model.LabelSet {
+	return t.config.Labels
+}
+
+// Details returns target-specific details.
+func (t *Target) Details() interface{} {
+	return map[string]string{}
+}
+
+// Stop shuts down the target.
+func (t *Target) Stop() error {
+	level.Info(t.logger).Log("msg", "stopping heroku drain target", "job", t.jobName)
+	t.server.Shutdown()
+	t.handler.Stop()
+	return nil
+}
+
+// message is a syslog message sent by a Heroku log drain.
+type message struct {
+	timestamp time.Time
+	hostname  string
+	appname   string
+	procID    string
+	msgID     string
+	message   string
+}
+
+// parseFrames calls the callback with each of the octet counted frames of the logplex body.
+func parseFrames(r io.Reader, callback func(frame []byte)) error {
+	buf := bufio.NewReader(r)
+	for {
+		header, err := buf.ReadString(' ')
+		if err == io.EOF && strings.TrimSpace(header) == "" {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid logplex frame header: %w", err)
+		}
+		length, err := strconv.Atoi(strings.TrimSpace(header))
+		if err != nil || length <= 0 || length > maxFrameLength {
+			return fmt.Errorf("invalid logplex frame length %q", strings.TrimSpace(header))
+		}
+
+		frame := make([]byte, length)
+		if _, err := io.ReadFull(buf, frame); err != nil {
+			return fmt.Errorf("incomplete logplex frame: %w", err)
+		}
+		callback(frame)
+	}
+}
+
+// parseMessage parses a logplex frame, a syslog message with the format:
+// <PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID MSG
+// As opposed to rfc5424, logplex messages have no structured data.
+func parseMessage(frame []byte) (message, error) {
+	frame = bytes.TrimRight(frame, "\r\n")
+	if len(frame) == 0 || frame[0] != '<' {
+		return message{}, errors.New("expecting a priority value within angle brackets")
+	}
+
+	fields := strings.SplitN(string(frame), " ", 7)
+	if len(fields) < 6 {
+		return message{}, fmt.Errorf("expecting at least 6 fields in the syslog header, got %d", len(fields))
+	}
+
+	var msg message
+	if fields[1] != "-" {
+		ts, err := time.Parse(time.RFC3339Nano, fields[1])
+		if err != nil {
+			return message{}, fmt.Errorf("invalid timestamp: %w", err)
+		}
+		msg.timestamp = ts
+	}
+	msg.hostname = fields[2]
+	msg.appname = fields[3]
+	msg.procID = fields[4]
+	msg.msgID = fields[5]
+	if len(fields) == 7 {
+		msg.message = fields[6]
+	}
+	return msg, nil
+}
@@ -0,0 +1,159 @@
+package heroku
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/server"
+
+	lokiClient "github.com/grafana/loki/clients/pkg/promtail/client"
+	"github.com/grafana/loki/clients/pkg/promtail/client/fake"
+	"github.com/grafana/loki/clients/pkg/promtail/scrapeconfig"
+)
+
+const localhost = "127.0.0.1"
+
+// logplexBody frames the messages with octet counting, like Heroku log drains do.
+func logplexBody(messages ...string) string {
+	var sb strings.Builder
+	for _, m := range messages {
+		fmt.Fprintf(&sb, "%d %s", len(m), m)
+	}
+	return sb.String()
+}
+
+func TestHerokuDrainTarget(t *testing.T) {
+	w := log.NewSyncWriter(os.Stderr)
+	logger := log.NewLogfmtLogger(w)
+
+	eh := fake.New(func() {})
+	defer eh.Stop()
+
+	// Get a randomly available port by open and closing a TCP socket
+	addr, err := net.ResolveTCPAddr("tcp", localhost+":0")
+	require.NoError(t, err)
+	l, err := net.ListenTCP("tcp", addr)
+	require.NoError(t, err)
+	port := l.Addr().(*net.TCPAddr).Port
+	require.NoError(t, l.Close())
+
+	defaults := server.Config{}
+	defaults.RegisterFlags(flag.NewFlagSet("empty", flag.ContinueOnError))
+	defaults.HTTPListenAddress = localhost
+	defaults.HTTPListenPort = port
+	defaults.GRPCListenAddress = localhost
+	defaults.GRPCListenPort = 0 // Not testing GRPC, a random port will be assigned
+
+	config := &scrapeconfig.HerokuDrainTargetConfig{
+		Server: defaults,
+		Labels: model.LabelSet{
+			"job": "heroku",
+		},
+		UseIncomingTimestamp: true,
+	}
+	rlbl := []*relabel.Config{
+		{
+			SourceLabels: model.LabelNames{"__heroku_drain_app"},
+			TargetLabel:  "app",
+			Action:       relabel.Replace,
+			Regex:        relabel.MustNewRegexp("(.*)"),
+			Replacement:  "$1",
+		},
+		{
+			SourceLabels: model.LabelNames{"__heroku_drain_proc"},
+			TargetLabel:  "proc",
+			Action:       relabel.Replace,
+			Regex:        relabel.MustNewRegexp("(.*)"),
+			Replacement:  "$1",
+		},
+		{
+			SourceLabels: model.LabelNames{"__heroku_drain_host"},
+			TargetLabel:  "host",
+			Action:       relabel.Replace,
+			Regex:        relabel.MustNewRegexp("(.*)"),
+			Replacement:  "$1",
+		},
+	}
+
+	metrics := NewMetrics(prometheus.NewRegistry())
+	tgt, err := NewTarget(metrics, logger, eh, "job1", config, rlbl)
+	require.NoError(t, err)
+	defer func() {
+		_ = tgt.Stop()
+	}()
+
+	body := logplexBody(
+		"<190>1 2022-06-13T14:52:23.622778+00:00 host app web.1 - Started GET \"/\" for 127.0.0.1",
+		"<158>1 2022-06-13T14:52:24.000000+00:00 host heroku router - at=info method=GET path=\"/\"\n",
+	)
+	url := "http://" + localhost + ":" + strconv.Itoa(port) + DrainPath + "?tenant_id=tenant1"
+
+	// The server may take a moment to start listening.
+	var res *http.Response
+	require.Eventually(t, func() bool {
+		res, err = http.Post(url, "application/logplex-1", strings.NewReader(body))
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, http.StatusNoContent, res.StatusCode)
+	res.Body.Close()
+
+	require.Eventually(t, func() bool {
+		return len(eh.Received()) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	received := eh.Received()
+	require.Equal(t, model.LabelSet{
+		"job":                            "heroku",
+		"app":                            "app",
+		"proc":                           "web.1",
+		"host":                           "host",
+		lokiClient.ReservedLabelTenantID: "tenant1",
+	}, received[0].Labels)
+	require.Equal(t, "Started GET \"/\" for 127.0.0.1", received[0].Line)
+	require.Equal(t, time.Date(2022, 6, 13, 14, 52, 23, 622778000, time.UTC), received[0].Timestamp.UTC())
+
+	require.Equal(t, model.LabelValue("heroku"), received[1].Labels["app"])
+	require.Equal(t, model.LabelValue("router"), received[1].Labels["proc"])
+	require.Equal(t, "at=info method=GET path=\"/\"", received[1].Line)
+
+	// Bodies with invalid framing are rejected.
+	res, err = http.Post(url, "application/logplex-1", strings.NewReader("12 <190>1"))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+	res.Body.Close()
+}
+
+func TestParseFrames(t *testing.T) {
+	var frames []string
+	err := parseFrames(strings.NewReader(logplexBody("<1>1 a", "<2>1 bc")), func(frame []byte) {
+		frames = append(frames, string(frame))
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"<1>1 a", "<2>1 bc"}, frames)
+
+	require.Error(t, parseFrames(strings.NewReader("abc <1>1 a"), func([]byte) {}))
+	require.Error(t, parseFrames(strings.NewReader("100 <1>1 a"), func([]byte) {}))
+}
+
+func TestParseMessage(t *testing.T) {
+	msg, err := parseMessage([]byte("<190>1 - host app web.1 - hello world"))
+	require.NoError(t, err)
+	require.Equal(t, message{hostname: "host", appname: "app", procID: "web.1", msgID: "-", message: "hello world"}, msg)
+
+	_, err = parseMessage([]byte("hello world"))
+	require.Error(t, err)
+	_, err = parseMessage([]byte("<190>1 notatime host app web.1 - hello"))
+	require.Error(t, err)
+}
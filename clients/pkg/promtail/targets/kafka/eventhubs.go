@@ -0,0 +1,146 @@
+package kafka
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
+
+	"github.com/grafana/loki/clients/pkg/promtail/api"
+	"github.com/grafana/loki/clients/pkg/promtail/scrapeconfig"
+
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+const (
+	// eventHubsKafkaPort is the port of the Kafka endpoint of the Event Hubs namespaces.
+	eventHubsKafkaPort = "9093"
+	// eventHubsKafkaVersion is the oldest Kafka version supported by Event Hubs.
+	eventHubsKafkaVersion = "1.0.0"
+	// eventHubsConnectionStringUser is the SASL user authenticating with a connection string.
+	eventHubsConnectionStringUser = "$ConnectionString"
+
+	labelKeyAzureEventHubsCategory   = "__meta_azure_event_hubs_category"
+	labelKeyAzureEventHubsResourceID = "__meta_azure_event_hubs_resource_id"
+)
+
+// eventHubsKafkaConfig returns the configuration of the Kafka endpoint of the Event Hubs.
+func eventHubsKafkaConfig(cfg *scrapeconfig.AzureEventHubsTargetConfig) (*scrapeconfig.KafkaTargetConfig, error) {
+	if cfg.FullyQualifiedNamespace == "" {
+		return nil, errors.New("no Azure Event Hubs fully qualified namespace defined")
+	}
+	if cfg.ConnectionString.Value == "" {
+		return nil, errors.New("no Azure Event Hubs connection string defined")
+	}
+	if len(cfg.EventHubs) == 0 {
+		return nil, errors.New("no event hubs given to be consumed")
+	}
+
+	broker := cfg.FullyQualifiedNamespace
+	if _, _, err := net.SplitHostPort(broker); err != nil {
+		broker = net.JoinHostPort(broker, eventHubsKafkaPort)
+	}
+	return &scrapeconfig.KafkaTargetConfig{
+		Labels:               cfg.Labels,
+		UseIncomingTimestamp: cfg.UseIncomingTimestamp,
+		Brokers:              []string{broker},
+		GroupID:              cfg.GroupID,
+		Topics:               cfg.EventHubs,
+		Version:              eventHubsKafkaVersion,
+		Authentication: scrapeconfig.KafkaAuthentication{
+			Type: scrapeconfig.KafkaAuthenticationTypeSASL,
+			SASLConfig: scrapeconfig.KafkaSASLConfig{
+				Mechanism: sarama.SASLTypePlaintext,
+				User:      eventHubsConnectionStringUser,
+				Password:  cfg.ConnectionString,
+				UseTLS:    true,
+			},
+		},
+	}, nil
+}
+
+// azureResourceLogs is the envelope of the resource logs exported by Azure to Event Hubs.
+type azureResourceLogs struct {
+	Records []json.RawMessage `json:"records"`
+}
+
+// azureResourceLog holds the fields of a resource log used by the parser, see
+// https://docs.microsoft.com/en-us/azure/azure-monitor/essentials/resource-logs-schema.
+type azureResourceLog struct {
+	Time string `json:"time"`
+	// The activity logs name the time timeStamp.
+	TimeStamp  string `json:"timeStamp"`
+	Category   string `json:"category"`
+	ResourceID string `json:"resourceId"`
+}
+
+func (l azureResourceLog) time() (time.Time, bool) {
+	for _, v := range []string{l.Time, l.TimeStamp} {
+		if v == "" {
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// EventHubsMessageParser converts each record of the Azure resource logs exported to Event Hubs into an entry.
+// The category and the resource id of the records are available to the relabeling as the
+// __meta_azure_event_hubs_category and __meta_azure_event_hubs_resource_id labels. The other messages are
+// converted as they are, unless DisallowCustomMessages is set.
+type EventHubsMessageParser struct {
+	DisallowCustomMessages bool
+}
+
+// Parse implements MessageParser.
+func (p *EventHubsMessageParser) Parse(message *sarama.ConsumerMessage, lbs model.LabelSet, relabels []*relabel.Config, useIncomingTimestamp bool) ([]api.Entry, error) {
+	var logs azureResourceLogs
+	err := json.Unmarshal(message.Value, &logs)
+	if err != nil || len(logs.Records) == 0 {
+		if p.DisallowCustomMessages {
+			return nil, errors.New("the message is not Azure resource logs")
+		}
+		return KafkaMessageParser{}.Parse(message, lbs, relabels, useIncomingTimestamp)
+	}
+
+	entries := make([]api.Entry, 0, len(logs.Records))
+	for i, raw := range logs.Records {
+		var record azureResourceLog
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return entries, fmt.Errorf("invalid record %d: %w", i, err)
+		}
+		var line bytes.Buffer
+		if err := json.Compact(&line, raw); err != nil {
+			return entries, fmt.Errorf("invalid record %d: %w", i, err)
+		}
+
+		ts := time.Now()
+		if useIncomingTimestamp {
+			ts = message.Timestamp
+			if t, ok := record.time(); ok {
+				ts = t
+			}
+		}
+		entries = append(entries, api.Entry{
+			Labels: messageLabels(lbs, relabels,
+				messageKeyLabel(message),
+				labels.Label{Name: labelKeyAzureEventHubsCategory, Value: record.Category},
+				labels.Label{Name: labelKeyAzureEventHubsResourceID, Value: record.ResourceID},
+			),
+			Entry: logproto.Entry{
+				Line:      line.String(),
+				Timestamp: ts,
+			},
+		})
+	}
+	return entries, nil
+}
@@ -0,0 +1,124 @@
+package kafka
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/clients/pkg/promtail/scrapeconfig"
+)
+
+const testResourceLogs = `{
+  "records": [
+    {
+      "time": "2022-03-08T10:22:41.1234567Z",
+      "resourceId": "/SUBSCRIPTIONS/0000/RESOURCEGROUPS/RG/PROVIDERS/MICROSOFT.WEB/SITES/APP",
+      "category": "AppServiceHTTPLogs",
+      "properties": {"CsMethod": "GET", "ScStatus": 200}
+    },
+    {
+      "timeStamp": "2022-03-08T10:22:42Z",
+      "resourceId": "/SUBSCRIPTIONS/0000/RESOURCEGROUPS/RG",
+      "category": "Administrative"
+    }
+  ]
+}`
+
+func Test_EventHubsMessageParser(t *testing.T) {
+	relabels := []*relabel.Config{
+		{
+			SourceLabels: model.LabelNames{labelKeyAzureEventHubsCategory},
+			Regex:        relabel.MustNewRegexp("(.*)"),
+			TargetLabel:  "category",
+			Replacement:  "$1",
+			Action:       relabel.Replace,
+		},
+	}
+	messageTime := time.Unix(10, 0)
+	message := &sarama.ConsumerMessage{
+		Timestamp: messageTime,
+		Value:     []byte(testResourceLogs),
+	}
+
+	entries, err := (&EventHubsMessageParser{}).Parse(message, model.LabelSet{"job": "azure"}, relabels, true)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	require.Equal(t, model.LabelSet{"job": "azure", "category": "AppServiceHTTPLogs"}, entries[0].Labels)
+	require.Equal(t, time.Date(2022, 3, 8, 10, 22, 41, 123456700, time.UTC), entries[0].Timestamp)
+	require.Equal(t, `{"time":"2022-03-08T10:22:41.1234567Z","resourceId":"/SUBSCRIPTIONS/0000/RESOURCEGROUPS/RG/PROVIDERS/MICROSOFT.WEB/SITES/APP","category":"AppServiceHTTPLogs","properties":{"CsMethod":"GET","ScStatus":200}}`, entries[0].Line)
+
+	require.Equal(t, model.LabelSet{"job": "azure", "category": "Administrative"}, entries[1].Labels)
+	require.Equal(t, time.Date(2022, 3, 8, 10, 22, 42, 0, time.UTC), entries[1].Timestamp)
+
+	// Without use_incoming_timestamp, the time the message is read is used.
+	entries, err = (&EventHubsMessageParser{}).Parse(message, model.LabelSet{"job": "azure"}, relabels, false)
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now(), entries[0].Timestamp, time.Minute)
+}
+
+func Test_EventHubsMessageParser_CustomMessages(t *testing.T) {
+	for _, value := range []string{"plain text", `{"foo": "bar"}`, `{"records": []}`} {
+		message := &sarama.ConsumerMessage{
+			Timestamp: time.Unix(10, 0),
+			Key:       []byte("key"),
+			Value:     []byte(value),
+		}
+
+		entries, err := (&EventHubsMessageParser{}).Parse(message, model.LabelSet{"job": "azure"}, nil, true)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		require.Equal(t, value, entries[0].Line)
+		require.Equal(t, time.Unix(10, 0), entries[0].Timestamp)
+
+		entries, err = (&EventHubsMessageParser{DisallowCustomMessages: true}).Parse(message, model.LabelSet{"job": "azure"}, nil, true)
+		require.Error(t, err)
+		require.Empty(t, entries)
+	}
+}
+
+func Test_validateConfig_EventHubs(t *testing.T) {
+	cfg := &scrapeconfig.Config{
+		AzureEventHubsConfig: &scrapeconfig.AzureEventHubsTargetConfig{
+			FullyQualifiedNamespace: "my-namespace.servicebus.windows.net",
+			ConnectionString:        flagext.Secret{Value: "Endpoint=sb://my-namespace.servicebus.windows.net/;SharedAccessKeyName=promtail;SharedAccessKey=secret"},
+			EventHubs:               []string{"insights-logs"},
+			Labels:                  model.LabelSet{"job": "azure"},
+		},
+	}
+	require.NoError(t, validateConfig(cfg))
+	require.Equal(t, []string{"my-namespace.servicebus.windows.net:9093"}, cfg.KafkaConfig.Brokers)
+	require.Equal(t, []string{"insights-logs"}, cfg.KafkaConfig.Topics)
+	require.Equal(t, "promtail", cfg.KafkaConfig.GroupID)
+	require.Equal(t, model.LabelSet{"job": "azure"}, cfg.KafkaConfig.Labels)
+	require.Equal(t, scrapeconfig.KafkaAuthentication{
+		Type: scrapeconfig.KafkaAuthenticationTypeSASL,
+		SASLConfig: scrapeconfig.KafkaSASLConfig{
+			Mechanism: sarama.SASLTypePlaintext,
+			User:      "$ConnectionString",
+			Password:  cfg.AzureEventHubsConfig.ConnectionString,
+			UseTLS:    true,
+		},
+	}, cfg.KafkaConfig.Authentication)
+	require.IsType(t, &EventHubsMessageParser{}, messageParser(*cfg))
+
+	cfg.AzureEventHubsConfig.FullyQualifiedNamespace = "my-namespace.servicebus.windows.net:19093"
+	cfg.KafkaConfig = nil
+	require.NoError(t, validateConfig(cfg))
+	require.Equal(t, []string{"my-namespace.servicebus.windows.net:19093"}, cfg.KafkaConfig.Brokers)
+
+	// The Kafka configuration can't be set too.
+	require.Error(t, validateConfig(cfg))
+
+	require.Error(t, validateConfig(&scrapeconfig.Config{
+		AzureEventHubsConfig: &scrapeconfig.AzureEventHubsTargetConfig{
+			FullyQualifiedNamespace: "my-namespace.servicebus.windows.net",
+			EventHubs:               []string{"insights-logs"},
+		},
+	}))
+}
@@ -0,0 +1,55 @@
+package kafka
+
+import (
+	"github.com/Shopify/sarama"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
+
+	"github.com/grafana/loki/clients/pkg/promtail/api"
+
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+// MessageParser converts a Kafka message into the entries it holds.
+type MessageParser interface {
+	Parse(message *sarama.ConsumerMessage, labels model.LabelSet, relabels []*relabel.Config, useIncomingTimestamp bool) ([]api.Entry, error)
+}
+
+// KafkaMessageParser converts each message into an entry.
+type KafkaMessageParser struct{}
+
+// Parse implements MessageParser.
+func (KafkaMessageParser) Parse(message *sarama.ConsumerMessage, lbs model.LabelSet, relabels []*relabel.Config, useIncomingTimestamp bool) ([]api.Entry, error) {
+	return []api.Entry{{
+		Labels: messageLabels(lbs, relabels, messageKeyLabel(message)),
+		Entry: logproto.Entry{
+			Line:      string(message.Value),
+			Timestamp: timestamp(useIncomingTimestamp, message.Timestamp),
+		},
+	}}, nil
+}
+
+func messageKeyLabel(message *sarama.ConsumerMessage) labels.Label {
+	mk := string(message.Key)
+	if len(mk) == 0 {
+		mk = defaultKafkaMessageKey
+	}
+	return labels.Label{
+		Name:  labelKeyKafkaMessageKey,
+		Value: mk,
+	}
+}
+
+// messageLabels returns the labels of the target merged with the labels of the message, relabeled.
+func messageLabels(lbs model.LabelSet, relabels []*relabel.Config, messageLabels ...labels.Label) model.LabelSet {
+	// TODO: Possibly need to format after merging with discovered labels because we can specify multiple labels in source labels
+	// https://github.com/grafana/loki/pull/4745#discussion_r750022234
+	formatted := format(labels.New(messageLabels...), relabels)
+
+	out := lbs.Clone()
+	if len(formatted) > 0 {
+		out = out.Merge(formatted)
+	}
+	return out
+}
@@ -4,7 +4,8 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/prometheus/prometheus/model/labels"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
 	"github.com/prometheus/prometheus/model/relabel"
 
 	"github.com/Shopify/sarama"
@@ -12,8 +13,6 @@ import (
 
 	"github.com/grafana/loki/clients/pkg/promtail/api"
 	"github.com/grafana/loki/clients/pkg/promtail/targets/target"
-
-	"github.com/grafana/loki/pkg/logproto"
 )
 
 type runnableDroppedTarget struct {
@@ -26,6 +25,7 @@ func (d *runnableDroppedTarget) run() {
 }
 
 type Target struct {
+	logger               log.Logger
 	discoveredLabels     model.LabelSet
 	lbs                  model.LabelSet
 	details              ConsumerDetails
@@ -34,17 +34,21 @@ type Target struct {
 	client               api.EntryHandler
 	relabelConfig        []*relabel.Config
 	useIncomingTimestamp bool
+	messageParser        MessageParser
 }
 
 func NewTarget(
+	logger log.Logger,
 	session sarama.ConsumerGroupSession,
 	claim sarama.ConsumerGroupClaim,
 	discoveredLabels, lbs model.LabelSet,
 	relabelConfig []*relabel.Config,
 	client api.EntryHandler,
 	useIncomingTimestamp bool,
+	messageParser MessageParser,
 ) *Target {
 	return &Target{
+		logger:               logger,
 		discoveredLabels:     discoveredLabels,
 		lbs:                  lbs,
 		details:              newDetails(session, claim),
@@ -53,6 +57,7 @@ func NewTarget(
 		client:               client,
 		relabelConfig:        relabelConfig,
 		useIncomingTimestamp: useIncomingTimestamp,
+		messageParser:        messageParser,
 	}
 }
 
@@ -64,28 +69,12 @@ const (
 func (t *Target) run() {
 	defer t.client.Stop()
 	for message := range t.claim.Messages() {
-		mk := string(message.Key)
-		if len(mk) == 0 {
-			mk = defaultKafkaMessageKey
-		}
-
-		// TODO: Possibly need to format after merging with discovered labels because we can specify multiple labels in source labels
-		// https://github.com/grafana/loki/pull/4745#discussion_r750022234
-		lbs := format([]labels.Label{{
-			Name:  labelKeyKafkaMessageKey,
-			Value: mk,
-		}}, t.relabelConfig)
-
-		out := t.lbs.Clone()
-		if len(lbs) > 0 {
-			out = out.Merge(lbs)
+		entries, err := t.messageParser.Parse(message, t.lbs, t.relabelConfig, t.useIncomingTimestamp)
+		if err != nil {
+			level.Warn(t.logger).Log("msg", "dropping message that could not be parsed", "topic", message.Topic, "partition", message.Partition, "offset", message.Offset, "err", err)
 		}
-		t.client.Chan() <- api.Entry{
-			Entry: logproto.Entry{
-				Line:      string(message.Value),
-				Timestamp: timestamp(t.useIncomingTimestamp, message.Timestamp),
-			},
-			Labels: out,
+		for _, e := range entries {
+			t.client.Chan() <- e
 		}
 		t.session.MarkMessage(message, "")
 	}
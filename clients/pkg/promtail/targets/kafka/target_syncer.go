@@ -287,6 +287,7 @@ func (ts *TargetSyncer) NewTarget(session sarama.ConsumerGroupSession, claim sar
 		}, nil
 	}
 	t := NewTarget(
+		log.With(ts.logger, "topic", claim.Topic(), "partition", claim.Partition()),
 		session,
 		claim,
 		discoveredLabels,
@@ -294,12 +295,33 @@ func (ts *TargetSyncer) NewTarget(session sarama.ConsumerGroupSession, claim sar
 		ts.cfg.RelabelConfigs,
 		ts.pipeline.Wrap(ts.client),
 		ts.cfg.KafkaConfig.UseIncomingTimestamp,
+		messageParser(ts.cfg),
 	)
 
 	return t, nil
 }
 
+// messageParser returns the parser of the messages of the scrape config.
+func messageParser(cfg scrapeconfig.Config) MessageParser {
+	if cfg.AzureEventHubsConfig != nil {
+		return &EventHubsMessageParser{
+			DisallowCustomMessages: cfg.AzureEventHubsConfig.DisallowCustomMessages,
+		}
+	}
+	return KafkaMessageParser{}
+}
+
 func validateConfig(cfg *scrapeconfig.Config) error {
+	if cfg.AzureEventHubsConfig != nil {
+		if cfg.KafkaConfig != nil {
+			return errors.New("Kafka and Azure Event Hubs configurations cannot be used together")
+		}
+		kafkaConfig, err := eventHubsKafkaConfig(cfg.AzureEventHubsConfig)
+		if err != nil {
+			return err
+		}
+		cfg.KafkaConfig = kafkaConfig
+	}
 	if cfg.KafkaConfig == nil {
 		return errors.New("Kafka configuration is empty")
 	}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/Shopify/sarama"
+	"github.com/go-kit/log"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/relabel"
 	"github.com/stretchr/testify/require"
@@ -151,7 +152,7 @@ func Test_TargetRun(t *testing.T) {
 					closed = true
 				},
 			)
-			tg := NewTarget(session, claim, tt.inDiscoveredLS, tt.inLS, tt.relabels, fc, true)
+			tg := NewTarget(log.NewNopLogger(), session, claim, tt.inDiscoveredLS, tt.inLS, tt.relabels, fc, true, KafkaMessageParser{})
 
 			var wg sync.WaitGroup
 			wg.Add(1)
@@ -16,9 +16,11 @@ import (
 	"github.com/grafana/loki/clients/pkg/promtail/targets/file"
 	"github.com/grafana/loki/clients/pkg/promtail/targets/gcplog"
 	"github.com/grafana/loki/clients/pkg/promtail/targets/gelf"
+	"github.com/grafana/loki/clients/pkg/promtail/targets/heroku"
 	"github.com/grafana/loki/clients/pkg/promtail/targets/journal"
 	"github.com/grafana/loki/clients/pkg/promtail/targets/kafka"
 	"github.com/grafana/loki/clients/pkg/promtail/targets/lokipush"
+	"github.com/grafana/loki/clients/pkg/promtail/targets/podlogs"
 	"github.com/grafana/loki/clients/pkg/promtail/targets/stdin"
 	"github.com/grafana/loki/clients/pkg/promtail/targets/syslog"
 	"github.com/grafana/loki/clients/pkg/promtail/targets/target"
@@ -37,6 +39,8 @@ const (
 	CloudflareConfigs    = "cloudflareConfigs"
 	DockerConfigs        = "dockerConfigs"
 	DockerSDConfigs      = "dockerSDConfigs"
+	HerokuDrainConfigs   = "herokuDrainConfigs"
+	PodLogsConfigs       = "podLogsConfigs"
 )
 
 type targetManager interface {
@@ -76,6 +80,9 @@ func NewTargetManagers(
 
 	for _, cfg := range scrapeConfigs {
 		switch {
+		// The pod logs configs have kubernetes_sd_configs, which are otherwise used to discover files.
+		case cfg.KubernetesPodLogsConfig != nil:
+			targetScrapeConfigs[PodLogsConfigs] = append(targetScrapeConfigs[PodLogsConfigs], cfg)
 		case cfg.HasServiceDiscoveryConfig():
 			targetScrapeConfigs[FileScrapeConfigs] = append(targetScrapeConfigs[FileScrapeConfigs], cfg)
 		case cfg.JournalConfig != nil:
@@ -88,7 +95,7 @@ func NewTargetManagers(
 			targetScrapeConfigs[PushScrapeConfigs] = append(targetScrapeConfigs[PushScrapeConfigs], cfg)
 		case cfg.WindowsConfig != nil:
 			targetScrapeConfigs[WindowsEventsConfigs] = append(targetScrapeConfigs[WindowsEventsConfigs], cfg)
-		case cfg.KafkaConfig != nil:
+		case cfg.KafkaConfig != nil, cfg.AzureEventHubsConfig != nil:
 			targetScrapeConfigs[KafkaConfigs] = append(targetScrapeConfigs[KafkaConfigs], cfg)
 		case cfg.GelfConfig != nil:
 			targetScrapeConfigs[GelfConfigs] = append(targetScrapeConfigs[GelfConfigs], cfg)
@@ -96,6 +103,8 @@ func NewTargetManagers(
 			targetScrapeConfigs[CloudflareConfigs] = append(targetScrapeConfigs[CloudflareConfigs], cfg)
 		case cfg.DockerSDConfigs != nil:
 			targetScrapeConfigs[DockerSDConfigs] = append(targetScrapeConfigs[DockerSDConfigs], cfg)
+		case cfg.HerokuDrainConfig != nil:
+			targetScrapeConfigs[HerokuDrainConfigs] = append(targetScrapeConfigs[HerokuDrainConfigs], cfg)
 		default:
 			return nil, fmt.Errorf("no valid target scrape config defined for %q", cfg.JobName)
 		}
@@ -122,6 +131,8 @@ func NewTargetManagers(
 		gelfMetrics       *gelf.Metrics
 		cloudflareMetrics *cloudflare.Metrics
 		dockerMetrics     *docker.Metrics
+		herokuMetrics     *heroku.Metrics
+		podLogsMetrics    *podlogs.Metrics
 	)
 	if len(targetScrapeConfigs[FileScrapeConfigs]) > 0 {
 		fileMetrics = file.NewMetrics(reg)
@@ -141,6 +152,12 @@ func NewTargetManagers(
 	if len(targetScrapeConfigs[DockerConfigs]) > 0 || len(targetScrapeConfigs[DockerSDConfigs]) > 0 {
 		dockerMetrics = docker.NewMetrics(reg)
 	}
+	if len(targetScrapeConfigs[HerokuDrainConfigs]) > 0 {
+		herokuMetrics = heroku.NewMetrics(reg)
+	}
+	if len(targetScrapeConfigs[PodLogsConfigs]) > 0 {
+		podLogsMetrics = podlogs.NewMetrics(reg)
+	}
 
 	for target, scrapeConfigs := range targetScrapeConfigs {
 		switch target {
@@ -258,6 +275,22 @@ func NewTargetManagers(
 				return nil, errors.Wrap(err, "failed to make Docker service discovery target manager")
 			}
 			targetManagers = append(targetManagers, cfTargetManager)
+		case HerokuDrainConfigs:
+			herokuTargetManager, err := heroku.NewTargetManager(herokuMetrics, logger, client, scrapeConfigs)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to make Heroku drain target manager")
+			}
+			targetManagers = append(targetManagers, herokuTargetManager)
+		case PodLogsConfigs:
+			pos, err := getPositionFile()
+			if err != nil {
+				return nil, err
+			}
+			podLogsTargetManager, err := podlogs.NewTargetManager(podLogsMetrics, logger, pos, client, scrapeConfigs)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to make Kubernetes pod logs target manager")
+			}
+			targetManagers = append(targetManagers, podLogsTargetManager)
 		default:
 			return nil, errors.New("unknown scrape config")
 		}
@@ -0,0 +1,52 @@
+package podlogs
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Metrics holds a set of Kubernetes pod logs target metrics.
+type Metrics struct {
+	reg prometheus.Registerer
+
+	podLogsEntries      prometheus.Counter
+	podLogsErrors       prometheus.Counter
+	podLogsReconnects   prometheus.Counter
+	podLogsTargetsCount prometheus.Gauge
+}
+
+// NewMetrics creates a new set of Kubernetes pod logs target metrics. If reg is
+// non-nil, the metrics will be registered.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	var m Metrics
+	m.reg = reg
+
+	m.podLogsEntries = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "promtail",
+		Name:      "kubernetes_pod_logs_target_entries_total",
+		Help:      "Total number of successful entries read from the Kubernetes API",
+	})
+	m.podLogsErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "promtail",
+		Name:      "kubernetes_pod_logs_target_parsing_errors_total",
+		Help:      "Total number of parsing errors while reading logs from the Kubernetes API",
+	})
+	m.podLogsReconnects = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "promtail",
+		Name:      "kubernetes_pod_logs_target_reconnects_total",
+		Help:      "Total number of times the log streams of containers were reopened",
+	})
+	m.podLogsTargetsCount = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "promtail",
+		Name:      "kubernetes_pod_logs_targets",
+		Help:      "Number of containers whose logs are read from the Kubernetes API",
+	})
+
+	if reg != nil {
+		reg.MustRegister(
+			m.podLogsEntries,
+			m.podLogsErrors,
+			m.podLogsReconnects,
+			m.podLogsTargetsCount,
+		)
+	}
+
+	return &m
+}
@@ -0,0 +1,62 @@
+package podlogs
+
+import (
+	"context"
+	"io"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// LogStreamer opens the log streams of containers.
+type LogStreamer interface {
+	// Stream follows the logs of the container, prefixed by their timestamp, starting at since when it is not zero.
+	Stream(ctx context.Context, namespace, pod, container string, since time.Time) (io.ReadCloser, error)
+}
+
+// apiStreamer streams the logs of containers through the pods/log endpoint of the Kubernetes API.
+type apiStreamer struct {
+	client kubernetes.Interface
+}
+
+// newAPIStreamer creates a client of the Kubernetes API, from the kubeconfig file if set, otherwise from the
+// service account of the pod promtail runs in.
+func newAPIStreamer(kubeconfig string) (*apiStreamer, error) {
+	var (
+		config *rest.Config
+		err    error
+	)
+	if kubeconfig != "" {
+		config, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
+	} else {
+		config, err = rest.InClusterConfig()
+	}
+	if err != nil {
+		return nil, err
+	}
+	config.UserAgent = "promtail"
+
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &apiStreamer{client: client}, nil
+}
+
+func (s *apiStreamer) Stream(ctx context.Context, namespace, pod, container string, since time.Time) (io.ReadCloser, error) {
+	opts := &corev1.PodLogOptions{
+		Container:  container,
+		Follow:     true,
+		Timestamps: true,
+	}
+	if !since.IsZero() {
+		// The API has a precision of a second, the lines already read are skipped by the target.
+		sinceTime := metav1.NewTime(since)
+		opts.SinceTime = &sinceTime
+	}
+	return s.client.CoreV1().Pods(namespace).GetLogs(pod, opts).Stream(ctx)
+}
@@ -0,0 +1,260 @@
+package podlogs
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/backoff"
+	"github.com/prometheus/common/model"
+	"go.uber.org/atomic"
+
+	"github.com/grafana/loki/clients/pkg/promtail/api"
+	"github.com/grafana/loki/clients/pkg/promtail/positions"
+	"github.com/grafana/loki/clients/pkg/promtail/targets/target"
+
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+var reconnectBackoff = backoff.Config{
+	MinBackoff: time.Second,
+	MaxBackoff: time.Minute,
+}
+
+// Target follows the logs of a container through the Kubernetes API. The stream is reopened when it ends, for
+// example when the container restarts, starting at the timestamp of the last line read, which is recorded in
+// the positions.
+type Target struct {
+	metrics          *Metrics
+	logger           log.Logger
+	handler          api.EntryHandler
+	positions        positions.Positions
+	streamer         LogStreamer
+	namespace        string
+	pod              string
+	container        string
+	discoveredLabels model.LabelSet
+	labels           model.LabelSet
+
+	cancel  context.CancelFunc
+	done    chan struct{}
+	running *atomic.Bool
+
+	// The timestamp of the last line read and the lines read at that timestamp, to
+	// not read them twice when the stream is reopened.
+	lastTs    time.Time
+	lastLines map[string]int
+
+	mtx sync.Mutex
+	err error
+}
+
+// NewTarget creates a new Target and starts following the logs of the container.
+func NewTarget(
+	metrics *Metrics,
+	logger log.Logger,
+	handler api.EntryHandler,
+	position positions.Positions,
+	streamer LogStreamer,
+	namespace, pod, container string,
+	discoveredLabels, labels model.LabelSet,
+) *Target {
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &Target{
+		metrics:          metrics,
+		logger:           logger,
+		handler:          handler,
+		positions:        position,
+		streamer:         streamer,
+		namespace:        namespace,
+		pod:              pod,
+		container:        container,
+		discoveredLabels: discoveredLabels,
+		labels:           labels,
+		cancel:           cancel,
+		done:             make(chan struct{}),
+		running:          atomic.NewBool(false),
+	}
+	go t.run(ctx)
+	return t
+}
+
+// positionKey returns the key of the timestamp of the last line read from the container.
+func (t *Target) positionKey() string {
+	return positions.CursorKey(fmt.Sprintf("kubernetes/%s/%s/%s", t.namespace, t.pod, t.container))
+}
+
+func (t *Target) run(ctx context.Context) {
+	defer close(t.done)
+
+	bo := backoff.New(ctx, reconnectBackoff)
+	for {
+		read, err := t.follow(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		t.setErr(err)
+		if err != nil {
+			level.Warn(t.logger).Log("msg", "could not follow the container logs", "err", err)
+		} else {
+			level.Debug(t.logger).Log("msg", "container log stream ended", "lines", read)
+		}
+		if read > 0 {
+			bo.Reset()
+		}
+
+		bo.Wait()
+		if ctx.Err() != nil {
+			return
+		}
+		t.metrics.podLogsReconnects.Inc()
+	}
+}
+
+// follow reads the log stream of the container until it ends, and returns the number of lines read.
+func (t *Target) follow(ctx context.Context) (int, error) {
+	var since time.Time
+	pos, err := t.positions.Get(t.positionKey())
+	if err != nil {
+		return 0, err
+	}
+	if pos != 0 {
+		since = time.Unix(0, pos)
+	}
+
+	stream, err := t.streamer.Stream(ctx, t.namespace, t.pod, t.container, since)
+	if err != nil {
+		return 0, err
+	}
+	defer stream.Close()
+
+	t.running.Store(true)
+	defer t.running.Store(false)
+	t.setErr(nil)
+
+	// The lines read at the position are skipped, other lines sharing their timestamp are not.
+	skip := map[string]int{}
+	if since.Equal(t.lastTs) {
+		for line, n := range t.lastLines {
+			skip[line] = n
+		}
+	}
+
+	entries := t.handler.Chan()
+	r := bufio.NewReader(stream)
+	read := 0
+	for {
+		line, err := r.ReadString('\n')
+		if len(line) > 0 {
+			ts, msg, parseErr := extractTs(strings.TrimRight(line, "\r\n"))
+			switch {
+			case parseErr != nil:
+				level.Error(t.logger).Log("msg", "could not extract timestamp, skipping line", "err", parseErr)
+				t.metrics.podLogsErrors.Inc()
+			case ts.Before(since):
+				// Lines before the position were read before the stream was reopened.
+			case ts.Equal(since) && skip[msg] > 0:
+				// So were the lines at the position read by this target.
+				skip[msg]--
+			default:
+				select {
+				case entries <- api.Entry{
+					Labels: t.labels.Clone(),
+					Entry: logproto.Entry{
+						Timestamp: ts,
+						Line:      msg,
+					},
+				}:
+				case <-ctx.Done():
+					return read, nil
+				}
+				read++
+				t.metrics.podLogsEntries.Inc()
+				t.positions.Put(t.positionKey(), ts.UnixNano())
+				if !ts.Equal(t.lastTs) {
+					t.lastTs, t.lastLines = ts, map[string]int{}
+				}
+				t.lastLines[msg]++
+			}
+		}
+		if err == io.EOF || ctx.Err() != nil {
+			return read, nil
+		}
+		if err != nil {
+			return read, err
+		}
+	}
+}
+
+// extractTs reads the timestamp from the beginning of the log line, which is
+// expected to follow the format 2006-01-02T15:04:05.999999999Z07:00.
+func extractTs(line string) (time.Time, string, error) {
+	pair := strings.SplitN(line, " ", 2)
+	if len(pair) != 2 {
+		return time.Time{}, line, fmt.Errorf("could not find timestamp in '%s'", line)
+	}
+	ts, err := time.Parse(time.RFC3339Nano, pair[0])
+	if err != nil {
+		return time.Time{}, line, fmt.Errorf("could not parse timestamp from '%s': %w", pair[0], err)
+	}
+	return ts, pair[1], nil
+}
+
+func (t *Target) setErr(err error) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.err = err
+}
+
+// Stop stops following the logs of the container.
+func (t *Target) Stop() {
+	t.cancel()
+	<-t.done
+	level.Debug(t.logger).Log("msg", "stopped Kubernetes pod logs target")
+}
+
+// Type implements Target.
+func (t *Target) Type() target.TargetType {
+	return target.KubernetesPodLogsTargetType
+}
+
+// Ready returns true while the log stream of the container is open.
+func (t *Target) Ready() bool {
+	return t.running.Load()
+}
+
+// DiscoveredLabels implements Target.
+func (t *Target) DiscoveredLabels() model.LabelSet {
+	return t.discoveredLabels
+}
+
+// Labels implements Target.
+func (t *Target) Labels() model.LabelSet {
+	return t.labels
+}
+
+// Details returns target-specific details.
+func (t *Target) Details() interface{} {
+	t.mtx.Lock()
+	errMsg := ""
+	if t.err != nil {
+		errMsg = t.err.Error()
+	}
+	t.mtx.Unlock()
+
+	return map[string]string{
+		"namespace": t.namespace,
+		"pod":       t.pod,
+		"container": t.container,
+		"error":     errMsg,
+		"position":  t.positions.GetString(t.positionKey()),
+		"running":   strconv.FormatBool(t.running.Load()),
+	}
+}
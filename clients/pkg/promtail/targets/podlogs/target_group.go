@@ -0,0 +1,165 @@
+package podlogs
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
+
+	"github.com/grafana/loki/clients/pkg/promtail/api"
+	"github.com/grafana/loki/clients/pkg/promtail/positions"
+	"github.com/grafana/loki/clients/pkg/promtail/targets/target"
+)
+
+const (
+	// See github.com/prometheus/prometheus/discovery/kubernetes
+	kubernetesLabel              = model.MetaLabelPrefix + "kubernetes_"
+	kubernetesLabelNamespace     = kubernetesLabel + "namespace"
+	kubernetesLabelPodName       = kubernetesLabel + "pod_name"
+	kubernetesLabelContainerName = kubernetesLabel + "pod_container_name"
+)
+
+// targetGroup manages the container targets of one job.
+type targetGroup struct {
+	metrics       *Metrics
+	logger        log.Logger
+	positions     positions.Positions
+	streamer      LogStreamer
+	entryHandler  api.EntryHandler
+	defaultLabels model.LabelSet
+	relabelConfig []*relabel.Config
+
+	mtx     sync.Mutex
+	targets map[string]*Target
+	dropped []target.Target
+}
+
+// sync starts following the containers discovered, and stops following the containers which are gone. The groups
+// are all the groups discovered for the job.
+func (tg *targetGroup) sync(groups []*targetgroup.Group) {
+	tg.mtx.Lock()
+	defer tg.mtx.Unlock()
+
+	// The pod role discovers a target per port of each container, the logs of the containers only being followed once.
+	discovered := map[string]model.LabelSet{}
+	for _, group := range groups {
+		for _, t := range group.Targets {
+			lbls := group.Labels.Merge(t)
+			namespace, pod, container := lbls[kubernetesLabelNamespace], lbls[kubernetesLabelPodName], lbls[kubernetesLabelContainerName]
+			if namespace == "" || pod == "" || container == "" {
+				level.Debug(tg.logger).Log("msg", "Kubernetes target is not a pod container, make sure to use the pod role", "source", group.Source)
+				continue
+			}
+			discovered[fmt.Sprintf("%s/%s/%s", namespace, pod, container)] = lbls
+		}
+	}
+
+	for key, t := range tg.targets {
+		if _, ok := discovered[key]; !ok {
+			level.Info(tg.logger).Log("msg", "removing Kubernetes pod logs target", "container", key)
+			t.Stop()
+			// The pod is gone, so is its position.
+			tg.positions.Remove(t.positionKey())
+			delete(tg.targets, key)
+			tg.metrics.podLogsTargetsCount.Dec()
+		}
+	}
+
+	tg.dropped = tg.dropped[:0]
+	for key, discoveredLabels := range discovered {
+		if _, ok := tg.targets[key]; ok {
+			continue
+		}
+
+		lbls := discoveredLabels.Merge(tg.defaultLabels)
+		lb := labels.NewBuilder(nil)
+		for k, v := range lbls {
+			lb.Set(string(k), string(v))
+		}
+		processed := relabel.Process(lb.Labels(), tg.relabelConfig...)
+		if processed == nil {
+			tg.dropped = append(tg.dropped, target.NewDroppedTarget("dropping target, no labels", discoveredLabels))
+			continue
+		}
+		filtered := model.LabelSet{}
+		for _, lbl := range processed {
+			if strings.HasPrefix(lbl.Name, "__") {
+				continue
+			}
+			filtered[model.LabelName(lbl.Name)] = model.LabelValue(lbl.Value)
+		}
+
+		level.Info(tg.logger).Log("msg", "adding Kubernetes pod logs target", "container", key)
+		tg.targets[key] = NewTarget(
+			tg.metrics,
+			log.With(tg.logger, "target", "kubernetes/"+key),
+			tg.entryHandler,
+			tg.positions,
+			tg.streamer,
+			string(discoveredLabels[kubernetesLabelNamespace]),
+			string(discoveredLabels[kubernetesLabelPodName]),
+			string(discoveredLabels[kubernetesLabelContainerName]),
+			discoveredLabels,
+			filtered,
+		)
+		tg.metrics.podLogsTargetsCount.Inc()
+	}
+}
+
+// Ready returns true if at least one target is running.
+func (tg *targetGroup) Ready() bool {
+	tg.mtx.Lock()
+	defer tg.mtx.Unlock()
+
+	for _, t := range tg.targets {
+		if t.Ready() {
+			return true
+		}
+	}
+	return false
+}
+
+// Stop all targets
+func (tg *targetGroup) Stop() {
+	tg.mtx.Lock()
+	defer tg.mtx.Unlock()
+
+	for key, t := range tg.targets {
+		t.Stop()
+		delete(tg.targets, key)
+		tg.metrics.podLogsTargetsCount.Dec()
+	}
+	tg.entryHandler.Stop()
+}
+
+// ActiveTargets return all targets that are ready.
+func (tg *targetGroup) ActiveTargets() []target.Target {
+	tg.mtx.Lock()
+	defer tg.mtx.Unlock()
+
+	result := make([]target.Target, 0, len(tg.targets))
+	for _, t := range tg.targets {
+		if t.Ready() {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+// AllTargets returns all targets of this group, including the dropped ones.
+func (tg *targetGroup) AllTargets() []target.Target {
+	tg.mtx.Lock()
+	defer tg.mtx.Unlock()
+
+	result := make([]target.Target, 0, len(tg.targets)+len(tg.dropped))
+	for _, t := range tg.targets {
+		result = append(result, t)
+	}
+	return append(result, tg.dropped...)
+}
@@ -0,0 +1,192 @@
+package podlogs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/clients/pkg/promtail/client/fake"
+	"github.com/grafana/loki/clients/pkg/promtail/positions"
+)
+
+// fakeStreamer serves the logs of the containers, each call to Stream returning the next stream of the container.
+type fakeStreamer struct {
+	mtx     sync.Mutex
+	streams map[string][]string
+	since   map[string][]time.Time
+}
+
+func (s *fakeStreamer) Stream(ctx context.Context, namespace, pod, container string, since time.Time) (io.ReadCloser, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	key := namespace + "/" + pod + "/" + container
+	s.since[key] = append(s.since[key], since)
+	streams := s.streams[key]
+	if len(streams) == 0 {
+		return nil, errors.New("container is waiting to start")
+	}
+	s.streams[key] = streams[1:]
+	return ioutil.NopCloser(strings.NewReader(streams[0])), nil
+}
+
+func (s *fakeStreamer) sinces(key string) []time.Time {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return append([]time.Time(nil), s.since[key]...)
+}
+
+func newTestPositions(t *testing.T) positions.Positions {
+	t.Helper()
+	dir := t.TempDir()
+	pos, err := positions.New(log.NewNopLogger(), positions.Config{
+		SyncPeriod:    10 * time.Second,
+		PositionsFile: dir + "/positions.yml",
+	})
+	require.NoError(t, err)
+	return pos
+}
+
+func podGroup(pod string, containers ...string) *targetgroup.Group {
+	group := &targetgroup.Group{
+		Source: "pod/default/" + pod,
+		Labels: model.LabelSet{
+			kubernetesLabelNamespace:                      "default",
+			kubernetesLabelPodName:                        model.LabelValue(pod),
+			"__meta_kubernetes_pod_label_app":             "app",
+			"__meta_kubernetes_pod_label_promtail_ignore": "",
+		},
+	}
+	for _, c := range containers {
+		// The pod role discovers a target per container port.
+		for _, port := range []string{"8080", "9090"} {
+			group.Targets = append(group.Targets, model.LabelSet{
+				model.AddressLabel:           model.LabelValue("10.0.0.1:" + port),
+				kubernetesLabelContainerName: model.LabelValue(c),
+			})
+		}
+	}
+	return group
+}
+
+func TestTargetGroup(t *testing.T) {
+	defaultBackoff := reconnectBackoff
+	reconnectBackoff.MinBackoff, reconnectBackoff.MaxBackoff = time.Millisecond, time.Millisecond
+	defer func() { reconnectBackoff = defaultBackoff }()
+
+	w := log.NewSyncWriter(os.Stderr)
+	logger := log.NewLogfmtLogger(w)
+
+	streamer := &fakeStreamer{
+		streams: map[string][]string{
+			"default/pod1/app": {
+				"2022-06-13T14:52:23.000000001Z line 1\n2022-06-13T14:52:23.000000002Z line 2\n",
+				// The container restarted, the lines already read are skipped but not the new
+				// lines sharing the timestamp of the position.
+				"2022-06-13T14:52:23.000000001Z line 1\n2022-06-13T14:52:23.000000002Z line 2\n2022-06-13T14:52:23.000000002Z line 2b\nnot a line\n2022-06-13T14:52:24Z line 3",
+			},
+			"default/pod2/app": {
+				"2022-06-13T14:52:25Z line 4\n",
+			},
+		},
+		since: map[string][]time.Time{},
+	}
+	pos := newTestPositions(t)
+	defer pos.Stop()
+	handler := fake.New(func() {})
+	metrics := NewMetrics(prometheus.NewRegistry())
+
+	tg := &targetGroup{
+		metrics:       metrics,
+		logger:        logger,
+		positions:     pos,
+		streamer:      streamer,
+		entryHandler:  handler,
+		defaultLabels: model.LabelSet{"job": "kubernetes"},
+		relabelConfig: []*relabel.Config{
+			{
+				SourceLabels: model.LabelNames{"__meta_kubernetes_pod_label_app"},
+				TargetLabel:  "app",
+				Action:       relabel.Replace,
+				Regex:        relabel.MustNewRegexp("(.*)"),
+				Replacement:  "$1",
+			},
+			{
+				SourceLabels: model.LabelNames{kubernetesLabelPodName},
+				TargetLabel:  "pod",
+				Action:       relabel.Replace,
+				Regex:        relabel.MustNewRegexp("(.*)"),
+				Replacement:  "$1",
+			},
+			{
+				SourceLabels: model.LabelNames{kubernetesLabelContainerName},
+				Action:       relabel.Drop,
+				Regex:        relabel.MustNewRegexp("sidecar"),
+			},
+		},
+		targets: make(map[string]*Target),
+	}
+
+	tg.sync([]*targetgroup.Group{podGroup("pod1", "app", "sidecar"), podGroup("pod2", "app")})
+	require.Len(t, tg.targets, 2)
+	require.Len(t, tg.AllTargets(), 3)
+
+	require.Eventually(t, func() bool {
+		return len(handler.Received()) == 5
+	}, 5*time.Second, 10*time.Millisecond)
+
+	lines := map[string][]string{}
+	for _, e := range handler.Received() {
+		pod := string(e.Labels["pod"])
+		require.Equal(t, model.LabelSet{"job": "kubernetes", "app": "app", "pod": model.LabelValue(pod)}, e.Labels)
+		lines[pod] = append(lines[pod], e.Line)
+	}
+	require.Equal(t, map[string][]string{
+		"pod1": {"line 1", "line 2", "line 2b", "line 3"},
+		"pod2": {"line 4"},
+	}, lines)
+
+	// The stream is reopened after the last line read.
+	sinces := streamer.sinces("default/pod1/app")
+	require.True(t, sinces[0].IsZero())
+	require.Equal(t, time.Date(2022, 6, 13, 14, 52, 23, 2, time.UTC), sinces[1].UTC())
+	require.Eventually(t, func() bool {
+		return len(streamer.sinces("default/pod1/app")) > 2
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, time.Date(2022, 6, 13, 14, 52, 24, 0, time.UTC), streamer.sinces("default/pod1/app")[2].UTC())
+
+	// The targets of the deleted pods are stopped, and their positions removed.
+	pod2Key := tg.targets["default/pod2/app"].positionKey()
+	require.NotEmpty(t, pos.GetString(pod2Key))
+	tg.sync([]*targetgroup.Group{podGroup("pod1", "app")})
+	require.Len(t, tg.targets, 1)
+	require.Empty(t, pos.GetString(pod2Key))
+
+	tg.Stop()
+	require.Len(t, tg.targets, 0)
+}
+
+func TestExtractTs(t *testing.T) {
+	ts, line, err := extractTs("2022-06-13T14:52:23.123456789Z hello world")
+	require.NoError(t, err)
+	require.Equal(t, "hello world", line)
+	require.Equal(t, time.Date(2022, 6, 13, 14, 52, 23, 123456789, time.UTC), ts)
+
+	_, _, err = extractTs("hello")
+	require.Error(t, err)
+	_, _, err = extractTs("hello world")
+	require.Error(t, err)
+}
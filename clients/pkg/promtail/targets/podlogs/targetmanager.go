@@ -0,0 +1,155 @@
+package podlogs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/prometheus/discovery"
+	"github.com/prometheus/prometheus/discovery/kubernetes"
+
+	"github.com/grafana/loki/clients/pkg/logentry/stages"
+	"github.com/grafana/loki/clients/pkg/promtail/api"
+	"github.com/grafana/loki/clients/pkg/promtail/positions"
+	"github.com/grafana/loki/clients/pkg/promtail/scrapeconfig"
+	"github.com/grafana/loki/clients/pkg/promtail/targets/target"
+
+	"github.com/grafana/loki/pkg/util"
+)
+
+// TargetManager manages the Kubernetes pod logs targets, discovered by the kubernetes_sd_configs of the jobs.
+type TargetManager struct {
+	logger  log.Logger
+	cancel  context.CancelFunc
+	done    chan struct{}
+	manager *discovery.Manager
+	groups  map[string]*targetGroup
+}
+
+// NewTargetManager creates a new TargetManager.
+func NewTargetManager(
+	metrics *Metrics,
+	logger log.Logger,
+	positions positions.Positions,
+	pushClient api.EntryHandler,
+	scrapeConfigs []scrapeconfig.Config,
+) (*TargetManager, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	tm := &TargetManager{
+		logger:  logger,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		manager: discovery.NewManager(ctx, log.With(logger, "component", "kubernetes_pod_logs_discovery")),
+		groups:  make(map[string]*targetGroup),
+	}
+
+	configs := map[string]discovery.Configs{}
+	for _, cfg := range scrapeConfigs {
+		if _, ok := tm.groups[cfg.JobName]; ok {
+			cancel()
+			return nil, fmt.Errorf("`job_name` must be unique for each `kubernetes_pod_logs` scrape_config, a duplicate `job_name` of %s was found", cfg.JobName)
+		}
+		for _, sdConfig := range cfg.ServiceDiscoveryConfig.KubernetesSDConfigs {
+			if sdConfig.Role != kubernetes.RolePod {
+				cancel()
+				return nil, fmt.Errorf("the `kubernetes_sd_configs` of the `kubernetes_pod_logs` scrape_config %s must use the pod role, found %s", cfg.JobName, sdConfig.Role)
+			}
+			configs[cfg.JobName] = append(configs[cfg.JobName], sdConfig)
+		}
+		if len(configs[cfg.JobName]) == 0 {
+			cancel()
+			return nil, fmt.Errorf("the `kubernetes_pod_logs` scrape_config %s must have `kubernetes_sd_configs`", cfg.JobName)
+		}
+
+		streamer, err := newAPIStreamer(cfg.KubernetesPodLogsConfig.KubeConfig)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to create the Kubernetes client of %s: %w", cfg.JobName, err)
+		}
+
+		pipeline, err := stages.NewPipeline(
+			log.With(logger, "component", "kubernetes_pod_logs_pipeline"),
+			cfg.PipelineStages,
+			&cfg.JobName,
+			metrics.reg,
+		)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+
+		tm.groups[cfg.JobName] = &targetGroup{
+			metrics:       metrics,
+			logger:        log.With(logger, "job", cfg.JobName),
+			positions:     positions,
+			streamer:      streamer,
+			entryHandler:  pipeline.Wrap(pushClient),
+			defaultLabels: cfg.KubernetesPodLogsConfig.Labels,
+			relabelConfig: cfg.RelabelConfigs,
+			targets:       make(map[string]*Target),
+		}
+	}
+
+	go tm.run(ctx)
+	go util.LogError("running target manager", tm.manager.Run)
+
+	return tm, tm.manager.ApplyConfig(configs)
+}
+
+// run listens on the service discovery and syncs the targets.
+func (tm *TargetManager) run(ctx context.Context) {
+	defer close(tm.done)
+	for {
+		select {
+		case targetGroups := <-tm.manager.SyncCh():
+			for jobName, groups := range targetGroups {
+				tg, ok := tm.groups[jobName]
+				if !ok {
+					level.Debug(tm.logger).Log("msg", "unknown target for job", "job", jobName)
+					continue
+				}
+				tg.sync(groups)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Ready returns true if at least one Kubernetes pod logs target is active.
+func (tm *TargetManager) Ready() bool {
+	for _, s := range tm.groups {
+		if s.Ready() {
+			return true
+		}
+	}
+	return false
+}
+
+// Stop stops the discovery and all the targets.
+func (tm *TargetManager) Stop() {
+	tm.cancel()
+	<-tm.done
+	for _, s := range tm.groups {
+		s.Stop()
+	}
+}
+
+// ActiveTargets returns the targets whose log stream is open.
+func (tm *TargetManager) ActiveTargets() map[string][]target.Target {
+	result := make(map[string][]target.Target, len(tm.groups))
+	for k, s := range tm.groups {
+		result[k] = s.ActiveTargets()
+	}
+	return result
+}
+
+// AllTargets returns all the targets.
+func (tm *TargetManager) AllTargets() map[string][]target.Target {
+	result := make(map[string][]target.Target, len(tm.groups))
+	for k, s := range tm.groups {
+		result[k] = s.AllTargets()
+	}
+	return result
+}
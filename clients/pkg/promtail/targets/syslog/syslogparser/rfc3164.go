@@ -0,0 +1,255 @@
+package syslogparser
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/go-syslog/v3"
+)
+
+// maxTagLength is the maximum length of the tag of a rfc3164 message. The rfc
+// limits it to 32 characters, but longer application names are common.
+const maxTagLength = 128
+
+var errMissingPriority = errors.New("expecting a priority value within angle brackets")
+
+// RFC3164Message is a BSD syslog message as described in rfc3164.
+type RFC3164Message struct {
+	syslog.Base
+}
+
+// ParseRFC3164 parses a single rfc3164 syslog message. As rfc3164 timestamps
+// have no year, the year is the latest one where the timestamp is at most a
+// day in the future, so that messages sent around new year get the right year.
+func ParseRFC3164(input []byte, now time.Time) (*RFC3164Message, error) {
+	input = bytes.TrimRight(input, "\r\n\x00")
+
+	if len(input) < 3 || input[0] != '<' {
+		return nil, errMissingPriority
+	}
+	end := bytes.IndexByte(input[:min(len(input), 5)], '>')
+	if end < 2 {
+		return nil, errMissingPriority
+	}
+	priority, err := strconv.ParseUint(string(input[1:end]), 10, 8)
+	if err != nil || priority > 191 {
+		return nil, fmt.Errorf("invalid priority value %q", input[1:end])
+	}
+
+	msg := &RFC3164Message{}
+	msg.ComputeFromPriority(uint8(priority))
+
+	rest := input[end+1:]
+	ts, rest, ok := parseRFC3164Timestamp(rest, now)
+	if ok {
+		msg.Timestamp = &ts
+
+		// The hostname follows the timestamp.
+		if i := bytes.IndexByte(rest, ' '); i > 0 {
+			hostname := string(rest[:i])
+			msg.Hostname = &hostname
+			rest = rest[i+1:]
+		}
+	}
+
+	// The content may start with a tag, optionally followed by a process id.
+	if appname, procID, content, ok := parseRFC3164Tag(rest); ok {
+		msg.Appname = &appname
+		if procID != "" {
+			msg.ProcID = &procID
+		}
+		rest = content
+	}
+
+	if len(rest) > 0 {
+		message := string(rest)
+		msg.Message = &message
+	}
+	return msg, nil
+}
+
+// parseRFC3164Timestamp parses the timestamp at the start of the input, either
+// in the "Mmm dd hh:mm:ss" format of the rfc or in the rfc3339 format used by
+// modern syslog daemons.
+func parseRFC3164Timestamp(input []byte, now time.Time) (time.Time, []byte, bool) {
+	if len(input) > len(time.Stamp) && input[len(time.Stamp)] == ' ' {
+		ts, err := time.ParseInLocation(time.Stamp, string(input[:len(time.Stamp)]), now.Location())
+		if err == nil {
+			return rfc3164Year(ts, now), input[len(time.Stamp)+1:], true
+		}
+	}
+
+	if i := bytes.IndexByte(input, ' '); i > 0 {
+		ts, err := time.Parse(time.RFC3339Nano, string(input[:i]))
+		if err == nil {
+			return ts, input[i+1:], true
+		}
+	}
+	return time.Time{}, input, false
+}
+
+// rfc3164Year returns the timestamp, parsed without a year, in the latest year
+// where it is at most a day in the future and where its day exists, as Feb 29
+// only exists in leap years.
+func rfc3164Year(ts, now time.Time) time.Time {
+	month, day := ts.Month(), ts.Day()
+	limit := now.Add(24 * time.Hour)
+	// Feb 29 exists at least once every 8 years.
+	for year := now.Year() + 1; year >= now.Year()-8; year-- {
+		t := time.Date(year, month, day, ts.Hour(), ts.Minute(), ts.Second(), ts.Nanosecond(), now.Location())
+		if t.Day() == day && !t.After(limit) {
+			return t
+		}
+	}
+	return time.Date(now.Year(), month, day, ts.Hour(), ts.Minute(), ts.Second(), ts.Nanosecond(), now.Location())
+}
+
+// parseRFC3164Tag parses the "tag[pid]: " prefix of the content of a message.
+func parseRFC3164Tag(input []byte) (appname, procID string, content []byte, ok bool) {
+	i := 0
+	for i < len(input) && i <= maxTagLength && isTagChar(input[i]) {
+		i++
+	}
+	if i == 0 || i > maxTagLength || i == len(input) {
+		return "", "", nil, false
+	}
+	appname = string(input[:i])
+
+	if input[i] == '[' {
+		end := bytes.IndexByte(input[i:], ']')
+		if end < 0 {
+			return "", "", nil, false
+		}
+		procID = string(input[i+1 : i+end])
+		i += end + 1
+	}
+
+	if i == len(input) || input[i] != ':' {
+		return "", "", nil, false
+	}
+	content = input[i+1:]
+	if len(content) > 0 && content[0] == ' ' {
+		content = content[1:]
+	}
+	return appname, procID, content, true
+}
+
+func isTagChar(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') ||
+		c == '-' || c == '_' || c == '.' || c == '/'
+}
+
+// ParseRFC3164Stream parses a rfc3164 syslog stream from the given Reader,
+// calling the callback function with the parsed messages. Like ParseStream,
+// it automatically detects octet counting, otherwise messages are separated
+// by newlines.
+// The function returns on EOF or unrecoverable errors.
+func ParseRFC3164Stream(r io.Reader, callback func(res *syslog.Result), maxMessageLength int) error {
+	buf := bufio.NewReaderSize(r, maxMessageLength+1)
+
+	firstByte, err := buf.Peek(1)
+	if err != nil {
+		return err
+	}
+
+	b := firstByte[0]
+	if b == '<' {
+		parseNonTransparent(buf, callback, maxMessageLength)
+	} else if b >= '0' && b <= '9' {
+		parseOctetCounting(buf, callback, maxMessageLength)
+	} else {
+		return fmt.Errorf("invalid or unsupported framing. first byte: '%s'", firstByte)
+	}
+
+	return nil
+}
+
+func parseNonTransparent(buf *bufio.Reader, callback func(res *syslog.Result), maxMessageLength int) {
+	for {
+		line, err := buf.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			callback(&syslog.Result{Error: fmt.Errorf("message too long to parse. max length %d", maxMessageLength)})
+			// Skip the rest of the message.
+			for err == bufio.ErrBufferFull {
+				_, err = buf.ReadSlice('\n')
+			}
+			if err != nil {
+				emitReadError(err, callback)
+				return
+			}
+			continue
+		}
+		if len(bytes.TrimRight(line, "\r\n")) > 0 {
+			emitRFC3164(line, callback)
+		}
+		if err != nil {
+			emitReadError(err, callback)
+			return
+		}
+	}
+}
+
+func parseOctetCounting(buf *bufio.Reader, callback func(res *syslog.Result), maxMessageLength int) {
+	for {
+		header, err := buf.ReadSlice(' ')
+		if err != nil {
+			emitReadError(err, callback)
+			return
+		}
+		// Some senders terminate the messages with a newline in addition to octet counting.
+		header = bytes.TrimLeft(header[:len(header)-1], "\r\n")
+		length, err := strconv.Atoi(string(header))
+		if err != nil || length <= 0 {
+			callback(&syslog.Result{Error: fmt.Errorf("invalid message length %q", header)})
+			return
+		}
+
+		if length > maxMessageLength {
+			callback(&syslog.Result{Error: fmt.Errorf("message too long to parse. was size %d, max length %d", length, maxMessageLength)})
+			if _, err := buf.Discard(length); err != nil {
+				emitReadError(err, callback)
+				return
+			}
+			continue
+		}
+
+		msg := make([]byte, length)
+		if _, err := io.ReadFull(buf, msg); err != nil {
+			emitReadError(err, callback)
+			return
+		}
+		emitRFC3164(msg, callback)
+	}
+}
+
+func emitRFC3164(input []byte, callback func(res *syslog.Result)) {
+	msg, err := ParseRFC3164(input, time.Now())
+	if err != nil {
+		callback(&syslog.Result{Error: err})
+		return
+	}
+	callback(&syslog.Result{Message: msg})
+}
+
+// emitReadError reports the errors reading the stream, except for its end.
+func emitReadError(err error, callback func(res *syslog.Result)) {
+	if err == io.EOF {
+		return
+	}
+	if err == io.ErrUnexpectedEOF {
+		err = fmt.Errorf("incomplete message: %w", err)
+	}
+	callback(&syslog.Result{Error: err})
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -4,6 +4,7 @@ import (
 	"io"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/influxdata/go-syslog/v3"
 	"github.com/influxdata/go-syslog/v3/rfc5424"
@@ -80,3 +81,164 @@ func TestParseStream_EmptyStream(t *testing.T) {
 	err := syslogparser.ParseStream(r, func(res *syslog.Result) {}, defaultMaxMessageLength)
 	require.Equal(t, err, io.EOF)
 }
+
+func TestParseRFC3164(t *testing.T) {
+	now := time.Date(2022, 1, 2, 10, 0, 0, 0, time.UTC)
+	str := func(s string) *string { return &s }
+
+	for _, tc := range []struct {
+		name      string
+		input     string
+		timestamp time.Time
+		hostname  *string
+		appname   *string
+		procID    *string
+		message   *string
+	}{
+		{
+			name:      "full message",
+			input:     "<34>Oct 11 22:14:15 mymachine su[1234]: 'su root' failed\n",
+			timestamp: time.Date(2021, 10, 11, 22, 14, 15, 0, time.UTC),
+			hostname:  str("mymachine"),
+			appname:   str("su"),
+			procID:    str("1234"),
+			message:   str("'su root' failed"),
+		},
+		{
+			name:      "space padded day in the current year",
+			input:     "<13>Jan  2 09:00:00 host app: hello",
+			timestamp: time.Date(2022, 1, 2, 9, 0, 0, 0, time.UTC),
+			hostname:  str("host"),
+			appname:   str("app"),
+			message:   str("hello"),
+		},
+		{
+			name:      "rfc3339 timestamp",
+			input:     "<13>2021-03-04T05:06:07Z host app: hello",
+			timestamp: time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC),
+			hostname:  str("host"),
+			appname:   str("app"),
+			message:   str("hello"),
+		},
+		{
+			name:      "no tag",
+			input:     "<13>Jan  2 09:00:00 host just a message",
+			timestamp: time.Date(2022, 1, 2, 9, 0, 0, 0, time.UTC),
+			hostname:  str("host"),
+			message:   str("just a message"),
+		},
+		{
+			name:    "no header",
+			input:   "<13>just a message",
+			message: str("just a message"),
+		},
+		{
+			name:    "no timestamp and no tag",
+			input:   "<13>hello",
+			message: str("hello"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			msg, err := syslogparser.ParseRFC3164([]byte(tc.input), now)
+			require.NoError(t, err)
+
+			if tc.timestamp.IsZero() {
+				require.Nil(t, msg.Timestamp)
+			} else {
+				require.Equal(t, tc.timestamp, *msg.Timestamp)
+			}
+			require.Equal(t, tc.hostname, msg.Hostname)
+			require.Equal(t, tc.appname, msg.Appname)
+			require.Equal(t, tc.procID, msg.ProcID)
+			require.Equal(t, tc.message, msg.Message)
+		})
+	}
+
+	_, err := syslogparser.ParseRFC3164([]byte("no priority"), now)
+	require.Error(t, err)
+	_, err = syslogparser.ParseRFC3164([]byte("<192>Jan  2 09:00:00 host app: hello"), now)
+	require.Error(t, err)
+}
+
+func TestParseRFC3164_Year(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		now       time.Time
+		input     string
+		timestamp time.Time
+	}{
+		{
+			name:      "sent in december, received in january",
+			now:       time.Date(2022, 1, 1, 0, 5, 0, 0, time.UTC),
+			input:     "<13>Dec 31 23:59:00 host app: hello",
+			timestamp: time.Date(2021, 12, 31, 23, 59, 0, 0, time.UTC),
+		},
+		{
+			name:      "sent in january, received in december",
+			now:       time.Date(2021, 12, 31, 23, 59, 30, 0, time.UTC),
+			input:     "<13>Jan  1 00:00:10 host app: hello",
+			timestamp: time.Date(2022, 1, 1, 0, 0, 10, 0, time.UTC),
+		},
+		{
+			name:      "feb 29 in a leap year",
+			now:       time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+			input:     "<13>Feb 29 09:00:00 host app: hello",
+			timestamp: time.Date(2024, 2, 29, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "feb 29 in a non leap year",
+			now:       time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC),
+			input:     "<13>Feb 29 09:00:00 host app: hello",
+			timestamp: time.Date(2020, 2, 29, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "feb 29 in the previous year",
+			now:       time.Date(2025, 1, 10, 10, 0, 0, 0, time.UTC),
+			input:     "<13>Feb 29 09:00:00 host app: hello",
+			timestamp: time.Date(2024, 2, 29, 9, 0, 0, 0, time.UTC),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			msg, err := syslogparser.ParseRFC3164([]byte(tc.input), tc.now)
+			require.NoError(t, err)
+			require.Equal(t, tc.timestamp, *msg.Timestamp)
+		})
+	}
+}
+
+func TestParseRFC3164Stream(t *testing.T) {
+	for name, input := range map[string]string{
+		"newline separated": "<13>Jan  2 09:00:00 host app: First\n<13>Jan  2 09:00:00 host app: Second\n",
+		"octet counting":    "35 <13>Jan  2 09:00:00 host app: First36 <13>Jan  2 09:00:00 host app: Second",
+	} {
+		t.Run(name, func(t *testing.T) {
+			results := make([]*syslog.Result, 0)
+			cb := func(res *syslog.Result) {
+				results = append(results, res)
+			}
+
+			err := syslogparser.ParseRFC3164Stream(strings.NewReader(input), cb, defaultMaxMessageLength)
+			require.NoError(t, err)
+
+			require.Equal(t, 2, len(results))
+			require.NoError(t, results[0].Error)
+			require.Equal(t, "First", *results[0].Message.(*syslogparser.RFC3164Message).Message)
+			require.NoError(t, results[1].Error)
+			require.Equal(t, "Second", *results[1].Message.(*syslogparser.RFC3164Message).Message)
+		})
+	}
+}
+
+func TestParseRFC3164Stream_LongMessage(t *testing.T) {
+	results := make([]*syslog.Result, 0)
+	cb := func(res *syslog.Result) {
+		results = append(results, res)
+	}
+
+	err := syslogparser.ParseRFC3164Stream(strings.NewReader("<13>"+strings.Repeat("a", 20)+"\n<13>Second\n"), cb, 10)
+	require.NoError(t, err)
+
+	require.Equal(t, 2, len(results))
+	require.EqualError(t, results[0].Error, "message too long to parse. max length 10")
+	require.Equal(t, "Second", *results[1].Message.(*syslogparser.RFC3164Message).Message)
+}
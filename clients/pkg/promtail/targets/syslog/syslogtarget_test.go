@@ -291,6 +291,162 @@ func testSyslogTarget(t *testing.T, octetCounting bool) {
 	require.NotZero(t, client.Received()[0].Timestamp)
 }
 
+func TestSyslogTarget_RFC3164(t *testing.T) {
+	t.Run("NewlineSeparatedMessages", func(t *testing.T) {
+		testSyslogTargetRFC3164(t, "tcp", false)
+	})
+	t.Run("OctetCounting", func(t *testing.T) {
+		testSyslogTargetRFC3164(t, "tcp", true)
+	})
+	t.Run("UDP", func(t *testing.T) {
+		testSyslogTargetRFC3164(t, "udp", false)
+	})
+}
+
+func testSyslogTargetRFC3164(t *testing.T, protocol string, octetCounting bool) {
+	w := log.NewSyncWriter(os.Stderr)
+	logger := log.NewLogfmtLogger(w)
+	client := fake.New(func() {})
+
+	metrics := NewMetrics(nil)
+	tgt, err := NewSyslogTarget(metrics, logger, client, relabelConfig(t), &scrapeconfig.SyslogTargetConfig{
+		ListenAddress:        "127.0.0.1:0",
+		ListenProtocol:       protocol,
+		SyslogFormat:         "rfc3164",
+		UseIncomingTimestamp: true,
+		Labels: model.LabelSet{
+			"test": "syslog_target",
+		},
+	})
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, tgt.Stop())
+	}()
+
+	addr := tgt.ListenAddress().String()
+	c, err := net.Dial(protocol, addr)
+	require.NoError(t, err)
+
+	messages := []string{
+		`<34>Oct 11 22:14:15 mymachine su[1234]: 'su root' failed for lonvick on /dev/pts/8`,
+		`<13>Oct  1 02:04:05 router1 kernel: link down`,
+		`<165>2018-10-11T22:14:15.003Z switch2 lldpd: neighbor added`,
+	}
+
+	if protocol == "udp" {
+		for _, msg := range messages {
+			_, err = c.Write([]byte(msg))
+			require.NoError(t, err)
+		}
+	} else {
+		err = writeMessagesToStream(c, messages, octetCounting)
+		require.NoError(t, err)
+	}
+	require.NoError(t, c.Close())
+
+	require.Eventuallyf(t, func() bool {
+		return len(client.Received()) == len(messages)
+	}, time.Second, time.Millisecond, "Expected to receive %d messages, got %d.", len(messages), len(client.Received()))
+
+	received := client.Received()
+	require.Equal(t, model.LabelSet{
+		"test": "syslog_target",
+
+		"severity": "critical",
+		"facility": "auth",
+		"hostname": "mymachine",
+		"app_name": "su",
+		"proc_id":  "1234",
+	}, received[0].Labels)
+	require.Equal(t, "'su root' failed for lonvick on /dev/pts/8", received[0].Line)
+	require.Equal(t, time.October, received[0].Timestamp.Month())
+	require.Equal(t, 22, received[0].Timestamp.Hour())
+
+	require.Equal(t, model.LabelSet{
+		"test": "syslog_target",
+
+		"severity": "notice",
+		"facility": "user",
+		"hostname": "router1",
+		"app_name": "kernel",
+	}, received[1].Labels)
+	require.Equal(t, "link down", received[1].Line)
+
+	require.Equal(t, model.LabelSet{
+		"test": "syslog_target",
+
+		"severity": "notice",
+		"facility": "local4",
+		"hostname": "switch2",
+		"app_name": "lldpd",
+	}, received[2].Labels)
+	require.Equal(t, "neighbor added", received[2].Line)
+	require.Equal(t, time.Date(2018, 10, 11, 22, 14, 15, 3000000, time.UTC), received[2].Timestamp.UTC())
+}
+
+func TestSyslogTarget_UDP(t *testing.T) {
+	w := log.NewSyncWriter(os.Stderr)
+	logger := log.NewLogfmtLogger(w)
+	client := fake.New(func() {})
+
+	metrics := NewMetrics(nil)
+	tgt, err := NewSyslogTarget(metrics, logger, client, relabelConfig(t), &scrapeconfig.SyslogTargetConfig{
+		ListenAddress:       "127.0.0.1:0",
+		ListenProtocol:      "udp",
+		LabelStructuredData: true,
+	})
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, tgt.Stop())
+	}()
+
+	c, err := net.Dial("udp", tgt.ListenAddress().String())
+	require.NoError(t, err)
+	_, err = c.Write([]byte(`<165>1 2018-10-11T22:14:15.003Z host5 e - id1 [custom@32473 exkey="1"] An application event log entry...` + "\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.Close())
+
+	require.Eventually(t, func() bool {
+		return len(client.Received()) == 1
+	}, time.Second, time.Millisecond)
+	require.Equal(t, model.LabelSet{
+		"severity":        "notice",
+		"facility":        "local4",
+		"hostname":        "host5",
+		"app_name":        "e",
+		"msg_id":          "id1",
+		"sd_custom_exkey": "1",
+	}, client.Received()[0].Labels)
+	require.Equal(t, "An application event log entry...", client.Received()[0].Line)
+}
+
+func TestSyslogTarget_InvalidConfig(t *testing.T) {
+	client := fake.New(func() {})
+	metrics := NewMetrics(nil)
+
+	_, err := NewSyslogTarget(metrics, log.NewNopLogger(), client, nil, &scrapeconfig.SyslogTargetConfig{
+		ListenAddress:  "127.0.0.1:0",
+		ListenProtocol: "sctp",
+	})
+	require.EqualError(t, err, `error setting up syslog target: unsupported listen protocol "sctp"`)
+
+	_, err = NewSyslogTarget(metrics, log.NewNopLogger(), client, nil, &scrapeconfig.SyslogTargetConfig{
+		ListenAddress: "127.0.0.1:0",
+		SyslogFormat:  "rfc1234",
+	})
+	require.EqualError(t, err, `error setting up syslog target: unsupported syslog format "rfc1234"`)
+
+	_, err = NewSyslogTarget(metrics, log.NewNopLogger(), client, nil, &scrapeconfig.SyslogTargetConfig{
+		ListenAddress:  "127.0.0.1:0",
+		ListenProtocol: "udp",
+		TLSConfig: promconfig.TLSConfig{
+			CertFile: "foo",
+			KeyFile:  "bar",
+		},
+	})
+	require.EqualError(t, err, "error setting up syslog target: TLS is not supported with the udp protocol")
+}
+
 func relabelConfig(t *testing.T) []*relabel.Config {
 	relabelCfg := `
 - source_labels: ['__syslog_message_severity']
@@ -41,6 +41,12 @@ const (
 
 	// DockerTargetType is a Docker target
 	DockerTargetType = TargetType("Docker")
+
+	// HerokuDrainTargetType is a Heroku log drain target
+	HerokuDrainTargetType = TargetType("HerokuDrain")
+
+	// KubernetesPodLogsTargetType is a Kubernetes API pod logs target
+	KubernetesPodLogsTargetType = TargetType("KubernetesPodLogs")
 )
 
 // Target is a promtail scrape target
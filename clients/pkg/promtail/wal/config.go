@@ -0,0 +1,49 @@
+package wal
+
+import (
+	"errors"
+	"flag"
+	"time"
+
+	"github.com/grafana/loki/pkg/util/flagext"
+)
+
+// Config describes the write-ahead log persisting entries before they are sent by the clients.
+type Config struct {
+	Enabled        bool             `yaml:"enabled"`
+	Dir            string           `yaml:"dir"`
+	MaxSegmentSize flagext.ByteSize `yaml:"max_segment_size"`
+	MaxSize        flagext.ByteSize `yaml:"max_size"`
+	MaxAge         time.Duration    `yaml:"max_age"`
+}
+
+// RegisterFlagsWithPrefix with prefix registers flags where every name is prefixed by
+// prefix. If prefix is a non-empty string, prefix should end with a period.
+func (cfg *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, prefix+"wal.enabled", false, "Persist entries in a write-ahead log before they are sent, so they are not lost when Loki is unavailable or promtail restarts.")
+	f.StringVar(&cfg.Dir, prefix+"wal.dir", "/var/lib/promtail/wal", "Directory in which the write-ahead log is stored.")
+	cfg.MaxSegmentSize = 8 << 20
+	f.Var(&cfg.MaxSegmentSize, prefix+"wal.max-segment-size", "Size of a write-ahead log segment file after which a new segment is started.")
+	cfg.MaxSize = 1 << 30
+	f.Var(&cfg.MaxSize, prefix+"wal.max-size", "Maximum size of the write-ahead log. The oldest segments are dropped, even if not sent yet, when it is exceeded. 0 means no limit.")
+	f.DurationVar(&cfg.MaxAge, prefix+"wal.max-age", 24*time.Hour, "Maximum age of the write-ahead log segments. Older segments are dropped, even if not sent yet. 0 means no limit.")
+}
+
+// RegisterFlags registers flags.
+func (cfg *Config) RegisterFlags(flags *flag.FlagSet) {
+	cfg.RegisterFlagsWithPrefix("", flags)
+}
+
+// Validate validates the config.
+func (cfg *Config) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.Dir == "" {
+		return errors.New("the write-ahead log directory must be set")
+	}
+	if cfg.MaxSegmentSize == 0 {
+		return errors.New("the write-ahead log max segment size must be positive")
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package wal
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+
+	"github.com/grafana/loki/clients/pkg/promtail/api"
+)
+
+// Record is an entry read from the WAL.
+type Record struct {
+	api.Entry
+	// Start is the position of the record and End the position following it.
+	Start, End Position
+}
+
+// Reader reads the entries of the WAL in order, starting after the last position it committed.
+type Reader struct {
+	wal        *WAL
+	logger     log.Logger
+	cursorPath string
+
+	// pos is the position of the next record to read.
+	pos  Position
+	file *os.File
+	// committed is guarded by the lock of the WAL.
+	committed Position
+}
+
+// NewReader creates a reader, the position it committed is persisted under its name.
+func (w *WAL) NewReader(name string) (*Reader, error) {
+	cursorPath := filepath.Join(w.cfg.Dir, cursorsDir, name)
+	pos, found, err := readCursor(cursorPath)
+	if err != nil {
+		return nil, err
+	}
+
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	if _, ok := w.readers[name]; ok {
+		return nil, fmt.Errorf("write-ahead log reader %s already exists", name)
+	}
+	// New readers start with the oldest entries.
+	if !found || pos.Segment < w.segments[0] {
+		pos = Position{Segment: w.segments[0]}
+	}
+
+	r := &Reader{
+		wal:        w,
+		logger:     log.With(w.logger, "reader", name),
+		cursorPath: cursorPath,
+		pos:        pos,
+		committed:  pos,
+	}
+	w.readers[name] = r
+	return r, nil
+}
+
+// Next returns the next record of the WAL, waiting for it to be written if needed.
+// It returns ErrClosed once the WAL is closed and all its records have been read.
+func (r *Reader) Next(ctx context.Context) (Record, error) {
+	for {
+		if err := ctx.Err(); err != nil {
+			return Record{}, err
+		}
+
+		// the state is retrieved before reading so that no write notification can be missed.
+		state := r.wal.segmentState(r.pos.Segment)
+		if !state.exists {
+			// the segment has been dropped.
+			r.closeFile()
+			if state.next < 0 {
+				return Record{}, ErrClosed
+			}
+			r.pos = Position{Segment: state.next}
+			continue
+		}
+
+		if r.file == nil {
+			f, err := os.Open(r.wal.segmentPath(r.pos.Segment))
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return Record{}, err
+			}
+			r.file = f
+		}
+
+		e, n, err := readRecord(r.file, r.pos.Offset)
+		switch err {
+		case nil:
+			start := r.pos
+			r.pos.Offset += n
+			return Record{Entry: e, Start: start, End: r.pos}, nil
+		case errNoRecord, errTruncatedRecord, errCorruptedRecord:
+			// the end of the active segment may not be written yet.
+			if state.active {
+				if state.closed {
+					return Record{}, ErrClosed
+				}
+				select {
+				case <-state.notify:
+				case <-ctx.Done():
+					return Record{}, ctx.Err()
+				}
+				continue
+			}
+
+			if err != errNoRecord {
+				dropped, _ := countRecords(r.wal.segmentPath(r.pos.Segment), r.pos.Offset)
+				if dropped == 0 {
+					dropped = 1
+				}
+				r.wal.metrics.droppedEntries.WithLabelValues(reasonCorrupted).Add(float64(dropped))
+				level.Warn(r.logger).Log("msg", "skipping the end of a write-ahead log segment", "segment", r.pos.Segment, "offset", r.pos.Offset, "err", err)
+			}
+			r.closeFile()
+			r.pos = Position{Segment: state.next}
+		default:
+			return Record{}, err
+		}
+	}
+}
+
+// Commit records that the entries before the position have been handled.
+func (r *Reader) Commit(pos Position) error {
+	r.wal.mtx.Lock()
+	if !r.committed.Before(pos) {
+		r.wal.mtx.Unlock()
+		return nil
+	}
+	r.committed = pos
+	r.wal.mtx.Unlock()
+
+	return writeCursor(r.cursorPath, pos)
+}
+
+// Close closes the reader, its committed position is kept.
+func (r *Reader) Close() {
+	r.closeFile()
+}
+
+func (r *Reader) closeFile() {
+	if r.file == nil {
+		return
+	}
+	if err := r.file.Close(); err != nil {
+		level.Warn(r.logger).Log("msg", "error closing segment", "err", err)
+	}
+	r.file = nil
+}
+
+func readCursor(path string) (Position, bool, error) {
+	buf, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Position{}, false, nil
+	}
+	if err != nil {
+		return Position{}, false, err
+	}
+
+	var pos Position
+	if _, err := fmt.Sscanf(string(buf), "%d %d", &pos.Segment, &pos.Offset); err != nil {
+		return Position{}, false, fmt.Errorf("invalid write-ahead log cursor %s: %w", path, err)
+	}
+	return pos, true, nil
+}
+
+// writeCursor atomically replaces the cursor file.
+func writeCursor(path string, pos Position) error {
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, []byte(fmt.Sprintf("%d %d\n", pos.Segment, pos.Offset)), segmentFileMode); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
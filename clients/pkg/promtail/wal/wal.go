@@ -0,0 +1,505 @@
+package wal
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/gogo/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/promql/parser"
+
+	"github.com/grafana/loki/clients/pkg/promtail/api"
+
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+const (
+	// recordHeaderSize is the size of the length and the checksum of the payload preceding each record.
+	recordHeaderSize = 4 + 4
+	maxRecordSize    = 64 << 20
+
+	segmentFileMode = 0640
+	cursorsDir      = "cursors"
+	cleanupPeriod   = time.Minute
+
+	reasonSize       = "size"
+	reasonAge        = "age"
+	reasonCorrupted  = "corrupted"
+	reasonWriteError = "write_error"
+)
+
+var (
+	castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+	// ErrClosed is returned by the readers once the WAL is closed and all its records have been read.
+	ErrClosed = errors.New("write-ahead log closed")
+
+	errNoRecord        = errors.New("no record")
+	errTruncatedRecord = errors.New("truncated write-ahead log record")
+	errCorruptedRecord = errors.New("corrupted write-ahead log record")
+)
+
+type metrics struct {
+	writtenEntries prometheus.Counter
+	writtenBytes   prometheus.Counter
+	droppedEntries *prometheus.CounterVec
+	segments       prometheus.Gauge
+	sizeBytes      prometheus.Gauge
+}
+
+func newMetrics(reg prometheus.Registerer) *metrics {
+	return &metrics{
+		writtenEntries: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Namespace: "promtail",
+			Name:      "wal_written_entries_total",
+			Help:      "Number of log entries written to the write-ahead log.",
+		}),
+		writtenBytes: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Namespace: "promtail",
+			Name:      "wal_written_bytes_total",
+			Help:      "Number of bytes written to the write-ahead log.",
+		}),
+		droppedEntries: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "promtail",
+			Name:      "wal_dropped_entries_total",
+			Help:      "Number of log entries dropped from the write-ahead log before being sent, by reason.",
+		}, []string{"reason"}),
+		segments: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Namespace: "promtail",
+			Name:      "wal_segments",
+			Help:      "Number of segments of the write-ahead log.",
+		}),
+		sizeBytes: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Namespace: "promtail",
+			Name:      "wal_size_bytes",
+			Help:      "Size of the segments of the write-ahead log.",
+		}),
+	}
+}
+
+// Position is a position in the WAL.
+type Position struct {
+	Segment int
+	Offset  int64
+}
+
+// Before returns true if the position is before the other one.
+func (p Position) Before(o Position) bool {
+	return p.Segment < o.Segment || (p.Segment == o.Segment && p.Offset < o.Offset)
+}
+
+// WAL is a write-ahead log of entries, made of segment files.
+// Entries are appended to the last segment, and read by one or more named readers which commit the position up to
+// which their entries have been handled. The segments are removed once every reader committed them, or when they
+// exceed the max size or age of the WAL.
+type WAL struct {
+	cfg     Config
+	logger  log.Logger
+	metrics *metrics
+
+	mtx sync.Mutex
+	// segments are sorted, the last one is the active segment entries are written to.
+	segments   []int
+	active     *os.File
+	activeSize int64
+	readers    map[string]*Reader
+	// notify is closed when entries are written while readers are waiting for them, or when the WAL is closed.
+	notify  chan struct{}
+	waiting bool
+	closed  bool
+
+	quit chan struct{}
+	done chan struct{}
+}
+
+// New opens the WAL stored in the configured directory.
+// The entries of a previous run which have not been committed by the readers are read again.
+func New(cfg Config, logger log.Logger, reg prometheus.Registerer) (*WAL, error) {
+	if err := os.MkdirAll(filepath.Join(cfg.Dir, cursorsDir), 0750); err != nil {
+		return nil, err
+	}
+	segments, err := listSegments(cfg.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &WAL{
+		cfg:      cfg,
+		logger:   log.With(logger, "component", "wal"),
+		metrics:  newMetrics(reg),
+		segments: segments,
+		readers:  map[string]*Reader{},
+		notify:   make(chan struct{}),
+		quit:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	// Entries are never appended to the segments of a previous run, which may end with a truncated record.
+	next := 0
+	if len(segments) > 0 {
+		next = segments[len(segments)-1] + 1
+		level.Info(w.logger).Log("msg", "replaying write-ahead log", "segments", len(segments))
+	}
+	if err := w.openSegment(next); err != nil {
+		return nil, err
+	}
+
+	go w.run()
+	return w, nil
+}
+
+// Write appends the entry to the WAL.
+func (w *WAL) Write(e api.Entry) error {
+	payload, err := proto.Marshal(&logproto.PushRequest{
+		Streams: []logproto.Stream{{
+			Labels:  e.Labels.String(),
+			Entries: []logproto.Entry{e.Entry},
+		}},
+	})
+	if err != nil {
+		w.metrics.droppedEntries.WithLabelValues(reasonWriteError).Inc()
+		return err
+	}
+	buf := make([]byte, recordHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(buf[0:], uint32(len(payload)))
+	binary.BigEndian.PutUint32(buf[4:], crc32.Checksum(payload, castagnoliTable))
+	copy(buf[recordHeaderSize:], payload)
+
+	w.mtx.Lock()
+	if w.closed {
+		w.mtx.Unlock()
+		return ErrClosed
+	}
+
+	rotated := false
+	if w.activeSize > 0 && w.activeSize+int64(len(buf)) > int64(w.cfg.MaxSegmentSize) {
+		if err := w.openSegment(w.segments[len(w.segments)-1] + 1); err != nil {
+			w.mtx.Unlock()
+			w.metrics.droppedEntries.WithLabelValues(reasonWriteError).Inc()
+			return err
+		}
+		rotated = true
+	}
+
+	if _, err := w.active.Write(buf); err != nil {
+		// don't append any record after a partially written one.
+		if rotateErr := w.openSegment(w.segments[len(w.segments)-1] + 1); rotateErr != nil {
+			level.Error(w.logger).Log("msg", "error starting a new segment after a write error", "err", rotateErr)
+		}
+		w.mtx.Unlock()
+		w.metrics.droppedEntries.WithLabelValues(reasonWriteError).Inc()
+		return err
+	}
+	w.activeSize += int64(len(buf))
+	w.metrics.writtenEntries.Inc()
+	w.metrics.writtenBytes.Add(float64(len(buf)))
+
+	if w.waiting {
+		close(w.notify)
+		w.notify = make(chan struct{})
+		w.waiting = false
+	}
+	w.mtx.Unlock()
+
+	if rotated {
+		w.cleanup()
+	}
+	return nil
+}
+
+// Sync persists the written entries to disk.
+func (w *WAL) Sync() error {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	if w.closed {
+		return nil
+	}
+	return w.active.Sync()
+}
+
+// Close syncs and closes the WAL. The readers can still read the remaining entries until they get ErrClosed.
+func (w *WAL) Close() error {
+	w.mtx.Lock()
+	if w.closed {
+		w.mtx.Unlock()
+		return nil
+	}
+	close(w.quit)
+	w.closed = true
+	close(w.notify)
+	err := w.active.Sync()
+	if closeErr := w.active.Close(); err == nil {
+		err = closeErr
+	}
+	w.mtx.Unlock()
+
+	<-w.done
+	return err
+}
+
+// openSegment starts a new active segment, it must be called with the lock held.
+func (w *WAL) openSegment(index int) error {
+	f, err := os.OpenFile(w.segmentPath(index), os.O_CREATE|os.O_WRONLY|os.O_APPEND, segmentFileMode)
+	if err != nil {
+		return err
+	}
+
+	if w.active != nil {
+		if err := w.active.Sync(); err != nil {
+			level.Warn(w.logger).Log("msg", "error syncing segment", "err", err)
+		}
+		if err := w.active.Close(); err != nil {
+			level.Warn(w.logger).Log("msg", "error closing segment", "err", err)
+		}
+	}
+
+	w.active = f
+	w.activeSize = 0
+	w.segments = append(w.segments, index)
+	w.metrics.segments.Set(float64(len(w.segments)))
+	return nil
+}
+
+func (w *WAL) segmentPath(index int) string {
+	return filepath.Join(w.cfg.Dir, fmt.Sprintf("%08d", index))
+}
+
+func (w *WAL) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(cleanupPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.quit:
+			return
+		case <-ticker.C:
+			w.cleanup()
+		}
+	}
+}
+
+// cleanup removes the segments committed by all the readers, as well as the oldest segments exceeding the max size
+// or age of the WAL.
+func (w *WAL) cleanup() {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	if w.closed {
+		return
+	}
+
+	committed, hasReaders := w.minCommitted()
+
+	var (
+		totalSize int64
+		sizes     = make([]int64, len(w.segments))
+		modTimes  = make([]time.Time, len(w.segments))
+	)
+	for i, s := range w.segments {
+		fi, err := os.Stat(w.segmentPath(s))
+		if err != nil {
+			level.Warn(w.logger).Log("msg", "error getting segment size", "segment", s, "err", err)
+			continue
+		}
+		sizes[i], modTimes[i] = fi.Size(), fi.ModTime()
+		totalSize += fi.Size()
+	}
+
+	kept := make([]int, 0, len(w.segments))
+	for i, s := range w.segments {
+		// the active segment is never removed.
+		if i == len(w.segments)-1 {
+			kept = append(kept, s)
+			break
+		}
+
+		var reason string
+		switch {
+		case hasReaders && s < committed.Segment:
+			// all the entries of the segment have been handled.
+		case w.cfg.MaxSize > 0 && totalSize > int64(w.cfg.MaxSize):
+			reason = reasonSize
+		case w.cfg.MaxAge > 0 && time.Since(modTimes[i]) > w.cfg.MaxAge:
+			reason = reasonAge
+		default:
+			kept = append(kept, s)
+			continue
+		}
+
+		path := w.segmentPath(s)
+		if reason != "" {
+			var from int64
+			if hasReaders && s == committed.Segment {
+				from = committed.Offset
+			}
+			dropped, err := countRecords(path, from)
+			if err != nil {
+				level.Warn(w.logger).Log("msg", "error counting entries of dropped segment", "segment", s, "err", err)
+			}
+			w.metrics.droppedEntries.WithLabelValues(reason).Add(float64(dropped))
+			level.Warn(w.logger).Log("msg", "dropping write-ahead log segment", "segment", s, "reason", reason, "entries", dropped)
+		}
+
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			level.Error(w.logger).Log("msg", "error removing segment", "segment", s, "err", err)
+			kept = append(kept, s)
+			continue
+		}
+		totalSize -= sizes[i]
+	}
+
+	w.segments = kept
+	w.metrics.segments.Set(float64(len(kept)))
+	w.metrics.sizeBytes.Set(float64(totalSize))
+}
+
+// minCommitted returns the position up to which all the readers committed their entries.
+// It must be called with the lock held.
+func (w *WAL) minCommitted() (Position, bool) {
+	var (
+		min   Position
+		found bool
+	)
+	for _, r := range w.readers {
+		if !found || r.committed.Before(min) {
+			min = r.committed
+			found = true
+		}
+	}
+	return min, found
+}
+
+// segmentState describes a segment to a reader.
+type segmentState struct {
+	exists, active, closed bool
+	// next is the index of the following segment, or -1 if there is none.
+	next   int
+	notify <-chan struct{}
+}
+
+func (w *WAL) segmentState(index int) segmentState {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	state := segmentState{next: -1, closed: w.closed, notify: w.notify}
+	if !w.closed {
+		w.waiting = true
+	}
+	i := sort.SearchInts(w.segments, index)
+	if i < len(w.segments) && w.segments[i] == index {
+		state.exists = true
+		state.active = i == len(w.segments)-1
+		i++
+	}
+	if i < len(w.segments) {
+		state.next = w.segments[i]
+	}
+	return state
+}
+
+func listSegments(dir string) ([]int, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var segments []int
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		index, err := strconv.Atoi(f.Name())
+		if err != nil {
+			continue
+		}
+		segments = append(segments, index)
+	}
+	sort.Ints(segments)
+	return segments, nil
+}
+
+// readRecord reads the record at the offset of the segment file, and returns its entry and size.
+func readRecord(f *os.File, offset int64) (api.Entry, int64, error) {
+	header := make([]byte, recordHeaderSize)
+	n, err := f.ReadAt(header, offset)
+	if n < recordHeaderSize {
+		switch {
+		case n == 0 && err == io.EOF:
+			return api.Entry{}, 0, errNoRecord
+		case err == io.EOF:
+			return api.Entry{}, 0, errTruncatedRecord
+		default:
+			return api.Entry{}, 0, err
+		}
+	}
+
+	length := binary.BigEndian.Uint32(header[0:])
+	if length > maxRecordSize {
+		return api.Entry{}, 0, errCorruptedRecord
+	}
+	payload := make([]byte, length)
+	n, err = f.ReadAt(payload, offset+recordHeaderSize)
+	if n < int(length) {
+		if err == io.EOF {
+			return api.Entry{}, 0, errTruncatedRecord
+		}
+		return api.Entry{}, 0, err
+	}
+	if crc32.Checksum(payload, castagnoliTable) != binary.BigEndian.Uint32(header[4:]) {
+		return api.Entry{}, 0, errCorruptedRecord
+	}
+
+	var req logproto.PushRequest
+	if err := proto.Unmarshal(payload, &req); err != nil || len(req.Streams) != 1 || len(req.Streams[0].Entries) != 1 {
+		return api.Entry{}, 0, errCorruptedRecord
+	}
+	stream := req.Streams[0]
+	labels := model.LabelSet{}
+	if stream.Labels != "{}" {
+		lbls, err := parser.ParseMetric(stream.Labels)
+		if err != nil {
+			return api.Entry{}, 0, errCorruptedRecord
+		}
+		for _, l := range lbls {
+			labels[model.LabelName(l.Name)] = model.LabelValue(l.Value)
+		}
+	}
+
+	return api.Entry{Labels: labels, Entry: stream.Entries[0]}, recordHeaderSize + int64(length), nil
+}
+
+// countRecords returns the number of records of the segment file after the offset.
+func countRecords(path string, offset int64) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var count int
+	for {
+		_, n, err := readRecord(f, offset)
+		if err == errNoRecord {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+		count++
+		offset += n
+	}
+}
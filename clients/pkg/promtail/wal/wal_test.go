@@ -0,0 +1,163 @@
+package wal
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/clients/pkg/promtail/api"
+
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+func testEntry(i int) api.Entry {
+	return api.Entry{
+		Labels: model.LabelSet{"job": "test", "i": model.LabelValue(fmt.Sprint(i % 2))},
+		Entry:  logproto.Entry{Timestamp: time.Unix(int64(i), 0).UTC(), Line: fmt.Sprintf("line %d", i)},
+	}
+}
+
+func testConfig(t *testing.T) Config {
+	return Config{
+		Enabled:        true,
+		Dir:            t.TempDir(),
+		MaxSegmentSize: 1 << 20,
+	}
+}
+
+func readEntries(t *testing.T, r *Reader, n int) []Record {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	records := make([]Record, 0, n)
+	for i := 0; i < n; i++ {
+		rec, err := r.Next(ctx)
+		require.NoError(t, err)
+		records = append(records, rec)
+	}
+	return records
+}
+
+func TestWAL_WriteRead(t *testing.T) {
+	w, err := New(testConfig(t), log.NewNopLogger(), nil)
+	require.NoError(t, err)
+
+	r, err := w.NewReader("client")
+	require.NoError(t, err)
+	defer r.Close()
+
+	// entries written while the reader is waiting are received.
+	done := make(chan []Record)
+	go func() {
+		done <- readEntries(t, r, 10)
+	}()
+	for i := 0; i < 10; i++ {
+		require.NoError(t, w.Write(testEntry(i)))
+	}
+
+	records := <-done
+	for i, rec := range records {
+		require.Equal(t, testEntry(i), rec.Entry)
+		if i > 0 {
+			require.Equal(t, records[i-1].End, rec.Start)
+		}
+	}
+
+	require.NoError(t, w.Close())
+	_, err = r.Next(context.Background())
+	require.Equal(t, ErrClosed, err)
+}
+
+func TestWAL_Replay(t *testing.T) {
+	cfg := testConfig(t)
+	w, err := New(cfg, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	r, err := w.NewReader("client")
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, w.Write(testEntry(i)))
+	}
+	records := readEntries(t, r, 10)
+	require.NoError(t, r.Commit(records[3].End))
+	r.Close()
+	require.NoError(t, w.Close())
+
+	// the entries which were not committed are read again after a restart.
+	w, err = New(cfg, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	defer w.Close()
+	r, err = w.NewReader("client")
+	require.NoError(t, err)
+	defer r.Close()
+
+	_, err = w.NewReader("client")
+	require.Error(t, err)
+
+	require.NoError(t, w.Write(testEntry(10)))
+	records = readEntries(t, r, 7)
+	for i, rec := range records {
+		require.Equal(t, testEntry(i+4), rec.Entry)
+	}
+}
+
+func TestWAL_Cleanup(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.MaxSegmentSize = 100
+	w, err := New(cfg, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	defer w.Close()
+	r, err := w.NewReader("client")
+	require.NoError(t, err)
+	defer r.Close()
+
+	for i := 0; i < 20; i++ {
+		require.NoError(t, w.Write(testEntry(i)))
+	}
+	require.Greater(t, len(w.segments), 2)
+
+	records := readEntries(t, r, 20)
+	require.NoError(t, r.Commit(records[19].End))
+	w.cleanup()
+
+	// only the active segment is left once everything is committed.
+	require.Len(t, w.segments, 1)
+	segments, err := listSegments(cfg.Dir)
+	require.NoError(t, err)
+	require.Equal(t, w.segments, segments)
+}
+
+func TestWAL_MaxSize(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.MaxSegmentSize = 100
+	cfg.MaxSize = 300
+	reg := prometheus.NewRegistry()
+	w, err := New(cfg, log.NewNopLogger(), reg)
+	require.NoError(t, err)
+	defer w.Close()
+	r, err := w.NewReader("client")
+	require.NoError(t, err)
+	defer r.Close()
+
+	const written = 30
+	for i := 0; i < written; i++ {
+		require.NoError(t, w.Write(testEntry(i)))
+	}
+
+	dropped := int(testutil.ToFloat64(w.metrics.droppedEntries.WithLabelValues(reasonSize)))
+	require.Greater(t, dropped, 0)
+
+	// the reader skips the dropped entries.
+	records := readEntries(t, r, written-dropped)
+	for i, rec := range records {
+		require.Equal(t, testEntry(i+dropped), rec.Entry)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"math"
 	"net/url"
@@ -14,22 +15,26 @@ import (
 	"gopkg.in/alecthomas/kingpin.v2"
 
 	"github.com/grafana/loki/pkg/logcli/client"
+	"github.com/grafana/loki/pkg/logcli/deletequery"
 	"github.com/grafana/loki/pkg/logcli/labelquery"
 	"github.com/grafana/loki/pkg/logcli/output"
+	"github.com/grafana/loki/pkg/logcli/pushquery"
 	"github.com/grafana/loki/pkg/logcli/query"
+	"github.com/grafana/loki/pkg/logcli/rulesquery"
 	"github.com/grafana/loki/pkg/logcli/seriesquery"
 	_ "github.com/grafana/loki/pkg/util/build"
 )
 
 var (
-	app        = kingpin.New("logcli", "A command-line for loki.").Version(version.Print("logcli"))
-	quiet      = app.Flag("quiet", "Suppress query metadata").Default("false").Short('q').Bool()
-	statistics = app.Flag("stats", "Show query statistics").Default("false").Bool()
-	outputMode = app.Flag("output", "Specify output mode [default, raw, jsonl]. raw suppresses log labels and timestamp.").Default("default").Short('o').Enum("default", "raw", "jsonl")
-	timezone   = app.Flag("timezone", "Specify the timezone to use when formatting output timestamps [Local, UTC]").Default("Local").Short('z').Enum("Local", "UTC")
-	cpuProfile = app.Flag("cpuprofile", "Specify the location for writing a CPU profile.").Default("").String()
-	memProfile = app.Flag("memprofile", "Specify the location for writing a memory profile.").Default("").String()
-	stdin      = app.Flag("stdin", "Take input logs from stdin").Bool()
+	app            = kingpin.New("logcli", "A command-line for loki.").Version(version.Print("logcli"))
+	quiet          = app.Flag("quiet", "Suppress query metadata").Default("false").Short('q').Bool()
+	statistics     = app.Flag("stats", "Show query statistics").Default("false").Bool()
+	outputMode     = app.Flag("output", "Specify output mode [default, raw, jsonl, csv, table]. raw suppresses log labels and timestamp. csv prints the labels given with --include-label in their own column. csv and table also format the samples of metric queries, which are printed as JSON otherwise.").Default("default").Short('o').Enum("default", "raw", "jsonl", "csv", "table")
+	outputTemplate = app.Flag("output-template", "Print every log entry, or sample of a metric query, with this Go template instead of the output mode, eg '{{.Timestamp.Unix}} {{.Labels.app}} {{.Line}}'. The template is given the Timestamp, the Labels, and the Line of an entry or the Value of a sample.").Default("").String()
+	timezone       = app.Flag("timezone", "Specify the timezone to use when formatting output timestamps [Local, UTC]").Default("Local").Short('z').Enum("Local", "UTC")
+	cpuProfile     = app.Flag("cpuprofile", "Specify the location for writing a CPU profile.").Default("").String()
+	memProfile     = app.Flag("memprofile", "Specify the location for writing a memory profile.").Default("").String()
+	stdin          = app.Flag("stdin", "Take input logs from stdin").Bool()
 
 	queryClient = newQueryClient(app)
 
@@ -68,6 +73,20 @@ Example:
 
 The output is limited to 30 entries by default; use --limit to increase.
 
+Large time ranges can be exported in parallel with --parallel-duration and
+--parallel-workers: the range is split into intervals queried concurrently
+into part files, which can be merged in order into the output with
+--merge-parts. Running the same command again resumes an interrupted export.
+
+	logcli query
+	   --from="2021-01-19T00:00:00Z"
+	   --to="2021-01-20T00:00:00Z"
+	   --limit=0
+	   --parallel-duration=1h
+	   --parallel-workers=4
+	   --merge-parts
+	   'my-query' > export.log
+
 While "query" does support metrics queries, its output contains multiple
 data points between the start and end query time. This output is used to
 build graphs, similar to what is seen in the Grafana Explore graph view.
@@ -78,6 +97,7 @@ the "instant-query" command instead.`)
 	tail       = queryCmd.Flag("tail", "Tail the logs").Short('t').Default("false").Bool()
 	follow     = queryCmd.Flag("follow", "Alias for --tail").Short('f').Default("false").Bool()
 	delayFor   = queryCmd.Flag("delay-for", "Delay in tailing by number of seconds to accumulate logs for re-ordering").Default("0").Int()
+	explain    = queryCmd.Flag("explain", "Print the plan of the query (AST, splits, shards and estimated chunks) without executing it.").Default("false").Bool()
 
 	instantQueryCmd = app.Command("instant-query", `Run an instant LogQL query.
 
@@ -109,6 +129,49 @@ Use the --analyze-labels flag to get a summary of the labels found in all stream
 This is helpful to find high cardinality labels.
 `)
 	seriesQuery = newSeriesQuery(seriesCmd)
+
+	deleteCmd = app.Command("delete", `Manage the delete requests of the tenant.
+
+The delete requests are processed by the compactor, which must have
+the deletion of logs enabled.`)
+	deleteCreateCmd = deleteCmd.Command("create", `Request the deletion of the logs matching any of the selectors.
+
+The selectors are LogQL stream selectors, without line filters, eg
+'{app="foo", env=~"dev|staging"}'.`)
+	deleteCreateQuery = newDeleteCreateQuery(deleteCreateCmd)
+	deleteListCmd     = deleteCmd.Command("list", "List the delete requests, or a single request with --request-id.")
+	deleteListQuery   = newDeleteListQuery(deleteListCmd)
+	deleteCancelCmd   = deleteCmd.Command("cancel", "Cancel a delete request which is not processed yet.")
+	deleteCancelQuery = newDeleteCancelQuery(deleteCancelCmd)
+
+	rulesCmd = app.Command("rules", `Manage the rule groups of the ruler.
+
+The rule files hold the rule groups of the namespace named after the
+file, without its extension, in the same format as the files of the
+ruler, eg rules/alerts.yaml for the namespace "alerts".`)
+	rulesLintCmd     = rulesCmd.Command("lint", "Validate the rule files, with the LogQL parser and the validation of the ruler.")
+	rulesLintQuery   = newRulesQuery(rulesLintCmd, true, false)
+	rulesListCmd     = rulesCmd.Command("list", "List the rule groups of the ruler.")
+	rulesListQuery   = newRulesListQuery(rulesListCmd)
+	rulesDiffCmd     = rulesCmd.Command("diff", "Print the changes that syncing the rule files would make to the ruler.")
+	rulesDiffQuery   = newRulesQuery(rulesDiffCmd, true, true)
+	rulesSyncCmd     = rulesCmd.Command("sync", "Create, update and delete the rule groups of the ruler so that they match the rule files.")
+	rulesSyncQuery   = newRulesQuery(rulesSyncCmd, true, true)
+	rulesDeleteCmd   = rulesCmd.Command("delete", "Delete a namespace, or a single rule group of a namespace, from the ruler.")
+	rulesDeleteQuery = newRulesDeleteQuery(rulesDeleteCmd)
+
+	pushCmd = app.Command("push", `Push the lines of files, or of the standard input, to Loki.
+
+The lines are pushed to a single stream, in batches, the same way as
+promtail does. They are timestamped with the time they are read,
+unless they start with a timestamp of the layout given with
+--timestamp-layout, in the format of the Go time package, eg:
+
+  logcli push --labels='{job="app"}' --timestamp-layout='2006-01-02 15:04:05' app.log
+
+The command fails if entries are rejected by Loki, eg entries too old
+or above the rate limit of the tenant.`)
+	pushQuery = newPushQuery(pushCmd)
 )
 
 func main() {
@@ -168,8 +231,24 @@ func main() {
 		rangeQuery.Limit = math.MaxInt // TODO(kavi): is it a good idea?
 	}
 
+	if *stdin && (rangeQuery.LocalConfig != "" || instantQuery.LocalConfig != "") {
+		log.Fatal("--store-config and --stdin can't be used together")
+	}
+	if rangeQuery.LocalConfig != "" && (*tail || *follow) {
+		log.Fatal("--store-config and --tail can't be used together")
+	}
+	if rangeQuery.LocalConfig != "" && rangeQuery.Step.Seconds() == 0 {
+		// Same as with `--stdin`, the default `step` is usually set on Loki server side.
+		rangeQuery.Step = defaultQueryRangeStep(rangeQuery.Start, rangeQuery.End)
+	}
+
 	switch cmd {
 	case queryCmd.FullCommand():
+		if *explain {
+			rangeQuery.DoExplain(planClient(), os.Stdout)
+			return
+		}
+
 		location, err := time.LoadLocation(*timezone)
 		if err != nil {
 			log.Fatalf("Unable to load timezone '%s': %s", *timezone, err)
@@ -179,15 +258,19 @@ func main() {
 			Timezone:      location,
 			NoLabels:      rangeQuery.NoLabels,
 			ColoredOutput: rangeQuery.ColoredOutput,
+			LabelColumns:  rangeQuery.ShowLabelsKey,
+			Template:      *outputTemplate,
 		}
 
-		out, err := output.NewLogOutput(os.Stdout, *outputMode, outputOptions)
+		out, err := output.NewLogOutput(os.Stdout, logOutputMode(), outputOptions)
 		if err != nil {
 			log.Fatalf("Unable to create log output: %s", err)
 		}
 
 		if *tail || *follow {
 			rangeQuery.TailQuery(time.Duration(*delayFor)*time.Second, queryClient, out)
+		} else if rangeQuery.Parallel.Duration > 0 {
+			rangeQuery.DoQueryParallel(queryClient, out, os.Stdout, *statistics)
 		} else {
 			rangeQuery.DoQuery(queryClient, out, *statistics)
 		}
@@ -201,9 +284,11 @@ func main() {
 			Timezone:      location,
 			NoLabels:      instantQuery.NoLabels,
 			ColoredOutput: instantQuery.ColoredOutput,
+			LabelColumns:  instantQuery.ShowLabelsKey,
+			Template:      *outputTemplate,
 		}
 
-		out, err := output.NewLogOutput(os.Stdout, *outputMode, outputOptions)
+		out, err := output.NewLogOutput(os.Stdout, logOutputMode(), outputOptions)
 		if err != nil {
 			log.Fatalf("Unable to create log output: %s", err)
 		}
@@ -213,6 +298,24 @@ func main() {
 		labelsQuery.DoLabels(queryClient)
 	case seriesCmd.FullCommand():
 		seriesQuery.DoSeries(queryClient)
+	case deleteCreateCmd.FullCommand():
+		deleteCreateQuery.DoCreate(deleteClient())
+	case deleteListCmd.FullCommand():
+		deleteListQuery.DoList(deleteClient())
+	case deleteCancelCmd.FullCommand():
+		deleteCancelQuery.DoCancel(deleteClient())
+	case rulesLintCmd.FullCommand():
+		rulesLintQuery.DoLint()
+	case rulesListCmd.FullCommand():
+		rulesListQuery.DoList(rulesClient())
+	case rulesDiffCmd.FullCommand():
+		rulesDiffQuery.DoDiff(rulesClient())
+	case rulesSyncCmd.FullCommand():
+		rulesSyncQuery.DoSync(rulesClient())
+	case rulesDeleteCmd.FullCommand():
+		rulesDeleteQuery.DoDelete(rulesClient())
+	case pushCmd.FullCommand():
+		pushQuery.DoPush(pushClient())
 	}
 }
 
@@ -248,6 +351,9 @@ func newQueryClient(app *kingpin.Application) client.Client {
 	return client
 }
 
+// storeConfigHelp is the help of the flag querying the storage of a Loki configuration file instead of a Loki server.
+const storeConfigHelp = "Execute the current query using a configured storage from a given Loki configuration file, like a local directory or an S3-compatible bucket, instead of a Loki server. The tenant must be set with --org-id."
+
 func newLabelQuery(cmd *kingpin.CmdClause) *labelquery.LabelQuery {
 	var labelName, from, to string
 	var since time.Duration
@@ -271,6 +377,7 @@ func newLabelQuery(cmd *kingpin.CmdClause) *labelquery.LabelQuery {
 	cmd.Flag("since", "Lookback window.").Default("1h").DurationVar(&since)
 	cmd.Flag("from", "Start looking for labels at this absolute time (inclusive)").StringVar(&from)
 	cmd.Flag("to", "Stop looking for labels at this absolute time (exclusive)").StringVar(&to)
+	cmd.Flag("store-config", storeConfigHelp).Default("").StringVar(&q.LocalConfig)
 
 	return q
 }
@@ -299,6 +406,7 @@ func newSeriesQuery(cmd *kingpin.CmdClause) *seriesquery.SeriesQuery {
 	cmd.Flag("from", "Start looking for logs at this absolute time (inclusive)").StringVar(&from)
 	cmd.Flag("to", "Stop looking for logs at this absolute time (exclusive)").StringVar(&to)
 	cmd.Flag("analyze-labels", "Printout a summary of labels including count of label value combinations, useful for debugging high cardinality series").BoolVar(&q.AnalyzeLabels)
+	cmd.Flag("store-config", storeConfigHelp).Default("").StringVar(&q.LocalConfig)
 
 	return q
 }
@@ -338,7 +446,13 @@ func newQuery(instant bool, cmd *kingpin.CmdClause) *query.Query {
 		cmd.Flag("step", "Query resolution step width, for metric queries. Evaluate the query at the specified step over the time range.").DurationVar(&q.Step)
 		cmd.Flag("interval", "Query interval, for log queries. Return entries at the specified interval, ignoring those between. **This parameter is experimental, please see Issue 1779**").DurationVar(&q.Interval)
 		cmd.Flag("batch", "Query batch size to use until 'limit' is reached").Default("1000").IntVar(&q.BatchSize)
-
+		cmd.Flag("parallel-duration", "Split the range into intervals of this duration, queried concurrently into part files. The limit applies to every interval, 0 meaning no limit.").DurationVar(&q.Parallel.Duration)
+		cmd.Flag("parallel-workers", "Number of intervals queried concurrently with --parallel-duration.").Default("1").IntVar(&q.Parallel.Workers)
+		cmd.Flag("part-path-prefix", "Prefix of the part files written with --parallel-duration. Defaults to a prefix in the temporary directory derived from the query, its range, limit, tenant and output mode.").StringVar(&q.Parallel.PartPathPrefix)
+		cmd.Flag("overwrite-completed-parts", "Query again the intervals whose part file is complete, instead of resuming the export.").Default("false").BoolVar(&q.Parallel.OverwriteCompleted)
+		cmd.Flag("merge-parts", "Write the part files in order to the output once they are complete, then remove them.").Default("false").BoolVar(&q.Parallel.MergeParts)
+		cmd.Flag("keep-parts", "Keep the part files after they are merged.").Default("false").BoolVar(&q.Parallel.KeepParts)
+		cmd.Flag("tail-reconnect-attempts", "Number of attempts in a row to reconnect the tail when the connection is lost, backfilling the logs of the gap, 0 to stop tailing instead.").Default("10").IntVar(&q.TailReconnectAttempts)
 	}
 
 	cmd.Flag("forward", "Scan forwards through logs.").Default("false").BoolVar(&q.Forward)
@@ -346,12 +460,172 @@ func newQuery(instant bool, cmd *kingpin.CmdClause) *query.Query {
 	cmd.Flag("exclude-label", "Exclude labels given the provided key during output.").StringsVar(&q.IgnoreLabelsKey)
 	cmd.Flag("include-label", "Include labels given the provided key during output.").StringsVar(&q.ShowLabelsKey)
 	cmd.Flag("labels-length", "Set a fixed padding to labels").Default("0").IntVar(&q.FixedLabelsLen)
-	cmd.Flag("store-config", "Execute the current query using a configured storage from a given Loki configuration file.").Default("").StringVar(&q.LocalConfig)
+	cmd.Flag("store-config", storeConfigHelp).Default("").StringVar(&q.LocalConfig)
 	cmd.Flag("colored-output", "Show output with colored labels").Default("false").BoolVar(&q.ColoredOutput)
 
 	return q
 }
 
+// logOutputMode returns the output mode, the template output being used when a template is given.
+func logOutputMode() string {
+	if *outputTemplate != "" {
+		return "template"
+	}
+	return *outputMode
+}
+
+// deleteClient returns the client managing the delete requests, which are not supported by the local clients.
+func deleteClient() client.DeleteClient {
+	c, ok := queryClient.(client.DeleteClient)
+	if !ok {
+		log.Fatal("Delete requests can only be managed on a Loki server")
+	}
+	return c
+}
+
+func newDeleteCreateQuery(cmd *kingpin.CmdClause) *deletequery.DeleteQuery {
+	var from, to string
+
+	q := &deletequery.DeleteQuery{}
+
+	// executed after all command flags are parsed
+	cmd.Action(func(c *kingpin.ParseContext) error {
+		q.Start = mustParse(from, time.Time{})
+		q.End = mustParse(to, time.Now())
+		q.Quiet = *quiet
+		return nil
+	})
+
+	cmd.Arg("selectors", "eg '{foo=\"bar\",baz=~\".*blip\"}'").Required().StringsVar(&q.Selectors)
+	cmd.Flag("from", "Start deleting logs at this absolute time (inclusive)").Required().StringVar(&from)
+	cmd.Flag("to", "Stop deleting logs at this absolute time (inclusive), defaults to now").StringVar(&to)
+	addDeleteWaitFlags(cmd, q)
+
+	return q
+}
+
+func newDeleteListQuery(cmd *kingpin.CmdClause) *deletequery.DeleteQuery {
+	q := &deletequery.DeleteQuery{}
+
+	// executed after all command flags are parsed
+	cmd.Action(func(c *kingpin.ParseContext) error {
+		q.Quiet = *quiet
+		return nil
+	})
+
+	cmd.Flag("request-id", "Only list the delete request with this id.").StringVar(&q.RequestID)
+	addDeleteWaitFlags(cmd, q)
+
+	return q
+}
+
+func newDeleteCancelQuery(cmd *kingpin.CmdClause) *deletequery.DeleteQuery {
+	q := &deletequery.DeleteQuery{}
+
+	// executed after all command flags are parsed
+	cmd.Action(func(c *kingpin.ParseContext) error {
+		q.Quiet = *quiet
+		return nil
+	})
+
+	cmd.Arg("request-id", "The id of the delete request.").Required().StringVar(&q.RequestID)
+
+	return q
+}
+
+func addDeleteWaitFlags(cmd *kingpin.CmdClause, q *deletequery.DeleteQuery) {
+	cmd.Flag("wait", "Wait until the delete request is processed by the compactor.").Default("false").BoolVar(&q.Wait)
+	cmd.Flag("wait-timeout", "Stop waiting for the delete request after this duration, 0 meaning no timeout.").Default("0").DurationVar(&q.WaitTimeout)
+	cmd.Flag("poll-interval", "Interval between the checks of the status of the delete request.").Default("1m").Action(positiveDuration("poll-interval", &q.PollInterval)).DurationVar(&q.PollInterval)
+}
+
+// positiveDuration returns the action rejecting the non-positive values of a duration flag.
+func positiveDuration(name string, d *time.Duration) kingpin.Action {
+	return func(c *kingpin.ParseContext) error {
+		if *d <= 0 {
+			return fmt.Errorf("--%s must be positive, got %s", name, *d)
+		}
+		return nil
+	}
+}
+
+// rulesClient returns the client managing the rules, which are not supported by the local clients.
+func rulesClient() client.RulesClient {
+	c, ok := queryClient.(client.RulesClient)
+	if !ok {
+		log.Fatal("Rules can only be managed on a Loki server")
+	}
+	return c
+}
+
+func newRulesQuery(cmd *kingpin.CmdClause, files, prune bool) *rulesquery.RulesQuery {
+	q := &rulesquery.RulesQuery{}
+
+	// executed after all command flags are parsed
+	cmd.Action(func(c *kingpin.ParseContext) error {
+		q.Quiet = *quiet
+		return nil
+	})
+
+	if files {
+		cmd.Arg("files", "The rule files.").Required().ExistingFilesVar(&q.Files)
+	}
+	if prune {
+		cmd.Flag("prune", "Also delete the namespaces of the ruler which have no rule file.").Default("false").BoolVar(&q.Prune)
+	}
+
+	return q
+}
+
+func newRulesListQuery(cmd *kingpin.CmdClause) *rulesquery.RulesQuery {
+	q := newRulesQuery(cmd, false, false)
+	cmd.Arg("namespace", "Only list the rule groups of this namespace.").StringVar(&q.Namespace)
+	return q
+}
+
+func newRulesDeleteQuery(cmd *kingpin.CmdClause) *rulesquery.RulesQuery {
+	q := newRulesQuery(cmd, false, false)
+	cmd.Arg("namespace", "The namespace to delete, or of the rule group to delete.").Required().StringVar(&q.Namespace)
+	cmd.Arg("group", "The rule group to delete.").StringVar(&q.Group)
+	return q
+}
+
+// pushClient returns the client pushing the logs, which is not supported by the local clients.
+func pushClient() client.PushClient {
+	c, ok := queryClient.(client.PushClient)
+	if !ok {
+		log.Fatal("Logs can only be pushed to a Loki server")
+	}
+	return c
+}
+
+// planClient returns the client explaining the queries, which is not supported by the local clients.
+func planClient() client.PlanClient {
+	c, ok := queryClient.(client.PlanClient)
+	if !ok {
+		log.Fatal("Queries can only be explained by a Loki server")
+	}
+	return c
+}
+
+func newPushQuery(cmd *kingpin.CmdClause) *pushquery.PushQuery {
+	q := &pushquery.PushQuery{}
+
+	// executed after all command flags are parsed
+	cmd.Action(func(c *kingpin.ParseContext) error {
+		q.Quiet = *quiet
+		return nil
+	})
+
+	cmd.Arg("files", "The files to push, the standard input being read when there is no file or for the file \"-\".").StringsVar(&q.Files)
+	cmd.Flag("labels", "The labels of the stream of the lines, eg '{job=\"app\"}'.").Required().StringVar(&q.Labels)
+	cmd.Flag("timestamp-layout", "Parse the timestamps at the start of the lines with this layout of the Go time package, eg '2006-01-02T15:04:05Z07:00'.").StringVar(&q.TimestampLayout)
+	cmd.Flag("batch-size", "Maximum size in bytes of the lines of a batch.").Default("1048576").IntVar(&q.BatchSize)
+	cmd.Flag("batch-wait", "Maximum time to wait before pushing a batch which is not full.").Default("1s").Action(positiveDuration("batch-wait", &q.BatchWait)).DurationVar(&q.BatchWait)
+
+	return q
+}
+
 func mustParse(t string, defaultTime time.Time) time.Time {
 	if t == "" {
 		return defaultTime
@@ -54,18 +54,44 @@ func compareStreams(expectedRaw, actualRaw json.RawMessage, opts querytee.Sample
 			return err
 		}
 
-		for i, expectedSamplePair := range expectedStream.Entries {
-			actualSamplePair := actualStream.Entries[i]
-			if !expectedSamplePair.Timestamp.Equal(actualSamplePair.Timestamp) {
-				return fmt.Errorf("expected timestamp %v but got %v for stream %s", expectedSamplePair.Timestamp.UnixNano(),
-					actualSamplePair.Timestamp.UnixNano(), expectedStream.Labels)
+		// Entries sharing the same timestamp have no guaranteed order, so they are
+		// compared as a group.
+		for i, j := 0, 0; i < expectedValuesLen; i = j {
+			ts := expectedStream.Entries[i].Timestamp
+			for j = i; j < expectedValuesLen && expectedStream.Entries[j].Timestamp.Equal(ts); j++ {
+				if !actualStream.Entries[j].Timestamp.Equal(ts) {
+					return fmt.Errorf("expected timestamp %v but got %v for stream %s", ts.UnixNano(),
+						actualStream.Entries[j].Timestamp.UnixNano(), expectedStream.Labels)
+				}
 			}
-			if expectedSamplePair.Line != actualSamplePair.Line {
-				return fmt.Errorf("expected line %s for timestamp %v but got %s for stream %s", expectedSamplePair.Line,
-					expectedSamplePair.Timestamp.UnixNano(), actualSamplePair.Line, expectedStream.Labels)
+			if err := compareEntries(expectedStream.Entries[i:j], actualStream.Entries[i:j]); err != nil {
+				return fmt.Errorf("%s for stream %s", err, expectedStream.Labels)
 			}
 		}
 	}
 
 	return nil
 }
+
+// compareEntries compares entries with the same timestamp regardless of their order.
+func compareEntries(expected, actual []loghttp.Entry) error {
+	if len(expected) == 1 {
+		if expected[0].Line != actual[0].Line {
+			return fmt.Errorf("expected line %s for timestamp %v but got %s", expected[0].Line,
+				expected[0].Timestamp.UnixNano(), actual[0].Line)
+		}
+		return nil
+	}
+
+	lines := make(map[string]int, len(actual))
+	for _, entry := range actual {
+		lines[entry.Line]++
+	}
+	for _, entry := range expected {
+		if lines[entry.Line] == 0 {
+			return fmt.Errorf("expected line %s for timestamp %v missing from actual response", entry.Line, entry.Timestamp.UnixNano())
+		}
+		lines[entry.Line]--
+	}
+	return nil
+}
@@ -81,6 +81,35 @@ func TestCompareStreams(t *testing.T) {
 						]`),
 			err: errors.New("expected line 2 for timestamp 2 but got 3 for stream {foo=\"bar\"}"),
 		},
+		{
+			name: "different order of lines with the same timestamp",
+			expected: json.RawMessage(`[
+							{"stream":{"foo":"bar"},"values":[["1","1"],["2","a"],["2","b"],["2","b"],["3","3"]]}
+						]`),
+			actual: json.RawMessage(`[
+							{"stream":{"foo":"bar"},"values":[["1","1"],["2","b"],["2","a"],["2","b"],["3","3"]]}
+						]`),
+		},
+		{
+			name: "difference in lines with the same timestamp",
+			expected: json.RawMessage(`[
+							{"stream":{"foo":"bar"},"values":[["2","a"],["2","b"],["2","b"]]}
+						]`),
+			actual: json.RawMessage(`[
+							{"stream":{"foo":"bar"},"values":[["2","b"],["2","a"],["2","a"]]}
+						]`),
+			err: errors.New("expected line b for timestamp 2 missing from actual response for stream {foo=\"bar\"}"),
+		},
+		{
+			name: "lines with the same timestamp split across timestamps",
+			expected: json.RawMessage(`[
+							{"stream":{"foo":"bar"},"values":[["2","a"],["2","b"],["3","c"]]}
+						]`),
+			actual: json.RawMessage(`[
+							{"stream":{"foo":"bar"},"values":[["2","a"],["3","c"],["3","b"]]}
+						]`),
+			err: errors.New("expected timestamp 2 but got 3 for stream {foo=\"bar\"}"),
+		},
 		{
 			name: "correct samples",
 			expected: json.RawMessage(`[
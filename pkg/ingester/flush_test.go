@@ -28,6 +28,7 @@ import (
 	"github.com/grafana/loki/pkg/logql/log"
 	"github.com/grafana/loki/pkg/runtime"
 	"github.com/grafana/loki/pkg/storage"
+	"github.com/grafana/loki/pkg/storage/bloom"
 	"github.com/grafana/loki/pkg/storage/chunk"
 	"github.com/grafana/loki/pkg/tenant"
 	"github.com/grafana/loki/pkg/validation"
@@ -343,6 +344,8 @@ func (s *testStore) Stop() {}
 
 func (s *testStore) SetChunkFilterer(_ storage.RequestChunkFilterer) {}
 
+func (s *testStore) SetBloomStore(_ *bloom.Store) {}
+
 func pushTestSamples(t *testing.T, ing logproto.PusherServer) map[string][]logproto.Stream {
 	userIDs := []string{"1", "2", "3"}
 
@@ -31,6 +31,7 @@ import (
 	"github.com/grafana/loki/pkg/storage"
 	"github.com/grafana/loki/pkg/storage/chunk"
 	"github.com/grafana/loki/pkg/storage/stores/shipper"
+	"github.com/grafana/loki/pkg/storage/tsdb"
 	"github.com/grafana/loki/pkg/tenant"
 	"github.com/grafana/loki/pkg/util"
 	errUtil "github.com/grafana/loki/pkg/util"
@@ -625,18 +626,18 @@ func (i *Ingester) QuerySample(req *logproto.SampleQueryRequest, queryServer log
 	return sendSampleBatches(ctx, it, queryServer)
 }
 
-// boltdbShipperMaxLookBack returns a max look back period only if active index type is boltdb-shipper.
-// max look back is limited to from time of boltdb-shipper config.
-// It considers previous periodic config's from time if that also has index type set to boltdb-shipper.
+// boltdbShipperMaxLookBack returns a max look back period only if active index type is boltdb-shipper or tsdb.
+// max look back is limited to from time of boltdb-shipper or tsdb config.
+// It considers previous periodic config's from time if that also has index type set to boltdb-shipper or tsdb.
 func (i *Ingester) boltdbShipperMaxLookBack() time.Duration {
 	activePeriodicConfigIndex := storage.ActivePeriodConfig(i.periodicConfigs)
 	activePeriodicConfig := i.periodicConfigs[activePeriodicConfigIndex]
-	if activePeriodicConfig.IndexType != shipper.BoltDBShipperType {
+	if !isShipperIndexType(activePeriodicConfig.IndexType) {
 		return 0
 	}
 
 	startTime := activePeriodicConfig.From
-	if activePeriodicConfigIndex != 0 && i.periodicConfigs[activePeriodicConfigIndex-1].IndexType == shipper.BoltDBShipperType {
+	if activePeriodicConfigIndex != 0 && isShipperIndexType(i.periodicConfigs[activePeriodicConfigIndex-1].IndexType) {
 		startTime = i.periodicConfigs[activePeriodicConfigIndex-1].From
 	}
 
@@ -644,6 +645,11 @@ func (i *Ingester) boltdbShipperMaxLookBack() time.Duration {
 	return maxLookBack
 }
 
+// isShipperIndexType returns whether the index of the index type is shipped asynchronously to the store, making the ingesters query their stores.
+func isShipperIndexType(indexType string) bool {
+	return indexType == shipper.BoltDBShipperType || indexType == tsdb.TSDBType
+}
+
 // GetChunkIDs is meant to be used only when using an async store like boltdb-shipper.
 func (i *Ingester) GetChunkIDs(ctx context.Context, req *logproto.GetChunkIDsRequest) (*logproto.GetChunkIDsResponse, error) {
 	orgID, err := tenant.TenantID(ctx)
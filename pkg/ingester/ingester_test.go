@@ -26,6 +26,7 @@ import (
 	"github.com/grafana/loki/pkg/logql"
 	"github.com/grafana/loki/pkg/runtime"
 	"github.com/grafana/loki/pkg/storage"
+	"github.com/grafana/loki/pkg/storage/bloom"
 	"github.com/grafana/loki/pkg/storage/chunk"
 	"github.com/grafana/loki/pkg/tenant"
 	"github.com/grafana/loki/pkg/validation"
@@ -289,6 +290,9 @@ func (s *mockStore) GetSchemaConfigs() []chunk.PeriodConfig {
 func (s *mockStore) SetChunkFilterer(_ storage.RequestChunkFilterer) {
 }
 
+func (s *mockStore) SetBloomStore(_ *bloom.Store) {
+}
+
 // chunk.Store methods
 func (s *mockStore) PutOne(ctx context.Context, from, through model.Time, chunk chunk.Chunk) error {
 	return nil
@@ -1,22 +1,34 @@
 package client
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"net/url"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
 	"github.com/gorilla/websocket"
+	"github.com/grafana/dskit/backoff"
 	json "github.com/json-iterator/go"
 	"github.com/prometheus/common/config"
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"gopkg.in/yaml.v3"
 
 	"github.com/grafana/loki/pkg/loghttp"
 	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/storage/stores/shipper/compactor/deletion"
 	"github.com/grafana/loki/pkg/util"
 	"github.com/grafana/loki/pkg/util/build"
 )
@@ -28,10 +40,31 @@ const (
 	labelValuesPath = "/loki/api/v1/label/%s/values"
 	seriesPath      = "/loki/api/v1/series"
 	tailPath        = "/loki/api/v1/tail"
+	queryPlanPath   = "/loki/api/v1/query_plan"
+
+	deletePath       = "/loki/api/admin/delete"
+	cancelDeletePath = "/loki/api/admin/cancel_delete_request"
+
+	rulesPath = "/loki/api/v1/rules"
+
+	pushPath = "/loki/api/v1/push"
 )
 
 var userAgent = fmt.Sprintf("loki-logcli/%s", build.Version)
 
+// pushBackoff is the backoff between the attempts of a push, the number of retries being set by the client.
+var pushBackoff = backoff.Config{
+	MinBackoff: 500 * time.Millisecond,
+	MaxBackoff: 30 * time.Second,
+}
+
+// ErrNotFound is returned when the server responds that the requested resource is not found.
+var ErrNotFound = errors.New("not found")
+
+// ErrRejected is returned when the server rejects entries of a push request, eg because they are out of order, too
+// old or above the rate limit of the tenant.
+var ErrRejected = errors.New("entries rejected")
+
 // Client contains all the methods to query a Loki instance, it's an interface to allow multiple implementations.
 type Client interface {
 	Query(queryStr string, limit int, time time.Time, direction logproto.Direction, quiet bool) (*loghttp.QueryResponse, error)
@@ -43,6 +76,31 @@ type Client interface {
 	GetOrgID() string
 }
 
+// DeleteClient contains the methods to manage the delete requests of a Loki instance.
+type DeleteClient interface {
+	CreateDeleteRequest(selectors []string, start, end time.Time, quiet bool) error
+	ListDeleteRequests(quiet bool) ([]deletion.DeleteRequest, error)
+	CancelDeleteRequest(requestID string, quiet bool) error
+}
+
+// RulesClient contains the methods to manage the rule groups of a Loki ruler, by namespace.
+type RulesClient interface {
+	ListRules(namespace string, quiet bool) (map[string][]rulefmt.RuleGroup, error)
+	SetRuleGroup(namespace string, group rulefmt.RuleGroup, quiet bool) error
+	DeleteRuleGroup(namespace, group string, quiet bool) error
+	DeleteNamespace(namespace string, quiet bool) error
+}
+
+// PushClient contains the methods to push logs to a Loki instance.
+type PushClient interface {
+	Push(req *logproto.PushRequest, quiet bool) error
+}
+
+// PlanClient contains the methods to explain queries without executing them.
+type PlanClient interface {
+	QueryPlan(queryStr string, start, end time.Time, step time.Duration, quiet bool) (*loghttp.QueryPlanResponse, error)
+}
+
 // Tripperware can wrap a roundtripper.
 type Tripperware func(http.RoundTripper) http.RoundTripper
 
@@ -136,6 +194,23 @@ func (c *DefaultClient) Series(matchers []string, start, end time.Time, quiet bo
 	return &seriesResponse, nil
 }
 
+// QueryPlan uses the /loki/api/v1/query_plan endpoint to explain how the range query would be executed
+func (c *DefaultClient) QueryPlan(queryStr string, start, end time.Time, step time.Duration, quiet bool) (*loghttp.QueryPlanResponse, error) {
+	params := util.NewQueryStringBuilder()
+	params.SetString("query", queryStr)
+	params.SetInt("start", start.UnixNano())
+	params.SetInt("end", end.UnixNano())
+	if step != 0 {
+		params.SetFloat("step", step.Seconds())
+	}
+
+	var planResponse loghttp.QueryPlanResponse
+	if err := c.doRequest(queryPlanPath, params.Encode(), quiet, &planResponse); err != nil {
+		return nil, err
+	}
+	return &planResponse, nil
+}
+
 // LiveTailQueryConn uses /api/prom/tail to set up a websocket connection and returns it
 func (c *DefaultClient) LiveTailQueryConn(queryStr string, delayFor time.Duration, limit int, start time.Time, quiet bool) (*websocket.Conn, error) {
 	params := util.NewQueryStringBuilder()
@@ -149,6 +224,199 @@ func (c *DefaultClient) LiveTailQueryConn(queryStr string, delayFor time.Duratio
 	return c.wsConnect(tailPath, params.Encode(), quiet)
 }
 
+// CreateDeleteRequest uses the /loki/api/admin/delete endpoint to request the deletion of the logs matching any of
+// the selectors between start and end.
+func (c *DefaultClient) CreateDeleteRequest(selectors []string, start, end time.Time, quiet bool) error {
+	params := util.NewQueryStringBuilder()
+	params.SetStringArray("match[]", selectors)
+	params.SetString("start", start.Format(time.RFC3339Nano))
+	params.SetString("end", end.Format(time.RFC3339Nano))
+
+	return c.doHTTPRequest(http.MethodPost, deletePath, params.Encode(), quiet, nil)
+}
+
+// ListDeleteRequests uses the /loki/api/admin/delete endpoint to list the delete requests of the tenant.
+func (c *DefaultClient) ListDeleteRequests(quiet bool) ([]deletion.DeleteRequest, error) {
+	var requests []deletion.DeleteRequest
+	if err := c.doRequest(deletePath, "", quiet, &requests); err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+// CancelDeleteRequest uses the /loki/api/admin/cancel_delete_request endpoint to cancel a delete request which
+// was not processed yet.
+func (c *DefaultClient) CancelDeleteRequest(requestID string, quiet bool) error {
+	params := util.NewQueryStringBuilder()
+	params.SetString("request_id", requestID)
+
+	return c.doHTTPRequest(http.MethodPost, cancelDeletePath, params.Encode(), quiet, nil)
+}
+
+// ListRules uses the /loki/api/v1/rules endpoint to list the rule groups of the tenant by namespace, only in the
+// given namespace when it is not empty.
+func (c *DefaultClient) ListRules(namespace string, quiet bool) (map[string][]rulefmt.RuleGroup, error) {
+	p := rulesPath
+	if namespace != "" {
+		p = path.Join(rulesPath, namespace)
+	}
+	resp, err := c.send(http.MethodGet, p, "", nil, quiet)
+	if errors.Is(err, ErrNotFound) {
+		// The ruler responds with a 404 when there are no rule groups.
+		return map[string][]rulefmt.RuleGroup{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Println("error closing body", err)
+		}
+	}()
+
+	rules := map[string][]rulefmt.RuleGroup{}
+	if err := yaml.NewDecoder(resp.Body).Decode(&rules); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// SetRuleGroup uses the /loki/api/v1/rules/{namespace} endpoint to create or replace the rule group in the namespace.
+func (c *DefaultClient) SetRuleGroup(namespace string, group rulefmt.RuleGroup, quiet bool) error {
+	body, err := yaml.Marshal(group)
+	if err != nil {
+		return err
+	}
+	resp, err := c.send(http.MethodPost, path.Join(rulesPath, namespace), "", body, quiet)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// DeleteRuleGroup uses the /loki/api/v1/rules/{namespace}/{groupName} endpoint to delete the rule group.
+func (c *DefaultClient) DeleteRuleGroup(namespace, group string, quiet bool) error {
+	resp, err := c.send(http.MethodDelete, path.Join(rulesPath, namespace, group), "", nil, quiet)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// DeleteNamespace uses the /loki/api/v1/rules/{namespace} endpoint to delete all the rule groups of the namespace.
+func (c *DefaultClient) DeleteNamespace(namespace string, quiet bool) error {
+	resp, err := c.send(http.MethodDelete, path.Join(rulesPath, namespace), "", nil, quiet)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// Push uses the /loki/api/v1/push endpoint to push the streams of the request, encoded the same way as promtail does.
+// The entries rejected by the server, with a 4xx status code, are not retried and an error wrapping ErrRejected
+// with the response of the server is returned; the rate limited requests are retried with a backoff, waiting at
+// least for the delay of the Retry-After header of the response, until running out of attempts.
+func (c *DefaultClient) Push(req *logproto.PushRequest, quiet bool) error {
+	buf, err := proto.Marshal(req)
+	if err != nil {
+		return err
+	}
+	// The push endpoint always expects snappy-compressed protobuf, the gzip content encoding is decoded first.
+	var body bytes.Buffer
+	gz := gzip.NewWriter(&body)
+	if _, err := gz.Write(snappy.Encode(nil, buf)); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+
+	us, err := buildURL(c.Address, pushPath, "")
+	if err != nil {
+		return err
+	}
+	if !quiet {
+		log.Print(us)
+	}
+	h, err := c.getHTTPRequestHeader()
+	if err != nil {
+		return err
+	}
+	h.Set("Content-Type", "application/x-protobuf")
+	h.Set("Content-Encoding", "gzip")
+	client, err := c.httpClient()
+	if err != nil {
+		return err
+	}
+
+	var (
+		bo         = backoff.New(context.Background(), pushBackoff)
+		retryAfter time.Duration
+	)
+	err = fmt.Errorf("Run out of attempts while pushing to the server")
+	for attempt := 0; attempt <= c.Retries; attempt++ {
+		if attempt > 0 {
+			// Wait the longest of the backoff delay and of the delay asked by the server before retrying.
+			delay := bo.NextDelay()
+			if retryAfter > delay {
+				delay = retryAfter
+			}
+			time.Sleep(delay)
+			retryAfter = 0
+		}
+
+		httpReq, reqErr := http.NewRequest(http.MethodPost, us, bytes.NewReader(body.Bytes()))
+		if reqErr != nil {
+			return reqErr
+		}
+		httpReq.Header = h
+
+		resp, respErr := client.Do(httpReq)
+		if respErr != nil {
+			log.Println("error sending request", respErr)
+			continue
+		}
+		msg, _ := ioutil.ReadAll(resp.Body) // nolint
+		if err := resp.Body.Close(); err != nil {
+			log.Println("error closing body", err)
+		}
+		switch {
+		case resp.StatusCode/100 == 2:
+			return nil
+		case resp.StatusCode == http.StatusTooManyRequests:
+			err = fmt.Errorf("%w: %s", ErrRejected, strings.TrimSpace(string(msg)))
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+			log.Printf("Error response from server: %s attempts remaining: %d", string(msg), c.Retries-attempt)
+		case resp.StatusCode/100 == 4:
+			return fmt.Errorf("%w: %s", ErrRejected, strings.TrimSpace(string(msg)))
+		default:
+			log.Printf("Error response from server: %s attempts remaining: %d", string(msg), c.Retries-attempt)
+		}
+	}
+	return err
+}
+
+// parseRetryAfter returns the delay of a Retry-After header, given in seconds or as an HTTP date, capped to the
+// maximum backoff of the pushes. It returns 0 when the header is missing or invalid.
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return 0
+	}
+	var d time.Duration
+	if secs, err := strconv.Atoi(header); err == nil {
+		d = time.Duration(secs) * time.Second
+	} else if t, err := http.ParseTime(header); err == nil {
+		d = t.Sub(now)
+	}
+	if d < 0 {
+		return 0
+	}
+	if d > pushBackoff.MaxBackoff {
+		return pushBackoff.MaxBackoff
+	}
+	return d
+}
+
 func (c *DefaultClient) GetOrgID() string {
 	return c.OrgID
 }
@@ -165,50 +433,74 @@ func (c *DefaultClient) doQuery(path string, query string, quiet bool) (*loghttp
 }
 
 func (c *DefaultClient) doRequest(path, query string, quiet bool, out interface{}) error {
-	us, err := buildURL(c.Address, path, query)
+	return c.doHTTPRequest(http.MethodGet, path, query, quiet, out)
+}
+
+// doHTTPRequest sends the request and decodes the JSON response into out, unless out is nil.
+func (c *DefaultClient) doHTTPRequest(method, path, query string, quiet bool, out interface{}) error {
+	resp, err := c.send(method, path, query, nil, quiet)
 	if err != nil {
 		return err
 	}
-	if !quiet {
-		log.Print(us)
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Println("error closing body", err)
+		}
+	}()
+	if out == nil {
+		return nil
 	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
 
-	req, err := http.NewRequest("GET", us, nil)
+// send sends the request with the given body, retrying until it succeeds, and returns the response. The responses
+// telling that the resource is not found are not retried, and return ErrNotFound.
+func (c *DefaultClient) send(method, path, query string, body []byte, quiet bool) (*http.Response, error) {
+	us, err := buildURL(c.Address, path, query)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if !quiet {
+		log.Print(us)
 	}
 
 	h, err := c.getHTTPRequestHeader()
 	if err != nil {
-		return err
-	}
-	req.Header = h
-
-	// Parse the URL to extract the host
-	clientConfig := config.HTTPClientConfig{
-		TLSConfig: c.TLSConfig,
+		return nil, err
 	}
 
-	client, err := config.NewClientFromConfig(clientConfig, "promtail", config.WithHTTP2Disabled())
+	client, err := c.httpClient()
 	if err != nil {
-		return err
-	}
-	if c.Tripperware != nil {
-		client.Transport = c.Tripperware(client.Transport)
+		return nil, err
 	}
 
-	var resp *http.Response
 	attempts := c.Retries + 1
-	success := false
 
 	for attempts > 0 {
 		attempts--
 
-		resp, err = client.Do(req)
+		var reqBody io.Reader
+		if body != nil {
+			reqBody = bytes.NewReader(body)
+		}
+		req, err := http.NewRequest(method, us, reqBody)
+		if err != nil {
+			return nil, err
+		}
+		req.Header = h
+
+		resp, err := client.Do(req)
 		if err != nil {
 			log.Println("error sending request", err)
 			continue
 		}
+		if resp.StatusCode == http.StatusNotFound {
+			buf, _ := ioutil.ReadAll(resp.Body) // nolint
+			if err := resp.Body.Close(); err != nil {
+				log.Println("error closing body", err)
+			}
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, strings.TrimSpace(string(buf)))
+		}
 		if resp.StatusCode/100 != 2 {
 			buf, _ := ioutil.ReadAll(resp.Body) // nolint
 			log.Printf("Error response from server: %s (%v) attempts remaining: %d", string(buf), err, attempts)
@@ -217,19 +509,25 @@ func (c *DefaultClient) doRequest(path, query string, quiet bool, out interface{
 			}
 			continue
 		}
-		success = true
-		break
+		return resp, nil
 	}
-	if !success {
-		return fmt.Errorf("Run out of attempts while querying the server")
+	return nil, fmt.Errorf("Run out of attempts while querying the server")
+}
+
+func (c *DefaultClient) httpClient() (*http.Client, error) {
+	// Parse the URL to extract the host
+	clientConfig := config.HTTPClientConfig{
+		TLSConfig: c.TLSConfig,
 	}
 
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			log.Println("error closing body", err)
-		}
-	}()
-	return json.NewDecoder(resp.Body).Decode(out)
+	client, err := config.NewClientFromConfig(clientConfig, "promtail", config.WithHTTP2Disabled())
+	if err != nil {
+		return nil, err
+	}
+	if c.Tripperware != nil {
+		client.Transport = c.Tripperware(client.Transport)
+	}
+	return client, nil
 }
 
 func (c *DefaultClient) getHTTPRequestHeader() (http.Header, error) {
@@ -2,10 +2,21 @@ package client
 
 import (
 	"encoding/base64"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
 
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/backoff"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/loghttp/push"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/storage/stores/shipper/compactor/deletion"
 )
 
 func Test_buildURL(t *testing.T) {
@@ -78,3 +89,184 @@ func Test_getHTTPRequestHeader(t *testing.T) {
 		})
 	}
 }
+
+func Test_DeleteRequests(t *testing.T) {
+	var requests []*http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r)
+		if r.Method == http.MethodGet {
+			_, _ = w.Write([]byte(`[{"request_id":"abc","start_time":0,"end_time":3600,"selectors":["{app=\"foo\"}"],"status":"received","created_at":7200}]`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+	c := DefaultClient{Address: srv.URL, OrgID: "tenant"}
+
+	start, end := time.Unix(0, 0).UTC(), time.Unix(3600, 0).UTC()
+	require.NoError(t, c.CreateDeleteRequest([]string{`{app="foo"}`, `{app="bar"}`}, start, end, true))
+	require.Equal(t, http.MethodPost, requests[0].Method)
+	require.Equal(t, "/loki/api/admin/delete", requests[0].URL.Path)
+	require.Equal(t, url.Values{
+		"match[]": []string{`{app="foo"}`, `{app="bar"}`},
+		"start":   []string{"1970-01-01T00:00:00Z"},
+		"end":     []string{"1970-01-01T01:00:00Z"},
+	}, requests[0].URL.Query())
+	require.Equal(t, "tenant", requests[0].Header.Get("X-Scope-OrgID"))
+
+	list, err := c.ListDeleteRequests(true)
+	require.NoError(t, err)
+	require.Equal(t, []deletion.DeleteRequest{{
+		RequestID: "abc",
+		StartTime: 0,
+		EndTime:   3600000,
+		Selectors: []string{`{app="foo"}`},
+		Status:    deletion.StatusReceived,
+		CreatedAt: 7200000,
+	}}, list)
+
+	require.NoError(t, c.CancelDeleteRequest("abc", true))
+	require.Equal(t, http.MethodPost, requests[2].Method)
+	require.Equal(t, "/loki/api/admin/cancel_delete_request", requests[2].URL.Path)
+	require.Equal(t, "abc", requests[2].URL.Query().Get("request_id"))
+}
+
+func Test_Rules(t *testing.T) {
+	var (
+		requests []*http.Request
+		bodies   []string
+		rules    = ""
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		requests = append(requests, r)
+		bodies = append(bodies, string(body))
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		if rules == "" {
+			http.Error(w, "no rule groups found", http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(rules))
+	}))
+	defer srv.Close()
+	c := DefaultClient{Address: srv.URL}
+
+	// The ruler responds with a 404 when there are no rules.
+	list, err := c.ListRules("", true)
+	require.NoError(t, err)
+	require.Empty(t, list)
+
+	rules = "app:\n  - name: errors\n    rules:\n      - record: app:errors\n        expr: sum(rate({app=\"foo\"}[1m]))\n"
+	list, err = c.ListRules("app", true)
+	require.NoError(t, err)
+	require.Equal(t, "/loki/api/v1/rules/app", requests[1].URL.Path)
+	require.Len(t, list["app"], 1)
+	require.Equal(t, "errors", list["app"][0].Name)
+	require.Equal(t, `sum(rate({app="foo"}[1m]))`, list["app"][0].Rules[0].Expr.Value)
+
+	require.NoError(t, c.SetRuleGroup("app", list["app"][0], true))
+	require.Equal(t, http.MethodPost, requests[2].Method)
+	require.Equal(t, "/loki/api/v1/rules/app", requests[2].URL.Path)
+	require.Contains(t, bodies[2], "name: errors\n")
+
+	require.NoError(t, c.DeleteRuleGroup("app", "errors", true))
+	require.Equal(t, http.MethodDelete, requests[3].Method)
+	require.Equal(t, "/loki/api/v1/rules/app/errors", requests[3].URL.Path)
+
+	require.NoError(t, c.DeleteNamespace("app", true))
+	require.Equal(t, http.MethodDelete, requests[4].Method)
+	require.Equal(t, "/loki/api/v1/rules/app", requests[4].URL.Path)
+}
+
+func Test_Push(t *testing.T) {
+	var (
+		pushed []*logproto.PushRequest
+		status = http.StatusNoContent
+		calls  int
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		require.Equal(t, "/loki/api/v1/push", r.URL.Path)
+		// The request is decoded the same way as the distributor does.
+		req, err := push.ParseRequest(log.NewNopLogger(), "", r, nil)
+		require.NoError(t, err)
+		pushed = append(pushed, req)
+		if status != http.StatusNoContent {
+			http.Error(w, "total ignored: 1 out of 1", status)
+			return
+		}
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+	c := DefaultClient{Address: srv.URL, Retries: 1}
+
+	req := &logproto.PushRequest{Streams: []logproto.Stream{{
+		Labels:  `{job="app"}`,
+		Entries: []logproto.Entry{{Timestamp: time.Unix(1, 0).UTC(), Line: "line"}},
+	}}}
+	require.NoError(t, c.Push(req, true))
+	require.Len(t, pushed, 1)
+	require.Equal(t, req, pushed[0])
+
+	// The rejected entries are not retried.
+	status = http.StatusBadRequest
+	err := c.Push(req, true)
+	require.ErrorIs(t, err, ErrRejected)
+	require.Contains(t, err.Error(), "total ignored: 1 out of 1")
+	require.Equal(t, 2, calls)
+
+	// The rate limited requests are retried after a backoff.
+	defer func(b backoff.Config) { pushBackoff = b }(pushBackoff)
+	pushBackoff = backoff.Config{MinBackoff: 50 * time.Millisecond, MaxBackoff: time.Second}
+	status = http.StatusTooManyRequests
+	start := time.Now()
+	require.ErrorIs(t, c.Push(req, true), ErrRejected)
+	require.Equal(t, 4, calls)
+	require.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+}
+
+func Test_Push_RetryAfter(t *testing.T) {
+	defer func(b backoff.Config) { pushBackoff = b }(pushBackoff)
+	pushBackoff = backoff.Config{MinBackoff: time.Millisecond, MaxBackoff: 2 * time.Second}
+
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "rate limited", http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+	c := DefaultClient{Address: srv.URL, Retries: 1}
+
+	start := time.Now()
+	require.NoError(t, c.Push(&logproto.PushRequest{}, true))
+	require.Equal(t, 2, calls)
+	require.GreaterOrEqual(t, time.Since(start), time.Second)
+}
+
+func Test_parseRetryAfter(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		header   string
+		expected time.Duration
+	}{
+		{"", 0},
+		{"invalid", 0},
+		{"-1", 0},
+		{"5", 5 * time.Second},
+		{"3600", pushBackoff.MaxBackoff},
+		{now.Add(10 * time.Second).Format(http.TimeFormat), 10 * time.Second},
+		{now.Add(-10 * time.Second).Format(http.TimeFormat), 0},
+	} {
+		t.Run(tc.header, func(t *testing.T) {
+			require.Equal(t, tc.expected, parseRetryAfter(tc.header, now))
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/loki"
+	"github.com/grafana/loki/pkg/storage"
+	chunk_storage "github.com/grafana/loki/pkg/storage/chunk/storage"
+	"github.com/grafana/loki/pkg/storage/stores/shipper"
+	"github.com/grafana/loki/pkg/util/cfg"
+	util_log "github.com/grafana/loki/pkg/util/log"
+	"github.com/grafana/loki/pkg/util/marshal"
+	"github.com/grafana/loki/pkg/validation"
+)
+
+var (
+	// The metrics of the storage clients are registered globally, so they can only be created once.
+	storeClientMetrics     chunk_storage.ClientMetrics
+	storeClientMetricsOnce sync.Once
+)
+
+// StoreClient is a type of LogCLI client that does LogQL directly on the chunks and index of a storage, configured
+// by a Loki configuration file, instead of querying Loki servers. The storage can be a local directory or an
+// object storage, like any S3-compatible endpoint; the index is only read.
+type StoreClient struct {
+	orgID  string
+	store  storage.Store
+	engine *logql.Engine
+}
+
+// NewStoreClient returns the new instance of StoreClient for the storage configured in the given Loki
+// configuration file, querying the data of the given tenant, which must be set.
+func NewStoreClient(configFile string, orgID string) (*StoreClient, error) {
+	if orgID == "" {
+		return nil, errors.New("the tenant of the configured storage must be set with --org-id")
+	}
+	conf, err := loadStoreConfig(configFile)
+	if err != nil {
+		return nil, err
+	}
+	conf.StorageConfig.BoltDBShipperConfig.Mode = shipper.ModeReadOnly
+	store, limits, err := newStore(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StoreClient{
+		orgID:  orgID,
+		store:  store,
+		engine: logql.NewEngine(conf.Querier.Engine, store, limits, util_log.Logger),
+	}, nil
+}
+
+func loadStoreConfig(configFile string) (loki.Config, error) {
+	var conf loki.Config
+	conf.RegisterFlags(flag.NewFlagSet("logcli", flag.ContinueOnError))
+	if configFile == "" {
+		return conf, fmt.Errorf("no supplied config file")
+	}
+	if err := cfg.YAML(configFile, false)(&conf); err != nil {
+		return conf, err
+	}
+	return conf, conf.Validate()
+}
+
+func newStore(conf loki.Config) (storage.Store, *validation.Overrides, error) {
+	limits, err := validation.NewOverrides(conf.LimitsConfig, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The client has its own registry, the metrics of the storage are not exposed.
+	reg := prometheus.NewRegistry()
+	storeClientMetricsOnce.Do(func() {
+		storeClientMetrics = chunk_storage.NewClientMetrics()
+	})
+	storage.RegisterCustomIndexClients(&conf.StorageConfig, storeClientMetrics, reg)
+	chunkStore, err := chunk_storage.NewStore(conf.StorageConfig.Config, conf.ChunkStoreConfig.StoreConfig, conf.SchemaConfig.SchemaConfig, limits, storeClientMetrics, reg, nil, util_log.Logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	store, err := storage.NewStore(conf.StorageConfig, conf.SchemaConfig, chunkStore, reg)
+	if err != nil {
+		chunkStore.Stop()
+		return nil, nil, err
+	}
+	return store, limits, nil
+}
+
+func (s *StoreClient) Query(queryStr string, limit int, t time.Time, direction logproto.Direction, quiet bool) (*loghttp.QueryResponse, error) {
+	return s.exec(logql.NewLiteralParams(queryStr, t, t, 0, 0, direction, uint32(limit), nil))
+}
+
+func (s *StoreClient) QueryRange(queryStr string, limit int, start, end time.Time, direction logproto.Direction, step, interval time.Duration, quiet bool) (*loghttp.QueryResponse, error) {
+	return s.exec(logql.NewLiteralParams(queryStr, start, end, step, interval, direction, uint32(limit), nil))
+}
+
+func (s *StoreClient) exec(params logql.LiteralParams) (*loghttp.QueryResponse, error) {
+	result, err := s.engine.Query(params).Exec(s.context())
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := marshal.NewResultValue(result.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &loghttp.QueryResponse{
+		Status: loghttp.QueryStatusSuccess,
+		Data: loghttp.QueryResponseData{
+			ResultType: value.Type(),
+			Result:     value,
+			Statistics: result.Statistics,
+		},
+	}, nil
+}
+
+func (s *StoreClient) ListLabelNames(quiet bool, start, end time.Time) (*loghttp.LabelResponse, error) {
+	names, err := s.store.LabelNamesForMetricName(s.context(), s.orgID, model.TimeFromUnixNano(start.UnixNano()), model.TimeFromUnixNano(end.UnixNano()), "logs")
+	if err != nil {
+		return nil, err
+	}
+	// The series of the chunks are named after the logs metric, which is not a label of the streams.
+	filtered := names[:0]
+	for _, name := range names {
+		if name != labels.MetricName {
+			filtered = append(filtered, name)
+		}
+	}
+	return &loghttp.LabelResponse{
+		Status: loghttp.QueryStatusSuccess,
+		Data:   filtered,
+	}, nil
+}
+
+func (s *StoreClient) ListLabelValues(name string, quiet bool, start, end time.Time) (*loghttp.LabelResponse, error) {
+	values, err := s.store.LabelValuesForMetricName(s.context(), s.orgID, model.TimeFromUnixNano(start.UnixNano()), model.TimeFromUnixNano(end.UnixNano()), "logs", name)
+	if err != nil {
+		return nil, err
+	}
+	return &loghttp.LabelResponse{
+		Status: loghttp.QueryStatusSuccess,
+		Data:   values,
+	}, nil
+}
+
+func (s *StoreClient) Series(matchers []string, start, end time.Time, quiet bool) (*loghttp.SeriesResponse, error) {
+	// Same as the querier, the series matching any of the matchers are returned.
+	seen := map[string]struct{}{}
+	var series []loghttp.LabelSet
+	for _, matcher := range matchers {
+		ids, err := s.store.GetSeries(s.context(), logql.SelectLogParams{
+			QueryRequest: &logproto.QueryRequest{
+				Selector:  matcher,
+				Limit:     1,
+				Start:     start,
+				End:       end,
+				Direction: logproto.FORWARD,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			lbs := loghttp.LabelSet(id.Labels)
+			key := lbs.String()
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			series = append(series, lbs)
+		}
+	}
+	sort.Slice(series, func(i, j int) bool {
+		return series[i].String() < series[j].String()
+	})
+
+	return &loghttp.SeriesResponse{
+		Status: loghttp.QueryStatusSuccess,
+		Data:   series,
+	}, nil
+}
+
+func (s *StoreClient) LiveTailQueryConn(queryStr string, delayFor time.Duration, limit int, start time.Time, quiet bool) (*websocket.Conn, error) {
+	return nil, fmt.Errorf("LiveTailQuery: %w", ErrNotSupported)
+}
+
+func (s *StoreClient) GetOrgID() string {
+	return s.orgID
+}
+
+// Stop stops the storage.
+func (s *StoreClient) Stop() {
+	s.store.Stop()
+}
+
+func (s *StoreClient) context() context.Context {
+	return user.InjectOrgID(context.Background(), s.orgID)
+}
@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/chunkenc"
+	ingester_client "github.com/grafana/loki/pkg/ingester/client"
+	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/storage/stores/shipper"
+	"github.com/grafana/loki/pkg/util"
+)
+
+const testStoreConfig = `
+auth_enabled: false
+schema_config:
+  configs:
+    - from: 2020-01-01
+      store: boltdb-shipper
+      object_store: filesystem
+      schema: v11
+      index:
+        prefix: index_
+        period: 24h
+storage_config:
+  boltdb_shipper:
+    active_index_directory: %[1]s/index
+    cache_location: %[1]s/cache
+    shared_store: filesystem
+  filesystem:
+    directory: %[1]s/chunks
+`
+
+func TestStoreClient(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "loki.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte(fmt.Sprintf(testStoreConfig, dir)), 0o644))
+
+	// Write the chunks the way Loki does, the index is uploaded when the store stops.
+	conf, err := loadStoreConfig(configFile)
+	require.NoError(t, err)
+	conf.StorageConfig.BoltDBShipperConfig.Mode = shipper.ModeReadWrite
+	store, _, err := newStore(conf)
+	require.NoError(t, err)
+	start := time.Date(2022, 3, 8, 10, 0, 0, 0, time.UTC)
+	for _, lbs := range []string{`{app="foo", env="prod"}`, `{app="bar", env="prod"}`} {
+		chk := newTestChunk(t, lbs, start, 10)
+		require.NoError(t, store.PutOne(context.Background(), chk.From, chk.Through, chk))
+	}
+	store.Stop()
+
+	// The tenant must be set explicitly.
+	_, err = NewStoreClient(configFile, "")
+	require.Error(t, err)
+
+	c, err := NewStoreClient(configFile, "fake")
+	require.NoError(t, err)
+	defer c.Stop()
+	require.Equal(t, "fake", c.GetOrgID())
+
+	resp, err := c.QueryRange(`{app="foo"} |= "line 1"`, 100, start, start.Add(time.Hour), logproto.FORWARD, 0, 0, true)
+	require.NoError(t, err)
+	streams := resp.Data.Result.(loghttp.Streams)
+	require.Len(t, streams, 1)
+	require.Equal(t, loghttp.LabelSet{"app": "foo", "env": "prod"}, streams[0].Labels)
+	require.Len(t, streams[0].Entries, 1)
+	require.Equal(t, "line 1", streams[0].Entries[0].Line)
+
+	resp, err = c.Query(`sum(count_over_time({env="prod"}[1h]))`, 100, start.Add(time.Minute), logproto.FORWARD, true)
+	require.NoError(t, err)
+	vector := resp.Data.Result.(loghttp.Vector)
+	require.Len(t, vector, 1)
+	require.Equal(t, 20., float64(vector[0].Value))
+
+	names, err := c.ListLabelNames(true, start, start.Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, []string{"app", "env"}, names.Data)
+
+	values, err := c.ListLabelValues("app", true, start, start.Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, []string{"bar", "foo"}, values.Data)
+
+	series, err := c.Series([]string{`{app="foo"}`, `{env="prod"}`}, start, start.Add(time.Hour), true)
+	require.NoError(t, err)
+	require.Equal(t, []loghttp.LabelSet{
+		{"app": "bar", "env": "prod"},
+		{"app": "foo", "env": "prod"},
+	}, series.Data)
+
+	_, err = c.LiveTailQueryConn(`{app="foo"}`, 0, 10, start, true)
+	require.ErrorIs(t, err, ErrNotSupported)
+}
+
+func newTestChunk(t *testing.T, stream string, start time.Time, n int) chunk.Chunk {
+	lbs, err := logql.ParseLabels(stream)
+	require.NoError(t, err)
+	lbs = labels.NewBuilder(lbs).Set(labels.MetricName, "logs").Labels()
+
+	chk := chunkenc.NewMemChunk(chunkenc.EncGZIP, chunkenc.UnorderedHeadBlockFmt, 256*1024, 0)
+	for i := 0; i < n; i++ {
+		require.NoError(t, chk.Append(&logproto.Entry{Timestamp: start.Add(time.Duration(i) * time.Second), Line: fmt.Sprintf("line %d", i)}))
+	}
+	require.NoError(t, chk.Close())
+	from, through := util.RoundToMilliseconds(start, start.Add(time.Duration(n-1)*time.Second))
+	c := chunk.NewChunk("fake", ingester_client.Fingerprint(lbs), lbs, chunkenc.NewFacade(chk, 0, 0), from, through)
+	require.NoError(t, c.Encode())
+	return c
+}
@@ -0,0 +1,220 @@
+package deletequery
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/grafana/loki/pkg/logcli/client"
+	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/storage/stores/shipper/compactor/deletion"
+)
+
+// DeleteQuery contains all necessary fields to create, list and cancel delete requests and print out the results
+type DeleteQuery struct {
+	Selectors []string
+	Start     time.Time
+	End       time.Time
+	RequestID string
+	// Wait waits until the created request is processed by the compactor, polling the requests every PollInterval
+	// until WaitTimeout, when it is positive.
+	Wait         bool
+	WaitTimeout  time.Duration
+	PollInterval time.Duration
+	Quiet        bool
+}
+
+// DoCreate validates the selectors and the time range, creates the delete request and prints it out
+func (q *DeleteQuery) DoCreate(c client.DeleteClient) {
+	request, err := q.Create(c)
+	if err != nil {
+		log.Fatalf("Error creating the delete request: %+v", err)
+	}
+	if request == nil {
+		// The request was created, but it could not be told apart from the other requests.
+		return
+	}
+	printRequests(os.Stdout, []deletion.DeleteRequest{*request})
+}
+
+// Create validates the selectors and the time range and creates the delete request. It returns the created request,
+// once processed if Wait is set, or nil if the request can't be found in the requests of the tenant.
+func (q *DeleteQuery) Create(c client.DeleteClient) (*deletion.DeleteRequest, error) {
+	if err := q.validate(); err != nil {
+		return nil, err
+	}
+
+	// The endpoint does not return the id of the request, so it is found in the requests which did not exist yet.
+	before, err := c.ListDeleteRequests(q.Quiet)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]struct{}, len(before))
+	for _, r := range before {
+		existing[r.RequestID] = struct{}{}
+	}
+
+	if err := c.CreateDeleteRequest(q.Selectors, q.Start, q.End, q.Quiet); err != nil {
+		return nil, err
+	}
+	request, err := q.find(c, existing)
+	if err != nil || request == nil {
+		return request, err
+	}
+	if !q.Quiet {
+		log.Println("Created delete request", request.RequestID)
+	}
+	if !q.Wait {
+		return request, nil
+	}
+
+	q.RequestID = request.RequestID
+	return q.waitProcessed(c)
+}
+
+func (q *DeleteQuery) validate() error {
+	if len(q.Selectors) == 0 {
+		return errors.New("no selectors given")
+	}
+	for _, selector := range q.Selectors {
+		if _, err := logql.ParseMatchers(selector); err != nil {
+			return fmt.Errorf("invalid selector %q: %w", selector, err)
+		}
+	}
+	if q.Start.After(q.End) {
+		return errors.New("the start time is after the end time")
+	}
+	if q.End.After(time.Now()) {
+		return errors.New("the end time is in the future")
+	}
+	return nil
+}
+
+// find returns the request created by the query which is not in the existing requests.
+func (q *DeleteQuery) find(c client.DeleteClient, existing map[string]struct{}) (*deletion.DeleteRequest, error) {
+	requests, err := c.ListDeleteRequests(q.Quiet)
+	if err != nil {
+		return nil, err
+	}
+	selectors := strings.Join(q.Selectors, ",")
+	for i, r := range requests {
+		if _, ok := existing[r.RequestID]; ok {
+			continue
+		}
+		if strings.Join(r.Selectors, ",") == selectors && r.StartTime.Time().Equal(q.Start.Truncate(time.Millisecond)) && r.EndTime.Time().Equal(q.End.Truncate(time.Millisecond)) {
+			return &requests[i], nil
+		}
+	}
+	if !q.Quiet {
+		log.Println("The delete request was created, but it was not found in the requests of the tenant")
+	}
+	return nil, nil
+}
+
+// waitProcessed polls the requests until the request of the query is processed.
+func (q *DeleteQuery) waitProcessed(c client.DeleteClient) (*deletion.DeleteRequest, error) {
+	var timeout <-chan time.Time
+	if q.WaitTimeout > 0 {
+		timer := time.NewTimer(q.WaitTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	ticker := time.NewTicker(q.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		request, err := q.get(c)
+		if err != nil {
+			return nil, err
+		}
+		if request.Status == deletion.StatusProcessed {
+			return request, nil
+		}
+		if !q.Quiet {
+			log.Printf("Waiting for the delete request %s to be processed, status: %s", request.RequestID, request.Status)
+		}
+		select {
+		case <-ticker.C:
+		case <-timeout:
+			return nil, fmt.Errorf("the delete request %s was not processed after %s", request.RequestID, q.WaitTimeout)
+		}
+	}
+}
+
+// get returns the request of the query.
+func (q *DeleteQuery) get(c client.DeleteClient) (*deletion.DeleteRequest, error) {
+	requests, err := c.ListDeleteRequests(q.Quiet)
+	if err != nil {
+		return nil, err
+	}
+	for i, r := range requests {
+		if r.RequestID == q.RequestID {
+			return &requests[i], nil
+		}
+	}
+	return nil, fmt.Errorf("delete request %s not found", q.RequestID)
+}
+
+// DoList prints out the delete requests of the tenant, or only the request of the query when RequestID is set,
+// after waiting for it to be processed if Wait is set
+func (q *DeleteQuery) DoList(c client.DeleteClient) {
+	var requests []deletion.DeleteRequest
+	switch {
+	case q.RequestID != "" && q.Wait:
+		request, err := q.waitProcessed(c)
+		if err != nil {
+			log.Fatalf("Error waiting for the delete request: %+v", err)
+		}
+		requests = append(requests, *request)
+	case q.RequestID != "":
+		request, err := q.get(c)
+		if err != nil {
+			log.Fatalf("Error getting the delete request: %+v", err)
+		}
+		requests = append(requests, *request)
+	default:
+		var err error
+		requests, err = c.ListDeleteRequests(q.Quiet)
+		if err != nil {
+			log.Fatalf("Error listing the delete requests: %+v", err)
+		}
+	}
+	printRequests(os.Stdout, requests)
+}
+
+// DoCancel cancels the delete request of the query
+func (q *DeleteQuery) DoCancel(c client.DeleteClient) {
+	if err := c.CancelDeleteRequest(q.RequestID, q.Quiet); err != nil {
+		log.Fatalf("Error cancelling the delete request: %+v", err)
+	}
+	if !q.Quiet {
+		log.Println("Cancelled delete request", q.RequestID)
+	}
+}
+
+// printRequests prints out the requests as a table, the most recent first.
+func printRequests(w io.Writer, requests []deletion.DeleteRequest) {
+	sort.SliceStable(requests, func(i, j int) bool {
+		return requests[i].CreatedAt > requests[j].CreatedAt
+	})
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "REQUEST ID\tSTATUS\tCREATED AT\tSTART\tEND\tSELECTORS\n")
+	for _, r := range requests {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			r.RequestID,
+			r.Status,
+			r.CreatedAt.Time().UTC().Format(time.RFC3339),
+			r.StartTime.Time().UTC().Format(time.RFC3339),
+			r.EndTime.Time().UTC().Format(time.RFC3339),
+			strings.Join(r.Selectors, " "),
+		)
+	}
+	tw.Flush()
+}
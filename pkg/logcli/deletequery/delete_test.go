@@ -0,0 +1,149 @@
+package deletequery
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/storage/stores/shipper/compactor/deletion"
+)
+
+// testDeleteClient stores the delete requests, which are processed after being listed processAfter times.
+type testDeleteClient struct {
+	requests     []deletion.DeleteRequest
+	lists        map[string]int
+	processAfter int
+}
+
+func (c *testDeleteClient) CreateDeleteRequest(selectors []string, start, end time.Time, quiet bool) error {
+	c.requests = append(c.requests, deletion.DeleteRequest{
+		RequestID: fmt.Sprintf("request%d", len(c.requests)),
+		StartTime: model.TimeFromUnixNano(start.UnixNano()),
+		EndTime:   model.TimeFromUnixNano(end.UnixNano()),
+		Selectors: selectors,
+		Status:    deletion.StatusReceived,
+		CreatedAt: model.Now(),
+	})
+	return nil
+}
+
+func (c *testDeleteClient) ListDeleteRequests(quiet bool) ([]deletion.DeleteRequest, error) {
+	for i, r := range c.requests {
+		c.lists[r.RequestID]++
+		if c.lists[r.RequestID] > c.processAfter {
+			c.requests[i].Status = deletion.StatusProcessed
+		}
+	}
+	return append([]deletion.DeleteRequest(nil), c.requests...), nil
+}
+
+func (c *testDeleteClient) CancelDeleteRequest(requestID string, quiet bool) error {
+	return nil
+}
+
+func TestDeleteQuery_Create(t *testing.T) {
+	c := &testDeleteClient{lists: map[string]int{}, processAfter: 3}
+	// An existing request with the same selectors is not mistaken for the created one.
+	end := time.Now().Add(-time.Minute)
+	require.NoError(t, c.CreateDeleteRequest([]string{`{app="foo"}`}, time.Unix(0, 0), end, true))
+
+	q := &DeleteQuery{
+		Selectors:    []string{`{app="foo"}`},
+		Start:        time.Unix(0, 0),
+		End:          end,
+		Quiet:        true,
+		Wait:         true,
+		PollInterval: time.Millisecond,
+	}
+	request, err := q.Create(c)
+	require.NoError(t, err)
+	require.Equal(t, "request1", request.RequestID)
+	require.Equal(t, deletion.StatusProcessed, request.Status)
+
+	q.Wait = false
+	q.Selectors = []string{`{app="bar"}`}
+	request, err = q.Create(c)
+	require.NoError(t, err)
+	require.Equal(t, "request2", request.RequestID)
+	require.Equal(t, deletion.StatusReceived, request.Status)
+
+	// The request is not processed before the timeout.
+	c.processAfter = 1000
+	q.Wait = true
+	q.WaitTimeout = 10 * time.Millisecond
+	_, err = q.Create(c)
+	require.Error(t, err)
+}
+
+func TestDeleteQuery_Validate(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		query DeleteQuery
+		err   string
+	}{
+		{
+			name:  "no selectors",
+			query: DeleteQuery{End: time.Now()},
+			err:   "no selectors given",
+		},
+		{
+			name:  "line filter",
+			query: DeleteQuery{Selectors: []string{`{app="foo"} |= "bar"`}, End: time.Now()},
+			err:   "only label matchers is supported",
+		},
+		{
+			name:  "invalid selector",
+			query: DeleteQuery{Selectors: []string{`{app="foo"`}, End: time.Now()},
+			err:   "invalid selector",
+		},
+		{
+			name:  "start after end",
+			query: DeleteQuery{Selectors: []string{`{app="foo"}`}, Start: time.Now(), End: time.Now().Add(-time.Hour)},
+			err:   "the start time is after the end time",
+		},
+		{
+			name:  "end in the future",
+			query: DeleteQuery{Selectors: []string{`{app="foo"}`}, End: time.Now().Add(time.Hour)},
+			err:   "the end time is in the future",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.query.validate()
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.err)
+		})
+	}
+}
+
+func Test_printRequests(t *testing.T) {
+	var buf bytes.Buffer
+	printRequests(&buf, []deletion.DeleteRequest{
+		{
+			RequestID: "a",
+			StartTime: model.TimeFromUnix(0),
+			EndTime:   model.TimeFromUnix(3600),
+			Selectors: []string{`{app="foo"}`},
+			Status:    deletion.StatusProcessed,
+			CreatedAt: model.TimeFromUnix(7200),
+		},
+		{
+			RequestID: "b",
+			StartTime: model.TimeFromUnix(0),
+			EndTime:   model.TimeFromUnix(3600),
+			Selectors: []string{`{app="foo"}`, `{app="bar"}`},
+			Status:    deletion.StatusReceived,
+			CreatedAt: model.TimeFromUnix(10800),
+		},
+	})
+	require.Equal(t, strings.Join([]string{
+		`REQUEST ID  STATUS     CREATED AT            START                 END                   SELECTORS`,
+		`b           received   1970-01-01T03:00:00Z  1970-01-01T00:00:00Z  1970-01-01T01:00:00Z  {app="foo"} {app="bar"}`,
+		`a           processed  1970-01-01T02:00:00Z  1970-01-01T00:00:00Z  1970-01-01T01:00:00Z  {app="foo"}`,
+		``,
+	}, "\n"), buf.String())
+}
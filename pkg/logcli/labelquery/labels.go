@@ -11,14 +11,24 @@ import (
 
 // LabelQuery contains all necessary fields to execute label queries and print out the results
 type LabelQuery struct {
-	LabelName string
-	Quiet     bool
-	Start     time.Time
-	End       time.Time
+	LabelName   string
+	Quiet       bool
+	Start       time.Time
+	End         time.Time
+	LocalConfig string
 }
 
 // DoLabels prints out label results
 func (q *LabelQuery) DoLabels(c client.Client) {
+	if q.LocalConfig != "" {
+		storeClient, err := client.NewStoreClient(q.LocalConfig, c.GetOrgID())
+		if err != nil {
+			log.Fatalf("Unable to open the configured storage: %+v", err)
+		}
+		defer storeClient.Stop()
+		c = storeClient
+	}
+
 	values := q.ListLabels(c)
 
 	for _, value := range values {
@@ -0,0 +1,112 @@
+package output
+
+import (
+	"encoding/csv"
+	"io"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/grafana/loki/pkg/loghttp"
+)
+
+// CSVOutput prints logs and samples as CSV rows after a header row, suitable for spreadsheets
+type CSVOutput struct {
+	w       io.Writer
+	options *LogOutputOptions
+	// header is whether the header row was printed.
+	header bool
+}
+
+// Format a log entry as a CSV row of the timestamp, the labels and the line
+func (o *CSVOutput) FormatAndPrintln(ts time.Time, lbls loghttp.LabelSet, maxLabelsLen int, line string) {
+	columns := o.options.LabelColumns
+	labelsColumn := len(columns) == 0 && !o.options.NoLabels
+
+	if !o.header {
+		header := []string{"timestamp"}
+		if labelsColumn {
+			header = append(header, "labels")
+		}
+		o.write(append(append(header, o.labelColumns(columns)...), "line"))
+	}
+
+	row := []string{ts.In(o.options.Timezone).Format(time.RFC3339Nano)}
+	if labelsColumn {
+		row = append(row, lbls.String())
+	}
+	o.write(append(append(row, o.labelValues(lbls, columns)...), line))
+}
+
+// FormatAndPrintSamples implements SampleOutput, a sample being a CSV row of the timestamp, the labels and the value.
+// The label columns default to all the label names of the samples.
+func (o *CSVOutput) FormatAndPrintSamples(samples []Sample) {
+	columns := o.options.LabelColumns
+	if len(columns) == 0 {
+		columns = labelNames(samples)
+	}
+
+	if !o.header {
+		o.write(append(append([]string{"timestamp"}, o.labelColumns(columns)...), "value"))
+	}
+	for _, s := range samples {
+		row := []string{s.Timestamp.In(o.options.Timezone).Format(time.RFC3339Nano)}
+		o.write(append(append(row, o.labelValues(s.Labels, columns)...), strconv.FormatFloat(s.Value, 'f', -1, 64)))
+	}
+}
+
+func (o *CSVOutput) labelColumns(columns []string) []string {
+	if o.options.NoLabels {
+		return nil
+	}
+	return columns
+}
+
+func (o *CSVOutput) labelValues(lbls loghttp.LabelSet, columns []string) []string {
+	if o.options.NoLabels {
+		return nil
+	}
+	values := make([]string, 0, len(columns))
+	for _, name := range columns {
+		values = append(values, lbls[name])
+	}
+	return values
+}
+
+func (o *CSVOutput) write(row []string) {
+	// The rows are flushed one by one, the entries of a tail being printed as they come.
+	w := csv.NewWriter(o.w)
+	if err := w.Write(row); err != nil {
+		log.Fatalf("error writing csv row: %s", err)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		log.Fatalf("error writing csv row: %s", err)
+	}
+	o.header = true
+}
+
+// WithWriter implements LogOutput, the copy printing its own header row
+func (o *CSVOutput) WithWriter(w io.Writer) LogOutput {
+	return &CSVOutput{
+		w:       w,
+		options: o.options,
+	}
+}
+
+// labelNames returns the sorted label names of the samples.
+func labelNames(samples []Sample) []string {
+	seen := map[string]struct{}{}
+	var names []string
+	for _, s := range samples {
+		for name := range s.Labels {
+			if _, ok := seen[name]; !ok {
+				seen[name] = struct{}{}
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
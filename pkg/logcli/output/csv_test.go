@@ -0,0 +1,79 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/grafana/loki/pkg/loghttp"
+)
+
+func TestCSVOutput_Format(t *testing.T) {
+	t.Parallel()
+
+	timestamp, _ := time.Parse(time.RFC3339, "2006-01-02T15:04:05+07:00")
+	someLabels := loghttp.LabelSet(map[string]string{
+		"type": "test",
+		"app":  "foo",
+	})
+
+	tests := map[string]struct {
+		options  *LogOutputOptions
+		expected string
+	}{
+		"labels column": {
+			&LogOutputOptions{Timezone: time.UTC},
+			"timestamp,labels,line\n" +
+				`2006-01-02T08:04:05Z,"{app=""foo"", type=""test""}","Hello, ""world"""` + "\n" +
+				`2006-01-02T08:04:05Z,"{app=""foo"", type=""test""}","Hello, ""world"""` + "\n",
+		},
+		"label columns": {
+			&LogOutputOptions{Timezone: time.UTC, LabelColumns: []string{"type", "missing"}},
+			"timestamp,type,missing,line\n" +
+				`2006-01-02T08:04:05Z,test,,"Hello, ""world"""` + "\n" +
+				`2006-01-02T08:04:05Z,test,,"Hello, ""world"""` + "\n",
+		},
+		"labels output disabled": {
+			&LogOutputOptions{Timezone: time.UTC, NoLabels: true, LabelColumns: []string{"type"}},
+			"timestamp,line\n" +
+				`2006-01-02T08:04:05Z,"Hello, ""world"""` + "\n" +
+				`2006-01-02T08:04:05Z,"Hello, ""world"""` + "\n",
+		},
+	}
+
+	for testName, testData := range tests {
+		testData := testData
+
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+			writer := &bytes.Buffer{}
+			out := &CSVOutput{w: writer, options: testData.options}
+			out.FormatAndPrintln(timestamp, someLabels, 0, `Hello, "world"`)
+			out.FormatAndPrintln(timestamp, someLabels, 0, `Hello, "world"`)
+
+			assert.Equal(t, testData.expected, writer.String())
+		})
+	}
+}
+
+func TestCSVOutput_FormatSamples(t *testing.T) {
+	t.Parallel()
+
+	timestamp, _ := time.Parse(time.RFC3339, "2006-01-02T15:04:05Z")
+	samples := []Sample{
+		{Timestamp: timestamp, Labels: loghttp.LabelSet{"app": "foo"}, Value: 1.5},
+		{Timestamp: timestamp, Labels: loghttp.LabelSet{"app": "bar", "env": "dev"}, Value: 2},
+	}
+
+	writer := &bytes.Buffer{}
+	out := &CSVOutput{w: writer, options: &LogOutputOptions{Timezone: time.UTC}}
+	out.FormatAndPrintSamples(samples)
+	assert.Equal(t, "timestamp,app,env,value\n2006-01-02T15:04:05Z,foo,,1.5\n2006-01-02T15:04:05Z,bar,dev,2\n", writer.String())
+
+	// The copies print their own header.
+	writer = &bytes.Buffer{}
+	out.WithWriter(writer).(SampleOutput).FormatAndPrintSamples(samples[:1])
+	assert.Equal(t, "timestamp,app,value\n2006-01-02T15:04:05Z,foo,1.5\n", writer.String())
+}
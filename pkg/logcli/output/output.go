@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"hash/fnv"
 	"io"
+	"text/template"
 	"time"
 
 	"github.com/fatih/color"
@@ -29,6 +30,21 @@ var colorList = []*color.Color{
 // LogOutput is the interface any output mode must implement
 type LogOutput interface {
 	FormatAndPrintln(ts time.Time, lbls loghttp.LabelSet, maxLabelsLen int, line string)
+	// WithWriter returns a copy of the output printing to the writer.
+	WithWriter(w io.Writer) LogOutput
+}
+
+// Sample is a sample of a series of the result of a metric query
+type Sample struct {
+	Timestamp time.Time
+	Labels    loghttp.LabelSet
+	Value     float64
+}
+
+// SampleOutput is implemented by the output modes which also format the samples of metric queries, which are printed
+// as JSON otherwise
+type SampleOutput interface {
+	FormatAndPrintSamples(samples []Sample)
 }
 
 // LogOutputOptions defines options supported by LogOutput
@@ -36,6 +52,10 @@ type LogOutputOptions struct {
 	Timezone      *time.Location
 	NoLabels      bool
 	ColoredOutput bool
+	// LabelColumns are the labels printed in their own column by the csv output, instead of a single labels column.
+	LabelColumns []string
+	// Template is the Go template of the template output.
+	Template string
 }
 
 // NewLogOutput creates a log output based on the input mode and options
@@ -60,6 +80,26 @@ func NewLogOutput(w io.Writer, mode string, options *LogOutputOptions) (LogOutpu
 			w:       w,
 			options: options,
 		}, nil
+	case "csv":
+		return &CSVOutput{
+			w:       w,
+			options: options,
+		}, nil
+	case "table":
+		return &TableOutput{
+			w:       w,
+			options: options,
+		}, nil
+	case "template":
+		tmpl, err := template.New("output").Option("missingkey=zero").Parse(options.Template)
+		if err != nil {
+			return nil, fmt.Errorf("invalid output template: %w", err)
+		}
+		return &TemplateOutput{
+			w:        w,
+			options:  options,
+			template: tmpl,
+		}, nil
 	default:
 		return nil, fmt.Errorf("unknown log output mode '%s'", mode)
 	}
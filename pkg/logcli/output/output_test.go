@@ -8,7 +8,7 @@ import (
 )
 
 func TestNewLogOutput(t *testing.T) {
-	options := &LogOutputOptions{time.UTC, false, false}
+	options := &LogOutputOptions{Timezone: time.UTC}
 
 	out, err := NewLogOutput(nil, "default", options)
 	assert.NoError(t, err)
@@ -22,6 +22,24 @@ func TestNewLogOutput(t *testing.T) {
 	assert.NoError(t, err)
 	assert.IsType(t, &RawOutput{nil, options}, out)
 
+	out, err = NewLogOutput(nil, "csv", options)
+	assert.NoError(t, err)
+	assert.IsType(t, &CSVOutput{}, out)
+
+	out, err = NewLogOutput(nil, "table", options)
+	assert.NoError(t, err)
+	assert.IsType(t, &TableOutput{nil, options}, out)
+
+	options.Template = "{{.Line}}"
+	out, err = NewLogOutput(nil, "template", options)
+	assert.NoError(t, err)
+	assert.IsType(t, &TemplateOutput{}, out)
+
+	options.Template = "{{.Line"
+	out, err = NewLogOutput(nil, "template", options)
+	assert.Error(t, err)
+	assert.Nil(t, out)
+
 	out, err = NewLogOutput(nil, "unknown", options)
 	assert.Error(t, err)
 	assert.Nil(t, out)
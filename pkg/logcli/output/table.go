@@ -0,0 +1,61 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/grafana/loki/pkg/loghttp"
+)
+
+// TableOutput prints logs and samples in aligned columns, without colors
+type TableOutput struct {
+	w       io.Writer
+	options *LogOutputOptions
+}
+
+// Format a log entry as the timestamp, the labels padded to the longest labels and the line
+func (o *TableOutput) FormatAndPrintln(ts time.Time, lbls loghttp.LabelSet, maxLabelsLen int, line string) {
+	timestamp := ts.In(o.options.Timezone).Format(time.RFC3339)
+	line = strings.TrimSpace(line)
+
+	if o.options.NoLabels {
+		fmt.Fprintf(o.w, "%s  %s\n", timestamp, line)
+		return
+	}
+	fmt.Fprintf(o.w, "%s  %s  %s\n", timestamp, padLabel(lbls, maxLabelsLen), line)
+}
+
+// FormatAndPrintSamples implements SampleOutput, printing a table of the samples with a column per label name
+func (o *TableOutput) FormatAndPrintSamples(samples []Sample) {
+	var names []string
+	if !o.options.NoLabels {
+		names = labelNames(samples)
+	}
+
+	tw := tabwriter.NewWriter(o.w, 0, 0, 2, ' ', 0)
+	header := []string{"TIMESTAMP"}
+	for _, name := range names {
+		header = append(header, strings.ToUpper(name))
+	}
+	fmt.Fprintln(tw, strings.Join(append(header, "VALUE"), "\t"))
+	for _, s := range samples {
+		row := []string{s.Timestamp.In(o.options.Timezone).Format(time.RFC3339)}
+		for _, name := range names {
+			row = append(row, s.Labels[name])
+		}
+		fmt.Fprintln(tw, strings.Join(append(row, strconv.FormatFloat(s.Value, 'f', -1, 64)), "\t"))
+	}
+	tw.Flush()
+}
+
+// WithWriter implements LogOutput
+func (o *TableOutput) WithWriter(w io.Writer) LogOutput {
+	return &TableOutput{
+		w:       w,
+		options: o.options,
+	}
+}
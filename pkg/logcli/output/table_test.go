@@ -0,0 +1,46 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/grafana/loki/pkg/loghttp"
+)
+
+func TestTableOutput_Format(t *testing.T) {
+	t.Parallel()
+
+	timestamp, _ := time.Parse(time.RFC3339, "2006-01-02T15:04:05Z")
+	someLabels := loghttp.LabelSet(map[string]string{
+		"type": "test",
+	})
+
+	writer := &bytes.Buffer{}
+	out := &TableOutput{writer, &LogOutputOptions{Timezone: time.UTC}}
+	out.FormatAndPrintln(timestamp, someLabels, 20, "Hello ")
+	assert.Equal(t, "2006-01-02T15:04:05Z  {type=\"test\"}         Hello\n", writer.String())
+
+	writer = &bytes.Buffer{}
+	out = &TableOutput{writer, &LogOutputOptions{Timezone: time.UTC, NoLabels: true}}
+	out.FormatAndPrintln(timestamp, someLabels, 20, "Hello")
+	assert.Equal(t, "2006-01-02T15:04:05Z  Hello\n", writer.String())
+}
+
+func TestTableOutput_FormatSamples(t *testing.T) {
+	t.Parallel()
+
+	timestamp, _ := time.Parse(time.RFC3339, "2006-01-02T15:04:05Z")
+	writer := &bytes.Buffer{}
+	out := &TableOutput{writer, &LogOutputOptions{Timezone: time.UTC}}
+	out.FormatAndPrintSamples([]Sample{
+		{Timestamp: timestamp, Labels: loghttp.LabelSet{"app": "foo"}, Value: 1.5},
+		{Timestamp: timestamp.Add(time.Minute), Labels: loghttp.LabelSet{"app": "bar", "env": "production"}, Value: 200},
+	})
+	assert.Equal(t, `TIMESTAMP             APP  ENV         VALUE
+2006-01-02T15:04:05Z  foo              1.5
+2006-01-02T15:05:05Z  bar  production  200
+`, writer.String())
+}
@@ -0,0 +1,63 @@
+package output
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"text/template"
+	"time"
+
+	"github.com/grafana/loki/pkg/loghttp"
+)
+
+// TemplateData is the data of the template of the template output, for a log entry or a sample.
+type TemplateData struct {
+	Timestamp time.Time
+	Labels    loghttp.LabelSet
+	// Line is the line of a log entry.
+	Line string
+	// Value is the value of a sample.
+	Value float64
+}
+
+// TemplateOutput prints logs and samples with a Go template, eg '{{.Timestamp.Unix}} {{.Labels.app}} {{.Line}}'
+type TemplateOutput struct {
+	w        io.Writer
+	options  *LogOutputOptions
+	template *template.Template
+}
+
+// Format a log entry with the template, followed by a newline
+func (o *TemplateOutput) FormatAndPrintln(ts time.Time, lbls loghttp.LabelSet, maxLabelsLen int, line string) {
+	o.execute(TemplateData{Timestamp: ts, Labels: lbls, Line: line})
+}
+
+// FormatAndPrintSamples implements SampleOutput, formatting every sample with the template
+func (o *TemplateOutput) FormatAndPrintSamples(samples []Sample) {
+	for _, s := range samples {
+		o.execute(TemplateData{Timestamp: s.Timestamp, Labels: s.Labels, Value: s.Value})
+	}
+}
+
+func (o *TemplateOutput) execute(data TemplateData) {
+	data.Timestamp = data.Timestamp.In(o.options.Timezone)
+	if o.options.NoLabels {
+		data.Labels = loghttp.LabelSet{}
+	}
+
+	var buf bytes.Buffer
+	if err := o.template.Execute(&buf, data); err != nil {
+		log.Fatalf("error executing the output template: %s", err)
+	}
+	buf.WriteByte('\n')
+	_, _ = o.w.Write(buf.Bytes())
+}
+
+// WithWriter implements LogOutput
+func (o *TemplateOutput) WithWriter(w io.Writer) LogOutput {
+	return &TemplateOutput{
+		w:        w,
+		options:  o.options,
+		template: o.template,
+	}
+}
@@ -0,0 +1,33 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/grafana/loki/pkg/loghttp"
+)
+
+func TestTemplateOutput_Format(t *testing.T) {
+	t.Parallel()
+
+	timestamp, _ := time.Parse(time.RFC3339, "2006-01-02T15:04:05+07:00")
+	someLabels := loghttp.LabelSet(map[string]string{
+		"type": "test",
+	})
+	tmpl := template.Must(template.New("output").Option("missingkey=zero").Parse(`{{.Timestamp.Format "15:04"}} {{.Labels.type}}{{.Labels.missing}} {{.Line}}{{if .Value}}{{.Value}}{{end}}`))
+
+	writer := &bytes.Buffer{}
+	out := &TemplateOutput{writer, &LogOutputOptions{Timezone: time.UTC}, tmpl}
+	out.FormatAndPrintln(timestamp, someLabels, 0, "Hello")
+	out.FormatAndPrintSamples([]Sample{{Timestamp: timestamp, Labels: someLabels, Value: 1.5}})
+	assert.Equal(t, "08:04 test Hello\n08:04 test 1.5\n", writer.String())
+
+	writer = &bytes.Buffer{}
+	out = &TemplateOutput{writer, &LogOutputOptions{Timezone: time.UTC, NoLabels: true}, tmpl}
+	out.FormatAndPrintln(timestamp, someLabels, 0, "Hello")
+	assert.Equal(t, "08:04  Hello\n", writer.String())
+}
@@ -0,0 +1,237 @@
+package pushquery
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/grafana/loki/pkg/logcli/client"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql"
+)
+
+// stdinFile is the name of the file reading the standard input.
+const stdinFile = "-"
+
+// ignoredEntriesRegexp matches the number of entries rejected by the ingesters, reported at the end of the response.
+var ignoredEntriesRegexp = regexp.MustCompile(`total ignored: (\d+) out of \d+`)
+
+// PushQuery contains all necessary fields to push the lines of files, or of the standard input, to Loki
+type PushQuery struct {
+	// Labels are the labels of the stream of the lines, eg '{job="x"}'.
+	Labels string
+	// Files are read one after the other, the standard input being read when there is no file or for the file "-".
+	Files []string
+	// TimestampLayout is the layout of the timestamps at the start of the lines, parsed with time.Parse. The lines
+	// are timestamped with the time they are read when there is no layout or their timestamp can't be parsed.
+	TimestampLayout string
+	// The lines are pushed in batches of at most BatchSize bytes, once the batch is full or every BatchWait.
+	BatchSize int
+	BatchWait time.Duration
+	Quiet     bool
+}
+
+// Stats are the statistics of the lines pushed by the query.
+type Stats struct {
+	Entries int
+	Bytes   int
+	Batches int
+	// Rejected are the entries rejected by the server, all the entries of the batch when it does not tell how many.
+	Rejected int
+	// Unparsed are the entries whose timestamp could not be parsed.
+	Unparsed int
+}
+
+// DoPush pushes the lines and prints out the statistics, failing if entries were rejected
+func (q *PushQuery) DoPush(c client.PushClient) {
+	stats, err := q.Push(c, os.Stdin)
+	if err != nil {
+		log.Fatalf("Error pushing the logs: %+v", err)
+	}
+	if !q.Quiet {
+		log.Printf("Pushed %d entries (%s) in %d batches", stats.Entries, humanize.Bytes(uint64(stats.Bytes)), stats.Batches)
+		if stats.Unparsed > 0 {
+			log.Printf("%d entries were timestamped with the time they were read, their timestamp could not be parsed", stats.Unparsed)
+		}
+	}
+	if stats.Rejected > 0 {
+		log.Fatalf("%d of %d entries were rejected", stats.Rejected, stats.Entries)
+	}
+}
+
+// Push reads the lines of the files of the query, or of stdin, and pushes them in batches. The batches rejected by
+// the server are reported and counted in the statistics, other errors stop the push.
+func (q *PushQuery) Push(c client.PushClient, stdin io.Reader) (Stats, error) {
+	var stats Stats
+	lbs, err := logql.ParseLabels(q.Labels)
+	if err != nil {
+		return stats, fmt.Errorf("invalid labels %q: %w", q.Labels, err)
+	}
+	if len(lbs) == 0 {
+		return stats, errors.New("no labels given")
+	}
+	if q.BatchWait <= 0 {
+		return stats, fmt.Errorf("the batch wait must be positive, got %s", q.BatchWait)
+	}
+	stream := lbs.String()
+
+	entries := make(chan entry)
+	done := make(chan struct{})
+	defer close(done)
+	readErr := make(chan error, 1)
+	go func() {
+		defer close(entries)
+		readErr <- q.read(stdin, entries, done)
+	}()
+
+	ticker := time.NewTicker(q.BatchWait)
+	defer ticker.Stop()
+
+	var (
+		batch     []logproto.Entry
+		batchSize int
+	)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		req := &logproto.PushRequest{Streams: []logproto.Stream{{Labels: stream, Entries: batch}}}
+		err := c.Push(req, q.Quiet)
+		if errors.Is(err, client.ErrRejected) {
+			rejected := rejectedEntries(err, len(batch))
+			log.Printf("%d of %d entries were rejected: %s", rejected, len(batch), err)
+			stats.Rejected += rejected
+		} else if err != nil {
+			return err
+		}
+		stats.Batches++
+		batch, batchSize = nil, 0
+		return nil
+	}
+
+	for {
+		select {
+		case e, ok := <-entries:
+			if !ok {
+				if err := <-readErr; err != nil {
+					return stats, err
+				}
+				return stats, flush()
+			}
+			if batchSize+len(e.Line) > q.BatchSize {
+				if err := flush(); err != nil {
+					return stats, err
+				}
+			}
+			batch = append(batch, e.Entry)
+			batchSize += len(e.Line)
+			stats.Entries++
+			stats.Bytes += len(e.Line)
+			if e.unparsed {
+				stats.Unparsed++
+			}
+		case <-ticker.C:
+			if err := flush(); err != nil {
+				return stats, err
+			}
+		}
+	}
+}
+
+// entry is an entry read by the query, telling whether its timestamp was parsed.
+type entry struct {
+	logproto.Entry
+	unparsed bool
+}
+
+// read sends the entries of the lines of the files, or of stdin, until done is closed.
+func (q *PushQuery) read(stdin io.Reader, entries chan<- entry, done <-chan struct{}) error {
+	files := q.Files
+	if len(files) == 0 {
+		files = []string{stdinFile}
+	}
+	for _, file := range files {
+		if file == stdinFile {
+			if err := q.readLines(stdin, entries, done); err != nil {
+				return fmt.Errorf("reading the standard input: %w", err)
+			}
+			continue
+		}
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		err = q.readLines(f, entries, done)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", file, err)
+		}
+	}
+	return nil
+}
+
+func (q *PushQuery) readLines(r io.Reader, entries chan<- entry, done <-chan struct{}) error {
+	// The timestamp is made of as many space separated fields as the layout, eg 2 for "2006-01-02 15:04:05".
+	timestampFields := len(strings.Fields(q.TimestampLayout))
+
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if len(line) > 0 {
+			line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+			ts, ok := parseTimestamp(line, q.TimestampLayout, timestampFields)
+			if !ok {
+				ts = time.Now()
+			}
+			select {
+			case entries <- entry{Entry: logproto.Entry{Timestamp: ts, Line: line}, unparsed: !ok && q.TimestampLayout != ""}:
+			case <-done:
+				return nil
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+// parseTimestamp parses the timestamp at the start of the line, made of the given number of fields.
+func parseTimestamp(line, layout string, fields int) (time.Time, bool) {
+	if layout == "" {
+		return time.Time{}, false
+	}
+	parts := strings.SplitN(line, " ", fields+1)
+	if len(parts) < fields {
+		return time.Time{}, false
+	}
+	ts, err := time.Parse(layout, strings.Join(parts[:fields], " "))
+	if err != nil {
+		return time.Time{}, false
+	}
+	return ts, true
+}
+
+// rejectedEntries returns the number of entries rejected by the server, as reported in the error if it tells.
+func rejectedEntries(err error, entries int) int {
+	m := ignoredEntriesRegexp.FindStringSubmatch(err.Error())
+	if m == nil {
+		return entries
+	}
+	n, convErr := strconv.Atoi(m[1])
+	if convErr != nil {
+		return entries
+	}
+	return n
+}
@@ -0,0 +1,94 @@
+package pushquery
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/logcli/client"
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+// testPushClient stores the pushed requests, rejecting the entries whose line contains "reject".
+type testPushClient struct {
+	requests []*logproto.PushRequest
+}
+
+func (c *testPushClient) Push(req *logproto.PushRequest, quiet bool) error {
+	c.requests = append(c.requests, req)
+	rejected := 0
+	for _, e := range req.Streams[0].Entries {
+		if strings.Contains(e.Line, "reject") {
+			rejected++
+		}
+	}
+	if rejected > 0 {
+		return fmt.Errorf("%w: total ignored: %d out of %d", client.ErrRejected, rejected, len(req.Streams[0].Entries))
+	}
+	return nil
+}
+
+func TestPushQuery_Push(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.log")
+	require.NoError(t, os.WriteFile(file, []byte("2022-03-08 10:00:00 first\r\n2022-03-08 10:00:01 second\nno timestamp\n"), 0o644))
+
+	c := &testPushClient{}
+	q := &PushQuery{
+		Labels:          `{job="app", env="dev"}`,
+		Files:           []string{file, "-"},
+		TimestampLayout: "2006-01-02 15:04:05",
+		BatchSize:       60,
+		BatchWait:       time.Hour,
+		Quiet:           true,
+	}
+	stats, err := q.Push(c, strings.NewReader("2022-03-08 10:00:02 reject\n2022-03-08 10:00:03 last"))
+	require.NoError(t, err)
+	require.Equal(t, Stats{Entries: 5, Bytes: 113, Batches: 3, Rejected: 1, Unparsed: 1}, stats)
+
+	require.Len(t, c.requests, 3)
+	for _, req := range c.requests {
+		require.Len(t, req.Streams, 1)
+		require.Equal(t, `{env="dev", job="app"}`, req.Streams[0].Labels)
+	}
+	entries := c.requests[0].Streams[0].Entries
+	require.Len(t, entries, 2)
+	require.Equal(t, logproto.Entry{Timestamp: time.Date(2022, 3, 8, 10, 0, 0, 0, time.UTC), Line: "2022-03-08 10:00:00 first"}, entries[0])
+	require.Equal(t, "2022-03-08 10:00:01 second", entries[1].Line)
+	entries = c.requests[1].Streams[0].Entries
+	require.Equal(t, "no timestamp", entries[0].Line)
+	require.WithinDuration(t, time.Now(), entries[0].Timestamp, time.Minute)
+	require.Equal(t, "2022-03-08 10:00:03 last", c.requests[2].Streams[0].Entries[0].Line)
+}
+
+func TestPushQuery_PushErrors(t *testing.T) {
+	q := &PushQuery{Labels: `{}`, BatchSize: 1, BatchWait: time.Hour}
+	_, err := q.Push(&testPushClient{}, strings.NewReader("line"))
+	require.EqualError(t, err, "no labels given")
+
+	q.Labels = `{job="app"}`
+	q.BatchWait = 0
+	_, err = q.Push(&testPushClient{}, strings.NewReader("line"))
+	require.EqualError(t, err, "the batch wait must be positive, got 0s")
+
+	q.BatchWait = time.Hour
+	q.Files = []string{filepath.Join(t.TempDir(), "missing.log")}
+	_, err = q.Push(&testPushClient{}, strings.NewReader("line"))
+	require.Error(t, err)
+	require.True(t, errors.Is(err, os.ErrNotExist))
+}
+
+func Test_rejectedEntries(t *testing.T) {
+	err := fmt.Errorf("%w: entry with timestamp 2022-03-08 10:00:00 +0000 UTC ignored, reason: 'entry too far behind' for stream: {job=\"app\"},\ntotal ignored: 3 out of 10", client.ErrRejected)
+	require.Equal(t, 3, rejectedEntries(err, 10))
+
+	// The rate limited requests are entirely rejected.
+	err = fmt.Errorf("%w: Ingestion rate limit exceeded", client.ErrRejected)
+	require.Equal(t, 10, rejectedEntries(err, 10))
+}
@@ -0,0 +1,88 @@
+package query
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/grafana/loki/pkg/logcli/client"
+	"github.com/grafana/loki/pkg/loghttp"
+)
+
+// DoExplain prints out how the query would be executed, without executing it.
+func (q *Query) DoExplain(c client.PlanClient, w io.Writer) {
+	if err := q.explain(c, w); err != nil {
+		log.Fatalf("Query explanation failed: %+v", err)
+	}
+}
+
+func (q *Query) explain(c client.PlanClient, w io.Writer) error {
+	resp, err := c.QueryPlan(q.QueryString, q.Start, q.End, q.Step, q.Quiet)
+	if err != nil {
+		return err
+	}
+	plan := resp.Data
+
+	fmt.Fprintf(w, "Query:            %s\n", plan.Query)
+	fmt.Fprintf(w, "Range:            %s - %s\n", plan.Start.Format(time.RFC3339Nano), plan.End.Format(time.RFC3339Nano))
+	fmt.Fprintf(w, "Results cache:    %t\n", plan.ResultsCache)
+	fmt.Fprintf(w, "Estimated chunks: %d\n", plan.Chunks)
+	fmt.Fprintf(w, "Streams:          %d\n", plan.Streams)
+	if plan.SplitInterval != "" {
+		fmt.Fprintf(w, "Split interval:   %s\n", plan.SplitInterval)
+	}
+
+	if plan.AST != nil {
+		fmt.Fprintln(w, "\nAST:")
+		printPlanNode(w, plan.AST, 1)
+	}
+
+	if len(plan.Splits) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(w, "\nSplits:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "START\tEND\tSHARDS\tCHUNKS\tSTREAMS\tNOTE")
+	var shardedQueries []string
+	seen := map[string]struct{}{}
+	for _, s := range plan.Splits {
+		note := s.NotShardedReason
+		if note == "" {
+			note = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%s\n", s.Start.Format(time.RFC3339), s.End.Format(time.RFC3339), s.Shards, s.Chunks, s.Streams, note)
+		if s.ShardedQuery == "" {
+			continue
+		}
+		if _, ok := seen[s.ShardedQuery]; !ok {
+			seen[s.ShardedQuery] = struct{}{}
+			shardedQueries = append(shardedQueries, s.ShardedQuery)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(shardedQueries) > 0 {
+		fmt.Fprintln(w, "\nSharded queries:")
+		for _, query := range shardedQueries {
+			fmt.Fprintf(w, "  %s\n", query)
+		}
+	}
+	return nil
+}
+
+func printPlanNode(w io.Writer, node *loghttp.PlanNode, depth int) {
+	shardable := ""
+	if !node.Shardable {
+		shardable = " (not shardable)"
+	}
+	fmt.Fprintf(w, "%s%s: %s%s\n", strings.Repeat("  ", depth), node.Type, node.Expr, shardable)
+	for _, child := range node.Children {
+		printPlanNode(w, child, depth+1)
+	}
+}
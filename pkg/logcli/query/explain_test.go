@@ -0,0 +1,65 @@
+package query
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/loghttp"
+)
+
+type planQueryClient struct {
+	plan loghttp.QueryPlan
+}
+
+func (c *planQueryClient) QueryPlan(queryStr string, start, end time.Time, step time.Duration, quiet bool) (*loghttp.QueryPlanResponse, error) {
+	return &loghttp.QueryPlanResponse{Status: "success", Data: c.plan}, nil
+}
+
+func Test_explain(t *testing.T) {
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	sharded := `downstream<rate({app="foo"}[1m]), shard=0_of_2> ++ downstream<rate({app="foo"}[1m]), shard=1_of_2>`
+	c := &planQueryClient{plan: loghttp.QueryPlan{
+		Query: `rate({app="foo"}[1m])`,
+		Start: start,
+		End:   start.Add(2 * time.Hour),
+		AST: &loghttp.PlanNode{Type: "range_aggregation", Expr: `rate({app="foo"}[1m])`, Shardable: true, Children: []*loghttp.PlanNode{
+			{Type: "log_range", Expr: `{app="foo"}[1m]`, Shardable: true, Children: []*loghttp.PlanNode{
+				{Type: "selector", Expr: `{app="foo"}`, Shardable: true},
+			}},
+		}},
+		SplitInterval: "1h0m0s",
+		ResultsCache:  true,
+		Splits: []loghttp.QueryPlanSplit{
+			{Start: start, End: start.Add(time.Hour), Shards: 2, ShardedQuery: sharded, Chunks: 3, Streams: 1},
+			{Start: start.Add(time.Hour), End: start.Add(2 * time.Hour), NotShardedReason: "sharded queries are disabled", Chunks: 2, Streams: 1},
+		},
+		Chunks:  5,
+		Streams: 1,
+	}}
+
+	var buf bytes.Buffer
+	q := &Query{QueryString: c.plan.Query, Start: start, End: start.Add(2 * time.Hour)}
+	require.NoError(t, q.explain(c, &buf))
+	require.Equal(t, `Query:            rate({app="foo"}[1m])
+Range:            2021-01-01T00:00:00Z - 2021-01-01T02:00:00Z
+Results cache:    true
+Estimated chunks: 5
+Streams:          1
+Split interval:   1h0m0s
+
+AST:
+  range_aggregation: rate({app="foo"}[1m])
+    log_range: {app="foo"}[1m]
+      selector: {app="foo"}
+
+Splits:
+START                 END                   SHARDS  CHUNKS  STREAMS  NOTE
+2021-01-01T00:00:00Z  2021-01-01T01:00:00Z  2       3       1        -
+2021-01-01T01:00:00Z  2021-01-01T02:00:00Z  0       2       1        sharded queries are disabled
+
+Sharded queries:
+  `+sharded+"\n", buf.String())
+}
@@ -0,0 +1,238 @@
+package query
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/grafana/loki/pkg/logcli/client"
+	"github.com/grafana/loki/pkg/logcli/output"
+)
+
+// partTimeFormat is the format of the times in the names of the part files.
+const partTimeFormat = "20060102T150405.000000000Z"
+
+// ParallelOptions configures the parallel mode of range queries, in which the range is split into intervals
+// queried concurrently, each into its own part file.
+type ParallelOptions struct {
+	// Duration is the duration of the intervals.
+	Duration time.Duration
+	// Workers is the number of intervals queried concurrently.
+	Workers int
+	// PartPathPrefix is the prefix of the paths of the part files. It defaults to a prefix in the temporary
+	// directory derived from the query, so that running the same query again resumes the export.
+	PartPathPrefix string
+	// OverwriteCompleted queries the intervals whose part file is complete again, instead of keeping them.
+	OverwriteCompleted bool
+	// MergeParts writes the parts in order to the output once they are all complete, then removes them.
+	MergeParts bool
+	// KeepParts keeps the parts after they are merged.
+	KeepParts bool
+}
+
+// part is an interval of a range query, exported to a part file.
+type part struct {
+	start, end time.Time
+	path       string
+}
+
+// DoQueryParallel executes the range query in parallel, as configured by q.Parallel, and writes the merged parts to w
+// when they are merged. The part files completed by a previous run are kept, unless OverwriteCompleted is set: part
+// files are written under a temporary name, renamed once the interval is complete.
+func (q *Query) DoQueryParallel(c client.Client, out output.LogOutput, w io.Writer, statistics bool) {
+	if err := q.doQueryParallel(c, out, w, statistics); err != nil {
+		log.Fatalf("Query failed: %+v", err)
+	}
+}
+
+func (q *Query) doQueryParallel(c client.Client, out output.LogOutput, w io.Writer, statistics bool) error {
+	if q.isInstant() {
+		return errors.New("the parallel mode requires a range query")
+	}
+	if q.LocalConfig != "" {
+		return errors.New("the parallel mode is not supported when querying a configured storage")
+	}
+	if q.Parallel.Duration <= 0 {
+		return errors.New("the parallel duration must be positive")
+	}
+	if q.Parallel.Workers < 1 {
+		return errors.New("the number of parallel workers must be at least 1")
+	}
+
+	prefix := q.partPathPrefix(c, out)
+	parts := q.parts(prefix)
+	if dir := filepath.Dir(prefix); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+
+	todo := make(chan part)
+	var (
+		wg     sync.WaitGroup
+		mtx    sync.Mutex
+		failed int
+	)
+	for i := 0; i < q.Parallel.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range todo {
+				if err := q.queryPart(c, out, statistics, p); err != nil {
+					log.Printf("Failed to query the interval from %s to %s: %v", p.start.Format(time.RFC3339Nano), p.end.Format(time.RFC3339Nano), err)
+					mtx.Lock()
+					failed++
+					mtx.Unlock()
+					continue
+				}
+				if !q.Quiet {
+					log.Println("Completed part", p.path)
+				}
+			}
+		}()
+	}
+	for _, p := range parts {
+		if !q.Parallel.OverwriteCompleted {
+			if _, err := os.Stat(p.path); err == nil {
+				if !q.Quiet {
+					log.Println("Keeping completed part", p.path)
+				}
+				continue
+			}
+		}
+		todo <- p
+	}
+	close(todo)
+	wg.Wait()
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d parts failed, run the same command again to resume", failed, len(parts))
+	}
+	if !q.Parallel.MergeParts {
+		if !q.Quiet {
+			log.Printf("Exported %d parts to %s_*.part", len(parts), prefix)
+		}
+		return nil
+	}
+	// The parts of the csv output all start with the header row, which is only kept once.
+	_, csv := out.(*output.CSVOutput)
+	return q.mergeParts(parts, w, csv)
+}
+
+// parts splits the range of the query into intervals of the parallel duration, exported to part files of the prefix.
+func (q *Query) parts(prefix string) []part {
+	var parts []part
+	for start := q.Start; start.Before(q.End); start = start.Add(q.Parallel.Duration) {
+		end := start.Add(q.Parallel.Duration)
+		if end.After(q.End) {
+			end = q.End
+		}
+		parts = append(parts, part{
+			start: start,
+			end:   end,
+			path:  fmt.Sprintf("%s_%s_%s.part", prefix, start.UTC().Format(partTimeFormat), end.UTC().Format(partTimeFormat)),
+		})
+	}
+	return parts
+}
+
+// partPathPrefix returns the prefix of the part files. The default prefix is derived from everything changing the
+// content of the parts, so that only the same export is resumed: the query, its range, direction and limit, the
+// tenant and the output mode.
+func (q *Query) partPathPrefix(c client.Client, out output.LogOutput) string {
+	if q.Parallel.PartPathPrefix != "" {
+		return q.Parallel.PartPathPrefix
+	}
+	h := fnv.New64a()
+	_, _ = fmt.Fprintf(h, "%s\xff%d\xff%d\xff%v\xff%d\xff%s\xff%T",
+		q.QueryString, q.Start.UnixNano(), q.End.UnixNano(), q.Forward, q.Limit, c.GetOrgID(), out)
+	return filepath.Join(os.TempDir(), fmt.Sprintf("logcli_%x", h.Sum64()))
+}
+
+// queryPart queries the interval of the part into its part file.
+func (q *Query) queryPart(c client.Client, out output.LogOutput, statistics bool, p part) error {
+	tmp := p.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	pq := *q
+	pq.Start, pq.End = p.start, p.end
+	// The limit applies to every part, 0 meaning no limit.
+	if pq.Limit == 0 {
+		pq.Limit = math.MaxInt
+	}
+	buf := bufio.NewWriter(f)
+	err = pq.doQueryRange(c, out.WithWriter(buf), statistics)
+	if err == nil {
+		err = buf.Flush()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, p.path)
+}
+
+// mergeParts writes the parts to w in the direction of the query, then removes them unless they are kept. The first
+// line of the parts is only written for the first part which is not empty if skipHeaders is set.
+func (q *Query) mergeParts(parts []part, w io.Writer, skipHeaders bool) error {
+	ordered := make([]part, 0, len(parts))
+	for i := range parts {
+		if q.Forward {
+			ordered = append(ordered, parts[i])
+		} else {
+			ordered = append(ordered, parts[len(parts)-1-i])
+		}
+	}
+
+	header := false
+	for _, p := range ordered {
+		written, err := copyFile(w, p.path, skipHeaders && header)
+		if err != nil {
+			return err
+		}
+		header = header || written > 0
+	}
+	if q.Parallel.KeepParts {
+		return nil
+	}
+	for _, p := range parts {
+		if err := os.Remove(p.path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyFile copies the file to w, without its first line if skipHeader is set, and returns the number of bytes
+// written.
+func copyFile(w io.Writer, path string, skipHeader bool) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	if skipHeader {
+		if _, err := r.ReadString('\n'); err != nil && err != io.EOF {
+			return 0, err
+		}
+	}
+	return io.Copy(w, r)
+}
@@ -0,0 +1,171 @@
+package query
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/logcli/output"
+	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+// lockedQueryClient serializes the queries of the parallel workers, the test client not being safe for concurrent use.
+type lockedQueryClient struct {
+	*testQueryClient
+	mtx sync.Mutex
+}
+
+func (c *lockedQueryClient) QueryRange(queryStr string, limit int, from, through time.Time, direction logproto.Direction, step, interval time.Duration, quiet bool) (*loghttp.QueryResponse, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.testQueryClient.QueryRange(queryStr, limit, from, through, direction, step, interval, quiet)
+}
+
+func Test_parallel(t *testing.T) {
+	stream := logproto.Stream{Labels: `{test="parallel"}`}
+	var expected []string
+	for i := 0; i < 100; i++ {
+		line := fmt.Sprintf("line%d", i)
+		stream.Entries = append(stream.Entries, logproto.Entry{Timestamp: time.Unix(int64(i), 0), Line: line})
+		expected = append(expected, line)
+	}
+
+	for _, forward := range []bool{true, false} {
+		t.Run(fmt.Sprintf("forward=%v", forward), func(t *testing.T) {
+			prefix := filepath.Join(t.TempDir(), "export")
+			q := Query{
+				QueryString: `{test="parallel"}`,
+				Start:       time.Unix(0, 0),
+				End:         time.Unix(100, 0),
+				BatchSize:   7,
+				Forward:     forward,
+				Quiet:       true,
+				Parallel: ParallelOptions{
+					Duration:       30 * time.Second,
+					Workers:        3,
+					PartPathPrefix: prefix,
+					MergeParts:     true,
+					KeepParts:      true,
+				},
+			}
+			tc := &lockedQueryClient{testQueryClient: newTestQueryClient(stream)}
+			writer := &bytes.Buffer{}
+			require.NoError(t, q.doQueryParallel(tc, output.NewRaw(nil, nil), writer, false))
+
+			want := append([]string(nil), expected...)
+			if !forward {
+				for i, j := 0, len(want)-1; i < j; i, j = i+1, j-1 {
+					want[i], want[j] = want[j], want[i]
+				}
+			}
+			require.Equal(t, strings.Join(want, "\n")+"\n", writer.String())
+
+			parts, err := filepath.Glob(prefix + "_*.part")
+			require.NoError(t, err)
+			require.Len(t, parts, 4)
+
+			// The completed parts are kept when the export is resumed.
+			last := q.parts(prefix)[3].path
+			require.NoError(t, os.WriteFile(last, []byte("kept\n"), 0o644))
+			require.NoError(t, os.Remove(q.parts(prefix)[0].path))
+			tc.queryRangeCalls = 0
+			q.Parallel.KeepParts = false
+			writer.Reset()
+			require.NoError(t, q.doQueryParallel(tc, output.NewRaw(nil, nil), writer, false))
+			require.Greater(t, tc.queryRangeCalls, 0)
+			require.Contains(t, writer.String(), "kept\n")
+			require.NotContains(t, writer.String(), "line95\n")
+			require.Contains(t, writer.String(), "line0\n")
+
+			// The parts are removed once merged.
+			parts, err = filepath.Glob(prefix + "_*")
+			require.NoError(t, err)
+			require.Empty(t, parts)
+		})
+	}
+}
+
+func Test_parallelCSV(t *testing.T) {
+	stream := logproto.Stream{Labels: `{test="parallel"}`}
+	// The first part is empty.
+	for i := 40; i < 100; i++ {
+		stream.Entries = append(stream.Entries, logproto.Entry{Timestamp: time.Unix(int64(i), 0), Line: fmt.Sprintf("line%d", i)})
+	}
+
+	q := Query{
+		QueryString:   `{test="parallel"}`,
+		Start:         time.Unix(0, 0),
+		End:           time.Unix(100, 0),
+		BatchSize:     7,
+		Forward:       true,
+		Quiet:         true,
+		ShowLabelsKey: []string{"test"},
+		Parallel: ParallelOptions{
+			Duration:       30 * time.Second,
+			Workers:        3,
+			PartPathPrefix: filepath.Join(t.TempDir(), "export"),
+			MergeParts:     true,
+		},
+	}
+	out, err := output.NewLogOutput(nil, "csv", &output.LogOutputOptions{Timezone: time.UTC, LabelColumns: q.ShowLabelsKey})
+	require.NoError(t, err)
+	writer := &bytes.Buffer{}
+	require.NoError(t, q.doQueryParallel(&lockedQueryClient{testQueryClient: newTestQueryClient(stream)}, out, writer, false))
+
+	lines := strings.Split(strings.TrimSuffix(writer.String(), "\n"), "\n")
+	require.Len(t, lines, 61)
+	require.Equal(t, "timestamp,test,line", lines[0])
+	require.Equal(t, "1970-01-01T00:00:40Z,parallel,line40", lines[1])
+	require.Equal(t, "1970-01-01T00:01:39Z,parallel,line99", lines[60])
+}
+
+// orgQueryClient is a test client of a tenant.
+type orgQueryClient struct {
+	*testQueryClient
+	orgID string
+}
+
+func (c *orgQueryClient) GetOrgID() string {
+	return c.orgID
+}
+
+func Test_partPathPrefix(t *testing.T) {
+	newQuery := func() *Query {
+		return &Query{
+			QueryString: `{test="parallel"}`,
+			Start:       time.Unix(0, 0),
+			End:         time.Unix(100, 0),
+			Limit:       30,
+		}
+	}
+	c := &orgQueryClient{orgID: "tenant"}
+	raw, jsonl := output.NewRaw(nil, nil), &output.JSONLOutput{}
+	prefix := newQuery().partPathPrefix(c, raw)
+	require.Equal(t, prefix, newQuery().partPathPrefix(c, raw))
+
+	// The exports which would write different parts have their own prefix.
+	for name, change := range map[string]func(q *Query){
+		"query": func(q *Query) { q.QueryString = `{test="other"}` },
+		"start": func(q *Query) { q.Start = q.Start.Add(time.Second) },
+		"end":   func(q *Query) { q.End = q.End.Add(time.Second) },
+		"limit": func(q *Query) { q.Limit = 0 },
+	} {
+		q := newQuery()
+		change(q)
+		require.NotEqual(t, prefix, q.partPathPrefix(c, raw), name)
+	}
+	require.NotEqual(t, prefix, newQuery().partPathPrefix(&orgQueryClient{orgID: "other"}, raw))
+	require.NotEqual(t, prefix, newQuery().partPathPrefix(c, jsonl))
+
+	q := newQuery()
+	q.Parallel.PartPathPrefix = "export"
+	require.Equal(t, "export", q.partPathPrefix(c, raw))
+}
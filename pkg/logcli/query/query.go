@@ -1,10 +1,8 @@
 package query
 
 import (
-	"context"
-	"errors"
-	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"sort"
@@ -12,26 +10,17 @@ import (
 	"text/tabwriter"
 	"time"
 
+	"github.com/dustin/go-humanize"
 	"github.com/fatih/color"
 	json "github.com/json-iterator/go"
-	"github.com/prometheus/client_golang/prometheus"
-	"github.com/weaveworks/common/user"
+	"github.com/prometheus/common/model"
 
 	"github.com/grafana/loki/pkg/logcli/client"
 	"github.com/grafana/loki/pkg/logcli/output"
 	"github.com/grafana/loki/pkg/loghttp"
 	"github.com/grafana/loki/pkg/logproto"
-	"github.com/grafana/loki/pkg/logql"
 	"github.com/grafana/loki/pkg/logqlmodel"
 	"github.com/grafana/loki/pkg/logqlmodel/stats"
-	"github.com/grafana/loki/pkg/loki"
-	"github.com/grafana/loki/pkg/storage"
-	chunk_storage "github.com/grafana/loki/pkg/storage/chunk/storage"
-	"github.com/grafana/loki/pkg/storage/stores/shipper"
-	"github.com/grafana/loki/pkg/util/cfg"
-	util_log "github.com/grafana/loki/pkg/util/log"
-	"github.com/grafana/loki/pkg/util/marshal"
-	"github.com/grafana/loki/pkg/validation"
 )
 
 type streamEntryPair struct {
@@ -56,6 +45,10 @@ type Query struct {
 	FixedLabelsLen  int
 	ColoredOutput   bool
 	LocalConfig     string
+	Parallel        ParallelOptions
+	// TailReconnectAttempts is the number of attempts to reconnect a tail in a row, 0 meaning the tail is not
+	// reconnected.
+	TailReconnectAttempts int
 }
 
 // DoQuery executes the query and prints out the results
@@ -82,74 +75,82 @@ func (q *Query) DoQuery(c client.Client, out output.LogOutput, statistics bool)
 		}
 		_, _ = q.printResult(resp.Data.Result, out, nil)
 	} else {
-		if q.Limit < q.BatchSize {
-			q.BatchSize = q.Limit
+		if err := q.doQueryRange(c, out, statistics); err != nil {
+			log.Fatalf("Query failed: %+v", err)
 		}
-		resultLength := 0
-		total := 0
-		start := q.Start
-		end := q.End
-		var lastEntry []*loghttp.Entry
-		for total < q.Limit {
-			bs := q.BatchSize
-			// We want to truncate the batch size if the remaining number
-			// of items needed to reach the limit is less than the batch size
-			if q.Limit-total < q.BatchSize {
-				// Truncated batchsize is q.Limit - total, however we add to this
-				// the length of the overlap from the last query to make sure we get the
-				// correct amount of new logs knowing there will be some overlapping logs returned.
-				bs = q.Limit - total + len(lastEntry)
-			}
-			resp, err = c.QueryRange(q.QueryString, bs, start, end, d, q.Step, q.Interval, q.Quiet)
-			if err != nil {
-				log.Fatalf("Query failed: %+v", err)
-			}
+	}
+}
 
-			if statistics {
-				q.printStats(resp.Data.Statistics)
-			}
+// doQueryRange executes the range query in batches until the limit is reached, and prints out the results.
+func (q *Query) doQueryRange(c client.Client, out output.LogOutput, statistics bool) error {
+	d := q.resultsDirection()
+	if q.Limit < q.BatchSize {
+		q.BatchSize = q.Limit
+	}
+	resultLength := 0
+	total := 0
+	start := q.Start
+	end := q.End
+	var lastEntry []*loghttp.Entry
+	for total < q.Limit {
+		bs := q.BatchSize
+		// We want to truncate the batch size if the remaining number
+		// of items needed to reach the limit is less than the batch size
+		if q.Limit-total < q.BatchSize {
+			// Truncated batchsize is q.Limit - total, however we add to this
+			// the length of the overlap from the last query to make sure we get the
+			// correct amount of new logs knowing there will be some overlapping logs returned.
+			bs = q.Limit - total + len(lastEntry)
+		}
+		resp, err := c.QueryRange(q.QueryString, bs, start, end, d, q.Step, q.Interval, q.Quiet)
+		if err != nil {
+			return err
+		}
 
-			resultLength, lastEntry = q.printResult(resp.Data.Result, out, lastEntry)
-			// Was not a log stream query, or no results, no more batching
-			if resultLength <= 0 {
-				break
-			}
-			// Also no result, wouldn't expect to hit this.
-			if len(lastEntry) == 0 {
-				break
-			}
-			// Can only happen if all the results return in one request
-			if resultLength == q.Limit {
-				break
-			}
-			if len(lastEntry) >= q.BatchSize {
-				log.Fatalf("Invalid batch size %v, the next query will have %v overlapping entries "+
-					"(there will always be 1 overlapping entry but Loki allows multiple entries to have "+
-					"the same timestamp, so when a batch ends in this scenario the next query will include "+
-					"all the overlapping entries again).  Please increase your batch size to at least %v to account "+
-					"for overlapping entryes\n", q.BatchSize, len(lastEntry), len(lastEntry)+1)
-			}
+		if statistics {
+			q.printStats(resp.Data.Statistics)
+		}
 
-			// Batching works by taking the timestamp of the last query and using it in the next query,
-			// because Loki supports multiple entries with the same timestamp it's possible for a batch to have
-			// fallen in the middle of a list of entries for the same time, so to make sure we get all entries
-			// we start the query on the same time as the last entry from the last batch, and then we keep this last
-			// entry and remove the duplicate when printing the results.
-			// Because of this duplicate entry, we have to subtract it here from the total for each batch
-			// to get the desired limit.
-			total += resultLength
-			// Based on the query direction we either set the start or end for the next query.
-			// If there are multiple entries in `lastEntry` they have to have the same timestamp so we can pick just the first
-			if q.Forward {
-				start = lastEntry[0].Timestamp
-			} else {
-				// The end timestamp is exclusive on a backward query, so to make sure we get back an overlapping result
-				// fudge the timestamp forward in time to make sure to get the last entry from this batch in the next query
-				end = lastEntry[0].Timestamp.Add(1 * time.Nanosecond)
-			}
+		resultLength, lastEntry = q.printResult(resp.Data.Result, out, lastEntry)
+		// Was not a log stream query, or no results, no more batching
+		if resultLength <= 0 {
+			break
+		}
+		// Also no result, wouldn't expect to hit this.
+		if len(lastEntry) == 0 {
+			break
+		}
+		// Can only happen if all the results return in one request
+		if resultLength == q.Limit {
+			break
+		}
+		if len(lastEntry) >= q.BatchSize {
+			return fmt.Errorf("invalid batch size %v, the next query will have %v overlapping entries "+
+				"(there will always be 1 overlapping entry but Loki allows multiple entries to have "+
+				"the same timestamp, so when a batch ends in this scenario the next query will include "+
+				"all the overlapping entries again).  Please increase your batch size to at least %v to account "+
+				"for overlapping entryes", q.BatchSize, len(lastEntry), len(lastEntry)+1)
+		}
 
+		// Batching works by taking the timestamp of the last query and using it in the next query,
+		// because Loki supports multiple entries with the same timestamp it's possible for a batch to have
+		// fallen in the middle of a list of entries for the same time, so to make sure we get all entries
+		// we start the query on the same time as the last entry from the last batch, and then we keep this last
+		// entry and remove the duplicate when printing the results.
+		// Because of this duplicate entry, we have to subtract it here from the total for each batch
+		// to get the desired limit.
+		total += resultLength
+		// Based on the query direction we either set the start or end for the next query.
+		// If there are multiple entries in `lastEntry` they have to have the same timestamp so we can pick just the first
+		if q.Forward {
+			start = lastEntry[0].Timestamp
+		} else {
+			// The end timestamp is exclusive on a backward query, so to make sure we get back an overlapping result
+			// fudge the timestamp forward in time to make sure to get the last entry from this batch in the next query
+			end = lastEntry[0].Timestamp.Add(1 * time.Nanosecond)
 		}
 	}
+	return nil
 }
 
 func (q *Query) printResult(value loghttp.ResultValue, out output.LogOutput, lastEntry []*loghttp.Entry) (int, []*loghttp.Entry) {
@@ -159,11 +160,11 @@ func (q *Query) printResult(value loghttp.ResultValue, out output.LogOutput, las
 	case logqlmodel.ValueTypeStreams:
 		length, entry = q.printStream(value.(loghttp.Streams), out, lastEntry)
 	case loghttp.ResultTypeScalar:
-		q.printScalar(value.(loghttp.Scalar))
+		q.printScalar(value.(loghttp.Scalar), out)
 	case loghttp.ResultTypeMatrix:
-		q.printMatrix(value.(loghttp.Matrix))
+		q.printMatrix(value.(loghttp.Matrix), out)
 	case loghttp.ResultTypeVector:
-		q.printVector(value.(loghttp.Vector))
+		q.printVector(value.(loghttp.Vector), out)
 	default:
 		log.Fatalf("Unable to print unsupported type: %v", value.Type())
 	}
@@ -172,80 +173,27 @@ func (q *Query) printResult(value loghttp.ResultValue, out output.LogOutput, las
 
 // DoLocalQuery executes the query against the local store using a Loki configuration file.
 func (q *Query) DoLocalQuery(out output.LogOutput, statistics bool, orgID string) error {
-	var conf loki.Config
-	conf.RegisterFlags(flag.CommandLine)
-	if q.LocalConfig == "" {
-		return errors.New("no supplied config file")
-	}
-	if err := cfg.YAML(q.LocalConfig, false)(&conf); err != nil {
-		return err
-	}
-
-	if err := conf.Validate(); err != nil {
-		return err
-	}
-
-	limits, err := validation.NewOverrides(conf.LimitsConfig, nil)
-	if err != nil {
-		return err
-	}
-	cm := chunk_storage.NewClientMetrics()
-	storage.RegisterCustomIndexClients(&conf.StorageConfig, cm, prometheus.DefaultRegisterer)
-	conf.StorageConfig.BoltDBShipperConfig.Mode = shipper.ModeReadOnly
-	chunkStore, err := chunk_storage.NewStore(conf.StorageConfig.Config, conf.ChunkStoreConfig.StoreConfig, conf.SchemaConfig.SchemaConfig, limits, cm, prometheus.DefaultRegisterer, nil, util_log.Logger)
-	if err != nil {
-		return err
-	}
-
-	querier, err := storage.NewStore(conf.StorageConfig, conf.SchemaConfig, chunkStore, prometheus.DefaultRegisterer)
+	c, err := client.NewStoreClient(q.LocalConfig, orgID)
 	if err != nil {
 		return err
 	}
+	defer c.Stop()
 
-	eng := logql.NewEngine(conf.Querier.Engine, querier, limits, util_log.Logger)
-	var query logql.Query
-
+	var resp *loghttp.QueryResponse
 	if q.isInstant() {
-		query = eng.Query(logql.NewLiteralParams(
-			q.QueryString,
-			q.Start,
-			q.Start,
-			0,
-			0,
-			q.resultsDirection(),
-			uint32(q.Limit),
-			nil,
-		))
+		resp, err = c.Query(q.QueryString, q.Limit, q.Start, q.resultsDirection(), q.Quiet)
 	} else {
-		query = eng.Query(logql.NewLiteralParams(
-			q.QueryString,
-			q.Start,
-			q.End,
-			q.Step,
-			q.Interval,
-			q.resultsDirection(),
-			uint32(q.Limit),
-			nil,
-		))
-	}
-
-	// execute the query
-	ctx := user.InjectOrgID(context.Background(), orgID)
-	result, err := query.Exec(ctx)
+		resp, err = c.QueryRange(q.QueryString, q.Limit, q.Start, q.End, q.resultsDirection(), q.Step, q.Interval, q.Quiet)
+	}
 	if err != nil {
 		return err
 	}
 
 	if statistics {
-		q.printStats(result.Statistics)
-	}
-
-	value, err := marshal.NewResultValue(result.Data)
-	if err != nil {
-		return err
+		q.printStats(resp.Data.Statistics)
 	}
 
-	q.printResult(value, out, nil)
+	q.printResult(resp.Data.Result, out, nil)
 	return nil
 }
 
@@ -365,7 +313,18 @@ func (q *Query) printStream(streams loghttp.Streams, out output.LogOutput, lastE
 	return printed, lel
 }
 
-func (q *Query) printMatrix(matrix loghttp.Matrix) {
+func (q *Query) printMatrix(matrix loghttp.Matrix, out output.LogOutput) {
+	if so, ok := out.(output.SampleOutput); ok {
+		var samples []output.Sample
+		for _, s := range matrix {
+			for _, v := range s.Values {
+				samples = append(samples, output.Sample{Timestamp: v.Timestamp.Time(), Labels: metricLabels(s.Metric), Value: float64(v.Value)})
+			}
+		}
+		so.FormatAndPrintSamples(samples)
+		return
+	}
+
 	// yes we are effectively unmarshalling and then immediately marshalling this object back to json.  we are doing this b/c
 	// it gives us more flexibility with regard to output types in the future.  initially we are supporting just formatted json but eventually
 	// we might add output options such as render to an image file on disk
@@ -377,7 +336,16 @@ func (q *Query) printMatrix(matrix loghttp.Matrix) {
 	fmt.Print(string(bytes))
 }
 
-func (q *Query) printVector(vector loghttp.Vector) {
+func (q *Query) printVector(vector loghttp.Vector, out output.LogOutput) {
+	if so, ok := out.(output.SampleOutput); ok {
+		samples := make([]output.Sample, 0, len(vector))
+		for _, s := range vector {
+			samples = append(samples, output.Sample{Timestamp: s.Timestamp.Time(), Labels: metricLabels(s.Metric), Value: float64(s.Value)})
+		}
+		so.FormatAndPrintSamples(samples)
+		return
+	}
+
 	bytes, err := json.MarshalIndent(vector, "", "  ")
 	if err != nil {
 		log.Fatalf("Error marshalling vector: %v", err)
@@ -386,7 +354,12 @@ func (q *Query) printVector(vector loghttp.Vector) {
 	fmt.Print(string(bytes))
 }
 
-func (q *Query) printScalar(scalar loghttp.Scalar) {
+func (q *Query) printScalar(scalar loghttp.Scalar, out output.LogOutput) {
+	if so, ok := out.(output.SampleOutput); ok {
+		so.FormatAndPrintSamples([]output.Sample{{Timestamp: scalar.Timestamp.Time(), Labels: loghttp.LabelSet{}, Value: float64(scalar.Value)}})
+		return
+	}
+
 	bytes, err := json.MarshalIndent(scalar, "", "  ")
 	if err != nil {
 		log.Fatalf("Error marshalling scalar: %v", err)
@@ -395,6 +368,15 @@ func (q *Query) printScalar(scalar loghttp.Scalar) {
 	fmt.Print(string(bytes))
 }
 
+// metricLabels returns the labels of the series of a metric query.
+func metricLabels(m model.Metric) loghttp.LabelSet {
+	lbls := make(loghttp.LabelSet, len(m))
+	for name, value := range m {
+		lbls[string(name)] = string(value)
+	}
+	return lbls
+}
+
 type kvLogger struct {
 	*tabwriter.Writer
 }
@@ -410,6 +392,43 @@ func (k kvLogger) Log(keyvals ...interface{}) error {
 func (q *Query) printStats(stats stats.Result) {
 	writer := tabwriter.NewWriter(os.Stderr, 0, 8, 0, '\t', 0)
 	stats.Log(kvLogger{Writer: writer})
+	printSubQueries(os.Stderr, stats)
+}
+
+// printSubQueries prints the statistics of each split of the query, followed by the ones of its shards.
+func printSubQueries(w io.Writer, r stats.Result) {
+	if len(r.Splits) == 0 && len(r.Shards) == 0 {
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SPLIT\tSHARD\tEXEC TIME\tQUEUE TIME\tBYTES\tLINES\tCHUNKS")
+	printSubQuery := func(split string, sub stats.SubQuery) {
+		shard := sub.Shard
+		if shard == "" {
+			shard = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%d\t%d\n",
+			split,
+			shard,
+			stats.ConvertSecondsToNanoseconds(sub.Summary.ExecTime),
+			stats.ConvertSecondsToNanoseconds(sub.Summary.QueueTime),
+			humanize.Bytes(uint64(sub.Summary.TotalBytesProcessed)),
+			sub.Summary.TotalLinesProcessed,
+			sub.TotalChunksDownloaded,
+		)
+	}
+	for _, split := range r.Splits {
+		name := fmt.Sprintf("%s - %s", time.Unix(0, split.Start).UTC().Format(time.RFC3339), time.Unix(0, split.End).UTC().Format(time.RFC3339))
+		printSubQuery(name, split)
+		for _, shard := range split.Shards {
+			printSubQuery(name, shard)
+		}
+	}
+	for _, shard := range r.Shards {
+		printSubQuery("-", shard)
+	}
+	tw.Flush()
 }
 
 func (q *Query) resultsDirection() logproto.Direction {
@@ -10,6 +10,7 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/gorilla/websocket"
+	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -17,6 +18,7 @@ import (
 	"github.com/grafana/loki/pkg/loghttp"
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/logqlmodel/stats"
 	"github.com/grafana/loki/pkg/util/marshal"
 )
 
@@ -486,6 +488,35 @@ func Test_batch(t *testing.T) {
 	}
 }
 
+func Test_printResultSamples(t *testing.T) {
+	ts := model.TimeFromUnix(60)
+	writer := &bytes.Buffer{}
+	out, err := output.NewLogOutput(writer, "csv", &output.LogOutputOptions{Timezone: time.UTC})
+	require.NoError(t, err)
+
+	q := &Query{}
+	q.printResult(loghttp.Matrix{
+		{Metric: model.Metric{"app": "foo"}, Values: []model.SamplePair{{Timestamp: ts, Value: 1}, {Timestamp: ts.Add(time.Minute), Value: 2}}},
+		{Metric: model.Metric{"app": "bar"}, Values: []model.SamplePair{{Timestamp: ts, Value: 3}}},
+	}, out, nil)
+	require.Equal(t, `timestamp,app,value
+1970-01-01T00:01:00Z,foo,1
+1970-01-01T00:02:00Z,foo,2
+1970-01-01T00:01:00Z,bar,3
+`, writer.String())
+
+	writer.Reset()
+	out, err = output.NewLogOutput(writer, "table", &output.LogOutputOptions{Timezone: time.UTC})
+	require.NoError(t, err)
+	q.printResult(loghttp.Vector{{Metric: model.Metric{"app": "foo"}, Timestamp: ts, Value: 1.5}}, out, nil)
+	q.printResult(loghttp.Scalar{Timestamp: ts, Value: 2}, out, nil)
+	require.Equal(t, `TIMESTAMP             APP  VALUE
+1970-01-01T00:01:00Z  foo  1.5
+TIMESTAMP             VALUE
+1970-01-01T00:01:00Z  2
+`, writer.String())
+}
+
 func mustParseLabels(t *testing.T, s string) loghttp.LabelSet {
 	t.Helper()
 	l, err := marshal.NewLabelSet(s)
@@ -557,3 +588,34 @@ func (t *testQueryClient) LiveTailQueryConn(queryStr string, delayFor time.Durat
 func (t *testQueryClient) GetOrgID() string {
 	panic("implement me")
 }
+
+func Test_printSubQueries(t *testing.T) {
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := stats.Result{
+		Splits: []stats.SubQuery{
+			{
+				Start:                 start.UnixNano(),
+				End:                   start.Add(time.Hour).UnixNano(),
+				Summary:               stats.Summary{ExecTime: 2, TotalBytesProcessed: 2000, TotalLinesProcessed: 20},
+				TotalChunksDownloaded: 4,
+				Shards: []stats.SubQuery{
+					{Shard: "0_of_2", Summary: stats.Summary{ExecTime: 1, QueueTime: 0.5, TotalBytesProcessed: 1000, TotalLinesProcessed: 10}, TotalChunksDownloaded: 2},
+					{Shard: "1_of_2", Summary: stats.Summary{ExecTime: 1, TotalBytesProcessed: 1000, TotalLinesProcessed: 10}, TotalChunksDownloaded: 2},
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	printSubQueries(&buf, r)
+	require.Equal(t, `SPLIT                                        SHARD   EXEC TIME  QUEUE TIME  BYTES   LINES  CHUNKS
+2021-01-01T00:00:00Z - 2021-01-01T01:00:00Z  -       2s         0s          2.0 kB  20     4
+2021-01-01T00:00:00Z - 2021-01-01T01:00:00Z  0_of_2  1s         500ms       1.0 kB  10     2
+2021-01-01T00:00:00Z - 2021-01-01T01:00:00Z  1_of_2  1s         0s          1.0 kB  10     2
+`, buf.String())
+
+	// Nothing is printed when the query was neither split nor sharded.
+	buf.Reset()
+	printSubQueries(&buf, stats.Result{})
+	require.Empty(t, buf.String())
+}
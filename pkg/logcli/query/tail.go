@@ -1,10 +1,13 @@
 package query
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"sort"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -14,75 +17,295 @@ import (
 	"github.com/grafana/loki/pkg/logcli/client"
 	"github.com/grafana/loki/pkg/logcli/output"
 	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/loki/pkg/util/unmarshal"
 )
 
-// TailQuery connects to the Loki websocket endpoint and tails logs
+var (
+	// The backoff between the attempts to reconnect the tail, doubling up to the max.
+	tailReconnectBackoff    = time.Second
+	tailReconnectMaxBackoff = 30 * time.Second
+)
+
+// TailQuery connects to the Loki websocket endpoint and tails logs. When the connection is lost, it reconnects up to
+// TailReconnectAttempts times in a row and backfills the entries of the gap with range queries.
 func (q *Query) TailQuery(delayFor time.Duration, c client.Client, out output.LogOutput) {
-	conn, err := c.LiveTailQueryConn(q.QueryString, delayFor, q.Limit, q.Start, q.Quiet)
-	if err != nil {
-		log.Fatalf("Tailing logs failed: %+v", err)
+	if len(q.IgnoreLabelsKey) > 0 {
+		log.Println("Ignoring labels key:", color.RedString(strings.Join(q.IgnoreLabelsKey, ",")))
 	}
 
+	if len(q.ShowLabelsKey) > 0 {
+		log.Println("Print only labels key:", color.RedString(strings.Join(q.ShowLabelsKey, ",")))
+	}
+
+	t := newTail(q, delayFor, c, out)
 	go func() {
 		stopChan := make(chan os.Signal, 1)
 		signal.Notify(stopChan, os.Interrupt, syscall.SIGTERM)
 		<-stopChan
-		if err := conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")); err != nil {
-			log.Println("Error closing websocket:", err)
-		}
+		t.close()
 		os.Exit(0)
 	}()
 
-	tailResponse := new(loghttp.TailResponse)
+	if err := t.run(); err != nil {
+		log.Fatalf("Tailing logs failed: %+v", err)
+	}
+}
 
-	if len(q.IgnoreLabelsKey) > 0 {
-		log.Println("Ignoring labels key:", color.RedString(strings.Join(q.IgnoreLabelsKey, ",")))
+// tail tails the logs of a query, resuming from the entries printed per stream when reconnecting.
+type tail struct {
+	q        *Query
+	delayFor time.Duration
+	c        client.Client
+	out      output.LogOutput
+
+	connMtx sync.Mutex
+	conn    *websocket.Conn
+
+	// positions are the last entries printed, or checkpointed by the server, per stream.
+	positions map[string]*tailPosition
+	// The entries up to dedupeUntil are skipped if they are not after the position of their stream, the tail
+	// overlapping the backfilled entries.
+	dedupeUntil time.Time
+	// live is the timestamp of the last entry printed or checkpointed by the server, from where the tail is resumed.
+	live time.Time
+}
+
+type tailPosition struct {
+	timestamp time.Time
+	// lines are the lines printed at the timestamp.
+	lines map[string]struct{}
+}
+
+func newTail(q *Query, delayFor time.Duration, c client.Client, out output.LogOutput) *tail {
+	return &tail{
+		q:         q,
+		delayFor:  delayFor,
+		c:         c,
+		out:       out,
+		positions: map[string]*tailPosition{},
+		live:      q.Start,
 	}
+}
 
-	if len(q.ShowLabelsKey) > 0 {
-		log.Println("Print only labels key:", color.RedString(strings.Join(q.ShowLabelsKey, ",")))
+// run tails the logs until the connection is lost and can't be reconnected.
+func (t *tail) run() error {
+	conn, err := t.c.LiveTailQueryConn(t.q.QueryString, t.delayFor, t.q.Limit, t.q.Start, t.q.Quiet)
+	if err != nil {
+		return err
 	}
+	t.setConn(conn)
 
 	for {
-		err := unmarshal.ReadTailResponseJSON(tailResponse, conn)
-		if err != nil {
+		err := t.read(conn)
+		if t.q.TailReconnectAttempts <= 0 {
 			log.Println("Error reading stream:", err)
+			return nil
+		}
+		log.Println("Lost the connection, reconnecting:", err)
+		if conn, err = t.reconnect(); err != nil {
+			return err
+		}
+	}
+}
+
+// read prints the responses of the connection until it fails.
+func (t *tail) read(conn *websocket.Conn) error {
+	for {
+		tailResponse := new(loghttp.TailResponse)
+		if err := unmarshal.ReadTailResponseJSON(tailResponse, conn); err != nil {
+			return err
+		}
+		t.handle(tailResponse)
+	}
+}
+
+// handle prints the entries of the response, and reports the entries dropped by the server. The positions don't
+// move past the dropped entries, which are backfilled if the tail reconnects.
+func (t *tail) handle(tailResponse *loghttp.TailResponse) {
+	for _, stream := range tailResponse.Streams {
+		for _, entry := range stream.Entries {
+			t.print(stream.Labels, entry)
+		}
+	}
+
+	if len(tailResponse.DroppedStreams) != 0 {
+		log.Printf("Server dropped %d entries due to slow client:", len(tailResponse.DroppedStreams))
+		for _, d := range tailResponse.DroppedStreams {
+			log.Println(d.Timestamp, d.Labels)
+		}
+	}
+
+	// The entries up to the checkpoint were received.
+	for _, c := range tailResponse.Checkpoints {
+		t.advance(c.Labels.String(), c.Timestamp)
+	}
+}
+
+// print prints the entry unless it is a duplicate of an entry already printed.
+func (t *tail) print(lbls loghttp.LabelSet, entry loghttp.Entry) {
+	key := lbls.String()
+	pos, ok := t.positions[key]
+	if !ok {
+		pos = &tailPosition{}
+		t.positions[key] = pos
+	}
+	if !entry.Timestamp.After(t.dedupeUntil) {
+		if entry.Timestamp.Before(pos.timestamp) {
+			return
+		}
+		if _, printed := pos.lines[entry.Line]; printed && entry.Timestamp.Equal(pos.timestamp) {
 			return
 		}
+	}
 
-		labels := loghttp.LabelSet{}
-		for _, stream := range tailResponse.Streams {
-			if !q.NoLabels {
-				if len(q.IgnoreLabelsKey) > 0 || len(q.ShowLabelsKey) > 0 {
+	if entry.Timestamp.After(pos.timestamp) {
+		pos.timestamp = entry.Timestamp
+		pos.lines = map[string]struct{}{}
+	}
+	if entry.Timestamp.Equal(pos.timestamp) {
+		pos.lines[entry.Line] = struct{}{}
+	}
+	if entry.Timestamp.After(t.live) {
+		t.live = entry.Timestamp
+	}
+	t.out.FormatAndPrintln(entry.Timestamp, t.labels(lbls), 0, entry.Line)
+}
 
-					ls := stream.Labels
+// advance moves the position of the stream to the timestamp, without printing the entries up to it.
+func (t *tail) advance(key string, ts time.Time) {
+	pos, ok := t.positions[key]
+	if !ok {
+		pos = &tailPosition{}
+		t.positions[key] = pos
+	}
+	if ts.After(pos.timestamp) {
+		pos.timestamp = ts
+		pos.lines = map[string]struct{}{}
+	}
+	if ts.After(t.live) {
+		t.live = ts
+	}
+}
 
-					if len(q.ShowLabelsKey) > 0 {
-						ls = matchLabels(true, ls, q.ShowLabelsKey)
-					}
+// labels returns the labels of the stream to print.
+func (t *tail) labels(lbls loghttp.LabelSet) loghttp.LabelSet {
+	if t.q.NoLabels {
+		return loghttp.LabelSet{}
+	}
+	if len(t.q.ShowLabelsKey) > 0 {
+		lbls = matchLabels(true, lbls, t.q.ShowLabelsKey)
+	}
+	if len(t.q.IgnoreLabelsKey) > 0 {
+		lbls = matchLabels(false, lbls, t.q.IgnoreLabelsKey)
+	}
+	return lbls
+}
 
-					if len(q.IgnoreLabelsKey) > 0 {
-						ls = matchLabels(false, ls, q.ShowLabelsKey)
-					}
+// reconnect backfills the entries since the tail was live and reconnects the tail from there, with a backoff
+// between the attempts.
+func (t *tail) reconnect() (*websocket.Conn, error) {
+	backoff := tailReconnectBackoff
+	var err error
+	for attempt := 1; attempt <= t.q.TailReconnectAttempts; attempt++ {
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > tailReconnectMaxBackoff {
+			backoff = tailReconnectMaxBackoff
+		}
 
-					labels = ls
+		end := time.Now()
+		if err = t.backfill(end); err != nil {
+			log.Printf("Failed to backfill the logs, attempt %d of %d: %v", attempt, t.q.TailReconnectAttempts, err)
+			continue
+		}
+		var conn *websocket.Conn
+		conn, err = t.c.LiveTailQueryConn(t.q.QueryString, t.delayFor, t.q.Limit, end, t.q.Quiet)
+		if err != nil {
+			log.Printf("Failed to reconnect, attempt %d of %d: %v", attempt, t.q.TailReconnectAttempts, err)
+			continue
+		}
+		t.setConn(conn)
+		if !t.q.Quiet {
+			log.Println("Reconnected")
+		}
+		return conn, nil
+	}
+	return nil, fmt.Errorf("giving up reconnecting after %d attempts: %w", t.q.TailReconnectAttempts, err)
+}
 
-				} else {
-					labels = stream.Labels
-				}
-			}
+// backfill prints the entries from the oldest position of the streams until end, skipping the entries already
+// printed per stream.
+func (t *tail) backfill(end time.Time) error {
+	start := t.backfillStart()
+	if !t.q.Quiet {
+		log.Printf("Backfilling the logs from %s to %s", start.Format(time.RFC3339Nano), end.Format(time.RFC3339Nano))
+	}
+	t.dedupeUntil = end
 
-			for _, entry := range stream.Entries {
-				out.FormatAndPrintln(entry.Timestamp, labels, 0, entry.Line)
+	batchSize := t.q.BatchSize
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+	for {
+		resp, err := t.c.QueryRange(t.q.QueryString, batchSize, start, end, logproto.FORWARD, 0, 0, t.q.Quiet)
+		if err != nil {
+			return err
+		}
+		streams, ok := resp.Data.Result.(loghttp.Streams)
+		if !ok {
+			return fmt.Errorf("unexpected result type %s", resp.Data.ResultType)
+		}
+
+		var entries []streamEntryPair
+		for _, s := range streams {
+			for _, e := range s.Entries {
+				entries = append(entries, streamEntryPair{entry: e, labels: s.Labels})
 			}
+		}
+		sort.SliceStable(entries, func(i, j int) bool { return entries[i].entry.Timestamp.Before(entries[j].entry.Timestamp) })
+		for _, e := range entries {
+			t.print(e.labels, e.entry)
+		}
 
+		if len(entries) < batchSize {
+			return nil
 		}
-		if len(tailResponse.DroppedStreams) != 0 {
-			log.Println("Server dropped following entries due to slow client")
-			for _, d := range tailResponse.DroppedStreams {
-				log.Println(d.Timestamp, d.Labels)
-			}
+		// The entries at the timestamp of the last entry are queried again, and skipped.
+		last := entries[len(entries)-1].entry.Timestamp
+		if !last.After(start) {
+			log.Printf("More than %d entries at %s, some of them may not be backfilled", batchSize, last.Format(time.RFC3339Nano))
+			last = last.Add(time.Nanosecond)
+		}
+		start = last
+	}
+}
+
+// backfillStart returns the oldest position of the streams, as the streams behind the others miss the entries
+// since, and no later than when the tail was live minus the delay of the tail, for the streams without entries yet.
+func (t *tail) backfillStart() time.Time {
+	start := t.live.Add(-t.delayFor)
+	for _, pos := range t.positions {
+		if pos.timestamp.Before(start) {
+			start = pos.timestamp
 		}
 	}
+	return start
+}
+
+func (t *tail) setConn(conn *websocket.Conn) {
+	t.connMtx.Lock()
+	defer t.connMtx.Unlock()
+	t.conn = conn
+}
+
+// close closes the current connection.
+func (t *tail) close() {
+	t.connMtx.Lock()
+	defer t.connMtx.Unlock()
+	if t.conn == nil {
+		return
+	}
+	if err := t.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")); err != nil {
+		log.Println("Error closing websocket:", err)
+	}
 }
@@ -0,0 +1,152 @@
+package query
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/logcli/output"
+	legacy "github.com/grafana/loki/pkg/loghttp/legacy"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/util/marshal"
+)
+
+// tailQueryClient tails from a websocket server, and backfills from the test streams.
+type tailQueryClient struct {
+	*testQueryClient
+	url    string
+	starts []time.Time
+}
+
+func (c *tailQueryClient) LiveTailQueryConn(queryStr string, delayFor time.Duration, limit int, start time.Time, quiet bool) (*websocket.Conn, error) {
+	c.starts = append(c.starts, start)
+	conn, _, err := websocket.DefaultDialer.Dial(c.url, nil)
+	return conn, err
+}
+
+func Test_tailReconnect(t *testing.T) {
+	defer func(backoff time.Duration) { tailReconnectBackoff = backoff }(tailReconnectBackoff)
+	tailReconnectBackoff = time.Millisecond
+
+	base := time.Now().Add(-time.Minute).Truncate(time.Second)
+	entry := func(i int) logproto.Entry {
+		return logproto.Entry{Timestamp: base.Add(time.Duration(i) * time.Second), Line: fmt.Sprintf("line%d", i)}
+	}
+	stream := logproto.Stream{Labels: `{app="foo"}`}
+	for i := 0; i < 5; i++ {
+		stream.Entries = append(stream.Entries, entry(i))
+	}
+
+	// The tail is lost after a checkpoint, then after a live entry, then the server can't be reached.
+	responses := [][]legacy.TailResponse{
+		{
+			{Streams: []logproto.Stream{{Labels: stream.Labels, Entries: stream.Entries[:2]}}},
+			{Checkpoints: []legacy.TailCheckpoint{{Timestamp: entry(1).Timestamp, Labels: stream.Labels}}},
+		},
+		{
+			// The historic entries of the tail overlap the backfilled entries.
+			{Streams: []logproto.Stream{{Labels: stream.Labels, Entries: []logproto.Entry{entry(4), {Timestamp: time.Now().Add(time.Minute), Line: "live"}}}}},
+		},
+	}
+	var (
+		mtx         sync.Mutex
+		connections int
+		upgrader    websocket.Upgrader
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		if connections == len(responses) {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+		for _, resp := range responses[connections] {
+			require.NoError(t, marshal.WriteTailResponseJSON(resp, conn))
+		}
+		connections++
+	}))
+	defer srv.Close()
+
+	c := &tailQueryClient{testQueryClient: newTestQueryClient(stream), url: "ws" + strings.TrimPrefix(srv.URL, "http")}
+	q := &Query{
+		QueryString:           `{app="foo"}`,
+		Start:                 base.Add(-time.Hour),
+		Limit:                 30,
+		BatchSize:             2,
+		Quiet:                 true,
+		TailReconnectAttempts: 2,
+	}
+	writer := &bytes.Buffer{}
+	err := newTail(q, 0, c, output.NewRaw(writer, &output.LogOutputOptions{})).run()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "giving up reconnecting after 2 attempts")
+
+	require.Equal(t, "line0\nline1\nline2\nline3\nline4\nlive\n", writer.String())
+	require.Len(t, c.starts, 4)
+	require.Equal(t, q.Start, c.starts[0])
+	require.True(t, c.starts[1].After(entry(4).Timestamp))
+}
+
+func Test_tailHandle(t *testing.T) {
+	q := &Query{IgnoreLabelsKey: []string{"pod"}}
+	writer := &bytes.Buffer{}
+	out, err := output.NewLogOutput(writer, "jsonl", &output.LogOutputOptions{})
+	require.NoError(t, err)
+	tl := newTail(q, 0, nil, out)
+
+	ts := time.Unix(10, 0)
+	resp, err := marshal.NewTailResponse(legacy.TailResponse{
+		Streams:        []logproto.Stream{{Labels: `{app="foo", pod="a"}`, Entries: []logproto.Entry{{Timestamp: ts, Line: "line"}}}},
+		DroppedEntries: []legacy.DroppedEntry{{Timestamp: ts.Add(time.Second), Labels: `{app="foo", pod="a"}`}},
+	})
+	require.NoError(t, err)
+	tl.handle(&resp)
+
+	require.Contains(t, writer.String(), `"labels":{"app":"foo"}`)
+	// The dropped entries don't move the position of their stream.
+	require.Equal(t, ts, tl.positions[`{app="foo", pod="a"}`].timestamp)
+	require.Equal(t, ts, tl.live)
+}
+
+func Test_tailBackfill(t *testing.T) {
+	base := time.Now().Add(-time.Minute).Truncate(time.Second)
+	entry := func(i int) logproto.Entry {
+		return logproto.Entry{Timestamp: base.Add(time.Duration(i) * time.Second), Line: fmt.Sprintf("line%d", i)}
+	}
+	a := logproto.Stream{Labels: `{app="foo", pod="a"}`}
+	b := logproto.Stream{Labels: `{app="foo", pod="b"}`}
+	for i := 0; i < 4; i++ {
+		a.Entries = append(a.Entries, entry(i))
+		b.Entries = append(b.Entries, entry(i))
+	}
+
+	q := &Query{QueryString: `{app="foo"}`, Start: base.Add(-time.Hour), Limit: 30, BatchSize: 10, Quiet: true}
+	writer := &bytes.Buffer{}
+	tl := newTail(q, 0, newTestQueryClient(a, b), output.NewRaw(writer, &output.LogOutputOptions{}))
+
+	// The stream a is behind the stream b when the tail is lost.
+	resp, err := marshal.NewTailResponse(legacy.TailResponse{
+		Streams: []logproto.Stream{
+			{Labels: a.Labels, Entries: a.Entries[:1]},
+			{Labels: b.Labels, Entries: b.Entries[:3]},
+		},
+	})
+	require.NoError(t, err)
+	tl.handle(&resp)
+	require.Equal(t, entry(0).Timestamp, tl.backfillStart())
+
+	writer.Reset()
+	require.NoError(t, tl.backfill(base.Add(time.Minute)))
+	require.Equal(t, "line1\nline2\nline3\nline3\n", writer.String())
+}
@@ -0,0 +1,309 @@
+package rulesquery
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"gopkg.in/yaml.v3"
+
+	"github.com/grafana/loki/pkg/logcli/client"
+	"github.com/grafana/loki/pkg/ruler"
+	"github.com/grafana/loki/pkg/ruler/rulespb"
+)
+
+// RulesQuery contains all necessary fields to lint, list, diff, sync and delete rule groups and print out the results
+type RulesQuery struct {
+	// Files are the rule files, each holding the rule groups of the namespace named after the file.
+	Files     []string
+	Namespace string
+	Group     string
+	// Prune deletes the namespaces of the ruler which have no file when syncing.
+	Prune bool
+	Quiet bool
+}
+
+// ChangeType is the type of change made by a sync to a rule group of the ruler.
+type ChangeType string
+
+const (
+	ChangeCreate ChangeType = "create"
+	ChangeUpdate ChangeType = "update"
+	ChangeDelete ChangeType = "delete"
+)
+
+// Change is a change made by a sync to a rule group of the ruler.
+type Change struct {
+	Type      ChangeType
+	Namespace string
+	// Local is the group in the files, nil when the group is deleted.
+	Local *rulefmt.RuleGroup
+	// Remote is the group of the ruler, nil when the group is created.
+	Remote *rulefmt.RuleGroup
+}
+
+// Group returns the name of the changed group.
+func (c Change) Group() string {
+	if c.Local != nil {
+		return c.Local.Name
+	}
+	return c.Remote.Name
+}
+
+// DoLint validates the rule files and prints out their errors
+func (q *RulesQuery) DoLint() {
+	if _, err := LoadFiles(q.Files); err != nil {
+		log.Fatalf("Invalid rules:\n%s", err)
+	}
+	if !q.Quiet {
+		log.Printf("%d rule files are valid", len(q.Files))
+	}
+}
+
+// DoList prints out the rule groups of the ruler, only of the namespace when Namespace is set
+func (q *RulesQuery) DoList(c client.RulesClient) {
+	remote, err := c.ListRules(q.Namespace, q.Quiet)
+	if err != nil {
+		log.Fatalf("Error listing the rules: %+v", err)
+	}
+	printGroups(os.Stdout, remote)
+}
+
+// DoDiff prints out the changes to the rule groups of the ruler that syncing the rule files would make
+func (q *RulesQuery) DoDiff(c client.RulesClient) {
+	changes := q.changes(c)
+	if len(changes) == 0 {
+		if !q.Quiet {
+			log.Println("No changes")
+		}
+		return
+	}
+	if err := printChanges(os.Stdout, changes); err != nil {
+		log.Fatalf("Error printing the changes: %+v", err)
+	}
+}
+
+// DoSync applies the changes to the rule groups of the ruler so that they match the rule files
+func (q *RulesQuery) DoSync(c client.RulesClient) {
+	for _, change := range q.changes(c) {
+		var err error
+		switch change.Type {
+		case ChangeCreate, ChangeUpdate:
+			err = c.SetRuleGroup(change.Namespace, *change.Local, q.Quiet)
+		case ChangeDelete:
+			err = c.DeleteRuleGroup(change.Namespace, change.Group(), q.Quiet)
+		}
+		if err != nil {
+			log.Fatalf("Error applying the %s of the rule group %s/%s: %+v", change.Type, change.Namespace, change.Group(), err)
+		}
+		fmt.Printf("%s %s/%s\n", change.Type, change.Namespace, change.Group())
+	}
+}
+
+// DoDelete deletes the rule group of the namespace, or the whole namespace when Group is not set
+func (q *RulesQuery) DoDelete(c client.RulesClient) {
+	var err error
+	if q.Group != "" {
+		err = c.DeleteRuleGroup(q.Namespace, q.Group, q.Quiet)
+	} else {
+		err = c.DeleteNamespace(q.Namespace, q.Quiet)
+	}
+	if err != nil {
+		log.Fatalf("Error deleting the rules: %+v", err)
+	}
+}
+
+func (q *RulesQuery) changes(c client.RulesClient) []Change {
+	local, err := LoadFiles(q.Files)
+	if err != nil {
+		log.Fatalf("Invalid rules:\n%s", err)
+	}
+	remote, err := c.ListRules("", q.Quiet)
+	if err != nil {
+		log.Fatalf("Error listing the rules: %+v", err)
+	}
+	changes, err := Diff(local, remote, q.Prune)
+	if err != nil {
+		log.Fatalf("Error comparing the rules: %+v", err)
+	}
+	return changes
+}
+
+// LoadFiles reads the rule groups of the files by namespace, the name of the file without its extension, and
+// validates them the same way as the ruler.
+func LoadFiles(files []string) (map[string][]rulefmt.RuleGroup, error) {
+	namespaces := make(map[string][]rulefmt.RuleGroup, len(files))
+	paths := make(map[string]string, len(files))
+	var errs []string
+	for _, file := range files {
+		namespace := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		if other, ok := paths[namespace]; ok {
+			errs = append(errs, fmt.Sprintf("%s: namespace %q is already defined by %s", file, namespace, other))
+			continue
+		}
+		paths[namespace] = file
+
+		groups, fileErrs := ruler.GroupLoader{}.Load(file)
+		for _, err := range fileErrs {
+			errs = append(errs, err.Error())
+		}
+		if groups != nil {
+			namespaces[namespace] = groups.Groups
+		}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(errs, "\n"))
+	}
+	return namespaces, nil
+}
+
+// Diff returns the changes to make to the remote rule groups so that the namespaces of the local rule groups match.
+// The remote namespaces without local rule groups are only deleted when pruning.
+func Diff(local, remote map[string][]rulefmt.RuleGroup, prune bool) ([]Change, error) {
+	var changes []Change
+	for namespace, groups := range local {
+		remoteGroups := make(map[string]rulefmt.RuleGroup, len(remote[namespace]))
+		for _, g := range remote[namespace] {
+			remoteGroups[g.Name] = g
+		}
+		for i := range groups {
+			localGroup := normalize(namespace, groups[i])
+			remoteGroup, ok := remoteGroups[localGroup.Name]
+			delete(remoteGroups, localGroup.Name)
+			if !ok {
+				changes = append(changes, Change{Type: ChangeCreate, Namespace: namespace, Local: &localGroup})
+				continue
+			}
+			remoteGroup = normalize(namespace, remoteGroup)
+			equal, err := groupsEqual(localGroup, remoteGroup)
+			if err != nil {
+				return nil, err
+			}
+			if !equal {
+				changes = append(changes, Change{Type: ChangeUpdate, Namespace: namespace, Local: &localGroup, Remote: &remoteGroup})
+			}
+		}
+		for _, g := range remoteGroups {
+			remoteGroup := normalize(namespace, g)
+			changes = append(changes, Change{Type: ChangeDelete, Namespace: namespace, Remote: &remoteGroup})
+		}
+	}
+	if prune {
+		for namespace, groups := range remote {
+			if _, ok := local[namespace]; ok {
+				continue
+			}
+			for i := range groups {
+				remoteGroup := normalize(namespace, groups[i])
+				changes = append(changes, Change{Type: ChangeDelete, Namespace: namespace, Remote: &remoteGroup})
+			}
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Namespace != changes[j].Namespace {
+			return changes[i].Namespace < changes[j].Namespace
+		}
+		return changes[i].Group() < changes[j].Group()
+	})
+	return changes, nil
+}
+
+// normalize returns the group as stored by the ruler, so that the formatting of the files does not make a difference.
+func normalize(namespace string, group rulefmt.RuleGroup) rulefmt.RuleGroup {
+	return rulespb.FromProto(rulespb.ToProto("", namespace, group))
+}
+
+func groupsEqual(a, b rulefmt.RuleGroup) (bool, error) {
+	aYAML, err := marshalGroup(a)
+	if err != nil {
+		return false, err
+	}
+	bYAML, err := marshalGroup(b)
+	if err != nil {
+		return false, err
+	}
+	return aYAML == bYAML, nil
+}
+
+func marshalGroup(group rulefmt.RuleGroup) (string, error) {
+	b, err := yaml.Marshal(group)
+	if err != nil {
+		return "", fmt.Errorf("marshaling rule group %s: %w", group.Name, err)
+	}
+	return string(b), nil
+}
+
+// printChanges prints out the changes as unified diffs of the groups, from the ruler to the files.
+func printChanges(w io.Writer, changes []Change) error {
+	for _, change := range changes {
+		var remote, local string
+		var err error
+		if change.Remote != nil {
+			if remote, err = marshalGroup(*change.Remote); err != nil {
+				return err
+			}
+		}
+		if change.Local != nil {
+			if local, err = marshalGroup(*change.Local); err != nil {
+				return err
+			}
+		}
+		name := change.Namespace + "/" + change.Group()
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(remote),
+			B:        difflib.SplitLines(local),
+			FromFile: "ruler " + name,
+			ToFile:   "local " + name,
+			Context:  3,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "%s %s\n%s\n", change.Type, name, diff); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printGroups prints out the groups as a table, sorted by namespace and group.
+func printGroups(w io.Writer, namespaces map[string][]rulefmt.RuleGroup) {
+	names := make([]string, 0, len(namespaces))
+	for namespace := range namespaces {
+		names = append(names, namespace)
+	}
+	sort.Strings(names)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "NAMESPACE\tGROUP\tINTERVAL\tRECORDING RULES\tALERTING RULES\n")
+	for _, namespace := range names {
+		groups := namespaces[namespace]
+		sort.Slice(groups, func(i, j int) bool {
+			return groups[i].Name < groups[j].Name
+		})
+		for _, g := range groups {
+			var records, alerts int
+			for _, r := range g.Rules {
+				if r.Alert.Value != "" {
+					alerts++
+				} else {
+					records++
+				}
+			}
+			interval := "default"
+			if g.Interval != 0 {
+				interval = g.Interval.String()
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\n", namespace, g.Name, interval, records, alerts)
+		}
+	}
+	tw.Flush()
+}
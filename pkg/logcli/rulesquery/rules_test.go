@@ -0,0 +1,156 @@
+package rulesquery
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const testRules = `
+groups:
+  - name: errors
+    interval: 1m
+    rules:
+      - record: app:errors:rate1m
+        expr: |
+          sum by (app) (rate({env="prod"} |= "error" [1m]))
+      - alert: HighErrorRate
+        expr: sum by (app) (rate({env="prod"} |= "error" [1m])) > 10
+        for: 5m
+        labels:
+          severity: page
+`
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func mustParseGroups(t *testing.T, content string) []rulefmt.RuleGroup {
+	t.Helper()
+	var groups rulefmt.RuleGroups
+	require.NoError(t, yaml.Unmarshal([]byte(content), &groups))
+	return groups.Groups
+}
+
+func TestLoadFiles(t *testing.T) {
+	dir := t.TempDir()
+	valid := writeFile(t, dir, "app.yaml", testRules)
+	namespaces, err := LoadFiles([]string{valid})
+	require.NoError(t, err)
+	require.Len(t, namespaces["app"], 1)
+	require.Equal(t, "errors", namespaces["app"][0].Name)
+
+	invalid := writeFile(t, dir, "invalid.yml", `
+groups:
+  - name: errors
+    rules:
+      - record: app:errors
+        expr: sum(rate({env="prod"}[1m])
+`)
+	unknownField := writeFile(t, dir, "unknown.yaml", `
+groups:
+  - name: errors
+    unknown: field
+`)
+	duplicate := writeFile(t, t.TempDir(), "app.yml", testRules)
+	_, err = LoadFiles([]string{valid, invalid, unknownField, duplicate})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "could not parse expression for record 'app:errors' in group 'errors'")
+	require.Contains(t, err.Error(), "field unknown not found")
+	require.Contains(t, err.Error(), `namespace "app" is already defined`)
+}
+
+func TestDiff(t *testing.T) {
+	local := map[string][]rulefmt.RuleGroup{
+		"app": append(mustParseGroups(t, testRules), mustParseGroups(t, `
+groups:
+  - name: new
+    rules:
+      - record: app:lines:rate1m
+        expr: sum(rate({env="prod"}[1m]))
+`)...),
+	}
+	remote := map[string][]rulefmt.RuleGroup{
+		// The same group, formatted differently.
+		"app": append(mustParseGroups(t, `
+groups:
+  - name: errors
+    interval: 60s
+    rules:
+      - record: app:errors:rate1m
+        expr: "sum by (app) (rate({env=\"prod\"} |= \"error\" [1m]))\n"
+      - alert: HighErrorRate
+        expr: sum by (app) (rate({env="prod"} |= "error" [1m])) > 10
+        for: 300s
+        labels:
+          severity: page
+`), mustParseGroups(t, `
+groups:
+  - name: removed
+    rules:
+      - record: app:removed
+        expr: sum(rate({env="prod"}[1m]))
+`)...),
+		"other": mustParseGroups(t, `
+groups:
+  - name: other
+    rules:
+      - record: other:lines
+        expr: sum(rate({env="dev"}[1m]))
+`),
+	}
+
+	changes, err := Diff(local, remote, false)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	require.Equal(t, ChangeCreate, changes[0].Type)
+	require.Equal(t, "app", changes[0].Namespace)
+	require.Equal(t, "new", changes[0].Group())
+	require.Equal(t, ChangeDelete, changes[1].Type)
+	require.Equal(t, "removed", changes[1].Group())
+
+	changes, err = Diff(local, remote, true)
+	require.NoError(t, err)
+	require.Len(t, changes, 3)
+	require.Equal(t, ChangeDelete, changes[2].Type)
+	require.Equal(t, "other", changes[2].Namespace)
+
+	// The changed groups are updated.
+	local["app"][0].Rules[1].For = 0
+	changes, err = Diff(local, remote, false)
+	require.NoError(t, err)
+	require.Len(t, changes, 3)
+	require.Equal(t, ChangeUpdate, changes[0].Type)
+	require.Equal(t, "errors", changes[0].Group())
+
+	var buf bytes.Buffer
+	require.NoError(t, printChanges(&buf, changes[:1]))
+	require.Contains(t, buf.String(), "update app/errors\n--- ruler app/errors\n+++ local app/errors\n")
+	require.Contains(t, buf.String(), "-      for: 5m\n")
+}
+
+func Test_printGroups(t *testing.T) {
+	var buf bytes.Buffer
+	printGroups(&buf, map[string][]rulefmt.RuleGroup{
+		"app": mustParseGroups(t, testRules),
+		"other": mustParseGroups(t, `
+groups:
+  - name: other
+    rules:
+      - record: other:lines
+        expr: sum(rate({env="dev"}[1m]))
+`),
+	})
+	require.Equal(t, `NAMESPACE  GROUP   INTERVAL  RECORDING RULES  ALERTING RULES
+app        errors  1m        1                1
+other      other   default   1                0
+`, buf.String())
+}
@@ -19,6 +19,7 @@ type SeriesQuery struct {
 	End           time.Time
 	AnalyzeLabels bool
 	Quiet         bool
+	LocalConfig   string
 }
 
 type labelDetails struct {
@@ -29,6 +30,15 @@ type labelDetails struct {
 
 // DoSeries prints out series results
 func (q *SeriesQuery) DoSeries(c client.Client) {
+	if q.LocalConfig != "" {
+		storeClient, err := client.NewStoreClient(q.LocalConfig, c.GetOrgID())
+		if err != nil {
+			log.Fatalf("Unable to open the configured storage: %+v", err)
+		}
+		defer storeClient.Stop()
+		c = storeClient
+	}
+
 	streams := q.GetSeries(c)
 
 	if q.AnalyzeLabels {
@@ -12,8 +12,15 @@ type DroppedEntry struct {
 	Labels    string
 }
 
+// TailCheckpoint represents the timestamp of the last entry of a stream delivered, before any dropped entry, in a tail call
+type TailCheckpoint struct {
+	Timestamp time.Time
+	Labels    string
+}
+
 // TailResponse represents the http json response to a tail query
 type TailResponse struct {
 	Streams        []logproto.Stream `json:"streams"`
 	DroppedEntries []DroppedEntry    `json:"dropped_entries"`
+	Checkpoints    []TailCheckpoint  `json:"checkpoints,omitempty"`
 }
@@ -0,0 +1,50 @@
+package loghttp
+
+import (
+	"time"
+)
+
+// QueryPlanResponse represents the http json response to a query plan request
+type QueryPlanResponse struct {
+	Status string    `json:"status"`
+	Data   QueryPlan `json:"data"`
+}
+
+// QueryPlan explains how a query would be executed, without executing it.
+type QueryPlan struct {
+	Query string `json:"query"`
+	// Start and End are the time range of the query once the limits are applied.
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	AST   *PlanNode `json:"ast"`
+	// SplitInterval is the interval the query is split by, empty when it is not split.
+	SplitInterval string `json:"splitInterval,omitempty"`
+	// ResultsCache tells whether the results of the query are looked up in and stored to the results cache.
+	ResultsCache bool             `json:"resultsCache"`
+	Splits       []QueryPlanSplit `json:"splits,omitempty"`
+	// Chunks and Streams are the number of chunks and streams matching the selectors of the query in the index.
+	Chunks  int `json:"chunks"`
+	Streams int `json:"streams"`
+}
+
+// QueryPlanSplit is a sub-query of a query split by time.
+type QueryPlanSplit struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	// Shards is the number of shards the sub-query is executed on, 0 when it is not sharded.
+	Shards int `json:"shards"`
+	// ShardedQuery is the sub-query mapped to its sharded equivalent.
+	ShardedQuery string `json:"shardedQuery,omitempty"`
+	// NotShardedReason tells why the sub-query is not sharded.
+	NotShardedReason string `json:"notShardedReason,omitempty"`
+	Chunks           int    `json:"chunks"`
+	Streams          int    `json:"streams"`
+}
+
+// PlanNode is a node of the parsed AST of a query.
+type PlanNode struct {
+	Type      string      `json:"type"`
+	Expr      string      `json:"expr"`
+	Shardable bool        `json:"shardable"`
+	Children  []*PlanNode `json:"children,omitempty"`
+}
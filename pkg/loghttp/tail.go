@@ -17,8 +17,9 @@ const (
 
 // TailResponse represents the http json response to a tail query
 type TailResponse struct {
-	Streams        []Stream        `json:"streams,omitempty"`
-	DroppedStreams []DroppedStream `json:"dropped_entries,omitempty"`
+	Streams        []Stream         `json:"streams,omitempty"`
+	DroppedStreams []DroppedStream  `json:"dropped_entries,omitempty"`
+	Checkpoints    []TailCheckpoint `json:"checkpoints,omitempty"`
 }
 
 // DroppedStream represents a dropped stream in tail call
@@ -62,6 +63,20 @@ func (s *DroppedStream) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// TailCheckpoint represents the timestamp of the last entry of a stream delivered, before any dropped entry, in a
+// tail call. The entries of the stream up to the checkpoint don't need to be queried again when resuming the tail.
+type TailCheckpoint DroppedStream
+
+// MarshalJSON implements json.Marshaller
+func (c *TailCheckpoint) MarshalJSON() ([]byte, error) {
+	return (*DroppedStream)(c).MarshalJSON()
+}
+
+// UnmarshalJSON implements json.UnMarshaller
+func (c *TailCheckpoint) UnmarshalJSON(data []byte) error {
+	return (*DroppedStream)(c).UnmarshalJSON(data)
+}
+
 // ParseTailQuery parses a TailRequest request from an http request.
 func ParseTailQuery(r *http.Request) (*logproto.TailRequest, error) {
 	var err error
@@ -261,6 +261,37 @@ func (e *PipelineExpr) HasFilter() bool {
 	return false
 }
 
+// RequiredLineFilters returns the strings which every line returned by the log selector must contain,
+// i.e. the strings of the `|=` line filters which are applied before a stage, such as line_format or unpack,
+// changes the lines.
+func RequiredLineFilters(expr LogSelectorExpr) []string {
+	p, ok := expr.(*PipelineExpr)
+	if !ok {
+		return nil
+	}
+
+	var res []string
+	for _, stage := range p.MultiStages {
+		switch s := stage.(type) {
+		case *LineFilterExpr:
+			for f := s; f != nil; f = f.Left {
+				if f.Ty == labels.MatchEqual && f.Op == "" && f.Match != "" {
+					res = append(res, f.Match)
+				}
+			}
+		case *LabelParserExpr:
+			if s.Op == OpParserTypeUnpack {
+				return res
+			}
+		case *JSONExpressionParser, *LabelFilterExpr, *LabelFmtExpr:
+		default:
+			// Any other stage may change the lines.
+			return res
+		}
+	}
+	return res
+}
+
 type LineFilterExpr struct {
 	Left  *LineFilterExpr
 	Ty    labels.MatchType
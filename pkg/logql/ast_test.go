@@ -197,6 +197,28 @@ func Test_NilFilterDoesntPanic(t *testing.T) {
 	}
 }
 
+func Test_RequiredLineFilters(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		query    string
+		expected []string
+	}{
+		{`{app="foo"}`, nil},
+		{`{app="foo"} |= "bar"`, []string{"bar"}},
+		{`{app="foo"} |= "bar" != "baz" |~ "b.z" |= "qux"`, []string{"qux", "bar"}},
+		{`{app="foo"} |= "" |= ip("1.2.3.4")`, nil},
+		{`{app="foo"} | json |= "bar" | line_format "{{.baz}}" |= "qux"`, []string{"bar"}},
+		{`{app="foo"} |= "bar" | unpack |= "qux"`, []string{"bar"}},
+		{`{app="foo"} | logfmt | level="error" | label_format lvl=level |= "bar" | json |= "qux"`, []string{"bar", "qux"}},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			expr, err := ParseLogSelector(tc.query, true)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, RequiredLineFilters(expr))
+		})
+	}
+}
+
 type linecheck struct {
 	l string
 	e bool
@@ -0,0 +1,62 @@
+package logql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grafana/loki/pkg/loghttp"
+)
+
+// Explain returns the tree of the nodes of the parsed expression.
+func Explain(expr Expr) *loghttp.PlanNode {
+	node := &loghttp.PlanNode{
+		Expr:      strings.TrimSpace(expr.String()),
+		Shardable: expr.Shardable(),
+	}
+
+	var children []Expr
+	switch e := expr.(type) {
+	case *MatchersExpr:
+		node.Type = "selector"
+	case *PipelineExpr:
+		node.Type = "pipeline"
+		children = append(children, e.Left)
+		for _, stage := range e.MultiStages {
+			children = append(children, stage)
+		}
+	case *LineFilterExpr:
+		node.Type = "line_filter"
+	case *LabelParserExpr, *JSONExpressionParser:
+		node.Type = "parser"
+	case *LabelFilterExpr:
+		node.Type = "label_filter"
+	case *LineFmtExpr:
+		node.Type = "line_format"
+	case *LabelFmtExpr:
+		node.Type = "label_format"
+	case *LogRange:
+		node.Type = "log_range"
+		children = append(children, e.Left)
+	case *RangeAggregationExpr:
+		node.Type = "range_aggregation"
+		children = append(children, e.Left)
+	case *VectorAggregationExpr:
+		node.Type = "vector_aggregation"
+		children = append(children, e.Left)
+	case *BinOpExpr:
+		node.Type = "binary_operation"
+		children = append(children, e.SampleExpr, e.RHS)
+	case *LabelReplaceExpr:
+		node.Type = "label_replace"
+		children = append(children, e.Left)
+	case *LiteralExpr:
+		node.Type = "literal"
+	default:
+		node.Type = fmt.Sprintf("%T", expr)
+	}
+
+	for _, child := range children {
+		node.Children = append(node.Children, Explain(child))
+	}
+	return node
+}
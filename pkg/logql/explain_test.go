@@ -0,0 +1,53 @@
+package logql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/loghttp"
+)
+
+func Test_Explain(t *testing.T) {
+	expr, err := ParseExpr(`sum by (cluster) (rate({job="foo"} |= "bar" | logfmt [5m])) / 2`)
+	require.NoError(t, err)
+
+	require.Equal(t, &loghttp.PlanNode{
+		Type: "binary_operation",
+		Expr: `(sum by(cluster)(rate({job="foo"} |= "bar" | logfmt[5m])) / 2)`,
+		Children: []*loghttp.PlanNode{
+			{
+				Type:      "vector_aggregation",
+				Expr:      `sum by(cluster)(rate({job="foo"} |= "bar" | logfmt[5m]))`,
+				Shardable: true,
+				Children: []*loghttp.PlanNode{
+					{
+						Type:      "range_aggregation",
+						Expr:      `rate({job="foo"} |= "bar" | logfmt[5m])`,
+						Shardable: true,
+						Children: []*loghttp.PlanNode{
+							{
+								Type:      "log_range",
+								Expr:      `{job="foo"} |= "bar" | logfmt[5m]`,
+								Shardable: true,
+								Children: []*loghttp.PlanNode{
+									{
+										Type:      "pipeline",
+										Expr:      `{job="foo"} |= "bar" | logfmt`,
+										Shardable: true,
+										Children: []*loghttp.PlanNode{
+											{Type: "selector", Expr: `{job="foo"}`, Shardable: true},
+											{Type: "line_filter", Expr: `|= "bar"`, Shardable: true},
+											{Type: "parser", Expr: `| logfmt`, Shardable: true},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			{Type: "literal", Expr: "2", Shardable: true},
+		},
+	}, Explain(expr))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/go-kit/log"
@@ -178,8 +179,12 @@ func (ev DownstreamEvaluator) Downstream(ctx context.Context, queries []Downstre
 		return nil, err
 	}
 
-	for _, res := range results {
-		stats.JoinResults(ctx, res.Statistics)
+	for i, res := range results {
+		if len(queries[i].Shards) == 0 {
+			stats.JoinResults(ctx, res.Statistics)
+			continue
+		}
+		stats.JoinShard(ctx, strings.Join(queries[i].Shards.Encode(), ","), res.Statistics)
 	}
 
 	return results, nil
@@ -33,16 +33,22 @@ import (
 type (
 	ctxKeyType string
 	Component  int64
+	CacheType  string
 )
 
 const (
 	statsKey ctxKeyType = "stats"
+
+	ChunkCache  CacheType = "chunk"
+	IndexCache  CacheType = "index"
+	ResultCache CacheType = "result"
 )
 
 // Context is the statistics context. It is passed through the query path and accumulates statistics.
 type Context struct {
 	querier  Querier
 	ingester Ingester
+	caches   Caches
 
 	// store is the store statistics collected across the query path
 	store Store
@@ -79,6 +85,15 @@ func (c *Context) Ingester() Ingester {
 	}
 }
 
+// Caches returns the cache statistics accumulated so far.
+func (c *Context) Caches() Caches {
+	return Caches{
+		Chunk:  c.caches.Chunk,
+		Index:  c.caches.Index,
+		Result: c.caches.Result,
+	}
+}
+
 // Reset clears the statistics.
 func (c *Context) Reset() {
 	c.mtx.Lock()
@@ -87,6 +102,7 @@ func (c *Context) Reset() {
 	c.store.Reset()
 	c.querier.Reset()
 	c.ingester.Reset()
+	c.caches.Reset()
 	c.result.Reset()
 }
 
@@ -99,6 +115,7 @@ func (c *Context) Result(execTime time.Duration, queueTime time.Duration) Result
 			Store: c.store,
 		},
 		Ingester: c.ingester,
+		Caches:   c.caches,
 	})
 
 	r.ComputeSummary(execTime, queueTime)
@@ -124,6 +141,29 @@ func JoinIngesters(ctx context.Context, inc Ingester) {
 	stats.ingester.Merge(inc)
 }
 
+// JoinShard merges the Result of a shard of the query with the embedded Result in a context, and records it in the
+// shards breakdown, in a concurrency-safe manner.
+func JoinShard(ctx context.Context, shard string, res Result) {
+	stats := FromContext(ctx)
+	stats.mtx.Lock()
+	defer stats.mtx.Unlock()
+
+	sub := res.SubQuery()
+	sub.Shard = shard
+	stats.result.Merge(res)
+	stats.result.Shards = append(stats.result.Shards, sub)
+}
+
+// SubQuery returns the statistics of the result as the ones of a sub-query of a split or sharded query.
+func (r Result) SubQuery() SubQuery {
+	return SubQuery{
+		Summary:               r.Summary,
+		TotalChunksRef:        r.TotalChunksRef(),
+		TotalChunksDownloaded: r.TotalChunksDownloaded(),
+		Shards:                r.Shards,
+	}
+}
+
 // ComputeSummary compute the summary of the statistics.
 func (r *Result) ComputeSummary(execTime time.Duration, queueTime time.Duration) {
 	r.Summary.TotalBytesProcessed = r.Querier.Store.Chunk.DecompressedBytes + r.Querier.Store.Chunk.HeadChunkBytes +
@@ -142,6 +182,11 @@ func (r *Result) ComputeSummary(execTime time.Duration, queueTime time.Duration)
 	if queueTime != 0 {
 		r.Summary.QueueTime = queueTime.Seconds()
 	}
+	r.Summary.Splits = int64(len(r.Splits))
+	r.Summary.Shards = int64(len(r.Shards))
+	for _, split := range r.Splits {
+		r.Summary.Shards += int64(len(split.Shards))
+	}
 }
 
 func (s *Store) Merge(m Store) {
@@ -154,6 +199,19 @@ func (s *Store) Merge(m Store) {
 	s.Chunk.DecompressedLines += m.Chunk.DecompressedLines
 	s.Chunk.CompressedBytes += m.Chunk.CompressedBytes
 	s.Chunk.TotalDuplicates += m.Chunk.TotalDuplicates
+	s.Index.TotalQueries += m.Index.TotalQueries
+	s.Index.LookupTime += m.Index.LookupTime
+}
+
+func (c *Caches) Merge(m Caches) {
+	c.Chunk.Merge(m.Chunk)
+	c.Index.Merge(m.Index)
+	c.Result.Merge(m.Result)
+}
+
+func (c *Cache) Merge(m Cache) {
+	c.EntriesRequested += m.EntriesRequested
+	c.EntriesFound += m.EntriesFound
 }
 
 func (q *Querier) Merge(m Querier) {
@@ -171,6 +229,9 @@ func (i *Ingester) Merge(m Ingester) {
 func (r *Result) Merge(m Result) {
 	r.Querier.Merge(m.Querier)
 	r.Ingester.Merge(m.Ingester)
+	r.Caches.Merge(m.Caches)
+	r.Splits = append(r.Splits, m.Splits...)
+	r.Shards = append(r.Shards, m.Shards...)
 	r.ComputeSummary(ConvertSecondsToNanoseconds(r.Summary.ExecTime+m.Summary.ExecTime),
 		ConvertSecondsToNanoseconds(r.Summary.QueueTime+m.Summary.QueueTime))
 }
@@ -254,6 +315,51 @@ func (c *Context) AddChunksRef(i int64) {
 	atomic.AddInt64(&c.store.TotalChunksRef, i)
 }
 
+func (c *Context) AddIndexQueries(i int64) {
+	atomic.AddInt64(&c.store.Index.TotalQueries, i)
+}
+
+func (c *Context) AddIndexLookupTime(i time.Duration) {
+	atomic.AddInt64(&c.store.Index.LookupTime, int64(i))
+}
+
+func (c *Context) AddCacheEntriesRequested(t CacheType, i int) {
+	stats := c.getCacheStatsByType(t)
+	if stats == nil {
+		return
+	}
+	atomic.AddInt64(&stats.EntriesRequested, int64(i))
+}
+
+func (c *Context) AddCacheEntriesFound(t CacheType, i int) {
+	stats := c.getCacheStatsByType(t)
+	if stats == nil {
+		return
+	}
+	atomic.AddInt64(&stats.EntriesFound, int64(i))
+}
+
+// AddCacheLookup records the lookup of a single entry in the cache, found or not.
+func (c *Context) AddCacheLookup(t CacheType, found bool) {
+	c.AddCacheEntriesRequested(t, 1)
+	if found {
+		c.AddCacheEntriesFound(t, 1)
+	}
+}
+
+func (c *Context) getCacheStatsByType(t CacheType) *Cache {
+	switch t {
+	case ChunkCache:
+		return &c.caches.Chunk
+	case IndexCache:
+		return &c.caches.Index
+	case ResultCache:
+		return &c.caches.Result
+	default:
+		return nil
+	}
+}
+
 // Log logs a query statistics result.
 func (r Result) Log(log log.Logger) {
 	_ = log.Log(
@@ -270,6 +376,8 @@ func (r Result) Log(log log.Logger) {
 		"Ingester.DecompressedLines", r.Ingester.Store.Chunk.DecompressedLines,
 		"Ingester.CompressedBytes", humanize.Bytes(uint64(r.Ingester.Store.Chunk.CompressedBytes)),
 		"Ingester.TotalDuplicates", r.Ingester.Store.Chunk.TotalDuplicates,
+		"Ingester.IndexQueries", r.Ingester.Store.Index.TotalQueries,
+		"Ingester.IndexLookupTime", time.Duration(r.Ingester.Store.Index.LookupTime),
 
 		"Querier.TotalChunksRef", r.Querier.Store.TotalChunksRef,
 		"Querier.TotalChunksDownloaded", r.Querier.Store.TotalChunksDownloaded,
@@ -280,6 +388,15 @@ func (r Result) Log(log log.Logger) {
 		"Querier.DecompressedLines", r.Querier.Store.Chunk.DecompressedLines,
 		"Querier.CompressedBytes", humanize.Bytes(uint64(r.Querier.Store.Chunk.CompressedBytes)),
 		"Querier.TotalDuplicates", r.Querier.Store.Chunk.TotalDuplicates,
+		"Querier.IndexQueries", r.Querier.Store.Index.TotalQueries,
+		"Querier.IndexLookupTime", time.Duration(r.Querier.Store.Index.LookupTime),
+
+		"Cache.Chunk.EntriesRequested", r.Caches.Chunk.EntriesRequested,
+		"Cache.Chunk.EntriesFound", r.Caches.Chunk.EntriesFound,
+		"Cache.Index.EntriesRequested", r.Caches.Index.EntriesRequested,
+		"Cache.Index.EntriesFound", r.Caches.Index.EntriesFound,
+		"Cache.Result.EntriesRequested", r.Caches.Result.EntriesRequested,
+		"Cache.Result.EntriesFound", r.Caches.Result.EntriesFound,
 	)
 	r.Summary.Log(log)
 }
@@ -292,5 +409,7 @@ func (s Summary) Log(log log.Logger) {
 		"Summary.TotalLinesProcessed", s.TotalLinesProcessed,
 		"Summary.ExecTime", ConvertSecondsToNanoseconds(s.ExecTime),
 		"Summary.QueueTime", ConvertSecondsToNanoseconds(s.QueueTime),
+		"Summary.Splits", s.Splits,
+		"Summary.Shards", s.Shards,
 	)
 }
@@ -266,3 +266,54 @@ func TestIngester(t *testing.T) {
 		},
 	}, statsCtx.Ingester())
 }
+
+func TestCaches(t *testing.T) {
+	statsCtx, _ := NewContext(context.Background())
+	statsCtx.AddCacheEntriesRequested(ChunkCache, 10)
+	statsCtx.AddCacheEntriesFound(ChunkCache, 4)
+	statsCtx.AddCacheEntriesRequested(IndexCache, 3)
+	statsCtx.AddCacheEntriesFound(IndexCache, 3)
+	statsCtx.AddCacheLookup(ResultCache, false)
+	statsCtx.AddCacheLookup(ResultCache, true)
+	statsCtx.AddIndexQueries(3)
+	statsCtx.AddIndexLookupTime(time.Second)
+
+	res := statsCtx.Result(time.Second, 0)
+	require.Equal(t, Caches{
+		Chunk:  Cache{EntriesRequested: 10, EntriesFound: 4},
+		Index:  Cache{EntriesRequested: 3, EntriesFound: 3},
+		Result: Cache{EntriesRequested: 2, EntriesFound: 1},
+	}, res.Caches)
+	require.Equal(t, Index{TotalQueries: 3, LookupTime: time.Second.Nanoseconds()}, res.Querier.Store.Index)
+
+	res.Merge(res)
+	require.Equal(t, Cache{EntriesRequested: 20, EntriesFound: 8}, res.Caches.Chunk)
+	require.Equal(t, int64(6), res.Querier.Store.Index.TotalQueries)
+}
+
+func TestJoinShard(t *testing.T) {
+	statsCtx, ctx := NewContext(context.Background())
+	shard := Result{
+		Querier: Querier{Store: Store{TotalChunksRef: 2, TotalChunksDownloaded: 1}},
+		Summary: Summary{ExecTime: 1, QueueTime: 0.5},
+	}
+	JoinShard(ctx, "0_of_2", shard)
+	JoinShard(ctx, "1_of_2", shard)
+
+	res := statsCtx.Result(time.Second, 0)
+	require.Equal(t, int64(2), res.Summary.Shards)
+	require.Equal(t, 1.0, res.Summary.QueueTime)
+	require.Equal(t, int64(4), res.Querier.Store.TotalChunksRef)
+	require.Len(t, res.Shards, 2)
+	require.Equal(t, "0_of_2", res.Shards[0].Shard)
+	require.Equal(t, int64(2), res.Shards[0].TotalChunksRef)
+	require.Equal(t, int64(1), res.Shards[0].TotalChunksDownloaded)
+	require.Equal(t, 0.5, res.Shards[0].Summary.QueueTime)
+
+	// The shards of the splits are counted along the splits.
+	split := res.SubQuery()
+	merged := Result{Splits: []SubQuery{split}}
+	merged.Merge(Result{Splits: []SubQuery{split}})
+	require.Equal(t, int64(2), merged.Summary.Splits)
+	require.Equal(t, int64(4), merged.Summary.Shards)
+}
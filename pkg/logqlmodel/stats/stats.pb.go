@@ -31,6 +31,11 @@ type Result struct {
 	Summary  Summary  `protobuf:"bytes,1,opt,name=summary,proto3" json:"summary"`
 	Querier  Querier  `protobuf:"bytes,2,opt,name=querier,proto3" json:"querier"`
 	Ingester Ingester `protobuf:"bytes,3,opt,name=ingester,proto3" json:"ingester"`
+	Caches   Caches   `protobuf:"bytes,4,opt,name=caches,proto3" json:"cache"`
+	// Statistics of each sub-query of a query split by time.
+	Splits []SubQuery `protobuf:"bytes,5,rep,name=splits,proto3" json:"splits,omitempty"`
+	// Statistics of each sub-query of a sharded query.
+	Shards []SubQuery `protobuf:"bytes,6,rep,name=shards,proto3" json:"shards,omitempty"`
 }
 
 func (m *Result) Reset()      { *m = Result{} }
@@ -86,6 +91,27 @@ func (m *Result) GetIngester() Ingester {
 	return Ingester{}
 }
 
+func (m *Result) GetCaches() Caches {
+	if m != nil {
+		return m.Caches
+	}
+	return Caches{}
+}
+
+func (m *Result) GetSplits() []SubQuery {
+	if m != nil {
+		return m.Splits
+	}
+	return nil
+}
+
+func (m *Result) GetShards() []SubQuery {
+	if m != nil {
+		return m.Shards
+	}
+	return nil
+}
+
 // Summary is the summary of a query statistics.
 type Summary struct {
 	// Total bytes processed per second.
@@ -104,6 +130,10 @@ type Summary struct {
 	// In addition to internal calculations this is also returned by the HTTP API.
 	// Grafana expects time values to be returned in seconds as float.
 	QueueTime float64 `protobuf:"fixed64,6,opt,name=queueTime,proto3" json:"queueTime"`
+	// Total sub-queries of the query split by time.
+	Splits int64 `protobuf:"varint,7,opt,name=splits,proto3" json:"splits"`
+	// Total sub-queries of the sharded query, over all the splits.
+	Shards int64 `protobuf:"varint,8,opt,name=shards,proto3" json:"shards"`
 }
 
 func (m *Summary) Reset()      { *m = Summary{} }
@@ -180,6 +210,20 @@ func (m *Summary) GetQueueTime() float64 {
 	return 0
 }
 
+func (m *Summary) GetSplits() int64 {
+	if m != nil {
+		return m.Splits
+	}
+	return 0
+}
+
+func (m *Summary) GetShards() int64 {
+	if m != nil {
+		return m.Shards
+	}
+	return 0
+}
+
 type Querier struct {
 	Store Store `protobuf:"bytes,1,opt,name=store,proto3" json:"store"`
 }
@@ -310,6 +354,7 @@ type Store struct {
 	// Time spent fetching chunks in nanoseconds.
 	ChunksDownloadTime int64 `protobuf:"varint,3,opt,name=chunksDownloadTime,proto3" json:"chunksDownloadTime"`
 	Chunk              Chunk `protobuf:"bytes,4,opt,name=chunk,proto3" json:"chunk"`
+	Index              Index `protobuf:"bytes,5,opt,name=index,proto3" json:"index"`
 }
 
 func (m *Store) Reset()      { *m = Store{} }
@@ -372,6 +417,13 @@ func (m *Store) GetChunk() Chunk {
 	return Chunk{}
 }
 
+func (m *Store) GetIndex() Index {
+	if m != nil {
+		return m.Index
+	}
+	return Index{}
+}
+
 type Chunk struct {
 	// Total bytes processed but was already in memory. (found in the headchunk)
 	HeadChunkBytes int64 `protobuf:"varint,4,opt,name=headChunkBytes,proto3" json:"headChunkBytes"`
@@ -461,6 +513,270 @@ func (m *Chunk) GetTotalDuplicates() int64 {
 	return 0
 }
 
+type Index struct {
+	// Total of queries sent to the index, including the ones answered by the index cache.
+	TotalQueries int64 `protobuf:"varint,1,opt,name=totalQueries,proto3" json:"totalQueries"`
+	// Time spent looking up the chunk references in the index in nanoseconds.
+	LookupTime int64 `protobuf:"varint,2,opt,name=lookupTime,proto3" json:"lookupTime"`
+}
+
+func (m *Index) Reset()      { *m = Index{} }
+func (*Index) ProtoMessage() {}
+func (*Index) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6cdfe5d2aea33ebb, []int{6}
+}
+func (m *Index) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Index) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Index.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Index) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Index.Merge(m, src)
+}
+func (m *Index) XXX_Size() int {
+	return m.Size()
+}
+func (m *Index) XXX_DiscardUnknown() {
+	xxx_messageInfo_Index.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Index proto.InternalMessageInfo
+
+func (m *Index) GetTotalQueries() int64 {
+	if m != nil {
+		return m.TotalQueries
+	}
+	return 0
+}
+
+func (m *Index) GetLookupTime() int64 {
+	if m != nil {
+		return m.LookupTime
+	}
+	return 0
+}
+
+// Caches contains the statistics of the caches looked up by a query.
+type Caches struct {
+	Chunk  Cache `protobuf:"bytes,1,opt,name=chunk,proto3" json:"chunk"`
+	Index  Cache `protobuf:"bytes,2,opt,name=index,proto3" json:"index"`
+	Result Cache `protobuf:"bytes,3,opt,name=result,proto3" json:"result"`
+}
+
+func (m *Caches) Reset()      { *m = Caches{} }
+func (*Caches) ProtoMessage() {}
+func (*Caches) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6cdfe5d2aea33ebb, []int{7}
+}
+func (m *Caches) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Caches) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Caches.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Caches) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Caches.Merge(m, src)
+}
+func (m *Caches) XXX_Size() int {
+	return m.Size()
+}
+func (m *Caches) XXX_DiscardUnknown() {
+	xxx_messageInfo_Caches.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Caches proto.InternalMessageInfo
+
+func (m *Caches) GetChunk() Cache {
+	if m != nil {
+		return m.Chunk
+	}
+	return Cache{}
+}
+
+func (m *Caches) GetIndex() Cache {
+	if m != nil {
+		return m.Index
+	}
+	return Cache{}
+}
+
+func (m *Caches) GetResult() Cache {
+	if m != nil {
+		return m.Result
+	}
+	return Cache{}
+}
+
+type Cache struct {
+	// Total entries looked up in the cache.
+	EntriesRequested int64 `protobuf:"varint,1,opt,name=entriesRequested,proto3" json:"entriesRequested"`
+	// Total entries found in the cache.
+	EntriesFound int64 `protobuf:"varint,2,opt,name=entriesFound,proto3" json:"entriesFound"`
+}
+
+func (m *Cache) Reset()      { *m = Cache{} }
+func (*Cache) ProtoMessage() {}
+func (*Cache) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6cdfe5d2aea33ebb, []int{8}
+}
+func (m *Cache) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Cache) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Cache.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Cache) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Cache.Merge(m, src)
+}
+func (m *Cache) XXX_Size() int {
+	return m.Size()
+}
+func (m *Cache) XXX_DiscardUnknown() {
+	xxx_messageInfo_Cache.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Cache proto.InternalMessageInfo
+
+func (m *Cache) GetEntriesRequested() int64 {
+	if m != nil {
+		return m.EntriesRequested
+	}
+	return 0
+}
+
+func (m *Cache) GetEntriesFound() int64 {
+	if m != nil {
+		return m.EntriesFound
+	}
+	return 0
+}
+
+// SubQuery contains the statistics of a sub-query of a split or sharded query.
+type SubQuery struct {
+	// Start of the split in nanoseconds since the epoch.
+	Start int64 `protobuf:"varint,1,opt,name=start,proto3" json:"start,omitempty"`
+	// End of the split in nanoseconds since the epoch.
+	End int64 `protobuf:"varint,2,opt,name=end,proto3" json:"end,omitempty"`
+	// Shard of the sub-query, e.g. 0_of_16.
+	Shard   string  `protobuf:"bytes,3,opt,name=shard,proto3" json:"shard,omitempty"`
+	Summary Summary `protobuf:"bytes,4,opt,name=summary,proto3" json:"summary"`
+	// Total of chunk references fetched from the index.
+	TotalChunksRef int64 `protobuf:"varint,5,opt,name=totalChunksRef,proto3" json:"totalChunksRef"`
+	// Total of chunks fetched.
+	TotalChunksDownloaded int64 `protobuf:"varint,6,opt,name=totalChunksDownloaded,proto3" json:"totalChunksDownloaded"`
+	// Statistics of each shard of a split.
+	Shards []SubQuery `protobuf:"bytes,7,rep,name=shards,proto3" json:"shards,omitempty"`
+}
+
+func (m *SubQuery) Reset()      { *m = SubQuery{} }
+func (*SubQuery) ProtoMessage() {}
+func (*SubQuery) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6cdfe5d2aea33ebb, []int{9}
+}
+func (m *SubQuery) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *SubQuery) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_SubQuery.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *SubQuery) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SubQuery.Merge(m, src)
+}
+func (m *SubQuery) XXX_Size() int {
+	return m.Size()
+}
+func (m *SubQuery) XXX_DiscardUnknown() {
+	xxx_messageInfo_SubQuery.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SubQuery proto.InternalMessageInfo
+
+func (m *SubQuery) GetStart() int64 {
+	if m != nil {
+		return m.Start
+	}
+	return 0
+}
+
+func (m *SubQuery) GetEnd() int64 {
+	if m != nil {
+		return m.End
+	}
+	return 0
+}
+
+func (m *SubQuery) GetShard() string {
+	if m != nil {
+		return m.Shard
+	}
+	return ""
+}
+
+func (m *SubQuery) GetSummary() Summary {
+	if m != nil {
+		return m.Summary
+	}
+	return Summary{}
+}
+
+func (m *SubQuery) GetTotalChunksRef() int64 {
+	if m != nil {
+		return m.TotalChunksRef
+	}
+	return 0
+}
+
+func (m *SubQuery) GetTotalChunksDownloaded() int64 {
+	if m != nil {
+		return m.TotalChunksDownloaded
+	}
+	return 0
+}
+
+func (m *SubQuery) GetShards() []SubQuery {
+	if m != nil {
+		return m.Shards
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*Result)(nil), "stats.Result")
 	proto.RegisterType((*Summary)(nil), "stats.Summary")
@@ -468,57 +784,80 @@ func init() {
 	proto.RegisterType((*Ingester)(nil), "stats.Ingester")
 	proto.RegisterType((*Store)(nil), "stats.Store")
 	proto.RegisterType((*Chunk)(nil), "stats.Chunk")
+	proto.RegisterType((*Index)(nil), "stats.Index")
+	proto.RegisterType((*Caches)(nil), "stats.Caches")
+	proto.RegisterType((*Cache)(nil), "stats.Cache")
+	proto.RegisterType((*SubQuery)(nil), "stats.SubQuery")
 }
 
 func init() { proto.RegisterFile("pkg/logqlmodel/stats/stats.proto", fileDescriptor_6cdfe5d2aea33ebb) }
 
 var fileDescriptor_6cdfe5d2aea33ebb = []byte{
-	// 714 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x55, 0x3d, 0x6f, 0xd3, 0x5c,
-	0x14, 0x8e, 0x93, 0xd7, 0x49, 0x7a, 0xdf, 0x7e, 0x71, 0xab, 0xd2, 0x00, 0x92, 0x5d, 0x65, 0xaa,
-	0x04, 0x34, 0xe2, 0x63, 0x01, 0xd1, 0xc5, 0xad, 0x90, 0x2a, 0x81, 0x28, 0xa7, 0xb0, 0xb0, 0x39,
-	0xce, 0x6d, 0x62, 0xd5, 0xf1, 0x4d, 0xfd, 0x21, 0xe8, 0xc6, 0xc6, 0x08, 0x3f, 0x82, 0x81, 0x85,
-	0x9f, 0xc0, 0xde, 0xb1, 0x63, 0x27, 0x8b, 0xba, 0x0b, 0xf2, 0xd4, 0x9f, 0x80, 0x7c, 0xae, 0x63,
-	0xd7, 0x37, 0x8e, 0xc4, 0x92, 0x9c, 0xf3, 0x3c, 0xe7, 0x39, 0xe7, 0xfa, 0x3c, 0xd7, 0x32, 0xd9,
-	0x9c, 0x1c, 0x0f, 0x7b, 0x0e, 0x1f, 0x9e, 0x38, 0x63, 0x3e, 0x60, 0x4e, 0xcf, 0x0f, 0xcc, 0xc0,
-	0x17, 0xbf, 0xdb, 0x13, 0x8f, 0x07, 0x9c, 0xaa, 0x98, 0xdc, 0x7d, 0x38, 0xb4, 0x83, 0x51, 0xd8,
-	0xdf, 0xb6, 0xf8, 0xb8, 0x37, 0xe4, 0x43, 0xde, 0x43, 0xb6, 0x1f, 0x1e, 0x61, 0x86, 0x09, 0x46,
-	0x42, 0xd5, 0xfd, 0xa5, 0x90, 0x26, 0x30, 0x3f, 0x74, 0x02, 0xfa, 0x8c, 0xb4, 0xfc, 0x70, 0x3c,
-	0x36, 0xbd, 0xd3, 0x8e, 0xb2, 0xa9, 0x6c, 0xfd, 0xff, 0x78, 0x79, 0x5b, 0xf4, 0x3f, 0x14, 0xa8,
-	0xb1, 0x72, 0x16, 0xe9, 0xb5, 0x24, 0xd2, 0xa7, 0x65, 0x30, 0x0d, 0x52, 0xe9, 0x49, 0xc8, 0x3c,
-	0x9b, 0x79, 0x9d, 0x7a, 0x49, 0xfa, 0x56, 0xa0, 0x85, 0x34, 0x2b, 0x83, 0x69, 0x40, 0x77, 0x48,
-	0xdb, 0x76, 0x87, 0xcc, 0x0f, 0x98, 0xd7, 0x69, 0xa0, 0x76, 0x25, 0xd3, 0xee, 0x67, 0xb0, 0xb1,
-	0x9a, 0x89, 0xf3, 0x42, 0xc8, 0xa3, 0xee, 0xf7, 0x06, 0x69, 0x65, 0xe7, 0xa3, 0xef, 0xc9, 0x46,
-	0xff, 0x34, 0x60, 0xfe, 0x81, 0xc7, 0x2d, 0xe6, 0xfb, 0x6c, 0x70, 0xc0, 0xbc, 0x43, 0x66, 0x71,
-	0x77, 0x80, 0x0f, 0xd4, 0x30, 0xee, 0x25, 0x91, 0x3e, 0xaf, 0x04, 0xe6, 0x11, 0x69, 0x5b, 0xc7,
-	0x76, 0x2b, 0xdb, 0xd6, 0x8b, 0xb6, 0x73, 0x4a, 0x60, 0x1e, 0x41, 0xf7, 0xc9, 0x5a, 0xc0, 0x03,
-	0xd3, 0x31, 0x4a, 0x63, 0x71, 0x07, 0x0d, 0x63, 0x23, 0x89, 0xf4, 0x2a, 0x1a, 0xaa, 0xc0, 0xbc,
-	0xd5, 0xab, 0xd2, 0xa8, 0xce, 0x7f, 0x52, 0xab, 0x32, 0x0d, 0x55, 0x20, 0xdd, 0x22, 0x6d, 0xf6,
-	0x89, 0x59, 0xef, 0xec, 0x31, 0xeb, 0xa8, 0x9b, 0xca, 0x96, 0x62, 0x2c, 0xa6, 0x9b, 0x9f, 0x62,
-	0x90, 0x47, 0xf4, 0x3e, 0x59, 0x38, 0x09, 0x59, 0xc8, 0xb0, 0xb4, 0x89, 0xa5, 0x4b, 0x49, 0xa4,
-	0x17, 0x20, 0x14, 0x61, 0xf7, 0x05, 0x69, 0x65, 0x57, 0x81, 0x3e, 0x22, 0xaa, 0x1f, 0x70, 0x8f,
-	0x65, 0x97, 0x6c, 0x71, 0x7a, 0xc9, 0x52, 0xcc, 0x58, 0xca, 0xac, 0x16, 0x25, 0x20, 0xfe, 0xba,
-	0x3f, 0xeb, 0xa4, 0x3d, 0xbd, 0x0d, 0xf4, 0x29, 0x59, 0xc4, 0x83, 0x03, 0x33, 0xad, 0x11, 0x13,
-	0xd6, 0xaa, 0xc6, 0x6a, 0x12, 0xe9, 0x25, 0x1c, 0x4a, 0x19, 0x7d, 0x49, 0x28, 0xe6, 0xbb, 0xa3,
-	0xd0, 0x3d, 0xf6, 0x5f, 0x9b, 0x01, 0x6a, 0x85, 0x7f, 0xb7, 0x93, 0x48, 0xaf, 0x60, 0xa1, 0x02,
-	0xcb, 0xa7, 0x1b, 0x98, 0xfb, 0x99, 0x5d, 0xc5, 0xf4, 0x0c, 0x87, 0x52, 0x46, 0x9f, 0x93, 0xe5,
-	0x62, 0xd9, 0x87, 0xcc, 0x0d, 0x32, 0x6f, 0x68, 0x12, 0xe9, 0x12, 0x03, 0x52, 0x5e, 0xec, 0x4b,
-	0xfd, 0xe7, 0x7d, 0x7d, 0xad, 0x13, 0x15, 0xf9, 0x7c, 0xb0, 0x78, 0x08, 0x60, 0x47, 0xd9, 0x9b,
-	0x50, 0x0c, 0xce, 0x19, 0x90, 0x72, 0xfa, 0x86, 0xac, 0xdf, 0x40, 0xf6, 0xf8, 0x47, 0xd7, 0xe1,
-	0xe6, 0x20, 0xdf, 0xda, 0x9d, 0x24, 0xd2, 0xab, 0x0b, 0xa0, 0x1a, 0x4e, 0x3d, 0xb0, 0x4a, 0x18,
-	0x5e, 0x9d, 0x46, 0xe1, 0xc1, 0x2c, 0x0b, 0x15, 0x58, 0xba, 0x11, 0x44, 0x71, 0x89, 0xc5, 0x46,
-	0x70, 0x5e, 0xb1, 0x11, 0x2c, 0x01, 0xf1, 0xd7, 0xfd, 0xd2, 0x20, 0x2a, 0xf2, 0xe9, 0x46, 0x46,
-	0xcc, 0x1c, 0x88, 0xe2, 0xf4, 0x35, 0xba, 0x69, 0x45, 0x99, 0x01, 0x29, 0x2f, 0x69, 0xd1, 0x20,
-	0xf4, 0x44, 0xd6, 0x22, 0x03, 0x52, 0x4e, 0x77, 0xc9, 0xad, 0x01, 0xb3, 0xf8, 0x78, 0xe2, 0xe1,
-	0x8b, 0x26, 0x46, 0x37, 0x51, 0xbe, 0x9e, 0x44, 0xfa, 0x2c, 0x09, 0xb3, 0x90, 0xdc, 0x44, 0x9c,
-	0xa1, 0x55, 0xdd, 0x44, 0x1c, 0x63, 0x16, 0xa2, 0x3b, 0x64, 0x45, 0x3e, 0x47, 0x1b, 0x5b, 0xac,
-	0x25, 0x91, 0x2e, 0x53, 0x20, 0x03, 0xa9, 0x1c, 0xed, 0xdd, 0x0b, 0x27, 0x8e, 0x6d, 0x99, 0xa9,
-	0x7c, 0xa1, 0x90, 0x4b, 0x14, 0xc8, 0x80, 0xd1, 0x3f, 0xbf, 0xd4, 0x6a, 0x17, 0x97, 0x5a, 0xed,
-	0xfa, 0x52, 0x53, 0x3e, 0xc7, 0x9a, 0xf2, 0x23, 0xd6, 0x94, 0xb3, 0x58, 0x53, 0xce, 0x63, 0x4d,
-	0xf9, 0x1d, 0x6b, 0xca, 0x9f, 0x58, 0xab, 0x5d, 0xc7, 0x9a, 0xf2, 0xed, 0x4a, 0xab, 0x9d, 0x5f,
-	0x69, 0xb5, 0x8b, 0x2b, 0xad, 0xf6, 0xe1, 0xc1, 0xcd, 0xaf, 0x9a, 0x67, 0x1e, 0x99, 0xae, 0xd9,
-	0x73, 0xf8, 0xb1, 0xdd, 0xab, 0xfa, 0x2c, 0xf6, 0x9b, 0xf8, 0x6d, 0x7b, 0xf2, 0x37, 0x00, 0x00,
-	0xff, 0xff, 0xf4, 0x8b, 0xe3, 0xd1, 0x35, 0x07, 0x00, 0x00,
+	// 1020 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x56, 0xbd, 0x8f, 0xdc, 0x44,
+	0x14, 0x5f, 0xaf, 0xcf, 0xbb, 0x7b, 0xc3, 0x7d, 0x65, 0x42, 0x88, 0x01, 0xc9, 0x3e, 0x99, 0xe6,
+	0x10, 0xe1, 0x56, 0x7c, 0x34, 0x20, 0x22, 0x81, 0x2f, 0x8a, 0x74, 0x12, 0x88, 0x30, 0x07, 0x0d,
+	0x9d, 0x77, 0x3d, 0xb7, 0x6b, 0x9d, 0xed, 0xd9, 0xb3, 0xc7, 0x22, 0x57, 0x41, 0x47, 0x9b, 0x3f,
+	0x82, 0x82, 0x86, 0x7f, 0x82, 0x2a, 0xe5, 0x95, 0xa9, 0x2c, 0x6e, 0xaf, 0x41, 0x16, 0x45, 0x5a,
+	0x3a, 0x34, 0x6f, 0x66, 0xfd, 0xb5, 0x3e, 0x02, 0x49, 0xb3, 0x9e, 0xf7, 0x7b, 0xbf, 0xdf, 0x9b,
+	0xd9, 0xf7, 0xde, 0x7c, 0xa0, 0xfd, 0xc5, 0xd9, 0x6c, 0x1c, 0xb2, 0xd9, 0x79, 0x18, 0x31, 0x9f,
+	0x86, 0xe3, 0x94, 0x7b, 0x3c, 0x95, 0xbf, 0x87, 0x8b, 0x84, 0x71, 0x86, 0x0d, 0x30, 0xde, 0x7a,
+	0x7f, 0x16, 0xf0, 0x79, 0x36, 0x39, 0x9c, 0xb2, 0x68, 0x3c, 0x63, 0x33, 0x36, 0x06, 0xef, 0x24,
+	0x3b, 0x05, 0x0b, 0x0c, 0x18, 0x49, 0x95, 0xf3, 0x77, 0x1f, 0x0d, 0x08, 0x4d, 0xb3, 0x90, 0xe3,
+	0x4f, 0xd0, 0x30, 0xcd, 0xa2, 0xc8, 0x4b, 0x2e, 0x4c, 0x6d, 0x5f, 0x3b, 0x78, 0xed, 0xc3, 0x9d,
+	0x43, 0x19, 0xff, 0x44, 0xa2, 0xee, 0xee, 0xd3, 0xdc, 0xee, 0x15, 0xb9, 0xbd, 0xa2, 0x91, 0xd5,
+	0x40, 0x48, 0xcf, 0x33, 0x9a, 0x04, 0x34, 0x31, 0xfb, 0x0d, 0xe9, 0x37, 0x12, 0xad, 0xa4, 0x8a,
+	0x46, 0x56, 0x03, 0x7c, 0x1f, 0x8d, 0x82, 0x78, 0x46, 0x53, 0x4e, 0x13, 0x53, 0x07, 0xed, 0xae,
+	0xd2, 0x1e, 0x2b, 0xd8, 0xdd, 0x53, 0xe2, 0x92, 0x48, 0xca, 0x11, 0xfe, 0x18, 0x0d, 0xa6, 0xde,
+	0x74, 0x4e, 0x53, 0x73, 0x03, 0xc4, 0xdb, 0x4a, 0x7c, 0x04, 0xa0, 0xbb, 0xad, 0xa4, 0x06, 0x90,
+	0x88, 0xe2, 0xe2, 0x2f, 0xd0, 0x20, 0x5d, 0x84, 0x01, 0x4f, 0x4d, 0x63, 0x5f, 0xaf, 0x4d, 0x79,
+	0x92, 0x4d, 0xc4, 0x8a, 0x2f, 0x5c, 0x53, 0xe9, 0xf6, 0x24, 0xed, 0x1e, 0x8b, 0x02, 0x4e, 0xa3,
+	0x05, 0xbf, 0x20, 0x4a, 0x08, 0x21, 0xe6, 0x5e, 0xe2, 0xa7, 0xe6, 0xe0, 0x45, 0x21, 0x80, 0xd6,
+	0x08, 0x01, 0x88, 0xf3, 0x97, 0x8e, 0x86, 0x2a, 0xb7, 0xf8, 0x3b, 0x74, 0x77, 0x72, 0xc1, 0x69,
+	0xfa, 0x28, 0x61, 0x53, 0x9a, 0xa6, 0xd4, 0x7f, 0x44, 0x93, 0x13, 0x3a, 0x65, 0xb1, 0x0f, 0xc5,
+	0xd0, 0xdd, 0xb7, 0x8b, 0xdc, 0xbe, 0x89, 0x42, 0x6e, 0x72, 0x88, 0xb0, 0x61, 0x10, 0x77, 0x86,
+	0xed, 0x57, 0x61, 0x6f, 0xa0, 0x90, 0x9b, 0x1c, 0xf8, 0x18, 0xdd, 0xe6, 0x8c, 0x7b, 0xa1, 0xdb,
+	0x98, 0x16, 0xea, 0xa7, 0xbb, 0x77, 0x8b, 0xdc, 0xee, 0x72, 0x93, 0x2e, 0xb0, 0x0c, 0xf5, 0x65,
+	0x63, 0x2a, 0x73, 0xa3, 0x15, 0xaa, 0xe9, 0x26, 0x5d, 0x20, 0x3e, 0x40, 0x23, 0xfa, 0x98, 0x4e,
+	0xbf, 0x0d, 0x22, 0x6a, 0x1a, 0xfb, 0xda, 0x81, 0xe6, 0x6e, 0x89, 0xae, 0x59, 0x61, 0xa4, 0x1c,
+	0xe1, 0xf7, 0xd0, 0xe6, 0x79, 0x46, 0x33, 0x0a, 0xd4, 0x01, 0x50, 0xb7, 0x8b, 0xdc, 0xae, 0x40,
+	0x52, 0x0d, 0xb1, 0x53, 0x36, 0xcb, 0x10, 0x16, 0x85, 0x8a, 0xdc, 0x56, 0x48, 0xd9, 0x0d, 0x4e,
+	0xd9, 0x0d, 0xa3, 0x1a, 0x07, 0x90, 0xb2, 0xdc, 0x9f, 0xa1, 0xa1, 0xda, 0x0e, 0xf8, 0x03, 0x64,
+	0xa4, 0x9c, 0x25, 0x54, 0x6d, 0xb4, 0xad, 0x55, 0xef, 0x08, 0xac, 0xea, 0x59, 0xa0, 0x10, 0xf9,
+	0x71, 0x7e, 0xeb, 0xa3, 0xd1, 0x71, 0xd5, 0xf5, 0x5b, 0x90, 0x00, 0x42, 0x45, 0x3f, 0xcb, 0x16,
+	0x31, 0xdc, 0xbd, 0x22, 0xb7, 0x1b, 0x38, 0x69, 0x58, 0xf8, 0x21, 0xc2, 0x60, 0x1f, 0xcd, 0xb3,
+	0xf8, 0x2c, 0xfd, 0xca, 0xe3, 0xa0, 0x95, 0x7d, 0xf0, 0x46, 0x91, 0xdb, 0x1d, 0x5e, 0xd2, 0x81,
+	0x95, 0xb3, 0xbb, 0x60, 0xa7, 0xaa, 0xec, 0xd5, 0xec, 0x0a, 0x27, 0x0d, 0x0b, 0x7f, 0x8a, 0x76,
+	0xaa, 0xa2, 0x9d, 0xd0, 0x98, 0xab, 0x1a, 0xe3, 0x22, 0xb7, 0x5b, 0x1e, 0xd2, 0xb2, 0xab, 0x7c,
+	0x19, 0xff, 0x39, 0x5f, 0xbf, 0xf7, 0x91, 0x01, 0xfe, 0x72, 0x62, 0xf9, 0x27, 0x08, 0x3d, 0x35,
+	0xb5, 0xd6, 0xc4, 0xa5, 0x87, 0xb4, 0x6c, 0xfc, 0x35, 0xba, 0x53, 0x43, 0x1e, 0xb0, 0x1f, 0xe2,
+	0x90, 0x79, 0x7e, 0x99, 0xb5, 0x37, 0x8b, 0xdc, 0xee, 0x26, 0x90, 0x6e, 0x58, 0xd4, 0x60, 0xda,
+	0xc0, 0xa0, 0x05, 0xf5, 0xaa, 0x06, 0xeb, 0x5e, 0xd2, 0x81, 0x89, 0x8c, 0x00, 0x6a, 0x6e, 0x34,
+	0x32, 0x02, 0xf3, 0xd5, 0x4e, 0x3d, 0x61, 0x12, 0xf9, 0x11, 0x92, 0x20, 0xf6, 0xe9, 0xe3, 0x56,
+	0x12, 0x8f, 0x05, 0x56, 0x49, 0x80, 0x42, 0xe4, 0xc7, 0xf9, 0x59, 0x47, 0x06, 0x84, 0x14, 0x49,
+	0x9c, 0x53, 0xcf, 0x97, 0xf1, 0xc5, 0x0e, 0xae, 0x57, 0xaf, 0xe9, 0x21, 0x2d, 0xbb, 0xa1, 0x85,
+	0x9a, 0x9a, 0x46, 0x87, 0x16, 0x3c, 0xa4, 0x65, 0xe3, 0x23, 0x74, 0xcb, 0xa7, 0x53, 0x16, 0x2d,
+	0x12, 0xd8, 0xe3, 0x72, 0xea, 0x01, 0xc8, 0xef, 0x14, 0xb9, 0xbd, 0xee, 0x24, 0xeb, 0x50, 0x3b,
+	0x88, 0x5c, 0xc3, 0xb0, 0x3b, 0x88, 0x5c, 0xc6, 0x3a, 0x84, 0xef, 0xa3, 0xdd, 0xf6, 0x3a, 0xe4,
+	0x5e, 0xbf, 0x5d, 0xe4, 0x76, 0xdb, 0x45, 0xda, 0x80, 0x90, 0x43, 0x47, 0x3c, 0xc8, 0x16, 0x61,
+	0x30, 0xf5, 0x84, 0x7c, 0xb3, 0x92, 0xb7, 0x5c, 0xa4, 0x0d, 0x38, 0x11, 0x32, 0xa0, 0x50, 0xe5,
+	0xe6, 0x93, 0x47, 0x49, 0x6a, 0x6a, 0xad, 0xcd, 0xa7, 0x70, 0xd2, 0xb0, 0xf0, 0x21, 0x42, 0x21,
+	0x63, 0x67, 0xd9, 0x02, 0xda, 0x4d, 0x36, 0xef, 0x4e, 0x91, 0xdb, 0x35, 0x94, 0xd4, 0xc6, 0xce,
+	0x2f, 0x1a, 0x1a, 0xc8, 0x2b, 0xb4, 0xea, 0xb4, 0xe6, 0x59, 0x05, 0xde, 0x17, 0x75, 0x5a, 0xff,
+	0xdf, 0x24, 0xf5, 0x4e, 0x13, 0xf7, 0x78, 0x02, 0xcf, 0x10, 0x53, 0xef, 0xd0, 0xec, 0x28, 0x8d,
+	0xe2, 0x10, 0xf5, 0x75, 0x7e, 0x44, 0x06, 0x10, 0xf0, 0xe7, 0x68, 0x8f, 0xc6, 0x1c, 0xfe, 0x38,
+	0x3d, 0xcf, 0x68, 0xca, 0xe9, 0xea, 0xde, 0x7c, 0x5d, 0x5c, 0xc1, 0x6d, 0x1f, 0x59, 0x43, 0x44,
+	0x5e, 0x15, 0xf6, 0x90, 0x65, 0xe5, 0xf5, 0x08, 0x79, 0xad, 0xe3, 0xa4, 0x61, 0x39, 0x4f, 0x74,
+	0x34, 0x5a, 0xdd, 0xf8, 0xf8, 0x5d, 0x71, 0x4a, 0x79, 0x09, 0x37, 0xb5, 0xaa, 0xb0, 0x00, 0xd4,
+	0xee, 0x7e, 0xc9, 0xc0, 0xef, 0x20, 0x9d, 0x96, 0x93, 0xdc, 0x2a, 0x72, 0x7b, 0x9b, 0xc6, 0x7e,
+	0x8d, 0x26, 0xbc, 0x10, 0x4f, 0x5c, 0x1d, 0x90, 0x92, 0x4d, 0x15, 0x4f, 0x00, 0x8d, 0x78, 0x02,
+	0xa8, 0xbf, 0xdd, 0x36, 0xfe, 0xe7, 0xdb, 0x6d, 0xfd, 0x78, 0x34, 0x5e, 0xfd, 0x78, 0x1c, 0xbc,
+	0xe4, 0xf1, 0x58, 0xbd, 0xaa, 0x86, 0x2f, 0xf9, 0xaa, 0x72, 0x27, 0x97, 0x57, 0x56, 0xef, 0xd9,
+	0x95, 0xd5, 0x7b, 0x7e, 0x65, 0x69, 0x3f, 0x2d, 0x2d, 0xed, 0xd7, 0xa5, 0xa5, 0x3d, 0x5d, 0x5a,
+	0xda, 0xe5, 0xd2, 0xd2, 0xfe, 0x58, 0x5a, 0xda, 0x9f, 0x4b, 0xab, 0xf7, 0x7c, 0x69, 0x69, 0x4f,
+	0xae, 0xad, 0xde, 0xe5, 0xb5, 0xd5, 0x7b, 0x76, 0x6d, 0xf5, 0xbe, 0xbf, 0x57, 0x7f, 0x36, 0x27,
+	0xde, 0xa9, 0x17, 0x7b, 0xe3, 0x90, 0x9d, 0x05, 0xe3, 0xae, 0x77, 0xf7, 0x64, 0x00, 0x8f, 0xe7,
+	0x8f, 0xfe, 0x19, 0x00, 0x12, 0xaf, 0x5e, 0xc2, 0x96, 0x0b, 0x00, 0x00,
 }
 
 func (this *Result) Equal(that interface{}) bool {
@@ -549,6 +888,25 @@ func (this *Result) Equal(that interface{}) bool {
 	if !this.Ingester.Equal(&that1.Ingester) {
 		return false
 	}
+	if !this.Caches.Equal(&that1.Caches) {
+		return false
+	}
+	if len(this.Splits) != len(that1.Splits) {
+		return false
+	}
+	for i := range this.Splits {
+		if !this.Splits[i].Equal(&that1.Splits[i]) {
+			return false
+		}
+	}
+	if len(this.Shards) != len(that1.Shards) {
+		return false
+	}
+	for i := range this.Shards {
+		if !this.Shards[i].Equal(&that1.Shards[i]) {
+			return false
+		}
+	}
 	return true
 }
 func (this *Summary) Equal(that interface{}) bool {
@@ -588,6 +946,12 @@ func (this *Summary) Equal(that interface{}) bool {
 	if this.QueueTime != that1.QueueTime {
 		return false
 	}
+	if this.Splits != that1.Splits {
+		return false
+	}
+	if this.Shards != that1.Shards {
+		return false
+	}
 	return true
 }
 func (this *Querier) Equal(that interface{}) bool {
@@ -681,6 +1045,9 @@ func (this *Store) Equal(that interface{}) bool {
 	if !this.Chunk.Equal(&that1.Chunk) {
 		return false
 	}
+	if !this.Index.Equal(&that1.Index) {
+		return false
+	}
 	return true
 }
 func (this *Chunk) Equal(that interface{}) bool {
@@ -722,50 +1089,198 @@ func (this *Chunk) Equal(that interface{}) bool {
 	}
 	return true
 }
-func (this *Result) GoString() string {
-	if this == nil {
-		return "nil"
+func (this *Index) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
 	}
-	s := make([]string, 0, 7)
-	s = append(s, "&stats.Result{")
-	s = append(s, "Summary: "+strings.Replace(this.Summary.GoString(), `&`, ``, 1)+",\n")
-	s = append(s, "Querier: "+strings.Replace(this.Querier.GoString(), `&`, ``, 1)+",\n")
-	s = append(s, "Ingester: "+strings.Replace(this.Ingester.GoString(), `&`, ``, 1)+",\n")
-	s = append(s, "}")
-	return strings.Join(s, "")
-}
-func (this *Summary) GoString() string {
-	if this == nil {
-		return "nil"
+
+	that1, ok := that.(*Index)
+	if !ok {
+		that2, ok := that.(Index)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
 	}
-	s := make([]string, 0, 10)
-	s = append(s, "&stats.Summary{")
-	s = append(s, "BytesProcessedPerSecond: "+fmt.Sprintf("%#v", this.BytesProcessedPerSecond)+",\n")
-	s = append(s, "LinesProcessedPerSecond: "+fmt.Sprintf("%#v", this.LinesProcessedPerSecond)+",\n")
-	s = append(s, "TotalBytesProcessed: "+fmt.Sprintf("%#v", this.TotalBytesProcessed)+",\n")
-	s = append(s, "TotalLinesProcessed: "+fmt.Sprintf("%#v", this.TotalLinesProcessed)+",\n")
-	s = append(s, "ExecTime: "+fmt.Sprintf("%#v", this.ExecTime)+",\n")
-	s = append(s, "QueueTime: "+fmt.Sprintf("%#v", this.QueueTime)+",\n")
-	s = append(s, "}")
-	return strings.Join(s, "")
-}
-func (this *Querier) GoString() string {
-	if this == nil {
-		return "nil"
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	s := make([]string, 0, 5)
-	s = append(s, "&stats.Querier{")
-	s = append(s, "Store: "+strings.Replace(this.Store.GoString(), `&`, ``, 1)+",\n")
-	s = append(s, "}")
-	return strings.Join(s, "")
+	if this.TotalQueries != that1.TotalQueries {
+		return false
+	}
+	if this.LookupTime != that1.LookupTime {
+		return false
+	}
+	return true
 }
-func (this *Ingester) GoString() string {
-	if this == nil {
-		return "nil"
+func (this *Caches) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
 	}
-	s := make([]string, 0, 9)
-	s = append(s, "&stats.Ingester{")
-	s = append(s, "TotalReached: "+fmt.Sprintf("%#v", this.TotalReached)+",\n")
+
+	that1, ok := that.(*Caches)
+	if !ok {
+		that2, ok := that.(Caches)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if !this.Chunk.Equal(&that1.Chunk) {
+		return false
+	}
+	if !this.Index.Equal(&that1.Index) {
+		return false
+	}
+	if !this.Result.Equal(&that1.Result) {
+		return false
+	}
+	return true
+}
+func (this *Cache) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*Cache)
+	if !ok {
+		that2, ok := that.(Cache)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.EntriesRequested != that1.EntriesRequested {
+		return false
+	}
+	if this.EntriesFound != that1.EntriesFound {
+		return false
+	}
+	return true
+}
+func (this *SubQuery) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*SubQuery)
+	if !ok {
+		that2, ok := that.(SubQuery)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Start != that1.Start {
+		return false
+	}
+	if this.End != that1.End {
+		return false
+	}
+	if this.Shard != that1.Shard {
+		return false
+	}
+	if !this.Summary.Equal(&that1.Summary) {
+		return false
+	}
+	if this.TotalChunksRef != that1.TotalChunksRef {
+		return false
+	}
+	if this.TotalChunksDownloaded != that1.TotalChunksDownloaded {
+		return false
+	}
+	if len(this.Shards) != len(that1.Shards) {
+		return false
+	}
+	for i := range this.Shards {
+		if !this.Shards[i].Equal(&that1.Shards[i]) {
+			return false
+		}
+	}
+	return true
+}
+func (this *Result) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 10)
+	s = append(s, "&stats.Result{")
+	s = append(s, "Summary: "+strings.Replace(this.Summary.GoString(), `&`, ``, 1)+",\n")
+	s = append(s, "Querier: "+strings.Replace(this.Querier.GoString(), `&`, ``, 1)+",\n")
+	s = append(s, "Ingester: "+strings.Replace(this.Ingester.GoString(), `&`, ``, 1)+",\n")
+	s = append(s, "Caches: "+strings.Replace(this.Caches.GoString(), `&`, ``, 1)+",\n")
+	if this.Splits != nil {
+		vs := make([]SubQuery, len(this.Splits))
+		for i := range vs {
+			vs[i] = this.Splits[i]
+		}
+		s = append(s, "Splits: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	if this.Shards != nil {
+		vs := make([]SubQuery, len(this.Shards))
+		for i := range vs {
+			vs[i] = this.Shards[i]
+		}
+		s = append(s, "Shards: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *Summary) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 12)
+	s = append(s, "&stats.Summary{")
+	s = append(s, "BytesProcessedPerSecond: "+fmt.Sprintf("%#v", this.BytesProcessedPerSecond)+",\n")
+	s = append(s, "LinesProcessedPerSecond: "+fmt.Sprintf("%#v", this.LinesProcessedPerSecond)+",\n")
+	s = append(s, "TotalBytesProcessed: "+fmt.Sprintf("%#v", this.TotalBytesProcessed)+",\n")
+	s = append(s, "TotalLinesProcessed: "+fmt.Sprintf("%#v", this.TotalLinesProcessed)+",\n")
+	s = append(s, "ExecTime: "+fmt.Sprintf("%#v", this.ExecTime)+",\n")
+	s = append(s, "QueueTime: "+fmt.Sprintf("%#v", this.QueueTime)+",\n")
+	s = append(s, "Splits: "+fmt.Sprintf("%#v", this.Splits)+",\n")
+	s = append(s, "Shards: "+fmt.Sprintf("%#v", this.Shards)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *Querier) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&stats.Querier{")
+	s = append(s, "Store: "+strings.Replace(this.Store.GoString(), `&`, ``, 1)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *Ingester) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 9)
+	s = append(s, "&stats.Ingester{")
+	s = append(s, "TotalReached: "+fmt.Sprintf("%#v", this.TotalReached)+",\n")
 	s = append(s, "TotalChunksMatched: "+fmt.Sprintf("%#v", this.TotalChunksMatched)+",\n")
 	s = append(s, "TotalBatches: "+fmt.Sprintf("%#v", this.TotalBatches)+",\n")
 	s = append(s, "TotalLinesSent: "+fmt.Sprintf("%#v", this.TotalLinesSent)+",\n")
@@ -777,12 +1292,13 @@ func (this *Store) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 8)
+	s := make([]string, 0, 9)
 	s = append(s, "&stats.Store{")
 	s = append(s, "TotalChunksRef: "+fmt.Sprintf("%#v", this.TotalChunksRef)+",\n")
 	s = append(s, "TotalChunksDownloaded: "+fmt.Sprintf("%#v", this.TotalChunksDownloaded)+",\n")
 	s = append(s, "ChunksDownloadTime: "+fmt.Sprintf("%#v", this.ChunksDownloadTime)+",\n")
 	s = append(s, "Chunk: "+strings.Replace(this.Chunk.GoString(), `&`, ``, 1)+",\n")
+	s = append(s, "Index: "+strings.Replace(this.Index.GoString(), `&`, ``, 1)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -801,6 +1317,62 @@ func (this *Chunk) GoString() string {
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
+func (this *Index) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&stats.Index{")
+	s = append(s, "TotalQueries: "+fmt.Sprintf("%#v", this.TotalQueries)+",\n")
+	s = append(s, "LookupTime: "+fmt.Sprintf("%#v", this.LookupTime)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *Caches) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 7)
+	s = append(s, "&stats.Caches{")
+	s = append(s, "Chunk: "+strings.Replace(this.Chunk.GoString(), `&`, ``, 1)+",\n")
+	s = append(s, "Index: "+strings.Replace(this.Index.GoString(), `&`, ``, 1)+",\n")
+	s = append(s, "Result: "+strings.Replace(this.Result.GoString(), `&`, ``, 1)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *Cache) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&stats.Cache{")
+	s = append(s, "EntriesRequested: "+fmt.Sprintf("%#v", this.EntriesRequested)+",\n")
+	s = append(s, "EntriesFound: "+fmt.Sprintf("%#v", this.EntriesFound)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *SubQuery) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 11)
+	s = append(s, "&stats.SubQuery{")
+	s = append(s, "Start: "+fmt.Sprintf("%#v", this.Start)+",\n")
+	s = append(s, "End: "+fmt.Sprintf("%#v", this.End)+",\n")
+	s = append(s, "Shard: "+fmt.Sprintf("%#v", this.Shard)+",\n")
+	s = append(s, "Summary: "+strings.Replace(this.Summary.GoString(), `&`, ``, 1)+",\n")
+	s = append(s, "TotalChunksRef: "+fmt.Sprintf("%#v", this.TotalChunksRef)+",\n")
+	s = append(s, "TotalChunksDownloaded: "+fmt.Sprintf("%#v", this.TotalChunksDownloaded)+",\n")
+	if this.Shards != nil {
+		vs := make([]SubQuery, len(this.Shards))
+		for i := range vs {
+			vs[i] = this.Shards[i]
+		}
+		s = append(s, "Shards: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
 func valueToGoStringStats(v interface{}, typ string) string {
 	rv := reflect.ValueOf(v)
 	if rv.IsNil() {
@@ -829,6 +1401,44 @@ func (m *Result) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.Shards) > 0 {
+		for iNdEx := len(m.Shards) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Shards[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintStats(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x32
+		}
+	}
+	if len(m.Splits) > 0 {
+		for iNdEx := len(m.Splits) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Splits[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintStats(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x2a
+		}
+	}
+	{
+		size, err := m.Caches.MarshalToSizedBuffer(dAtA[:i])
+		if err != nil {
+			return 0, err
+		}
+		i -= size
+		i = encodeVarintStats(dAtA, i, uint64(size))
+	}
+	i--
+	dAtA[i] = 0x22
 	{
 		size, err := m.Ingester.MarshalToSizedBuffer(dAtA[:i])
 		if err != nil {
@@ -882,6 +1492,16 @@ func (m *Summary) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.Shards != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.Shards))
+		i--
+		dAtA[i] = 0x40
+	}
+	if m.Splits != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.Splits))
+		i--
+		dAtA[i] = 0x38
+	}
 	if m.QueueTime != 0 {
 		i -= 8
 		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.QueueTime))))
@@ -1023,6 +1643,16 @@ func (m *Store) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	{
+		size, err := m.Index.MarshalToSizedBuffer(dAtA[:i])
+		if err != nil {
+			return 0, err
+		}
+		i -= size
+		i = encodeVarintStats(dAtA, i, uint64(size))
+	}
+	i--
+	dAtA[i] = 0x2a
 	{
 		size, err := m.Chunk.MarshalToSizedBuffer(dAtA[:i])
 		if err != nil {
@@ -1104,57 +1734,270 @@ func (m *Chunk) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
-func encodeVarintStats(dAtA []byte, offset int, v uint64) int {
-	offset -= sovStats(v)
-	base := offset
-	for v >= 1<<7 {
-		dAtA[offset] = uint8(v&0x7f | 0x80)
-		v >>= 7
-		offset++
+func (m *Index) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
-	dAtA[offset] = uint8(v)
-	return base
+	return dAtA[:n], nil
 }
-func (m *Result) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	l = m.Summary.Size()
-	n += 1 + l + sovStats(uint64(l))
-	l = m.Querier.Size()
-	n += 1 + l + sovStats(uint64(l))
-	l = m.Ingester.Size()
-	n += 1 + l + sovStats(uint64(l))
-	return n
+
+func (m *Index) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
 }
 
-func (m *Summary) Size() (n int) {
-	if m == nil {
-		return 0
-	}
+func (m *Index) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
 	var l int
 	_ = l
-	if m.BytesProcessedPerSecond != 0 {
-		n += 1 + sovStats(uint64(m.BytesProcessedPerSecond))
-	}
-	if m.LinesProcessedPerSecond != 0 {
-		n += 1 + sovStats(uint64(m.LinesProcessedPerSecond))
-	}
-	if m.TotalBytesProcessed != 0 {
-		n += 1 + sovStats(uint64(m.TotalBytesProcessed))
-	}
-	if m.TotalLinesProcessed != 0 {
-		n += 1 + sovStats(uint64(m.TotalLinesProcessed))
-	}
-	if m.ExecTime != 0 {
-		n += 9
+	if m.LookupTime != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.LookupTime))
+		i--
+		dAtA[i] = 0x10
 	}
-	if m.QueueTime != 0 {
-		n += 9
+	if m.TotalQueries != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.TotalQueries))
+		i--
+		dAtA[i] = 0x8
 	}
-	return n
+	return len(dAtA) - i, nil
+}
+
+func (m *Caches) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Caches) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Caches) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	{
+		size, err := m.Result.MarshalToSizedBuffer(dAtA[:i])
+		if err != nil {
+			return 0, err
+		}
+		i -= size
+		i = encodeVarintStats(dAtA, i, uint64(size))
+	}
+	i--
+	dAtA[i] = 0x1a
+	{
+		size, err := m.Index.MarshalToSizedBuffer(dAtA[:i])
+		if err != nil {
+			return 0, err
+		}
+		i -= size
+		i = encodeVarintStats(dAtA, i, uint64(size))
+	}
+	i--
+	dAtA[i] = 0x12
+	{
+		size, err := m.Chunk.MarshalToSizedBuffer(dAtA[:i])
+		if err != nil {
+			return 0, err
+		}
+		i -= size
+		i = encodeVarintStats(dAtA, i, uint64(size))
+	}
+	i--
+	dAtA[i] = 0xa
+	return len(dAtA) - i, nil
+}
+
+func (m *Cache) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Cache) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Cache) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.EntriesFound != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.EntriesFound))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.EntriesRequested != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.EntriesRequested))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *SubQuery) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *SubQuery) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *SubQuery) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Shards) > 0 {
+		for iNdEx := len(m.Shards) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Shards[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintStats(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x3a
+		}
+	}
+	if m.TotalChunksDownloaded != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.TotalChunksDownloaded))
+		i--
+		dAtA[i] = 0x30
+	}
+	if m.TotalChunksRef != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.TotalChunksRef))
+		i--
+		dAtA[i] = 0x28
+	}
+	{
+		size, err := m.Summary.MarshalToSizedBuffer(dAtA[:i])
+		if err != nil {
+			return 0, err
+		}
+		i -= size
+		i = encodeVarintStats(dAtA, i, uint64(size))
+	}
+	i--
+	dAtA[i] = 0x22
+	if len(m.Shard) > 0 {
+		i -= len(m.Shard)
+		copy(dAtA[i:], m.Shard)
+		i = encodeVarintStats(dAtA, i, uint64(len(m.Shard)))
+		i--
+		dAtA[i] = 0x1a
+	}
+	if m.End != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.End))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.Start != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.Start))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintStats(dAtA []byte, offset int, v uint64) int {
+	offset -= sovStats(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *Result) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = m.Summary.Size()
+	n += 1 + l + sovStats(uint64(l))
+	l = m.Querier.Size()
+	n += 1 + l + sovStats(uint64(l))
+	l = m.Ingester.Size()
+	n += 1 + l + sovStats(uint64(l))
+	l = m.Caches.Size()
+	n += 1 + l + sovStats(uint64(l))
+	if len(m.Splits) > 0 {
+		for _, e := range m.Splits {
+			l = e.Size()
+			n += 1 + l + sovStats(uint64(l))
+		}
+	}
+	if len(m.Shards) > 0 {
+		for _, e := range m.Shards {
+			l = e.Size()
+			n += 1 + l + sovStats(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *Summary) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.BytesProcessedPerSecond != 0 {
+		n += 1 + sovStats(uint64(m.BytesProcessedPerSecond))
+	}
+	if m.LinesProcessedPerSecond != 0 {
+		n += 1 + sovStats(uint64(m.LinesProcessedPerSecond))
+	}
+	if m.TotalBytesProcessed != 0 {
+		n += 1 + sovStats(uint64(m.TotalBytesProcessed))
+	}
+	if m.TotalLinesProcessed != 0 {
+		n += 1 + sovStats(uint64(m.TotalLinesProcessed))
+	}
+	if m.ExecTime != 0 {
+		n += 9
+	}
+	if m.QueueTime != 0 {
+		n += 9
+	}
+	if m.Splits != 0 {
+		n += 1 + sovStats(uint64(m.Splits))
+	}
+	if m.Shards != 0 {
+		n += 1 + sovStats(uint64(m.Shards))
+	}
+	return n
 }
 
 func (m *Querier) Size() (n int) {
@@ -1208,6 +2051,8 @@ func (m *Store) Size() (n int) {
 	}
 	l = m.Chunk.Size()
 	n += 1 + l + sovStats(uint64(l))
+	l = m.Index.Size()
+	n += 1 + l + sovStats(uint64(l))
 	return n
 }
 
@@ -1238,6 +2083,84 @@ func (m *Chunk) Size() (n int) {
 	return n
 }
 
+func (m *Index) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.TotalQueries != 0 {
+		n += 1 + sovStats(uint64(m.TotalQueries))
+	}
+	if m.LookupTime != 0 {
+		n += 1 + sovStats(uint64(m.LookupTime))
+	}
+	return n
+}
+
+func (m *Caches) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = m.Chunk.Size()
+	n += 1 + l + sovStats(uint64(l))
+	l = m.Index.Size()
+	n += 1 + l + sovStats(uint64(l))
+	l = m.Result.Size()
+	n += 1 + l + sovStats(uint64(l))
+	return n
+}
+
+func (m *Cache) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.EntriesRequested != 0 {
+		n += 1 + sovStats(uint64(m.EntriesRequested))
+	}
+	if m.EntriesFound != 0 {
+		n += 1 + sovStats(uint64(m.EntriesFound))
+	}
+	return n
+}
+
+func (m *SubQuery) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Start != 0 {
+		n += 1 + sovStats(uint64(m.Start))
+	}
+	if m.End != 0 {
+		n += 1 + sovStats(uint64(m.End))
+	}
+	l = len(m.Shard)
+	if l > 0 {
+		n += 1 + l + sovStats(uint64(l))
+	}
+	l = m.Summary.Size()
+	n += 1 + l + sovStats(uint64(l))
+	if m.TotalChunksRef != 0 {
+		n += 1 + sovStats(uint64(m.TotalChunksRef))
+	}
+	if m.TotalChunksDownloaded != 0 {
+		n += 1 + sovStats(uint64(m.TotalChunksDownloaded))
+	}
+	if len(m.Shards) > 0 {
+		for _, e := range m.Shards {
+			l = e.Size()
+			n += 1 + l + sovStats(uint64(l))
+		}
+	}
+	return n
+}
+
 func sovStats(x uint64) (n int) {
 	return (math_bits.Len64(x|1) + 6) / 7
 }
@@ -1248,10 +2171,23 @@ func (this *Result) String() string {
 	if this == nil {
 		return "nil"
 	}
+	repeatedStringForSplits := "[]SubQuery{"
+	for _, f := range this.Splits {
+		repeatedStringForSplits += strings.Replace(strings.Replace(f.String(), "SubQuery", "SubQuery", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForSplits += "}"
+	repeatedStringForShards := "[]SubQuery{"
+	for _, f := range this.Shards {
+		repeatedStringForShards += strings.Replace(strings.Replace(f.String(), "SubQuery", "SubQuery", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForShards += "}"
 	s := strings.Join([]string{`&Result{`,
 		`Summary:` + strings.Replace(strings.Replace(this.Summary.String(), "Summary", "Summary", 1), `&`, ``, 1) + `,`,
 		`Querier:` + strings.Replace(strings.Replace(this.Querier.String(), "Querier", "Querier", 1), `&`, ``, 1) + `,`,
 		`Ingester:` + strings.Replace(strings.Replace(this.Ingester.String(), "Ingester", "Ingester", 1), `&`, ``, 1) + `,`,
+		`Caches:` + strings.Replace(strings.Replace(this.Caches.String(), "Caches", "Caches", 1), `&`, ``, 1) + `,`,
+		`Splits:` + repeatedStringForSplits + `,`,
+		`Shards:` + repeatedStringForShards + `,`,
 		`}`,
 	}, "")
 	return s
@@ -1267,6 +2203,8 @@ func (this *Summary) String() string {
 		`TotalLinesProcessed:` + fmt.Sprintf("%v", this.TotalLinesProcessed) + `,`,
 		`ExecTime:` + fmt.Sprintf("%v", this.ExecTime) + `,`,
 		`QueueTime:` + fmt.Sprintf("%v", this.QueueTime) + `,`,
+		`Splits:` + fmt.Sprintf("%v", this.Splits) + `,`,
+		`Shards:` + fmt.Sprintf("%v", this.Shards) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -1304,6 +2242,7 @@ func (this *Store) String() string {
 		`TotalChunksDownloaded:` + fmt.Sprintf("%v", this.TotalChunksDownloaded) + `,`,
 		`ChunksDownloadTime:` + fmt.Sprintf("%v", this.ChunksDownloadTime) + `,`,
 		`Chunk:` + strings.Replace(strings.Replace(this.Chunk.String(), "Chunk", "Chunk", 1), `&`, ``, 1) + `,`,
+		`Index:` + strings.Replace(strings.Replace(this.Index.String(), "Index", "Index", 1), `&`, ``, 1) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -1312,26 +2251,771 @@ func (this *Chunk) String() string {
 	if this == nil {
 		return "nil"
 	}
-	s := strings.Join([]string{`&Chunk{`,
-		`HeadChunkBytes:` + fmt.Sprintf("%v", this.HeadChunkBytes) + `,`,
-		`HeadChunkLines:` + fmt.Sprintf("%v", this.HeadChunkLines) + `,`,
-		`DecompressedBytes:` + fmt.Sprintf("%v", this.DecompressedBytes) + `,`,
-		`DecompressedLines:` + fmt.Sprintf("%v", this.DecompressedLines) + `,`,
-		`CompressedBytes:` + fmt.Sprintf("%v", this.CompressedBytes) + `,`,
-		`TotalDuplicates:` + fmt.Sprintf("%v", this.TotalDuplicates) + `,`,
-		`}`,
-	}, "")
-	return s
-}
-func valueToStringStats(v interface{}) string {
-	rv := reflect.ValueOf(v)
-	if rv.IsNil() {
-		return "nil"
+	s := strings.Join([]string{`&Chunk{`,
+		`HeadChunkBytes:` + fmt.Sprintf("%v", this.HeadChunkBytes) + `,`,
+		`HeadChunkLines:` + fmt.Sprintf("%v", this.HeadChunkLines) + `,`,
+		`DecompressedBytes:` + fmt.Sprintf("%v", this.DecompressedBytes) + `,`,
+		`DecompressedLines:` + fmt.Sprintf("%v", this.DecompressedLines) + `,`,
+		`CompressedBytes:` + fmt.Sprintf("%v", this.CompressedBytes) + `,`,
+		`TotalDuplicates:` + fmt.Sprintf("%v", this.TotalDuplicates) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *Index) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&Index{`,
+		`TotalQueries:` + fmt.Sprintf("%v", this.TotalQueries) + `,`,
+		`LookupTime:` + fmt.Sprintf("%v", this.LookupTime) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *Caches) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&Caches{`,
+		`Chunk:` + strings.Replace(strings.Replace(this.Chunk.String(), "Cache", "Cache", 1), `&`, ``, 1) + `,`,
+		`Index:` + strings.Replace(strings.Replace(this.Index.String(), "Cache", "Cache", 1), `&`, ``, 1) + `,`,
+		`Result:` + strings.Replace(strings.Replace(this.Result.String(), "Cache", "Cache", 1), `&`, ``, 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *Cache) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&Cache{`,
+		`EntriesRequested:` + fmt.Sprintf("%v", this.EntriesRequested) + `,`,
+		`EntriesFound:` + fmt.Sprintf("%v", this.EntriesFound) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *SubQuery) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForShards := "[]SubQuery{"
+	for _, f := range this.Shards {
+		repeatedStringForShards += strings.Replace(strings.Replace(f.String(), "SubQuery", "SubQuery", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForShards += "}"
+	s := strings.Join([]string{`&SubQuery{`,
+		`Start:` + fmt.Sprintf("%v", this.Start) + `,`,
+		`End:` + fmt.Sprintf("%v", this.End) + `,`,
+		`Shard:` + fmt.Sprintf("%v", this.Shard) + `,`,
+		`Summary:` + strings.Replace(strings.Replace(this.Summary.String(), "Summary", "Summary", 1), `&`, ``, 1) + `,`,
+		`TotalChunksRef:` + fmt.Sprintf("%v", this.TotalChunksRef) + `,`,
+		`TotalChunksDownloaded:` + fmt.Sprintf("%v", this.TotalChunksDownloaded) + `,`,
+		`Shards:` + repeatedStringForShards + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func valueToStringStats(v interface{}) string {
+	rv := reflect.ValueOf(v)
+	if rv.IsNil() {
+		return "nil"
+	}
+	pv := reflect.Indirect(rv).Interface()
+	return fmt.Sprintf("*%v", pv)
+}
+func (m *Result) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowStats
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Result: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Result: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Summary", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthStats
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthStats
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := m.Summary.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Querier", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthStats
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthStats
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := m.Querier.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Ingester", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthStats
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthStats
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := m.Ingester.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Caches", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthStats
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthStats
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := m.Caches.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Splits", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthStats
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthStats
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Splits = append(m.Splits, SubQuery{})
+			if err := m.Splits[len(m.Splits)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Shards", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthStats
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthStats
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Shards = append(m.Shards, SubQuery{})
+			if err := m.Shards[len(m.Shards)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipStats(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthStats
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthStats
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Summary) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowStats
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Summary: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Summary: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BytesProcessedPerSecond", wireType)
+			}
+			m.BytesProcessedPerSecond = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.BytesProcessedPerSecond |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LinesProcessedPerSecond", wireType)
+			}
+			m.LinesProcessedPerSecond = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.LinesProcessedPerSecond |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TotalBytesProcessed", wireType)
+			}
+			m.TotalBytesProcessed = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.TotalBytesProcessed |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TotalLinesProcessed", wireType)
+			}
+			m.TotalLinesProcessed = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.TotalLinesProcessed |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ExecTime", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.ExecTime = float64(math.Float64frombits(v))
+		case 6:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field QueueTime", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.QueueTime = float64(math.Float64frombits(v))
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Splits", wireType)
+			}
+			m.Splits = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Splits |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Shards", wireType)
+			}
+			m.Shards = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Shards |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipStats(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthStats
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthStats
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Querier) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowStats
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Querier: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Querier: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Store", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthStats
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthStats
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := m.Store.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipStats(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthStats
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthStats
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Ingester) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowStats
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Ingester: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Ingester: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TotalReached", wireType)
+			}
+			m.TotalReached = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.TotalReached |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TotalChunksMatched", wireType)
+			}
+			m.TotalChunksMatched = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.TotalChunksMatched |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TotalBatches", wireType)
+			}
+			m.TotalBatches = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.TotalBatches |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TotalLinesSent", wireType)
+			}
+			m.TotalLinesSent = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.TotalLinesSent |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Store", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthStats
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthStats
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := m.Store.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipStats(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthStats
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthStats
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
 	}
-	pv := reflect.Indirect(rv).Interface()
-	return fmt.Sprintf("*%v", pv)
+	return nil
 }
-func (m *Result) Unmarshal(dAtA []byte) error {
+func (m *Store) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1354,17 +3038,17 @@ func (m *Result) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: Result: wiretype end group for non-group")
+			return fmt.Errorf("proto: Store: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Result: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: Store: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Summary", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TotalChunksRef", wireType)
 			}
-			var msglen int
+			m.TotalChunksRef = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowStats
@@ -1374,28 +3058,52 @@ func (m *Result) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				m.TotalChunksRef |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthStats
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TotalChunksDownloaded", wireType)
 			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthStats
+			m.TotalChunksDownloaded = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.TotalChunksDownloaded |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ChunksDownloadTime", wireType)
 			}
-			if err := m.Summary.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			m.ChunksDownloadTime = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ChunksDownloadTime |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			iNdEx = postIndex
-		case 2:
+		case 4:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Querier", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Chunk", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -1422,13 +3130,13 @@ func (m *Result) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if err := m.Querier.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if err := m.Chunk.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
-		case 3:
+		case 5:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Ingester", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Index", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -1455,7 +3163,7 @@ func (m *Result) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if err := m.Ingester.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if err := m.Index.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
@@ -1483,7 +3191,7 @@ func (m *Result) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *Summary) Unmarshal(dAtA []byte) error {
+func (m *Chunk) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1506,17 +3214,17 @@ func (m *Summary) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: Summary: wiretype end group for non-group")
+			return fmt.Errorf("proto: Chunk: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Summary: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: Chunk: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
-		case 1:
+		case 4:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field BytesProcessedPerSecond", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field HeadChunkBytes", wireType)
 			}
-			m.BytesProcessedPerSecond = 0
+			m.HeadChunkBytes = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowStats
@@ -1526,16 +3234,16 @@ func (m *Summary) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.BytesProcessedPerSecond |= int64(b&0x7F) << shift
+				m.HeadChunkBytes |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 2:
+		case 5:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field LinesProcessedPerSecond", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field HeadChunkLines", wireType)
 			}
-			m.LinesProcessedPerSecond = 0
+			m.HeadChunkLines = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowStats
@@ -1545,16 +3253,16 @@ func (m *Summary) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.LinesProcessedPerSecond |= int64(b&0x7F) << shift
+				m.HeadChunkLines |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 3:
+		case 6:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field TotalBytesProcessed", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field DecompressedBytes", wireType)
 			}
-			m.TotalBytesProcessed = 0
+			m.DecompressedBytes = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowStats
@@ -1564,16 +3272,16 @@ func (m *Summary) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.TotalBytesProcessed |= int64(b&0x7F) << shift
+				m.DecompressedBytes |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 4:
+		case 7:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field TotalLinesProcessed", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field DecompressedLines", wireType)
 			}
-			m.TotalLinesProcessed = 0
+			m.DecompressedLines = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowStats
@@ -1583,33 +3291,49 @@ func (m *Summary) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.TotalLinesProcessed |= int64(b&0x7F) << shift
+				m.DecompressedLines |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 5:
-			if wireType != 1 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ExecTime", wireType)
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CompressedBytes", wireType)
 			}
-			var v uint64
-			if (iNdEx + 8) > l {
-				return io.ErrUnexpectedEOF
+			m.CompressedBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.CompressedBytes |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
-			iNdEx += 8
-			m.ExecTime = float64(math.Float64frombits(v))
-		case 6:
-			if wireType != 1 {
-				return fmt.Errorf("proto: wrong wireType = %d for field QueueTime", wireType)
+		case 9:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TotalDuplicates", wireType)
 			}
-			var v uint64
-			if (iNdEx + 8) > l {
-				return io.ErrUnexpectedEOF
+			m.TotalDuplicates = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.TotalDuplicates |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
-			iNdEx += 8
-			m.QueueTime = float64(math.Float64frombits(v))
 		default:
 			iNdEx = preIndex
 			skippy, err := skipStats(dAtA[iNdEx:])
@@ -1634,7 +3358,7 @@ func (m *Summary) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *Querier) Unmarshal(dAtA []byte) error {
+func (m *Index) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1657,17 +3381,36 @@ func (m *Querier) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: Querier: wiretype end group for non-group")
+			return fmt.Errorf("proto: Index: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Querier: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: Index: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Store", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TotalQueries", wireType)
+			}
+			m.TotalQueries = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.TotalQueries |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LookupTime", wireType)
 			}
-			var msglen int
+			m.LookupTime = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowStats
@@ -1677,25 +3420,11 @@ func (m *Querier) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				m.LookupTime |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthStats
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthStats
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			if err := m.Store.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipStats(dAtA[iNdEx:])
@@ -1720,7 +3449,7 @@ func (m *Querier) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *Ingester) Unmarshal(dAtA []byte) error {
+func (m *Caches) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1743,17 +3472,17 @@ func (m *Ingester) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: Ingester: wiretype end group for non-group")
+			return fmt.Errorf("proto: Caches: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Ingester: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: Caches: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field TotalReached", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Chunk", wireType)
 			}
-			m.TotalReached = 0
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowStats
@@ -1763,16 +3492,30 @@ func (m *Ingester) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.TotalReached |= int32(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			if msglen < 0 {
+				return ErrInvalidLengthStats
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthStats
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := m.Chunk.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		case 2:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field TotalChunksMatched", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Index", wireType)
 			}
-			m.TotalChunksMatched = 0
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowStats
@@ -1782,52 +3525,28 @@ func (m *Ingester) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.TotalChunksMatched |= int64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 3:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field TotalBatches", wireType)
+			if msglen < 0 {
+				return ErrInvalidLengthStats
 			}
-			m.TotalBatches = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowStats
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.TotalBatches |= int64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthStats
 			}
-		case 4:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field TotalLinesSent", wireType)
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
 			}
-			m.TotalLinesSent = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowStats
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.TotalLinesSent |= int64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
+			if err := m.Index.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
 			}
-		case 5:
+			iNdEx = postIndex
+		case 3:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Store", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Result", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -1854,7 +3573,7 @@ func (m *Ingester) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if err := m.Store.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if err := m.Result.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
@@ -1882,7 +3601,7 @@ func (m *Ingester) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *Store) Unmarshal(dAtA []byte) error {
+func (m *Cache) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1905,17 +3624,17 @@ func (m *Store) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: Store: wiretype end group for non-group")
+			return fmt.Errorf("proto: Cache: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Store: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: Cache: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field TotalChunksRef", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field EntriesRequested", wireType)
 			}
-			m.TotalChunksRef = 0
+			m.EntriesRequested = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowStats
@@ -1925,54 +3644,16 @@ func (m *Store) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.TotalChunksRef |= int64(b&0x7F) << shift
+				m.EntriesRequested |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
 		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field TotalChunksDownloaded", wireType)
-			}
-			m.TotalChunksDownloaded = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowStats
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.TotalChunksDownloaded |= int64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-		case 3:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ChunksDownloadTime", wireType)
-			}
-			m.ChunksDownloadTime = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowStats
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.ChunksDownloadTime |= int64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
+				return fmt.Errorf("proto: wrong wireType = %d for field EntriesFound", wireType)
 			}
-		case 4:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Chunk", wireType)
-			}
-			var msglen int
+			m.EntriesFound = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowStats
@@ -1982,25 +3663,11 @@ func (m *Store) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				m.EntriesFound |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthStats
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthStats
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			if err := m.Chunk.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipStats(dAtA[iNdEx:])
@@ -2025,7 +3692,7 @@ func (m *Store) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *Chunk) Unmarshal(dAtA []byte) error {
+func (m *SubQuery) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2048,17 +3715,17 @@ func (m *Chunk) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: Chunk: wiretype end group for non-group")
+			return fmt.Errorf("proto: SubQuery: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Chunk: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: SubQuery: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
-		case 4:
+		case 1:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field HeadChunkBytes", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Start", wireType)
 			}
-			m.HeadChunkBytes = 0
+			m.Start = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowStats
@@ -2068,16 +3735,16 @@ func (m *Chunk) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.HeadChunkBytes |= int64(b&0x7F) << shift
+				m.Start |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 5:
+		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field HeadChunkLines", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field End", wireType)
 			}
-			m.HeadChunkLines = 0
+			m.End = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowStats
@@ -2087,16 +3754,16 @@ func (m *Chunk) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.HeadChunkLines |= int64(b&0x7F) << shift
+				m.End |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 6:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field DecompressedBytes", wireType)
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Shard", wireType)
 			}
-			m.DecompressedBytes = 0
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowStats
@@ -2106,16 +3773,29 @@ func (m *Chunk) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.DecompressedBytes |= int64(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 7:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field DecompressedLines", wireType)
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthStats
 			}
-			m.DecompressedLines = 0
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthStats
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Shard = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Summary", wireType)
+			}
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowStats
@@ -2125,16 +3805,30 @@ func (m *Chunk) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.DecompressedLines |= int64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 8:
+			if msglen < 0 {
+				return ErrInvalidLengthStats
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthStats
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := m.Summary.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 5:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field CompressedBytes", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field TotalChunksRef", wireType)
 			}
-			m.CompressedBytes = 0
+			m.TotalChunksRef = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowStats
@@ -2144,16 +3838,16 @@ func (m *Chunk) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.CompressedBytes |= int64(b&0x7F) << shift
+				m.TotalChunksRef |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 9:
+		case 6:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field TotalDuplicates", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field TotalChunksDownloaded", wireType)
 			}
-			m.TotalDuplicates = 0
+			m.TotalChunksDownloaded = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowStats
@@ -2163,11 +3857,45 @@ func (m *Chunk) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.TotalDuplicates |= int64(b&0x7F) << shift
+				m.TotalChunksDownloaded |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Shards", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			if msglen < 0 {
+				return ErrInvalidLengthStats
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthStats
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Shards = append(m.Shards, SubQuery{})
+			if err := m.Shards[len(m.Shards)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipStats(dAtA[iNdEx:])
@@ -179,6 +179,12 @@ func (c *Config) Validate() error {
 	if err := c.StorageConfig.BoltDBShipperConfig.Validate(); err != nil {
 		return errors.Wrap(err, "invalid boltdb-shipper config")
 	}
+	if err := c.StorageConfig.TSDBShipperConfig.Validate(); err != nil {
+		return errors.Wrap(err, "invalid tsdb-shipper config")
+	}
+	if err := c.StorageConfig.BloomFilters.Validate(); err != nil {
+		return errors.Wrap(err, "invalid bloom filters config")
+	}
 	if err := c.CompactorConfig.Validate(); err != nil {
 		return errors.Wrap(err, "invalid compactor config")
 	}
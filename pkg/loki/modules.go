@@ -40,6 +40,7 @@ import (
 	"github.com/grafana/loki/pkg/scheduler"
 	"github.com/grafana/loki/pkg/scheduler/schedulerpb"
 	loki_storage "github.com/grafana/loki/pkg/storage"
+	"github.com/grafana/loki/pkg/storage/bloom"
 	"github.com/grafana/loki/pkg/storage/chunk"
 	"github.com/grafana/loki/pkg/storage/chunk/cache"
 	chunk_storage "github.com/grafana/loki/pkg/storage/chunk/storage"
@@ -49,6 +50,7 @@ import (
 	"github.com/grafana/loki/pkg/storage/stores/shipper/indexgateway"
 	"github.com/grafana/loki/pkg/storage/stores/shipper/indexgateway/indexgatewaypb"
 	"github.com/grafana/loki/pkg/storage/stores/shipper/uploads"
+	"github.com/grafana/loki/pkg/storage/tsdb"
 	"github.com/grafana/loki/pkg/util/httpreq"
 	util_log "github.com/grafana/loki/pkg/util/log"
 	serverutil "github.com/grafana/loki/pkg/util/server"
@@ -245,6 +247,7 @@ func (t *Loki) initQuerier() (services.Service, error) {
 		"/loki/api/v1/labels":              http.HandlerFunc(t.Querier.LabelHandler),
 		"/loki/api/v1/label/{name}/values": http.HandlerFunc(t.Querier.LabelHandler),
 		"/loki/api/v1/series":              http.HandlerFunc(t.Querier.SeriesHandler),
+		"/loki/api/v1/query_plan":          http.HandlerFunc(t.Querier.QueryPlanHandler),
 
 		"/api/prom/query":               httpMiddleware.Wrap(http.HandlerFunc(t.Querier.LogQueryHandler)),
 		"/api/prom/label":               http.HandlerFunc(t.Querier.LabelHandler),
@@ -379,13 +382,38 @@ func (t *Loki) initStore() (_ services.Service, err error) {
 		}
 	}
 
+	usingTSDBShipper := loki_storage.UsingTSDBShipper(t.Cfg.SchemaConfig.Configs)
+	if usingTSDBShipper {
+		t.Cfg.StorageConfig.TSDBShipperConfig.IngesterName = t.Cfg.Ingester.LifecyclerConfig.ID
+		switch true {
+		case t.Cfg.isModuleEnabled(Ingester), t.Cfg.isModuleEnabled(Write):
+			// We do not want ingester to unnecessarily keep downloading files
+			t.Cfg.StorageConfig.TSDBShipperConfig.Mode = shipper.ModeWriteOnly
+			t.Cfg.StorageConfig.TSDBShipperConfig.IngesterIndexRetainPeriod = tsdbShipperQuerierIndexUpdateDelay(t.Cfg) + 2*time.Minute
+		case t.Cfg.isModuleEnabled(Querier), t.Cfg.isModuleEnabled(Ruler), t.Cfg.isModuleEnabled(Read):
+			// We do not want query to do any updates to index
+			t.Cfg.StorageConfig.TSDBShipperConfig.Mode = shipper.ModeReadOnly
+		default:
+			t.Cfg.StorageConfig.TSDBShipperConfig.Mode = shipper.ModeReadWrite
+			t.Cfg.StorageConfig.TSDBShipperConfig.IngesterIndexRetainPeriod = tsdbShipperQuerierIndexUpdateDelay(t.Cfg) + 2*time.Minute
+		}
+	}
+
 	chunkStore, err := chunk_storage.NewStore(t.Cfg.StorageConfig.Config, t.Cfg.ChunkStoreConfig.StoreConfig, t.Cfg.SchemaConfig.SchemaConfig, t.overrides, t.clientMetrics, prometheus.DefaultRegisterer, nil, util_log.Logger)
 	if err != nil {
 		return
 	}
 
-	if loki_storage.UsingBoltdbShipper(t.Cfg.SchemaConfig.Configs) {
-		boltdbShipperMinIngesterQueryStoreDuration := boltdbShipperMinIngesterQueryStoreDuration(t.Cfg)
+	usingBoltdbShipper := loki_storage.UsingBoltdbShipper(t.Cfg.SchemaConfig.Configs)
+	if usingBoltdbShipper || usingTSDBShipper {
+		// Both shippers make the chunks flushed by the ingesters queryable only after some delay, during which the ingesters have to be queried.
+		var minIngesterQueryStoreDuration time.Duration
+		if usingBoltdbShipper {
+			minIngesterQueryStoreDuration = boltdbShipperMinIngesterQueryStoreDuration(t.Cfg)
+		}
+		if usingTSDBShipper && tsdbShipperMinIngesterQueryStoreDuration(t.Cfg) > minIngesterQueryStoreDuration {
+			minIngesterQueryStoreDuration = tsdbShipperMinIngesterQueryStoreDuration(t.Cfg)
+		}
 		switch true {
 		case t.Cfg.isModuleEnabled(Querier), t.Cfg.isModuleEnabled(Ruler), t.Cfg.isModuleEnabled(Read):
 			// Do not use the AsyncStore if the querier is configured with QueryStoreOnly set to true
@@ -395,19 +423,19 @@ func (t *Loki) initStore() (_ services.Service, err error) {
 			// Use AsyncStore to query both ingesters local store and chunk store for store queries.
 			// Only queriers should use the AsyncStore, it should never be used in ingesters.
 			chunkStore = loki_storage.NewAsyncStore(chunkStore, t.Cfg.SchemaConfig.SchemaConfig, t.ingesterQuerier,
-				calculateAsyncStoreQueryIngestersWithin(t.Cfg.Querier.QueryIngestersWithin, boltdbShipperMinIngesterQueryStoreDuration),
+				calculateAsyncStoreQueryIngestersWithin(t.Cfg.Querier.QueryIngestersWithin, minIngesterQueryStoreDuration),
 			)
 		case t.Cfg.isModuleEnabled(All):
 			// We want ingester to also query the store when using boltdb-shipper but only when running with target All.
 			// We do not want to use AsyncStore otherwise it would start spiraling around doing queries over and over again to the ingesters and store.
 			// ToDo: See if we can avoid doing this when not running loki in clustered mode.
 			t.Cfg.Ingester.QueryStore = true
-			boltdbShipperConfigIdx := loki_storage.ActivePeriodConfig(t.Cfg.SchemaConfig.Configs)
-			if t.Cfg.SchemaConfig.Configs[boltdbShipperConfigIdx].IndexType != shipper.BoltDBShipperType {
-				boltdbShipperConfigIdx++
+			shipperConfigIdx := loki_storage.ActivePeriodConfig(t.Cfg.SchemaConfig.Configs)
+			if indexType := t.Cfg.SchemaConfig.Configs[shipperConfigIdx].IndexType; indexType != shipper.BoltDBShipperType && indexType != tsdb.TSDBType {
+				shipperConfigIdx++
 			}
-			mlb, err := calculateMaxLookBack(t.Cfg.SchemaConfig.Configs[boltdbShipperConfigIdx], t.Cfg.Ingester.QueryStoreMaxLookBackPeriod,
-				boltdbShipperMinIngesterQueryStoreDuration)
+			mlb, err := calculateMaxLookBack(t.Cfg.SchemaConfig.Configs[shipperConfigIdx], t.Cfg.Ingester.QueryStoreMaxLookBackPeriod,
+				minIngesterQueryStoreDuration)
 			if err != nil {
 				return nil, err
 			}
@@ -420,12 +448,34 @@ func (t *Loki) initStore() (_ services.Service, err error) {
 		return
 	}
 
+	if t.Cfg.StorageConfig.BloomFilters.Enabled {
+		bloomStore, err := newBloomStore(t.Cfg, t.clientMetrics)
+		if err != nil {
+			return nil, err
+		}
+		t.Store.SetBloomStore(bloomStore)
+	}
+
 	return services.NewIdleService(nil, func(_ error) error {
 		t.Store.Stop()
 		return nil
 	}), nil
 }
 
+func newBloomStore(cfg Config, clientMetrics chunk_storage.ClientMetrics) (*bloom.Store, error) {
+	objectClient, err := chunk_storage.NewObjectClient(cfg.StorageConfig.BloomFilters.SharedStoreType, cfg.StorageConfig.Config, clientMetrics)
+	if err != nil {
+		return nil, err
+	}
+
+	bloomCache, err := cache.New(cfg.StorageConfig.BloomFilters.CacheConfig, prometheus.DefaultRegisterer, util_log.Logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return bloom.NewStore(cfg.StorageConfig.BloomFilters, cfg.SchemaConfig.SchemaConfig, objectClient, bloomCache, prometheus.DefaultRegisterer), nil
+}
+
 func (t *Loki) initIngesterQuerier() (_ services.Service, err error) {
 	t.ingesterQuerier, err = querier.NewIngesterQuerier(t.Cfg.IngesterClient, t.ring, t.Cfg.Querier.ExtraQueryDelay)
 	if err != nil {
@@ -537,6 +587,7 @@ func (t *Loki) initQueryFrontend() (_ services.Service, err error) {
 	t.Server.HTTP.Path("/loki/api/v1/labels").Methods("GET", "POST").Handler(frontendHandler)
 	t.Server.HTTP.Path("/loki/api/v1/label/{name}/values").Methods("GET", "POST").Handler(frontendHandler)
 	t.Server.HTTP.Path("/loki/api/v1/series").Methods("GET", "POST").Handler(frontendHandler)
+	t.Server.HTTP.Path("/loki/api/v1/query_plan").Methods("GET", "POST").Handler(frontendHandler)
 	t.Server.HTTP.Path("/api/prom/query").Methods("GET", "POST").Handler(frontendHandler)
 	t.Server.HTTP.Path("/api/prom/label").Methods("GET", "POST").Handler(frontendHandler)
 	t.Server.HTTP.Path("/api/prom/label/{name}/values").Methods("GET", "POST").Handler(frontendHandler)
@@ -700,7 +751,7 @@ func (t *Loki) initCompactor() (services.Service, error) {
 	if err != nil {
 		return nil, err
 	}
-	t.compactor, err = compactor.NewCompactor(t.Cfg.CompactorConfig, t.Cfg.StorageConfig.Config, t.Cfg.SchemaConfig, t.overrides, t.clientMetrics, prometheus.DefaultRegisterer)
+	t.compactor, err = compactor.NewCompactor(t.Cfg.CompactorConfig, t.Cfg.StorageConfig.Config, t.Cfg.SchemaConfig, t.Cfg.StorageConfig.BloomFilters, t.overrides, t.clientMetrics, prometheus.DefaultRegisterer)
 	if err != nil {
 		return nil, err
 	}
@@ -710,6 +761,10 @@ func (t *Loki) initCompactor() (services.Service, error) {
 		t.Server.HTTP.Path("/loki/api/admin/delete").Methods("PUT", "POST").Handler(t.HTTPAuthMiddleware.Wrap(http.HandlerFunc(t.compactor.DeleteRequestsHandler.AddDeleteRequestHandler)))
 		t.Server.HTTP.Path("/loki/api/admin/delete").Methods("GET").Handler(t.HTTPAuthMiddleware.Wrap(http.HandlerFunc(t.compactor.DeleteRequestsHandler.GetAllDeleteRequestsHandler)))
 		t.Server.HTTP.Path("/loki/api/admin/cancel_delete_request").Methods("PUT", "POST").Handler(t.HTTPAuthMiddleware.Wrap(http.HandlerFunc(t.compactor.DeleteRequestsHandler.CancelDeleteRequestHandler)))
+		t.Server.HTTP.Path("/loki/api/admin/retention_rules").Methods("GET").Handler(t.HTTPAuthMiddleware.Wrap(http.HandlerFunc(t.compactor.RetentionRulesHandler.GetRulesHandler)))
+		t.Server.HTTP.Path("/loki/api/admin/retention_rules").Methods("POST").Handler(t.HTTPAuthMiddleware.Wrap(http.HandlerFunc(t.compactor.RetentionRulesHandler.AddRuleHandler)))
+		t.Server.HTTP.Path("/loki/api/admin/retention_rules").Methods("PUT").Handler(t.HTTPAuthMiddleware.Wrap(http.HandlerFunc(t.compactor.RetentionRulesHandler.UpdateRuleHandler)))
+		t.Server.HTTP.Path("/loki/api/admin/retention_rules").Methods("DELETE").Handler(t.HTTPAuthMiddleware.Wrap(http.HandlerFunc(t.compactor.RetentionRulesHandler.RemoveRuleHandler)))
 	}
 
 	return t.compactor, nil
@@ -790,6 +845,17 @@ func boltdbShipperIngesterIndexUploadDelay() time.Duration {
 	return uploads.ShardDBsByDuration + shipper.UploadInterval
 }
 
+// tsdbShipperQuerierIndexUpdateDelay returns duration it could take for queriers to serve the tsdb index since it was uploaded.
+func tsdbShipperQuerierIndexUpdateDelay(cfg Config) time.Duration {
+	return cfg.StorageConfig.TSDBShipperConfig.ResyncInterval
+}
+
+// tsdbShipperMinIngesterQueryStoreDuration returns minimum duration(with some buffer) ingesters should query their stores to
+// avoid missing any logs or chunk ids due to the index being built and uploaded periodically by the TSDB Shipper.
+func tsdbShipperMinIngesterQueryStoreDuration(cfg Config) time.Duration {
+	return cfg.Ingester.MaxChunkAge + cfg.StorageConfig.TSDBShipperConfig.BuildInterval + tsdbShipperQuerierIndexUpdateDelay(cfg) + 2*time.Minute
+}
+
 // boltdbShipperMinIngesterQueryStoreDuration returns minimum duration(with some buffer) ingesters should query their stores to
 // avoid missing any logs or chunk ids due to async nature of BoltDB Shipper.
 func boltdbShipperMinIngesterQueryStoreDuration(cfg Config) time.Duration {
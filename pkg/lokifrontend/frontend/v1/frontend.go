@@ -5,6 +5,7 @@ import (
 	"flag"
 	"fmt"
 	"net/http"
+	"net/textproto"
 	"time"
 
 	"github.com/go-kit/log"
@@ -22,6 +23,7 @@ import (
 	"github.com/grafana/loki/pkg/tenant"
 	"github.com/grafana/loki/pkg/util"
 	lokigrpc "github.com/grafana/loki/pkg/util/httpgrpc"
+	lokihttpreq "github.com/grafana/loki/pkg/util/httpreq"
 	"github.com/grafana/loki/pkg/util/validation"
 )
 
@@ -207,9 +209,16 @@ func (f *Frontend) Process(server frontendv1pb.Frontend_ProcessServer) error {
 
 		req := reqWrapper.(*request)
 
-		f.queueDuration.Observe(time.Since(req.enqueueTime).Seconds())
+		reqQueueTime := time.Since(req.enqueueTime)
+		f.queueDuration.Observe(reqQueueTime.Seconds())
 		req.queueSpan.Finish()
 
+		// Add HTTP header to the request containing the query queue time
+		req.request.Headers = append(req.request.Headers, &httpgrpc.Header{
+			Key:    textproto.CanonicalMIMEHeaderKey(string(lokihttpreq.QueryQueueTimeHTTPHeader)),
+			Values: []string{reqQueueTime.String()},
+		})
+
 		/*
 		  We want to dequeue the next unexpired request from the chosen tenant queue.
 		  The chance of choosing a particular tenant for dequeueing is (1/active_tenants).
@@ -110,6 +110,26 @@ func (q *Querier) InstantQueryHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// QueryPlanHandler is a http.HandlerFunc explaining queries without executing them.
+func (q *Querier) QueryPlanHandler(w http.ResponseWriter, r *http.Request) {
+	request, err := loghttp.ParseRangeQuery(r)
+	if err != nil {
+		serverutil.WriteError(httpgrpc.Errorf(http.StatusBadRequest, err.Error()), w)
+		return
+	}
+
+	plan, err := q.QueryPlan(r.Context(), request)
+	if err != nil {
+		serverutil.WriteError(err, w)
+		return
+	}
+
+	if err := marshal.WriteQueryPlanResponseJSON(*plan, w); err != nil {
+		serverutil.WriteError(err, w)
+		return
+	}
+}
+
 // LogQueryHandler is a http.HandlerFunc for log only queries.
 func (q *Querier) LogQueryHandler(w http.ResponseWriter, r *http.Request) {
 	// Enforce the query timeout while querying backends
@@ -9,6 +9,7 @@ import (
 	"github.com/go-kit/log/level"
 	"github.com/pkg/errors"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
 	"github.com/weaveworks/common/httpgrpc"
 	"google.golang.org/grpc/health/grpc_health_v1"
 
@@ -367,6 +368,7 @@ func (q *Querier) Tail(ctx context.Context, req *logproto.TailRequest) (*Tailer,
 		},
 		q.cfg.TailMaxDuration,
 		tailerWaitEntryThrottle,
+		tailCheckpointPeriod,
 	), nil
 }
 
@@ -499,6 +501,79 @@ func (q *Querier) seriesForMatcher(ctx context.Context, from, through time.Time,
 	return ids, nil
 }
 
+// QueryPlan explains the query without executing it, estimating the chunks and streams matching its selectors in the
+// index of the store.
+func (q *Querier) QueryPlan(ctx context.Context, req *loghttp.RangeQuery) (*loghttp.QueryPlan, error) {
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	expr, err := logql.ParseExpr(req.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end, err := validateQueryTimeRangeLimits(ctx, userID, q.limits, req.Start, req.End)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &loghttp.QueryPlan{
+		Query: req.Query,
+		Start: start,
+		End:   end,
+		AST:   logql.Explain(expr),
+	}
+	if q.cfg.QueryIngesterOnly {
+		return plan, nil
+	}
+
+	// Like the engine, the store is queried from the start of the largest range of the query.
+	var (
+		selectors [][]*labels.Matcher
+		maxRange  time.Duration
+	)
+	expr.Walk(func(e interface{}) {
+		switch e := e.(type) {
+		case *logql.MatchersExpr:
+			selectors = append(selectors, e.Matchers())
+		case *logql.LogRange:
+			if r := e.Interval + e.Offset; r > maxRange {
+				maxRange = r
+			}
+		}
+	})
+
+	// Enforce the query timeout while querying backends
+	ctx, cancel := context.WithDeadline(ctx, time.Now().Add(q.cfg.QueryTimeout))
+	defer cancel()
+
+	type chunkKey struct {
+		fingerprint   model.Fingerprint
+		from, through model.Time
+		checksum      uint32
+	}
+	chunks := map[chunkKey]struct{}{}
+	streams := map[model.Fingerprint]struct{}{}
+	from, through := model.TimeFromUnixNano(start.Add(-maxRange).UnixNano()), model.TimeFromUnixNano(end.UnixNano())
+	for _, matchers := range selectors {
+		refs, _, err := q.store.GetChunkRefs(ctx, userID, from, through, matchers...)
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range refs {
+			for _, c := range group {
+				chunks[chunkKey{c.Fingerprint, c.From, c.Through, c.Checksum}] = struct{}{}
+				streams[c.Fingerprint] = struct{}{}
+			}
+		}
+	}
+	plan.Chunks, plan.Streams = len(chunks), len(streams)
+
+	return plan, nil
+}
+
 func (q *Querier) validateQueryRequest(ctx context.Context, req logql.QueryParams) (time.Time, time.Time, error) {
 	userID, err := tenant.TenantID(ctx)
 	if err != nil {
@@ -22,6 +22,7 @@ import (
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/loki/pkg/logql"
 	"github.com/grafana/loki/pkg/storage"
+	"github.com/grafana/loki/pkg/storage/bloom"
 	"github.com/grafana/loki/pkg/storage/chunk"
 	"github.com/grafana/loki/pkg/util"
 )
@@ -209,6 +210,8 @@ func newStoreMock() *storeMock {
 
 func (s *storeMock) SetChunkFilterer(storage.RequestChunkFilterer) {}
 
+func (s *storeMock) SetBloomStore(*bloom.Store) {}
+
 func (s *storeMock) SelectLogs(ctx context.Context, req logql.SelectLogParams) (iter.EntryIterator, error) {
 	args := s.Called(ctx, req)
 	res := args.Get(0)
@@ -234,7 +237,7 @@ func (s *storeMock) Get(ctx context.Context, userID string, from, through model.
 
 func (s *storeMock) GetChunkRefs(ctx context.Context, userID string, from, through model.Time, matchers ...*labels.Matcher) ([][]chunk.Chunk, []*chunk.Fetcher, error) {
 	args := s.Called(ctx, userID, from, through, matchers)
-	return args.Get(0).([][]chunk.Chunk), args.Get(0).([]*chunk.Fetcher), args.Error(2)
+	return args.Get(0).([][]chunk.Chunk), args.Get(1).([]*chunk.Fetcher), args.Error(2)
 }
 
 func (s *storeMock) Put(ctx context.Context, chunks []chunk.Chunk) error {
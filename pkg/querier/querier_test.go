@@ -19,9 +19,11 @@ import (
 	"github.com/weaveworks/common/user"
 
 	"github.com/grafana/loki/pkg/ingester/client"
+	"github.com/grafana/loki/pkg/loghttp"
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/loki/pkg/logql"
 	"github.com/grafana/loki/pkg/storage"
+	"github.com/grafana/loki/pkg/storage/chunk"
 	"github.com/grafana/loki/pkg/validation"
 )
 
@@ -214,6 +216,48 @@ func TestQuerier_validateQueryRequest(t *testing.T) {
 	require.Equal(t, httpgrpc.Errorf(http.StatusBadRequest, "the query time range exceeds the limit (query length: 3m0s, limit: 2m0s)"), err)
 }
 
+func TestQuerier_QueryPlan(t *testing.T) {
+	end := time.Now()
+	start := end.Add(-time.Hour)
+	ref := func(fp model.Fingerprint, from time.Time) chunk.Chunk {
+		return chunk.Chunk{Fingerprint: fp, From: model.TimeFromUnixNano(from.UnixNano()), Through: model.TimeFromUnixNano(end.UnixNano())}
+	}
+
+	store := newStoreMock()
+	// The store is queried from the start of the range of the query.
+	from := model.TimeFromUnixNano(start.Add(-5 * time.Minute).UnixNano())
+	store.On("GetChunkRefs", mock.Anything, "test", from, model.TimeFromUnixNano(end.UnixNano()), mock.Anything).Return(
+		[][]chunk.Chunk{{ref(1, start), ref(1, start.Add(time.Minute))}, {ref(2, start)}}, []*chunk.Fetcher{nil, nil}, nil,
+	)
+
+	limits, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
+	require.NoError(t, err)
+	q, err := newQuerier(
+		mockQuerierConfig(),
+		mockIngesterClientConfig(),
+		newIngesterClientMockFactory(newQuerierClientMock()),
+		mockReadRingWithOneActiveIngester(),
+		store, limits)
+	require.NoError(t, err)
+
+	ctx := user.InjectOrgID(context.Background(), "test")
+	// The chunks matched by both selectors are counted once.
+	plan, err := q.QueryPlan(ctx, &loghttp.RangeQuery{
+		Query: `sum(rate({app="foo"}[5m])) / sum(rate({app="foo"} |= "error" [1m]))`,
+		Start: start,
+		End:   end,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, plan.Chunks)
+	require.Equal(t, 2, plan.Streams)
+	require.Equal(t, "binary_operation", plan.AST.Type)
+	require.Len(t, plan.AST.Children, 2)
+	store.AssertNumberOfCalls(t, "GetChunkRefs", 2)
+
+	_, err = q.QueryPlan(ctx, &loghttp.RangeQuery{Query: `{app="foo"`, Start: start, End: end})
+	require.Error(t, err)
+}
+
 func TestQuerier_SeriesAPI(t *testing.T) {
 	mkReq := func(groups []string) *logproto.SeriesRequest {
 		return &logproto.SeriesRequest{
@@ -866,6 +866,20 @@ func (badReader) Read(p []byte) (n int, err error) {
 
 var (
 	statsResultString = `"stats" : {
+		"cache": {
+			"chunk": {
+				"entriesRequested": 0,
+				"entriesFound": 0
+			},
+			"index": {
+				"entriesRequested": 0,
+				"entriesFound": 0
+			},
+			"result": {
+				"entriesRequested": 0,
+				"entriesFound": 0
+			}
+		},
 		"ingester" : {
 			"store": {
 				"chunk":{
@@ -877,6 +891,10 @@ var (
 					"totalDuplicates": 8
 				},
 				"chunksDownloadTime": 0,
+				"index": {
+					"totalQueries": 0,
+					"lookupTime": 0
+				},
 				"totalChunksRef": 0,
 				"totalChunksDownloaded": 0
 			},
@@ -896,6 +914,10 @@ var (
 					"totalDuplicates": 19
 				},
 				"chunksDownloadTime": 16,
+				"index": {
+					"totalQueries": 0,
+					"lookupTime": 0
+				},
 				"totalChunksRef": 17,
 				"totalChunksDownloaded": 18
 			}
@@ -906,7 +928,9 @@ var (
 			"linesProcessedPerSecond": 23,
 			"queueTime": 21,
 			"totalBytesProcessed": 24,
-			"totalLinesProcessed": 25
+			"totalLinesProcessed": 25,
+			"splits": 0,
+			"shards": 0
 		}
 	},`
 	matrixString = `{
@@ -0,0 +1,125 @@
+package queryrange
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logqlmodel/stats"
+	"github.com/grafana/loki/pkg/querier/queryrange/queryrangebase"
+	"github.com/grafana/loki/pkg/storage/chunk/cache"
+	"github.com/grafana/loki/pkg/tenant"
+	"github.com/grafana/loki/pkg/util/validation"
+)
+
+// EmptyResultCacheMetrics is the metrics wrapper used in empty result cache.
+type EmptyResultCacheMetrics struct {
+	CacheHit    prometheus.Counter
+	CacheStored prometheus.Counter
+}
+
+// NewEmptyResultCacheMetrics creates metrics to be used in empty result cache.
+func NewEmptyResultCacheMetrics(registerer prometheus.Registerer) *EmptyResultCacheMetrics {
+	return &EmptyResultCacheMetrics{
+		CacheHit: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki",
+			Name:      "query_frontend_empty_result_cache_hit_total",
+			Help:      "Total number of split requests answered with an empty result found in the results cache.",
+		}),
+		CacheStored: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki",
+			Name:      "query_frontend_empty_result_cache_stored_total",
+			Help:      "Total number of empty split results stored in the results cache.",
+		}),
+	}
+}
+
+// NewEmptyResultCacheMiddleware creates a middleware remembering which split requests returned nothing.
+// It is meant to be placed after the split by interval middleware: when a split of a log or metric query is empty and
+// older than the max cache freshness, it is recorded in the results cache and subsequent identical split requests
+// are answered with an empty response without being executed.
+func NewEmptyResultCacheMiddleware(limits Limits, cache cache.Cache, metrics *EmptyResultCacheMetrics) queryrangebase.Middleware {
+	if metrics == nil {
+		metrics = NewEmptyResultCacheMetrics(nil)
+	}
+	return queryrangebase.MiddlewareFunc(func(next queryrangebase.Handler) queryrangebase.Handler {
+		return &emptyResultCache{
+			next:    next,
+			limits:  limits,
+			cache:   cache,
+			metrics: metrics,
+		}
+	})
+}
+
+type emptyResultCache struct {
+	next    queryrangebase.Handler
+	limits  Limits
+	cache   cache.Cache
+	metrics *EmptyResultCacheMetrics
+}
+
+func (e *emptyResultCache) Do(ctx context.Context, req queryrangebase.Request) (queryrangebase.Response, error) {
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+
+	lokiReq, ok := req.(*LokiRequest)
+	if !ok || lokiReq.GetCachingOptions().Disabled {
+		return e.next.Do(ctx, req)
+	}
+
+	// Never cache data for the latest freshness period, as it can still change.
+	maxCacheFreshness := validation.MaxDurationPerTenant(tenantIDs, e.limits.MaxCacheFreshness)
+	if lokiReq.EndTs.After(time.Now().Add(-maxCacheFreshness)) {
+		return e.next.Do(ctx, req)
+	}
+
+	key := emptyResultCacheKey(tenant.JoinTenantIDs(tenantIDs), lokiReq)
+	hashedKey := cache.HashKey(key)
+	found, bufs, _, _ := e.cache.Fetch(ctx, []string{hashedKey})
+	// the key is stored as the value to detect hash collisions.
+	hit := len(found) == 1 && bytes.Equal(bufs[0], []byte(key))
+	stats.FromContext(ctx).AddCacheLookup(stats.ResultCache, hit)
+	if hit {
+		e.metrics.CacheHit.Inc()
+		return NewEmptyResponse(lokiReq)
+	}
+
+	resp, err := e.next.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if isEmptyResponse(resp) {
+		e.metrics.CacheStored.Inc()
+		_ = e.cache.Store(ctx, []string{hashedKey}, [][]byte{[]byte(key)})
+	}
+	return resp, nil
+}
+
+// isEmptyResponse returns true if the response of a log or metric query holds no result.
+func isEmptyResponse(resp queryrangebase.Response) bool {
+	switch r := resp.(type) {
+	case *LokiResponse:
+		return r.Status == loghttp.QueryStatusSuccess && r.Count() == 0
+	case *LokiPromResponse:
+		return r.Response != nil && r.Response.Status == loghttp.QueryStatusSuccess && len(r.Response.Data.Result) == 0
+	default:
+		return false
+	}
+}
+
+// emptyResultCacheKey returns the cache key of the split request, only identical requests share the same key.
+// The limit and the direction are left out as they do not change whether the result is empty.
+func emptyResultCacheKey(userID string, req *LokiRequest) string {
+	return fmt.Sprintf("empty:%s:%s:%d:%d:%d", userID, req.Query, req.Step, req.StartTs.UnixNano(), req.EndTs.UnixNano())
+}
@@ -0,0 +1,92 @@
+package queryrange
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/querier/queryrange/queryrangebase"
+	"github.com/grafana/loki/pkg/storage/chunk/cache"
+)
+
+func Test_EmptyResultCache(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "1")
+	past := time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC)
+	now := time.Now()
+
+	nonEmptyLogResponse := &LokiResponse{
+		Status: loghttp.QueryStatusSuccess,
+		Data: LokiData{
+			ResultType: loghttp.ResultTypeStream,
+			Result:     []logproto.Stream{{Labels: `{app="foo"}`, Entries: []logproto.Entry{{Timestamp: past, Line: "foo"}}}},
+		},
+	}
+	nonEmptyMetricResponse := &LokiPromResponse{
+		Response: &queryrangebase.PrometheusResponse{
+			Status: loghttp.QueryStatusSuccess,
+			Data: queryrangebase.PrometheusData{
+				ResultType: loghttp.ResultTypeMatrix,
+				Result:     []queryrangebase.SampleStream{{Samples: []logproto.LegacySample{{Value: 1, TimestampMs: past.UnixNano() / int64(time.Millisecond)}}}},
+			},
+		},
+	}
+
+	for _, tc := range []struct {
+		name          string
+		req           *LokiRequest
+		resp          queryrangebase.Response
+		expectedCalls int
+	}{
+		{
+			name:          "empty log split",
+			req:           &LokiRequest{Query: `{app="foo"} |= "bar"`, Limit: 100, StartTs: past, EndTs: past.Add(time.Hour)},
+			resp:          &LokiResponse{Status: loghttp.QueryStatusSuccess, Data: LokiData{ResultType: loghttp.ResultTypeStream}},
+			expectedCalls: 1,
+		},
+		{
+			name:          "empty metric split",
+			req:           &LokiRequest{Query: `rate({app="foo"}[1m])`, Step: 60000, StartTs: past, EndTs: past.Add(time.Hour)},
+			resp:          &LokiPromResponse{Response: queryrangebase.NewEmptyPrometheusResponse()},
+			expectedCalls: 1,
+		},
+		{
+			name:          "non empty log split",
+			req:           &LokiRequest{Query: `{app="foo"} |= "bar"`, Limit: 100, StartTs: past, EndTs: past.Add(time.Hour)},
+			resp:          nonEmptyLogResponse,
+			expectedCalls: 2,
+		},
+		{
+			name:          "non empty metric split",
+			req:           &LokiRequest{Query: `rate({app="foo"}[1m])`, Step: 60000, StartTs: past, EndTs: past.Add(time.Hour)},
+			resp:          nonEmptyMetricResponse,
+			expectedCalls: 2,
+		},
+		{
+			name:          "recent empty split",
+			req:           &LokiRequest{Query: `{app="foo"} |= "bar"`, Limit: 100, StartTs: now.Add(-time.Hour), EndTs: now},
+			resp:          &LokiResponse{Status: loghttp.QueryStatusSuccess, Data: LokiData{ResultType: loghttp.ResultTypeStream}},
+			expectedCalls: 2,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls int
+			next := queryrangebase.HandlerFunc(func(_ context.Context, r queryrangebase.Request) (queryrangebase.Response, error) {
+				calls++
+				return tc.resp, nil
+			})
+			handler := NewEmptyResultCacheMiddleware(fakeLimits{}, cache.NewMockCache(), nil).Wrap(next)
+
+			for i := 0; i < 2; i++ {
+				resp, err := handler.Do(ctx, tc.req)
+				require.NoError(t, err)
+				require.Equal(t, isEmptyResponse(tc.resp), isEmptyResponse(resp))
+			}
+			require.Equal(t, tc.expectedCalls, calls)
+		})
+	}
+}
@@ -0,0 +1,367 @@
+package queryrange
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logqlmodel/stats"
+	"github.com/grafana/loki/pkg/querier/queryrange/queryrangebase"
+	"github.com/grafana/loki/pkg/storage/chunk/cache"
+	"github.com/grafana/loki/pkg/tenant"
+	"github.com/grafana/loki/pkg/util/validation"
+)
+
+// LogResultCacheMetrics is the metrics wrapper used in log result cache.
+type LogResultCacheMetrics struct {
+	CacheHit  prometheus.Counter
+	CacheMiss prometheus.Counter
+}
+
+// NewLogResultCacheMetrics creates metrics to be used in log result cache.
+func NewLogResultCacheMetrics(registerer prometheus.Registerer) *LogResultCacheMetrics {
+	return &LogResultCacheMetrics{
+		CacheHit: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki",
+			Name:      "query_frontend_log_result_cache_hit_total",
+			Help:      "Total number of log query split requests found in the results cache.",
+		}),
+		CacheMiss: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki",
+			Name:      "query_frontend_log_result_cache_miss_total",
+			Help:      "Total number of log query split requests not found in the results cache.",
+		}),
+	}
+}
+
+// NewLogResultCacheMiddleware creates a middleware caching the results of log queries.
+// It is meant to be placed after the split by interval middleware: the results of each split are cached using a key
+// made of the query, the limit, the direction and the split interval, and only the time ranges older than the
+// max cache freshness are cached.
+// Each cached extent holds every entry of its time range, so that limited responses can be merged with fresh ones.
+func NewLogResultCacheMiddleware(logger log.Logger, limits Limits, cache cache.Cache, metrics *LogResultCacheMetrics) queryrangebase.Middleware {
+	if metrics == nil {
+		metrics = NewLogResultCacheMetrics(nil)
+	}
+	return queryrangebase.MiddlewareFunc(func(next queryrangebase.Handler) queryrangebase.Handler {
+		return &logResultCache{
+			next:    next,
+			limits:  limits,
+			cache:   cache,
+			logger:  logger,
+			metrics: metrics,
+		}
+	})
+}
+
+type logResultCache struct {
+	next    queryrangebase.Handler
+	limits  Limits
+	cache   cache.Cache
+	logger  log.Logger
+	metrics *LogResultCacheMetrics
+}
+
+// logExtent is a time range [start, end) in nanoseconds for which all the entries of the query are known.
+type logExtent struct {
+	start, end int64
+	streams    []logproto.Stream
+}
+
+// logSegment is a part of a request either served from a cached extent or fetched.
+type logSegment struct {
+	start, end int64
+	extent     *logExtent
+}
+
+func (l *logResultCache) Do(ctx context.Context, req queryrangebase.Request) (queryrangebase.Response, error) {
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+
+	lokiReq, ok := req.(*LokiRequest)
+	if !ok || lokiReq.GetCachingOptions().Disabled {
+		return l.next.Do(ctx, req)
+	}
+
+	userID := tenant.JoinTenantIDs(tenantIDs)
+	interval := l.limits.QuerySplitDuration(userID)
+	if interval == 0 {
+		return l.next.Do(ctx, req)
+	}
+
+	// Never cache data for the latest freshness period, as it can still change.
+	maxCacheFreshness := validation.MaxDurationPerTenant(tenantIDs, l.limits.MaxCacheFreshness)
+	if lokiReq.EndTs.After(time.Now().Add(-maxCacheFreshness)) {
+		return l.next.Do(ctx, req)
+	}
+
+	key := logResultCacheKey(userID, lokiReq, interval)
+	extents, ok := l.get(ctx, key)
+	stats.FromContext(ctx).AddCacheLookup(stats.ResultCache, ok)
+	if ok {
+		l.metrics.CacheHit.Inc()
+	} else {
+		l.metrics.CacheMiss.Inc()
+	}
+
+	resp, fetched, err := l.handle(ctx, lokiReq, extents)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(fetched) > 0 {
+		l.put(ctx, key, lokiReq.Direction, mergeLogExtents(lokiReq.Direction, append(extents, fetched...)))
+	}
+	return resp, nil
+}
+
+// handle serves the request from the cached extents and fetches the missing time ranges, walking the segments
+// in the direction of the query until the limit is reached.
+// It returns the response as well as the extents built from the fetched responses.
+func (l *logResultCache) handle(ctx context.Context, req *LokiRequest, extents []logExtent) (queryrangebase.Response, []logExtent, error) {
+	segments := logSegments(req.StartTs.UnixNano(), req.EndTs.UnixNano(), extents)
+	if req.Direction == logproto.BACKWARD {
+		for i, j := 0, len(segments)-1; i < j; i, j = i+1, j-1 {
+			segments[i], segments[j] = segments[j], segments[i]
+		}
+	}
+
+	var (
+		responses = make([]queryrangebase.Response, 0, len(segments))
+		fetched   []logExtent
+		count     int64
+	)
+	for _, s := range segments {
+		var resp *LokiResponse
+		if s.extent != nil {
+			resp = &LokiResponse{
+				Status:    loghttp.QueryStatusSuccess,
+				Direction: req.Direction,
+				Limit:     req.Limit,
+				Version:   uint32(loghttp.GetVersion(req.Path)),
+				Data: LokiData{
+					ResultType: loghttp.ResultTypeStream,
+					Result:     extractStreams(s.start, s.end, s.extent.streams),
+				},
+			}
+		} else {
+			subReq := *req
+			subReq.StartTs = time.Unix(0, s.start)
+			subReq.EndTs = time.Unix(0, s.end)
+
+			res, err := l.next.Do(ctx, &subReq)
+			if err != nil {
+				return nil, nil, err
+			}
+			var ok bool
+			if resp, ok = res.(*LokiResponse); !ok {
+				level.Warn(l.logger).Log("msg", "unexpected response type in log result cache", "type", fmt.Sprintf("%T", res))
+				return res, nil, nil
+			}
+			if extent, ok := newLogExtent(s.start, s.end, req.Limit, req.Direction, resp); ok {
+				fetched = append(fetched, extent)
+			}
+		}
+
+		responses = append(responses, resp)
+		// the segments are ordered in the direction of the query, the following ones can't be part of the response.
+		count += resp.Count()
+		if req.Limit > 0 && count >= int64(req.Limit) {
+			break
+		}
+	}
+
+	if len(responses) == 1 {
+		return responses[0], fetched, nil
+	}
+	resp, err := LokiCodec.MergeResponse(responses...)
+	return resp, fetched, err
+}
+
+// logSegments splits the time range [start, end) in segments covered by the cached extents, sorted by start, or not.
+func logSegments(start, end int64, extents []logExtent) []logSegment {
+	var segments []logSegment
+	cursor := start
+	for i := range extents {
+		e := &extents[i]
+		if e.end <= cursor || e.start >= end {
+			continue
+		}
+		if e.start > cursor {
+			segments = append(segments, logSegment{start: cursor, end: e.start})
+			cursor = e.start
+		}
+		segmentEnd := e.end
+		if segmentEnd > end {
+			segmentEnd = end
+		}
+		segments = append(segments, logSegment{start: cursor, end: segmentEnd, extent: e})
+		cursor = segmentEnd
+	}
+	if cursor < end {
+		segments = append(segments, logSegment{start: cursor, end: end})
+	}
+	return segments
+}
+
+// newLogExtent builds the extent for which the response holds every entry.
+// When the response has been truncated by the limit, the extent is shrunk to exclude the timestamp of the last entry
+// in the direction of the query, since entries with that timestamp may have been dropped.
+func newLogExtent(start, end int64, limit uint32, direction logproto.Direction, resp *LokiResponse) (logExtent, bool) {
+	if limit == 0 || resp.Count() < int64(limit) {
+		return logExtent{start: start, end: end, streams: resp.Data.Result}, true
+	}
+
+	if direction == logproto.FORWARD {
+		var last int64 = math.MinInt64
+		for _, s := range resp.Data.Result {
+			for _, e := range s.Entries {
+				if ts := e.Timestamp.UnixNano(); ts > last {
+					last = ts
+				}
+			}
+		}
+		if last <= start {
+			return logExtent{}, false
+		}
+		return logExtent{start: start, end: last, streams: extractStreams(start, last, resp.Data.Result)}, true
+	}
+
+	var first int64 = math.MaxInt64
+	for _, s := range resp.Data.Result {
+		for _, e := range s.Entries {
+			if ts := e.Timestamp.UnixNano(); ts < first {
+				first = ts
+			}
+		}
+	}
+	if first+1 >= end {
+		return logExtent{}, false
+	}
+	return logExtent{start: first + 1, end: end, streams: extractStreams(first+1, end, resp.Data.Result)}, true
+}
+
+// extractStreams returns the entries of the streams within [start, end), dropping the streams left empty.
+func extractStreams(start, end int64, streams []logproto.Stream) []logproto.Stream {
+	result := make([]logproto.Stream, 0, len(streams))
+	for _, s := range streams {
+		entries := make([]logproto.Entry, 0, len(s.Entries))
+		for _, e := range s.Entries {
+			if ts := e.Timestamp.UnixNano(); ts >= start && ts < end {
+				entries = append(entries, e)
+			}
+		}
+		if len(entries) > 0 {
+			result = append(result, logproto.Stream{Labels: s.Labels, Entries: entries})
+		}
+	}
+	return result
+}
+
+// mergeLogExtents merges the overlapping and adjacent extents and returns them sorted by start.
+func mergeLogExtents(direction logproto.Direction, extents []logExtent) []logExtent {
+	if len(extents) == 0 {
+		return nil
+	}
+	sort.Slice(extents, func(i, j int) bool { return extents[i].start < extents[j].start })
+
+	merged := []logExtent{extents[0]}
+	for _, e := range extents[1:] {
+		last := &merged[len(merged)-1]
+		if e.start > last.end {
+			merged = append(merged, e)
+			continue
+		}
+		if e.end <= last.end {
+			continue
+		}
+
+		// both extents hold every entry of their time range, only the part of e after the last one is needed.
+		earlier := &LokiResponse{Data: LokiData{Result: last.streams}}
+		later := &LokiResponse{Data: LokiData{Result: extractStreams(last.end, e.end, e.streams)}}
+		ordered := []*LokiResponse{earlier, later}
+		if direction == logproto.BACKWARD {
+			ordered = []*LokiResponse{later, earlier}
+		}
+		last.streams = mergeOrderedNonOverlappingStreams(ordered, math.MaxUint32, direction)
+		last.end = e.end
+	}
+	return merged
+}
+
+// logResultCacheKey returns the cache key of the split log request.
+// The split interval is part of the key to ensure a cache key can't be reused when the interval changes.
+func logResultCacheKey(userID string, req *LokiRequest, interval time.Duration) string {
+	currentInterval := req.StartTs.UnixNano() / int64(interval)
+	return fmt.Sprintf("log:%s:%s:%d:%d:%d:%d", userID, req.Query, req.Limit, req.Direction, currentInterval, interval)
+}
+
+func (l *logResultCache) get(ctx context.Context, key string) ([]logExtent, bool) {
+	found, bufs, _, _ := l.cache.Fetch(ctx, []string{cache.HashKey(key)})
+	if len(found) != 1 {
+		return nil, false
+	}
+
+	var cached queryrangebase.CachedResponse
+	if err := proto.Unmarshal(bufs[0], &cached); err != nil {
+		level.Error(l.logger).Log("msg", "error unmarshalling cached value", "err", err)
+		return nil, false
+	}
+	if cached.Key != key {
+		return nil, false
+	}
+
+	extents := make([]logExtent, 0, len(cached.Extents))
+	for _, e := range cached.Extents {
+		var resp LokiResponse
+		if e.Response == nil || types.UnmarshalAny(e.Response, &resp) != nil {
+			return nil, false
+		}
+		extents = append(extents, logExtent{start: e.Start, end: e.End, streams: resp.Data.Result})
+	}
+	return extents, true
+}
+
+func (l *logResultCache) put(ctx context.Context, key string, direction logproto.Direction, extents []logExtent) {
+	cached := queryrangebase.CachedResponse{
+		Key:     key,
+		Extents: make([]queryrangebase.Extent, 0, len(extents)),
+	}
+	for _, e := range extents {
+		any, err := types.MarshalAny(&LokiResponse{
+			Status:    loghttp.QueryStatusSuccess,
+			Direction: direction,
+			Data: LokiData{
+				ResultType: loghttp.ResultTypeStream,
+				Result:     e.streams,
+			},
+		})
+		if err != nil {
+			level.Error(l.logger).Log("msg", "error marshalling cached extent", "err", err)
+			return
+		}
+		cached.Extents = append(cached.Extents, queryrangebase.Extent{Start: e.start, End: e.end, Response: any})
+	}
+
+	buf, err := proto.Marshal(&cached)
+	if err != nil {
+		level.Error(l.logger).Log("msg", "error marshalling cached value", "err", err)
+		return
+	}
+	_ = l.cache.Store(ctx, []string{cache.HashKey(key)}, [][]byte{buf})
+}
@@ -0,0 +1,202 @@
+package queryrange
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/querier/queryrange/queryrangebase"
+	"github.com/grafana/loki/pkg/storage/chunk/cache"
+	util_log "github.com/grafana/loki/pkg/util/log"
+)
+
+var logResultCacheTestStart = time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC)
+
+// logResultCacheTestStore answers log requests with an entry every minute, and records the requests it received.
+type logResultCacheTestStore struct {
+	requests []*LokiRequest
+}
+
+func (s *logResultCacheTestStore) Do(_ context.Context, r queryrangebase.Request) (queryrangebase.Response, error) {
+	req := r.(*LokiRequest)
+	s.requests = append(s.requests, req)
+
+	var entries []logproto.Entry
+	for ts := req.StartTs.Truncate(time.Minute); ts.Before(req.EndTs); ts = ts.Add(time.Minute) {
+		if ts.Before(req.StartTs) {
+			continue
+		}
+		entries = append(entries, logproto.Entry{Timestamp: ts, Line: ts.String()})
+	}
+	if req.Direction == logproto.BACKWARD {
+		for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+			entries[i], entries[j] = entries[j], entries[i]
+		}
+	}
+	if len(entries) > int(req.Limit) {
+		entries = entries[:req.Limit]
+	}
+
+	return &LokiResponse{
+		Status:    loghttp.QueryStatusSuccess,
+		Direction: req.Direction,
+		Limit:     req.Limit,
+		Version:   uint32(loghttp.VersionV1),
+		Data: LokiData{
+			ResultType: loghttp.ResultTypeStream,
+			Result:     []logproto.Stream{{Labels: `{app="foo"}`, Entries: entries}},
+		},
+	}, nil
+}
+
+func logResultCacheTestRequest(from, through time.Duration, limit uint32, direction logproto.Direction) *LokiRequest {
+	return &LokiRequest{
+		Query:     `{app="foo"} |= "bar"`,
+		Limit:     limit,
+		Direction: direction,
+		StartTs:   logResultCacheTestStart.Add(from),
+		EndTs:     logResultCacheTestStart.Add(through),
+		Path:      "/loki/api/v1/query_range",
+	}
+}
+
+func entryTimestamps(resp queryrangebase.Response) []time.Duration {
+	var result []time.Duration
+	for _, s := range resp.(*LokiResponse).Data.Result {
+		for _, e := range s.Entries {
+			result = append(result, e.Timestamp.Sub(logResultCacheTestStart))
+		}
+	}
+	return result
+}
+
+func Test_LogResultCache(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "1")
+	limits := fakeLimits{splits: map[string]time.Duration{"1": time.Hour}}
+
+	for _, tc := range []struct {
+		name string
+		// requests are sent in order, each one with the expected timestamps of its entries and the ranges fetched from the store.
+		requests []*LokiRequest
+		expected [][]time.Duration
+		fetched  [][][2]time.Duration
+	}{
+		{
+			name: "same request is served from the cache",
+			requests: []*LokiRequest{
+				logResultCacheTestRequest(0, 5*time.Minute, 100, logproto.FORWARD),
+				logResultCacheTestRequest(0, 5*time.Minute, 100, logproto.FORWARD),
+			},
+			expected: [][]time.Duration{
+				{0, time.Minute, 2 * time.Minute, 3 * time.Minute, 4 * time.Minute},
+				{0, time.Minute, 2 * time.Minute, 3 * time.Minute, 4 * time.Minute},
+			},
+			fetched: [][][2]time.Duration{
+				{{0, 5 * time.Minute}},
+				nil,
+			},
+		},
+		{
+			name: "only missing ranges are fetched",
+			requests: []*LokiRequest{
+				logResultCacheTestRequest(2*time.Minute, 4*time.Minute, 100, logproto.FORWARD),
+				logResultCacheTestRequest(0, 6*time.Minute, 100, logproto.FORWARD),
+				logResultCacheTestRequest(time.Minute, 5*time.Minute, 100, logproto.BACKWARD),
+			},
+			expected: [][]time.Duration{
+				{2 * time.Minute, 3 * time.Minute},
+				{0, time.Minute, 2 * time.Minute, 3 * time.Minute, 4 * time.Minute, 5 * time.Minute},
+				{4 * time.Minute, 3 * time.Minute, 2 * time.Minute, time.Minute},
+			},
+			fetched: [][][2]time.Duration{
+				{{2 * time.Minute, 4 * time.Minute}},
+				{{0, 2 * time.Minute}, {4 * time.Minute, 6 * time.Minute}},
+				// the backward query has its own cache key.
+				{{time.Minute, 5 * time.Minute}},
+			},
+		},
+		{
+			name: "forward limited responses",
+			requests: []*LokiRequest{
+				logResultCacheTestRequest(0, 10*time.Minute, 3, logproto.FORWARD),
+				logResultCacheTestRequest(0, 10*time.Minute, 3, logproto.FORWARD),
+				logResultCacheTestRequest(time.Minute, 10*time.Minute, 3, logproto.FORWARD),
+			},
+			expected: [][]time.Duration{
+				{0, time.Minute, 2 * time.Minute},
+				{0, time.Minute, 2 * time.Minute},
+				{time.Minute, 2 * time.Minute, 3 * time.Minute},
+			},
+			fetched: [][][2]time.Duration{
+				{{0, 10 * time.Minute}},
+				// entries at the timestamp of the last entry may have been truncated, they are fetched again.
+				{{2 * time.Minute, 10 * time.Minute}},
+				// the cached extents now hold enough entries to reach the limit.
+				nil,
+			},
+		},
+		{
+			name: "backward limited responses",
+			requests: []*LokiRequest{
+				logResultCacheTestRequest(0, 10*time.Minute, 3, logproto.BACKWARD),
+				logResultCacheTestRequest(0, 10*time.Minute, 3, logproto.BACKWARD),
+				logResultCacheTestRequest(0, 9*time.Minute, 3, logproto.BACKWARD),
+			},
+			expected: [][]time.Duration{
+				{9 * time.Minute, 8 * time.Minute, 7 * time.Minute},
+				{9 * time.Minute, 8 * time.Minute, 7 * time.Minute},
+				{8 * time.Minute, 7 * time.Minute, 6 * time.Minute},
+			},
+			fetched: [][][2]time.Duration{
+				{{0, 10 * time.Minute}},
+				{{0, 7*time.Minute + 1}},
+				nil,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &logResultCacheTestStore{}
+			handler := NewLogResultCacheMiddleware(util_log.Logger, limits, cache.NewMockCache(), nil).Wrap(store)
+
+			for i, req := range tc.requests {
+				store.requests = nil
+				resp, err := handler.Do(ctx, req)
+				require.NoError(t, err)
+				require.Equal(t, tc.expected[i], entryTimestamps(resp), "request %d", i)
+
+				var fetched [][2]time.Duration
+				for _, r := range store.requests {
+					fetched = append(fetched, [2]time.Duration{r.StartTs.Sub(logResultCacheTestStart), r.EndTs.Sub(logResultCacheTestStart)})
+				}
+				require.Equal(t, tc.fetched[i], fetched, "request %d", i)
+			}
+		})
+	}
+}
+
+func Test_LogResultCache_RecentRequests(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "1")
+	limits := fakeLimits{splits: map[string]time.Duration{"1": time.Hour}}
+	store := &logResultCacheTestStore{}
+	handler := NewLogResultCacheMiddleware(util_log.Logger, limits, cache.NewMockCache(), nil).Wrap(store)
+
+	// requests within the max cache freshness are never cached.
+	now := time.Now().Truncate(time.Minute)
+	req := &LokiRequest{
+		Query:     `{app="foo"} |= "bar"`,
+		Limit:     100,
+		Direction: logproto.FORWARD,
+		StartTs:   now.Add(-5 * time.Minute),
+		EndTs:     now,
+	}
+	for i := 0; i < 2; i++ {
+		_, err := handler.Do(ctx, req)
+		require.NoError(t, err)
+	}
+	require.Len(t, store.requests, 2)
+}
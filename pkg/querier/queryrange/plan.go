@@ -0,0 +1,256 @@
+package queryrange
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/grafana/dskit/concurrency"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/querier/queryrange/queryrangebase"
+	"github.com/grafana/loki/pkg/tenant"
+	"github.com/grafana/loki/pkg/util"
+	"github.com/grafana/loki/pkg/util/marshal"
+)
+
+// queryPlanner explains range queries without executing them. The query is planned like the tripperwares would
+// execute it: the limits are applied, the query is split by time and the splits are mapped to their sharded
+// equivalent. The queriers estimate the chunks and streams matched by the query and by each split from the index.
+type queryPlanner struct {
+	next    http.RoundTripper
+	cfg     Config
+	limits  Limits
+	confs   ShardingConfigs
+	metrics *logql.ShardingMetrics
+	now     func() time.Time
+}
+
+func newQueryPlanner(next http.RoundTripper, cfg Config, limits Limits, confs ShardingConfigs) queryPlanner {
+	return queryPlanner{
+		next:   next,
+		cfg:    cfg,
+		limits: limits,
+		confs:  confs,
+		// The mapping of the planned queries is not recorded in the metrics of the executed queries.
+		metrics: logql.NewShardingMetrics(nil),
+		now:     time.Now,
+	}
+}
+
+func (p queryPlanner) RoundTrip(req *http.Request) (*http.Response, error) {
+	rangeQuery, err := loghttp.ParseRangeQuery(req)
+	if err != nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+	expr, err := logql.ParseExpr(rangeQuery.Query)
+	if err != nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+	userID, err := tenant.TenantID(req.Context())
+	if err != nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+
+	var r queryrangebase.Request = &LokiRequest{
+		Query:     rangeQuery.Query,
+		Limit:     rangeQuery.Limit,
+		Direction: rangeQuery.Direction,
+		StartTs:   rangeQuery.Start.UTC(),
+		EndTs:     rangeQuery.End.UTC(),
+		// GetStep must return milliseconds
+		Step: int64(rangeQuery.Step) / 1e6,
+		Path: req.URL.Path,
+	}
+
+	// Only the metric queries and the log queries with filters go through the tripperwares.
+	var (
+		splitter     Splitter
+		resultsCache bool
+		middlewares  = []queryrangebase.Middleware{NewLimitsMiddleware(p.limits)}
+	)
+	switch e := expr.(type) {
+	case logql.SampleExpr:
+		splitter, resultsCache = splitMetricByTime, p.cfg.CacheResults
+		if p.cfg.AlignQueriesWithStep {
+			middlewares = append(middlewares, queryrangebase.StepAlignMiddleware)
+		}
+	case logql.LogSelectorExpr:
+		if e.HasFilter() {
+			splitter, resultsCache = splitByTime, p.cfg.CacheResults
+		}
+	}
+
+	if splitter != nil {
+		var limited queryrangebase.Request
+		_, err := queryrangebase.MergeMiddlewares(middlewares...).Wrap(queryrangebase.HandlerFunc(func(_ context.Context, r queryrangebase.Request) (queryrangebase.Response, error) {
+			limited = r
+			return nil, nil
+		})).Do(req.Context(), r)
+		if err != nil {
+			return nil, err
+		}
+		// The query is not executed at all when it is entirely before the max query lookback.
+		if limited == nil {
+			return p.response(loghttp.QueryPlan{Query: rangeQuery.Query, Start: rangeQuery.Start, End: rangeQuery.End, AST: logql.Explain(expr)})
+		}
+		r = limited
+	}
+
+	plan, err := p.estimate(req.Context(), req, util.TimeFromMillis(r.GetStart()), util.TimeFromMillis(r.GetEnd()))
+	if err != nil {
+		return nil, err
+	}
+	plan.Query, plan.AST, plan.ResultsCache = rangeQuery.Query, logql.Explain(expr), resultsCache
+	if splitter == nil {
+		return p.response(plan)
+	}
+
+	splits := []queryrangebase.Request{r}
+	if interval := p.limits.QuerySplitDuration(userID); interval != 0 {
+		if _, ok := expr.(logql.SampleExpr); ok {
+			if interval, err = reduceSplitIntervalForRangeVector(r, interval); err != nil {
+				return nil, err
+			}
+		}
+		plan.SplitInterval = interval.String()
+		reqs, err := splitter(r, interval)
+		if err != nil {
+			return nil, err
+		}
+		if len(reqs) > 0 {
+			splits = reqs
+		}
+	}
+
+	plan.Splits = make([]loghttp.QueryPlanSplit, len(splits))
+	jobs := make([]interface{}, len(splits))
+	for i, split := range splits {
+		plan.Splits[i] = loghttp.QueryPlanSplit{
+			Start: util.TimeFromMillis(split.GetStart()),
+			End:   util.TimeFromMillis(split.GetEnd()),
+		}
+		p.shard(userID, split, &plan.Splits[i])
+		jobs[i] = i
+	}
+
+	// The splits are estimated with the parallelism the tenant would query them with.
+	parallelism := p.limits.MaxQueryParallelism(userID)
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	err = concurrency.ForEach(req.Context(), jobs, parallelism, func(ctx context.Context, job interface{}) error {
+		s := &plan.Splits[job.(int)]
+		estimate, err := p.estimate(ctx, req, s.Start, s.End)
+		if err != nil {
+			return err
+		}
+		s.Chunks, s.Streams = estimate.Chunks, estimate.Streams
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return p.response(plan)
+}
+
+// shard maps the split to its sharded equivalent, or tells why it is not sharded.
+func (p queryPlanner) shard(userID string, r queryrangebase.Request, split *loghttp.QueryPlanSplit) {
+	if !p.cfg.ShardedQueries {
+		split.NotShardedReason = "sharded queries are disabled"
+		return
+	}
+	if !hasShards(p.confs) {
+		split.NotShardedReason = "no schema config with shards"
+		return
+	}
+	if minShardingLookback := p.limits.MinShardingLookback(userID); minShardingLookback != 0 &&
+		!util.TimeFromMillis(r.GetEnd()).Before(p.now().Add(-minShardingLookback)) {
+		split.NotShardedReason = fmt.Sprintf("the split ends within the min sharding lookback (%s)", minShardingLookback)
+		return
+	}
+
+	conf, err := p.confs.GetConf(r)
+	if err != nil {
+		split.NotShardedReason = err.Error()
+		return
+	}
+	mapper, err := logql.NewShardMapper(int(conf.RowShards), p.metrics)
+	if err != nil {
+		split.NotShardedReason = err.Error()
+		return
+	}
+	noop, mapped, err := mapper.Parse(r.GetQuery())
+	if err != nil {
+		split.NotShardedReason = err.Error()
+		return
+	}
+	if noop {
+		split.NotShardedReason = "the query has no sharded equivalent"
+		return
+	}
+	split.Shards = int(conf.RowShards)
+	split.ShardedQuery = mapped.String()
+}
+
+// estimate asks the queriers for the plan of the query between start and end.
+func (p queryPlanner) estimate(ctx context.Context, req *http.Request, start, end time.Time) (loghttp.QueryPlan, error) {
+	params := url.Values{}
+	for k, v := range req.Form {
+		params[k] = v
+	}
+	params.Set("start", fmt.Sprintf("%d", start.UnixNano()))
+	params.Set("end", fmt.Sprintf("%d", end.UnixNano()))
+	u := &url.URL{
+		Path:     "/loki/api/v1/query_plan",
+		RawQuery: params.Encode(),
+	}
+	downstream := &http.Request{
+		Method:     "GET",
+		RequestURI: u.String(), // This is what the httpgrpc code looks at.
+		URL:        u,
+		Body:       http.NoBody,
+		Header:     req.Header.Clone(),
+	}
+
+	resp, err := p.next.RoundTrip(downstream.WithContext(ctx))
+	if err != nil {
+		return loghttp.QueryPlan{}, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return loghttp.QueryPlan{}, httpgrpc.Errorf(http.StatusInternalServerError, "error decoding response: %v", err)
+	}
+	if resp.StatusCode/100 != 2 {
+		return loghttp.QueryPlan{}, httpgrpc.Errorf(resp.StatusCode, string(body))
+	}
+	var planResp loghttp.QueryPlanResponse
+	if err := json.Unmarshal(body, &planResp); err != nil {
+		return loghttp.QueryPlan{}, httpgrpc.Errorf(http.StatusInternalServerError, "error decoding response: %v", err)
+	}
+	return planResp.Data, nil
+}
+
+func (p queryPlanner) response(plan loghttp.QueryPlan) (*http.Response, error) {
+	var buf bytes.Buffer
+	if err := marshal.WriteQueryPlanResponseJSON(plan, &buf); err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		Header: http.Header{
+			"Content-Type": []string{"application/json"},
+		},
+		Body:       ioutil.NopCloser(&buf),
+		StatusCode: http.StatusOK,
+	}, nil
+}
@@ -0,0 +1,111 @@
+package queryrange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/storage/chunk"
+	util_log "github.com/grafana/loki/pkg/util/log"
+)
+
+func TestQueryPlanTripperware(t *testing.T) {
+	cfg := testConfig
+	cfg.ShardedQueries = true
+	schema := chunk.SchemaConfig{Configs: []chunk.PeriodConfig{{RowShards: 2}}}
+	tpw, stopper, err := NewTripperware(cfg, util_log.Logger, fakeLimits{maxQueryParallelism: 2, splits: map[string]time.Duration{"1": time.Hour}}, schema, nil)
+	if stopper != nil {
+		defer stopper.Stop()
+	}
+	require.NoError(t, err)
+
+	// The queriers estimate a chunk per minute of the range.
+	var (
+		mtx                   sync.Mutex
+		estimated             []string
+		inflight, maxInflight int
+	)
+	rt := tpw(http.RoundTripper(roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		require.Equal(t, "/loki/api/v1/query_plan", r.URL.Path)
+		start, _ := strconv.ParseInt(r.URL.Query().Get("start"), 10, 64)
+		end, _ := strconv.ParseInt(r.URL.Query().Get("end"), 10, 64)
+		mtx.Lock()
+		estimated = append(estimated, r.URL.Query().Get("query"))
+		inflight++
+		if inflight > maxInflight {
+			maxInflight = inflight
+		}
+		mtx.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mtx.Lock()
+		inflight--
+		mtx.Unlock()
+		rec := httptest.NewRecorder()
+		fmt.Fprintf(rec, `{"status":"success","data":{"chunks":%d,"streams":1}}`, time.Duration(end-start)/time.Minute)
+		return rec.Result(), nil
+	})))
+
+	plan := func(query string) loghttp.QueryPlan {
+		params := url.Values{
+			"query": {query},
+			"start": {fmt.Sprintf("%d", testTime.Add(-2*time.Hour).UnixNano())},
+			"end":   {fmt.Sprintf("%d", testTime.UnixNano())},
+			"step":  {"60"},
+		}
+		req, err := http.NewRequest(http.MethodGet, "/loki/api/v1/query_plan?"+params.Encode(), nil)
+		require.NoError(t, err)
+		resp, err := rt.RoundTrip(req.WithContext(user.InjectOrgID(context.Background(), "1")))
+		require.NoError(t, err)
+		var planResp loghttp.QueryPlanResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&planResp))
+		return planResp.Data
+	}
+
+	p := plan(`sum(rate({app="foo"} |= "bar" [1m]))`)
+	require.Equal(t, "vector_aggregation", p.AST.Type)
+	require.True(t, p.ResultsCache)
+	require.Equal(t, "1h0m0s", p.SplitInterval)
+	require.Equal(t, 120, p.Chunks)
+	// The range is aligned with the step and split at the hours.
+	require.Len(t, p.Splits, 3)
+	require.Equal(t, testTime.Add(-2*time.Hour).Truncate(time.Minute), p.Splits[0].Start.UTC())
+	require.Equal(t, 49, p.Splits[0].Chunks)
+	for _, s := range p.Splits {
+		require.Equal(t, 2, s.Shards)
+		require.Contains(t, s.ShardedQuery, `downstream<sum(rate({app="foo"} |= "bar"[1m])), shard=0_of_2>`)
+		require.Empty(t, s.NotShardedReason)
+	}
+	require.Len(t, estimated, 4)
+	// The splits are estimated with the max query parallelism of the tenant.
+	require.Equal(t, 2, maxInflight)
+
+	// The log queries without filters are neither split nor sharded.
+	estimated = nil
+	p = plan(`{app="foo"}`)
+	require.Equal(t, "selector", p.AST.Type)
+	require.False(t, p.ResultsCache)
+	require.Empty(t, p.Splits)
+	require.Equal(t, 120, p.Chunks)
+	require.Len(t, estimated, 1)
+
+	// The queries which can't be mapped are not sharded.
+	p = plan(`rate({app="foo"} | label_format app="bar" [1m])`)
+	require.Equal(t, "the query has no sharded equivalent", p.Splits[0].NotShardedReason)
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
@@ -32,7 +32,9 @@ func (PrometheusExtractor) Extract(start, end int64, from queryrangebase.Respons
 	}
 }
 
-// ResponseWithoutHeaders wraps the original prometheus caching without headers
+// ResponseWithoutHeaders wraps the original prometheus caching without headers. The statistics are not cached either:
+// they are the ones of the query which fetched the response, and the splits and shards breakdown of the cached extents
+// would be reported again by every cache hit.
 func (PrometheusExtractor) ResponseWithoutHeaders(resp queryrangebase.Response) queryrangebase.Response {
 	response := extractor.ResponseWithoutHeaders(resp.(*LokiPromResponse).Response)
 	return &LokiPromResponse{
@@ -9,13 +9,32 @@ import (
 
 	"github.com/grafana/loki/pkg/loghttp"
 	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logqlmodel/stats"
 	"github.com/grafana/loki/pkg/querier/queryrange/queryrangebase"
 )
 
 var emptyStats = `"stats": {
+	"cache": {
+		"chunk": {
+			"entriesRequested": 0,
+			"entriesFound": 0
+		},
+		"index": {
+			"entriesRequested": 0,
+			"entriesFound": 0
+		},
+		"result": {
+			"entriesRequested": 0,
+			"entriesFound": 0
+		}
+	},
 	"ingester" : {
 		"store": {
 			"chunksDownloadTime": 0,
+			"index": {
+				"totalQueries": 0,
+				"lookupTime": 0
+			},
 			"totalChunksRef": 0,
 			"totalChunksDownloaded": 0,
 			"chunk" :{
@@ -35,6 +54,10 @@ var emptyStats = `"stats": {
 	"querier": {
 		"store": {
 			"chunksDownloadTime": 0,
+			"index": {
+				"totalQueries": 0,
+				"lookupTime": 0
+			},
 			"totalChunksRef": 0,
 			"totalChunksDownloaded": 0,
 			"chunk" :{
@@ -53,7 +76,9 @@ var emptyStats = `"stats": {
 		"linesProcessedPerSecond": 0,
 		"queueTime": 0,
 		"totalBytesProcessed":0,
-		"totalLinesProcessed":0
+		"totalLinesProcessed":0,
+		"splits": 0,
+		"shards": 0
 	}
 }`
 
@@ -169,3 +194,36 @@ func Test_encodePromResponse(t *testing.T) {
 		})
 	}
 }
+
+func Test_PrometheusExtractorDropsStatistics(t *testing.T) {
+	resp := &LokiPromResponse{
+		Response: &queryrangebase.PrometheusResponse{
+			Status: loghttp.QueryStatusSuccess,
+			Data: queryrangebase.PrometheusData{
+				ResultType: loghttp.ResultTypeMatrix,
+				Result: []queryrangebase.SampleStream{{
+					Labels:  []logproto.LabelAdapter{{Name: "foo", Value: "bar"}},
+					Samples: []logproto.LegacySample{{Value: 1, TimestampMs: 1000}, {Value: 2, TimestampMs: 2000}},
+				}},
+			},
+		},
+		Statistics: stats.Result{
+			Splits: []stats.SubQuery{{Start: 1, End: 2, Shards: []stats.SubQuery{{Shard: "0_of_2"}}}},
+			Shards: []stats.SubQuery{{Shard: "1_of_2"}},
+		},
+	}
+
+	// The extents of the results cache hold neither the statistics nor their breakdown.
+	cached := PrometheusExtractor{}.ResponseWithoutHeaders(resp).(*LokiPromResponse)
+	require.Equal(t, stats.Result{}, cached.Statistics)
+	require.Equal(t, resp.Response.Data, cached.Response.Data)
+	extracted := PrometheusExtractor{}.Extract(0, 1500, resp).(*LokiPromResponse)
+	require.Equal(t, stats.Result{}, extracted.Statistics)
+
+	// Merging the cached extents does not bring back the breakdown of the queries which fetched them.
+	merged, err := LokiCodec.MergeResponse(cached, extracted)
+	require.NoError(t, err)
+	require.Empty(t, merged.(*LokiPromResponse).Statistics.Splits)
+	require.Empty(t, merged.(*LokiPromResponse).Statistics.Shards)
+	require.Zero(t, merged.(*LokiPromResponse).Statistics.Summary.Shards)
+}
@@ -47,16 +47,19 @@ func NewTripperware(
 	retryMetrics := queryrangebase.NewRetryMiddlewareMetrics(registerer)
 	shardingMetrics := logql.NewShardingMetrics(registerer)
 	splitByMetrics := NewSplitByMetrics(registerer)
+	logResultCacheMetrics := NewLogResultCacheMetrics(registerer)
+	emptyResultCacheMetrics := NewEmptyResultCacheMetrics(registerer)
 
 	metricsTripperware, cache, err := NewMetricTripperware(cfg, log, limits, schema, LokiCodec,
-		PrometheusExtractor{}, instrumentMetrics, retryMetrics, shardingMetrics, splitByMetrics, registerer)
+		PrometheusExtractor{}, instrumentMetrics, retryMetrics, shardingMetrics, splitByMetrics, emptyResultCacheMetrics, registerer)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	// NOTE: When we would start caching response from non-metric queries we would have to consider cache gen headers as well in
+	// The log results cache shares the cache of the metric results, their keys can't collide.
+	// NOTE: Cache gen numbers are not used for results caching, if they were we would have to consider cache gen headers as well in
 	// MergeResponse implementation for Loki codecs same as it is done in Cortex at https://github.com/cortexproject/cortex/blob/21bad57b346c730d684d6d0205efef133422ab28/pkg/querier/queryrange/query_range.go#L170
-	logFilterTripperware, err := NewLogFilterTripperware(cfg, log, limits, schema, LokiCodec, instrumentMetrics, retryMetrics, shardingMetrics, splitByMetrics)
+	logFilterTripperware, err := NewLogFilterTripperware(cfg, log, limits, schema, LokiCodec, cache, instrumentMetrics, retryMetrics, shardingMetrics, splitByMetrics, logResultCacheMetrics, emptyResultCacheMetrics)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -81,18 +84,19 @@ func NewTripperware(
 		seriesRT := seriesTripperware(next)
 		labelsRT := labelsTripperware(next)
 		instantRT := instantMetricTripperware(next)
-		return newRoundTripper(next, logFilterRT, metricRT, seriesRT, labelsRT, instantRT, limits)
+		planRT := newQueryPlanner(next, cfg, limits, schema.Configs)
+		return newRoundTripper(next, logFilterRT, metricRT, seriesRT, labelsRT, instantRT, planRT, limits)
 	}, cache, nil
 }
 
 type roundTripper struct {
-	next, log, metric, series, labels, instantMetric http.RoundTripper
+	next, log, metric, series, labels, instantMetric, plan http.RoundTripper
 
 	limits Limits
 }
 
 // newRoundTripper creates a new queryrange roundtripper
-func newRoundTripper(next, log, metric, series, labels, instantMetric http.RoundTripper, limits Limits) roundTripper {
+func newRoundTripper(next, log, metric, series, labels, instantMetric, plan http.RoundTripper, limits Limits) roundTripper {
 	return roundTripper{
 		log:           log,
 		limits:        limits,
@@ -100,6 +104,7 @@ func newRoundTripper(next, log, metric, series, labels, instantMetric http.Round
 		series:        series,
 		labels:        labels,
 		instantMetric: instantMetric,
+		plan:          plan,
 		next:          next,
 	}
 }
@@ -167,6 +172,8 @@ func (r roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 		default:
 			return r.next.RoundTrip(req)
 		}
+	case QueryPlanOp:
+		return r.plan.RoundTrip(req)
 	default:
 		return r.next.RoundTrip(req)
 	}
@@ -211,6 +218,7 @@ const (
 	QueryRangeOp   = "query_range"
 	SeriesOp       = "series"
 	LabelNamesOp   = "labels"
+	QueryPlanOp    = "query_plan"
 )
 
 func getOperation(path string) string {
@@ -223,22 +231,28 @@ func getOperation(path string) string {
 		return LabelNamesOp
 	case strings.HasSuffix(path, "/v1/query"):
 		return InstantQueryOp
+	case strings.HasSuffix(path, "/query_plan"):
+		return QueryPlanOp
 	default:
 		return ""
 	}
 }
 
 // NewLogFilterTripperware creates a new frontend tripperware responsible for handling log requests with regex.
+// When results caching is enabled, the splits of the requests are cached in c when the tenant has a split interval.
 func NewLogFilterTripperware(
 	cfg Config,
 	log log.Logger,
 	limits Limits,
 	schema chunk.SchemaConfig,
 	codec queryrangebase.Codec,
+	c cache.Cache,
 	instrumentMetrics *queryrangebase.InstrumentMiddlewareMetrics,
 	retryMiddlewareMetrics *queryrangebase.RetryMiddlewareMetrics,
 	shardingMetrics *logql.ShardingMetrics,
 	splitByMetrics *SplitByMetrics,
+	logResultCacheMetrics *LogResultCacheMetrics,
+	emptyResultCacheMetrics *EmptyResultCacheMetrics,
 ) (queryrangebase.Tripperware, error) {
 	queryRangeMiddleware := []queryrangebase.Middleware{
 		StatsCollectorMiddleware(),
@@ -247,6 +261,16 @@ func NewLogFilterTripperware(
 		SplitByIntervalMiddleware(limits, codec, splitByTime, splitByMetrics),
 	}
 
+	if cfg.CacheResults && c != nil {
+		queryRangeMiddleware = append(
+			queryRangeMiddleware,
+			queryrangebase.InstrumentMiddleware("empty_results_cache", instrumentMetrics),
+			NewEmptyResultCacheMiddleware(limits, c, emptyResultCacheMetrics),
+			queryrangebase.InstrumentMiddleware("log_results_cache", instrumentMetrics),
+			NewLogResultCacheMiddleware(log, limits, c, logResultCacheMetrics),
+		)
+	}
+
 	if cfg.ShardedQueries {
 		queryRangeMiddleware = append(queryRangeMiddleware,
 			NewQueryShardMiddleware(
@@ -348,7 +372,9 @@ func NewLabelsTripperware(
 	}, nil
 }
 
-// NewMetricTripperware creates a new frontend tripperware responsible for handling metric queries
+// NewMetricTripperware creates a new frontend tripperware responsible for handling metric queries.
+// It also returns the results cache it uses, which is shared with the log filter tripperware so that the results
+// of log queries are cached too, once they are split by interval.
 func NewMetricTripperware(
 	cfg Config,
 	log log.Logger,
@@ -360,8 +386,9 @@ func NewMetricTripperware(
 	retryMiddlewareMetrics *queryrangebase.RetryMiddlewareMetrics,
 	shardingMetrics *logql.ShardingMetrics,
 	splitByMetrics *SplitByMetrics,
+	emptyResultCacheMetrics *EmptyResultCacheMetrics,
 	registerer prometheus.Registerer,
-) (queryrangebase.Tripperware, Stopper, error) {
+) (queryrangebase.Tripperware, cache.Cache, error) {
 	queryRangeMiddleware := []queryrangebase.Middleware{StatsCollectorMiddleware(), NewLimitsMiddleware(limits)}
 	if cfg.AlignQueriesWithStep {
 		queryRangeMiddleware = append(
@@ -398,6 +425,8 @@ func NewMetricTripperware(
 		c = cache
 		queryRangeMiddleware = append(
 			queryRangeMiddleware,
+			queryrangebase.InstrumentMiddleware("empty_results_cache", instrumentMetrics),
+			NewEmptyResultCacheMiddleware(limits, c, emptyResultCacheMetrics),
 			queryrangebase.InstrumentMiddleware("results_cache", instrumentMetrics),
 			queryCacheMiddleware,
 		)
@@ -218,6 +218,50 @@ func TestLogFilterTripperware(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestLogFilterTripperware_CachesResults(t *testing.T) {
+	l := fakeLimits{maxQueryParallelism: 1, splits: map[string]time.Duration{"1": 24 * time.Hour}}
+	tpw, stopper, err := NewTripperware(testConfig, util_log.Logger, l, chunk.SchemaConfig{}, nil)
+	if stopper != nil {
+		defer stopper.Stop()
+	}
+	require.NoError(t, err)
+	rt, err := newfakeRoundTripper()
+	require.NoError(t, err)
+	defer rt.Close()
+
+	lreq := &LokiRequest{
+		Query:     `{app="foo"} |= "foo"`,
+		Limit:     1000,
+		StartTs:   testTime.Add(-6 * time.Hour),
+		EndTs:     testTime,
+		Direction: logproto.FORWARD,
+		Path:      "/loki/api/v1/query_range",
+	}
+	ctx := user.InjectOrgID(context.Background(), "1")
+
+	count, h := promqlResult(streams)
+	rt.setHandler(h)
+	var fetched int
+	for i := 0; i < 2; i++ {
+		req, err := LokiCodec.EncodeRequest(ctx, lreq)
+		require.NoError(t, err)
+		req = req.WithContext(ctx)
+		require.NoError(t, user.InjectOrgIDIntoHTTPRequest(ctx, req))
+
+		resp, err := tpw(rt).RoundTrip(req)
+		require.NoError(t, err)
+		lokiResponse, err := LokiCodec.DecodeResponse(ctx, resp, lreq)
+		require.NoError(t, err)
+		require.Equal(t, []logproto.Stream(streams), lokiResponse.(*LokiResponse).Data.Result)
+		if i == 0 {
+			fetched = *count
+		}
+	}
+	// the second query is answered by the results cache returned by the metric tripperware.
+	require.NotZero(t, fetched)
+	require.Equal(t, fetched, *count)
+}
+
 func TestInstantQueryTripperware(t *testing.T) {
 	testShardingConfig := testConfig
 	testShardingConfig.ShardedQueries = true
@@ -480,6 +524,10 @@ func TestPostQueries(t *testing.T) {
 			t.Error("unexpected instant roundtripper called")
 			return nil, nil
 		}),
+		queryrangebase.RoundTripFunc(func(*http.Request) (*http.Response, error) {
+			t.Error("unexpected plan roundtripper called")
+			return nil, nil
+		}),
 		fakeLimits{},
 	).RoundTrip(req)
 	require.NoError(t, err)
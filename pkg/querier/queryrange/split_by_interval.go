@@ -149,6 +149,9 @@ func (h *splitByInterval) loop(ctx context.Context, ch <-chan *lokiResult, next
 		data.req.LogToSpan(sp)
 
 		resp, err := next.Do(ctx, data.req)
+		if err == nil {
+			recordSplitStatistics(data.req, resp)
+		}
 
 		select {
 		case <-ctx.Done():
@@ -16,6 +16,7 @@ import (
 
 	"github.com/grafana/loki/pkg/loghttp"
 	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logqlmodel/stats"
 )
 
 var nilMetrics = NewSplitByMetrics(nil)
@@ -606,10 +607,11 @@ func Test_splitByInterval_Do(t *testing.T) {
 				Path:      "/api/prom/query_range",
 			},
 			&LokiResponse{
-				Status:    loghttp.QueryStatusSuccess,
-				Direction: logproto.BACKWARD,
-				Limit:     1000,
-				Version:   1,
+				Status:     loghttp.QueryStatusSuccess,
+				Direction:  logproto.BACKWARD,
+				Limit:      1000,
+				Version:    1,
+				Statistics: splitsStatistics(3, 2, 1, 0),
 				Data: LokiData{
 					ResultType: loghttp.ResultTypeStream,
 					Result: []logproto.Stream{
@@ -638,10 +640,11 @@ func Test_splitByInterval_Do(t *testing.T) {
 				Path:      "/api/prom/query_range",
 			},
 			&LokiResponse{
-				Status:    loghttp.QueryStatusSuccess,
-				Direction: logproto.FORWARD,
-				Limit:     1000,
-				Version:   1,
+				Status:     loghttp.QueryStatusSuccess,
+				Direction:  logproto.FORWARD,
+				Limit:      1000,
+				Version:    1,
+				Statistics: splitsStatistics(0, 1, 2, 3),
 				Data: LokiData{
 					ResultType: loghttp.ResultTypeStream,
 					Result: []logproto.Stream{
@@ -670,10 +673,11 @@ func Test_splitByInterval_Do(t *testing.T) {
 				Path:      "/api/prom/query_range",
 			},
 			&LokiResponse{
-				Status:    loghttp.QueryStatusSuccess,
-				Direction: logproto.FORWARD,
-				Limit:     2,
-				Version:   1,
+				Status:     loghttp.QueryStatusSuccess,
+				Direction:  logproto.FORWARD,
+				Limit:      2,
+				Version:    1,
+				Statistics: splitsStatistics(0, 1),
 				Data: LokiData{
 					ResultType: loghttp.ResultTypeStream,
 					Result: []logproto.Stream{
@@ -700,10 +704,11 @@ func Test_splitByInterval_Do(t *testing.T) {
 				Path:      "/api/prom/query_range",
 			},
 			&LokiResponse{
-				Status:    loghttp.QueryStatusSuccess,
-				Direction: logproto.BACKWARD,
-				Limit:     2,
-				Version:   1,
+				Status:     loghttp.QueryStatusSuccess,
+				Direction:  logproto.BACKWARD,
+				Limit:      2,
+				Version:    1,
+				Statistics: splitsStatistics(3, 2),
 				Data: LokiData{
 					ResultType: loghttp.ResultTypeStream,
 					Result: []logproto.Stream{
@@ -729,6 +734,18 @@ func Test_splitByInterval_Do(t *testing.T) {
 	}
 }
 
+// splitsStatistics returns the statistics of the hourly splits starting at the given hours.
+func splitsStatistics(hours ...int) stats.Result {
+	res := stats.Result{Summary: stats.Summary{Splits: int64(len(hours))}}
+	for _, h := range hours {
+		res.Splits = append(res.Splits, stats.SubQuery{
+			Start: (time.Duration(h) * time.Hour).Nanoseconds(),
+			End:   (time.Duration(h+1) * time.Hour).Nanoseconds(),
+		})
+	}
+	return res
+}
+
 func Test_series_splitByInterval_Do(t *testing.T) {
 	ctx := user.InjectOrgID(context.Background(), "1")
 	next := queryrangebase.HandlerFunc(func(_ context.Context, r queryrangebase.Request) (queryrangebase.Response, error) {
@@ -849,10 +866,11 @@ func Test_ExitEarly(t *testing.T) {
 	}
 
 	expected := &LokiResponse{
-		Status:    loghttp.QueryStatusSuccess,
-		Direction: logproto.FORWARD,
-		Limit:     2,
-		Version:   1,
+		Status:     loghttp.QueryStatusSuccess,
+		Direction:  logproto.FORWARD,
+		Limit:      2,
+		Version:    1,
+		Statistics: splitsStatistics(0, 1),
 		Data: LokiData{
 			ResultType: loghttp.ResultTypeStream,
 			Result: []logproto.Stream{
@@ -83,6 +83,9 @@ func StatsCollectorMiddleware() queryrangebase.Middleware {
 			logger := spanlogger.FromContext(ctx)
 			start := time.Now()
 
+			// the caches looked up by the frontend are recorded in the context.
+			statsCtx, ctx := stats.NewContext(ctx)
+
 			// execute the request
 			resp, err := next.Do(ctx, req)
 
@@ -101,6 +104,7 @@ func StatsCollectorMiddleware() queryrangebase.Middleware {
 				}
 			}
 			if statistics != nil {
+				statistics.Caches.Merge(statsCtx.Caches())
 				// Re-calculate the summary: the queueTime result is already merged so should not be updated
 				// Log and record metrics for the current query
 				statistics.ComputeSummary(time.Since(start), 0)
@@ -122,6 +126,27 @@ func StatsCollectorMiddleware() queryrangebase.Middleware {
 	})
 }
 
+// recordSplitStatistics moves the statistics of the response of a split to the splits breakdown, for them to be merged
+// with the ones of the other splits.
+func recordSplitStatistics(req queryrangebase.Request, resp queryrangebase.Response) {
+	var statistics *stats.Result
+	switch r := resp.(type) {
+	case *LokiResponse:
+		statistics = &r.Statistics
+	case *LokiPromResponse:
+		statistics = &r.Statistics
+	default:
+		return
+	}
+
+	split := statistics.SubQuery()
+	split.Start = req.GetStart() * int64(time.Millisecond)
+	split.End = req.GetEnd() * int64(time.Millisecond)
+	statistics.Shards = nil
+	statistics.Splits = []stats.SubQuery{split}
+	statistics.ComputeSummary(stats.ConvertSecondsToNanoseconds(statistics.Summary.ExecTime), 0)
+}
+
 // interceptor implements WriteHeader to intercept status codes. WriteHeader
 // may not be called on success, so initialize statusCode with the status you
 // want to report on success, i.e. http.StatusOK.
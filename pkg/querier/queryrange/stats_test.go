@@ -165,3 +165,58 @@ func Test_StatsUpdateResult(t *testing.T) {
 	require.NoError(t, err)
 	require.GreaterOrEqual(t, resp.(*LokiResponse).Statistics.Summary.ExecTime, (20 * time.Millisecond).Seconds())
 }
+
+func TestStatsCollectorMiddleware_Caches(t *testing.T) {
+	data := &queryData{}
+	ctx := context.WithValue(context.Background(), ctxKey, data)
+	_, _ = StatsCollectorMiddleware().Wrap(queryrangebase.HandlerFunc(func(ctx context.Context, r queryrangebase.Request) (queryrangebase.Response, error) {
+		// the results caches are looked up by the frontend.
+		stats.FromContext(ctx).AddCacheLookup(stats.ResultCache, true)
+		stats.FromContext(ctx).AddCacheLookup(stats.ResultCache, false)
+		// the chunks caches are looked up by the queriers.
+		return &LokiResponse{
+			Statistics: stats.Result{
+				Caches: stats.Caches{
+					Chunk: stats.Cache{EntriesRequested: 10, EntriesFound: 5},
+				},
+			},
+		}, nil
+	})).Do(ctx, &LokiRequest{
+		Query:   "foo",
+		StartTs: time.Now(),
+	})
+	require.Equal(t, stats.Caches{
+		Chunk:  stats.Cache{EntriesRequested: 10, EntriesFound: 5},
+		Result: stats.Cache{EntriesRequested: 2, EntriesFound: 1},
+	}, data.statistics.Caches)
+}
+
+func Test_recordSplitStatistics(t *testing.T) {
+	start := time.Unix(0, 0)
+	req := &LokiRequest{Query: `{app="foo"}`, StartTs: start, EndTs: start.Add(time.Hour)}
+	resp := &LokiPromResponse{
+		Statistics: stats.Result{
+			Summary: stats.Summary{ExecTime: 1},
+			Querier: stats.Querier{Store: stats.Store{TotalChunksDownloaded: 3}},
+			Shards:  []stats.SubQuery{{Shard: "0_of_2"}, {Shard: "1_of_2"}},
+		},
+	}
+	recordSplitStatistics(req, resp)
+
+	require.Empty(t, resp.Statistics.Shards)
+	require.Len(t, resp.Statistics.Splits, 1)
+	split := resp.Statistics.Splits[0]
+	require.Equal(t, start.UnixNano(), split.Start)
+	require.Equal(t, start.Add(time.Hour).UnixNano(), split.End)
+	require.Equal(t, int64(3), split.TotalChunksDownloaded)
+	require.Len(t, split.Shards, 2)
+	require.Equal(t, int64(1), resp.Statistics.Summary.Splits)
+	require.Equal(t, int64(2), resp.Statistics.Summary.Shards)
+
+	// The splits are concatenated when the responses are merged.
+	var merged stats.Result
+	merged.Merge(resp.Statistics)
+	merged.Merge(resp.Statistics)
+	require.Len(t, merged.Splits, 2)
+	require.Equal(t, int64(4), merged.Summary.Shards)
+}
@@ -29,6 +29,10 @@ const (
 	// with the next successfully pushed response. Once the dropped entries memory buffer
 	// exceed this value, we start skipping dropped entries too.
 	maxDroppedEntriesPerTailResponse = 1000
+
+	// the period of the checkpoints sent to the client, with the timestamp of the last
+	// entry delivered per stream, to resume the tail after a disconnection
+	tailCheckpointPeriod = time.Second * 10
 )
 
 // Tailer manages complete lifecycle of a tail request
@@ -45,6 +49,12 @@ type Tailer struct {
 	querierTailClients    map[string]logproto.Querier_TailClient // addr -> grpc clients for tailing logs from ingesters
 	querierTailClientsMtx sync.RWMutex
 
+	// the timestamp of the last entry delivered per stream since the last checkpoint
+	checkpoints map[string]time.Time
+	// the timestamp of the first entry dropped per stream, which the checkpoints of the stream never reach
+	// since the client misses the entries from there
+	dropped map[string]time.Time
+
 	stopped         bool
 	delayFor        time.Duration
 	responseChan    chan *loghttp.TailResponse
@@ -54,6 +64,8 @@ type Tailer struct {
 	// if we are not seeing any response from ingester,
 	// how long do we want to wait by going into sleep
 	waitEntryThrottle time.Duration
+
+	checkpointPeriod time.Duration
 }
 
 func (t *Tailer) readTailClients() {
@@ -74,6 +86,9 @@ func (t *Tailer) loop() {
 	tailMaxDurationTicker := time.NewTicker(t.tailMaxDuration)
 	defer tailMaxDurationTicker.Stop()
 
+	checkpointTicker := time.NewTicker(t.checkpointPeriod)
+	defer checkpointTicker.Stop()
+
 	droppedEntries := make([]loghttp.DroppedEntry, 0)
 
 	for !t.stopped {
@@ -89,6 +104,8 @@ func (t *Tailer) loop() {
 			}
 			t.closeErrChan <- errors.New("reached tail max duration limit")
 			return
+		case <-checkpointTicker.C:
+			t.sendCheckpoints()
 		default:
 		}
 
@@ -102,6 +119,7 @@ func (t *Tailer) loop() {
 			// to save the effort
 			if t.isResponseChanBlocked() {
 				droppedEntries = dropEntry(droppedEntries, t.currEntry.Timestamp, t.currLabels)
+				t.markDropped(t.currLabels, t.currEntry.Timestamp)
 				continue
 			}
 
@@ -150,15 +168,65 @@ func (t *Tailer) loop() {
 
 		select {
 		case t.responseChan <- tailResponse:
+			t.updateCheckpoints(tailResponse)
 			if len(droppedEntries) > 0 {
 				droppedEntries = make([]loghttp.DroppedEntry, 0)
 			}
 		default:
 			droppedEntries = dropEntries(droppedEntries, tailResponse.Streams)
+			for _, stream := range tailResponse.Streams {
+				for _, entry := range stream.Entries {
+					t.markDropped(stream.Labels, entry.Timestamp)
+				}
+			}
+		}
+	}
+}
+
+// updates the checkpoints of the streams with the entries of the response sent to the client,
+// up to before the first entry dropped of each stream
+func (t *Tailer) updateCheckpoints(resp *loghttp.TailResponse) {
+	for _, stream := range resp.Streams {
+		for _, entry := range stream.Entries {
+			ts := entry.Timestamp
+			if dropped, ok := t.dropped[stream.Labels]; ok && !ts.Before(dropped) {
+				ts = dropped.Add(-time.Nanosecond)
+			}
+			if ts.After(t.checkpoints[stream.Labels]) {
+				t.checkpoints[stream.Labels] = ts
+			}
 		}
 	}
 }
 
+// records an entry dropped for a slow client, moving the pending checkpoint of its stream back before it
+func (t *Tailer) markDropped(labels string, ts time.Time) {
+	if dropped, ok := t.dropped[labels]; ok && !ts.Before(dropped) {
+		return
+	}
+	t.dropped[labels] = ts
+	if checkpoint, ok := t.checkpoints[labels]; ok && !checkpoint.Before(ts) {
+		t.checkpoints[labels] = ts.Add(-time.Nanosecond)
+	}
+}
+
+// sends the checkpoints of the streams updated since the last checkpoint, unless the
+// response channel is blocked, in which case they are sent on the next period
+func (t *Tailer) sendCheckpoints() {
+	if len(t.checkpoints) == 0 {
+		return
+	}
+	resp := &loghttp.TailResponse{Checkpoints: make([]loghttp.TailCheckpoint, 0, len(t.checkpoints))}
+	for labels, ts := range t.checkpoints {
+		resp.Checkpoints = append(resp.Checkpoints, loghttp.TailCheckpoint{Timestamp: ts, Labels: labels})
+	}
+	select {
+	case t.responseChan <- resp:
+		t.checkpoints = make(map[string]time.Time)
+	default:
+	}
+}
+
 // Checks whether we are connected to all the ingesters to tail the logs.
 // Helps in connecting to disconnected ingesters or connecting to new ingesters
 func (t *Tailer) checkIngesterConnections() error {
@@ -270,16 +338,20 @@ func newTailer(
 	tailDisconnectedIngesters func([]string) (map[string]logproto.Querier_TailClient, error),
 	tailMaxDuration time.Duration,
 	waitEntryThrottle time.Duration,
+	checkpointPeriod time.Duration,
 ) *Tailer {
 	t := Tailer{
 		openStreamIterator:        iter.NewMergeEntryIterator(context.Background(), []iter.EntryIterator{historicEntries}, logproto.FORWARD),
 		querierTailClients:        querierTailClients,
+		checkpoints:               make(map[string]time.Time),
+		dropped:                   make(map[string]time.Time),
 		delayFor:                  delayFor,
 		responseChan:              make(chan *loghttp.TailResponse, maxBufferedTailResponses),
 		closeErrChan:              make(chan error),
 		tailDisconnectedIngesters: tailDisconnectedIngesters,
 		tailMaxDuration:           tailMaxDuration,
 		waitEntryThrottle:         waitEntryThrottle,
+		checkpointPeriod:          checkpointPeriod,
 	}
 
 	t.readTailClients()
@@ -298,7 +370,7 @@ func dropEntry(droppedEntries []loghttp.DroppedEntry, timestamp time.Time, label
 func dropEntries(droppedEntries []loghttp.DroppedEntry, streams []logproto.Stream) []loghttp.DroppedEntry {
 	for _, stream := range streams {
 		for _, entry := range stream.Entries {
-			droppedEntries = dropEntry(droppedEntries, entry.Timestamp, entry.Line)
+			droppedEntries = dropEntry(droppedEntries, entry.Timestamp, stream.Labels)
 		}
 	}
 
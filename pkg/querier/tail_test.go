@@ -14,8 +14,9 @@ import (
 )
 
 const (
-	timeout  = 1 * time.Second
-	throttle = 10 * time.Millisecond
+	timeout    = 1 * time.Second
+	throttle   = 10 * time.Millisecond
+	checkpoint = 1 * time.Hour
 )
 
 func TestTailer(t *testing.T) {
@@ -161,7 +162,7 @@ func TestTailer(t *testing.T) {
 				tailClients["test"] = test.tailClient
 			}
 
-			tailer := newTailer(0, tailClients, test.historicEntries, tailDisconnectedIngesters, timeout, throttle)
+			tailer := newTailer(0, tailClients, test.historicEntries, tailDisconnectedIngesters, timeout, throttle, checkpoint)
 			defer tailer.close()
 
 			test.tester(t, tailer, test.tailClient)
@@ -169,6 +170,62 @@ func TestTailer(t *testing.T) {
 	}
 }
 
+func TestTailer_Checkpoints(t *testing.T) {
+	t.Parallel()
+
+	tailDisconnectedIngesters := func([]string) (map[string]logproto.Querier_TailClient, error) {
+		return map[string]logproto.Querier_TailClient{}, nil
+	}
+	tailer := newTailer(0, map[string]logproto.Querier_TailClient{}, mockStreamIterator(1, 3), tailDisconnectedIngesters, timeout, throttle, 50*time.Millisecond)
+	defer tailer.close()
+
+	responses, err := readFromTailer(tailer, 3)
+	require.NoError(t, err)
+	require.Equal(t, 3, countEntriesInStreams(flattenStreamsFromResponses(responses)))
+
+	// The checkpoint of the stream is the timestamp of its last delivered entry.
+	select {
+	case response := <-tailer.getResponseChan():
+		require.Empty(t, response.Streams)
+		require.Equal(t, []loghttp.TailCheckpoint{{Timestamp: time.Unix(3, 0), Labels: mockStream(3, 1).Labels}}, response.Checkpoints)
+	case <-time.After(timeout):
+		t.Fatal("timeout expired while waiting for the checkpoint")
+	}
+
+	// There is no checkpoint until new entries are delivered.
+	select {
+	case response := <-tailer.getResponseChan():
+		t.Fatalf("unexpected response %v", response)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestTailer_CheckpointsBeforeDroppedEntries(t *testing.T) {
+	t.Parallel()
+
+	tailer := &Tailer{checkpoints: map[string]time.Time{}, dropped: map[string]time.Time{}}
+	response := func(labels string, secs ...int64) *loghttp.TailResponse {
+		stream := logproto.Stream{Labels: labels}
+		for _, sec := range secs {
+			stream.Entries = append(stream.Entries, logproto.Entry{Timestamp: time.Unix(sec, 0)})
+		}
+		return &loghttp.TailResponse{Streams: []logproto.Stream{stream}}
+	}
+
+	tailer.updateCheckpoints(response(`{app="a"}`, 1, 2))
+	tailer.updateCheckpoints(response(`{app="b"}`, 1, 2))
+	// The pending checkpoint of a stream is moved back before its dropped entries, and never reaches them.
+	tailer.markDropped(`{app="a"}`, time.Unix(2, 0))
+	tailer.markDropped(`{app="a"}`, time.Unix(3, 0))
+	require.Equal(t, time.Unix(2, 0).Add(-time.Nanosecond), tailer.checkpoints[`{app="a"}`])
+	tailer.updateCheckpoints(response(`{app="a"}`, 4))
+	tailer.updateCheckpoints(response(`{app="b"}`, 4))
+	require.Equal(t, map[string]time.Time{
+		`{app="a"}`: time.Unix(2, 0).Add(-time.Nanosecond),
+		`{app="b"}`: time.Unix(4, 0),
+	}, tailer.checkpoints)
+}
+
 func readFromTailer(tailer *Tailer, maxEntries int) ([]*loghttp.TailResponse, error) {
 	responses := make([]*loghttp.TailResponse, 0)
 	entriesCount := 0
@@ -20,6 +20,7 @@ import (
 	"github.com/grafana/loki/pkg/logql/log"
 	"github.com/grafana/loki/pkg/logqlmodel/stats"
 	"github.com/grafana/loki/pkg/querier/astmapper"
+	"github.com/grafana/loki/pkg/storage/bloom"
 	"github.com/grafana/loki/pkg/storage/chunk"
 	util_log "github.com/grafana/loki/pkg/util/log"
 )
@@ -85,6 +86,7 @@ type batchChunkIterator struct {
 	metrics         *ChunkMetrics
 	matchers        []*labels.Matcher
 	chunkFilterer   ChunkFilterer
+	bloomFilter     *bloomChunkFilter
 
 	begun      bool
 	ctx        context.Context
@@ -104,6 +106,7 @@ func newBatchChunkIterator(
 	metrics *ChunkMetrics,
 	matchers []*labels.Matcher,
 	chunkFilterer ChunkFilterer,
+	bloomFilter *bloomChunkFilter,
 ) *batchChunkIterator {
 	// __name__ is not something we filter by because it's a constant in loki
 	// and only used for upstream compatibility; therefore remove it.
@@ -121,6 +124,7 @@ func newBatchChunkIterator(
 		chunks:        lazyChunks{direction: direction, chunks: chunks},
 		next:          make(chan *chunkBatch),
 		chunkFilterer: chunkFilterer,
+		bloomFilter:   bloomFilter,
 	}
 	sort.Sort(res.chunks)
 	return res
@@ -178,7 +182,8 @@ func (it *batchChunkIterator) nextBatch() (res *chunkBatch) {
 		if !includesOverlap && it.direction == logproto.FORWARD {
 			batch = append(batch, it.lastOverlapping...)
 		}
-		batch = append(batch, it.chunks.pop(it.batchSize)...)
+		// chunks which can't contain the lines looked for are skipped before being downloaded.
+		batch = append(batch, it.bloomFilter.filter(it.ctx, it.chunks.pop(it.batchSize))...)
 		if !includesOverlap && it.direction == logproto.BACKWARD {
 			batch = append(batch, it.lastOverlapping...)
 		}
@@ -324,13 +329,14 @@ func newLogBatchIterator(
 	direction logproto.Direction,
 	start, end time.Time,
 	chunkFilterer ChunkFilterer,
+	bloomFilter *bloomChunkFilter,
 ) (iter.EntryIterator, error) {
 	ctx, cancel := context.WithCancel(ctx)
 	return &logBatchIterator{
 		pipeline:           pipeline,
 		ctx:                ctx,
 		cancel:             cancel,
-		batchChunkIterator: newBatchChunkIterator(ctx, schemas, chunks, batchSize, direction, start, end, metrics, matchers, chunkFilterer),
+		batchChunkIterator: newBatchChunkIterator(ctx, schemas, chunks, batchSize, direction, start, end, metrics, matchers, chunkFilterer, bloomFilter),
 	}, nil
 }
 
@@ -469,13 +475,14 @@ func newSampleBatchIterator(
 	extractor logql.SampleExtractor,
 	start, end time.Time,
 	chunkFilterer ChunkFilterer,
+	bloomFilter *bloomChunkFilter,
 ) (iter.SampleIterator, error) {
 	ctx, cancel := context.WithCancel(ctx)
 	return &sampleBatchIterator{
 		extractor:          extractor,
 		ctx:                ctx,
 		cancel:             cancel,
-		batchChunkIterator: newBatchChunkIterator(ctx, schemas, chunks, batchSize, logproto.FORWARD, start, end, metrics, matchers, chunkFilterer),
+		batchChunkIterator: newBatchChunkIterator(ctx, schemas, chunks, batchSize, logproto.FORWARD, start, end, metrics, matchers, chunkFilterer, bloomFilter),
 	}, nil
 }
 
@@ -587,6 +594,47 @@ func (it *sampleBatchIterator) buildHeapIterator(chks [][]*LazyChunk, from, thro
 	return iter.NewMergeSampleIterator(it.ctx, result), nil
 }
 
+// bloomChunkFilter skips the chunks whose bloom filters show they can't contain the strings of the line filters of a query.
+type bloomChunkFilter struct {
+	store       *bloom.Store
+	lineFilters []string
+}
+
+// newBloomChunkFilter returns nil, which skips no chunks, when there are no bloom filters or no line filters to check.
+func newBloomChunkFilter(store *bloom.Store, expr logql.LogSelectorExpr) *bloomChunkFilter {
+	if store == nil {
+		return nil
+	}
+	lineFilters := logql.RequiredLineFilters(expr)
+	if len(lineFilters) == 0 {
+		return nil
+	}
+	return &bloomChunkFilter{store: store, lineFilters: lineFilters}
+}
+
+func (f *bloomChunkFilter) filter(ctx context.Context, chunks []*LazyChunk) []*LazyChunk {
+	if f == nil || len(chunks) == 0 {
+		return chunks
+	}
+
+	chks := make([]chunk.Chunk, 0, len(chunks))
+	for _, c := range chunks {
+		chks = append(chks, c.Chunk)
+	}
+	mayContain := f.store.MayContain(ctx, chks, f.lineFilters)
+
+	filtered := make([]*LazyChunk, 0, len(chunks))
+	for i, c := range chunks {
+		if mayContain[i] {
+			filtered = append(filtered, c)
+		}
+	}
+	if skipped := len(chunks) - len(filtered); skipped > 0 {
+		level.Debug(util_log.WithContext(ctx, util_log.Logger)).Log("msg", "skipped chunks using bloom filters", "skipped", skipped, "chunks", len(chunks))
+	}
+	return filtered
+}
+
 func removeMatchersByName(matchers []*labels.Matcher, names ...string) []*labels.Matcher {
 	for _, omit := range names {
 		for i := range matchers {
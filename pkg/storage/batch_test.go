@@ -19,7 +19,10 @@ import (
 	"github.com/grafana/loki/pkg/logql"
 	"github.com/grafana/loki/pkg/logql/log"
 	"github.com/grafana/loki/pkg/logqlmodel/stats"
+	"github.com/grafana/loki/pkg/storage/bloom"
 	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/storage/chunk/cache"
+	"github.com/grafana/loki/pkg/storage/chunk/local"
 )
 
 var NilMetrics = NewChunkMetrics(nil, 0)
@@ -52,7 +55,7 @@ func Test_batchIterSafeStart(t *testing.T) {
 		},
 	}
 
-	batch := newBatchChunkIterator(context.Background(), s, chks, 1, logproto.FORWARD, from, from.Add(4*time.Millisecond), NilMetrics, []*labels.Matcher{}, nil)
+	batch := newBatchChunkIterator(context.Background(), s, chks, 1, logproto.FORWARD, from, from.Add(4*time.Millisecond), NilMetrics, []*labels.Matcher{}, nil, nil)
 
 	// if it was started already, we should see a panic before this
 	time.Sleep(time.Millisecond)
@@ -964,7 +967,7 @@ func Test_newLogBatchChunkIterator(t *testing.T) {
 	for name, tt := range tests {
 		tt := tt
 		t.Run(name, func(t *testing.T) {
-			it, err := newLogBatchIterator(context.Background(), s, NilMetrics, tt.chunks, tt.batchSize, newMatchers(tt.matchers), log.NewNoopPipeline(), tt.direction, tt.start, tt.end, nil)
+			it, err := newLogBatchIterator(context.Background(), s, NilMetrics, tt.chunks, tt.batchSize, newMatchers(tt.matchers), log.NewNoopPipeline(), tt.direction, tt.start, tt.end, nil, nil)
 			require.NoError(t, err)
 			streams, _, err := iter.ReadBatch(it, 1000)
 			_ = it.Close()
@@ -1375,7 +1378,7 @@ func Test_newSampleBatchChunkIterator(t *testing.T) {
 			ex, err := log.NewLineSampleExtractor(log.CountExtractor, nil, nil, false, false)
 			require.NoError(t, err)
 
-			it, err := newSampleBatchIterator(context.Background(), s, NilMetrics, tt.chunks, tt.batchSize, newMatchers(tt.matchers), ex, tt.start, tt.end, nil)
+			it, err := newSampleBatchIterator(context.Background(), s, NilMetrics, tt.chunks, tt.batchSize, newMatchers(tt.matchers), ex, tt.start, tt.end, nil, nil)
 			require.NoError(t, err)
 			series, _, err := iter.ReadSampleBatch(it, 1000)
 			_ = it.Close()
@@ -1665,7 +1668,7 @@ func TestBatchCancel(t *testing.T) {
 		},
 	}
 
-	it, err := newLogBatchIterator(ctx, s, NilMetrics, chunks, 1, newMatchers(fooLabels.String()), log.NewNoopPipeline(), logproto.FORWARD, from, time.Now(), nil)
+	it, err := newLogBatchIterator(ctx, s, NilMetrics, chunks, 1, newMatchers(fooLabels.String()), log.NewNoopPipeline(), logproto.FORWARD, from, time.Now(), nil, nil)
 	require.NoError(t, err)
 	defer require.NoError(t, it.Close())
 	for it.Next() {
@@ -1727,3 +1730,57 @@ func newOverlappingStreams(streamCount int, entryCount int) []*logproto.Stream {
 	}
 	return streams
 }
+
+func Test_bloomChunkFilter(t *testing.T) {
+	s := chunk.SchemaConfig{
+		Configs: []chunk.PeriodConfig{
+			{
+				From:      chunk.DayTime{Time: 0},
+				Schema:    "v11",
+				RowShards: 16,
+			},
+		},
+	}
+	objectClient, err := local.NewFSObjectClient(local.FSConfig{Directory: t.TempDir()})
+	require.NoError(t, err)
+	bloomStore := bloom.NewStore(bloom.Config{
+		SharedStoreKeyPrefix: "blooms/",
+		NGramLength:          4,
+		FalsePositiveRate:    0.01,
+		MaxFetchConcurrency:  1,
+	}, s, objectClient, cache.NewNoopCache(), nil)
+
+	newTestChunk := func(line string) *LazyChunk {
+		return newLazyChunk(logproto.Stream{
+			Labels:  fooLabelsWithName.String(),
+			Entries: []logproto.Entry{{Timestamp: from, Line: line}},
+		})
+	}
+	withNeedle := newTestChunk("msg=done trace_id=abc")
+	withoutNeedle := newTestChunk("msg=done trace_id=def")
+	withoutBloom := newTestChunk("msg=failed")
+	bloomStore.Put(context.Background(), []chunk.Chunk{withNeedle.Chunk, withoutNeedle.Chunk})
+	chunks := []*LazyChunk{withNeedle, withoutNeedle, withoutBloom}
+
+	for _, tc := range []struct {
+		query    string
+		expected []*LazyChunk
+	}{
+		{`{foo="bar"}`, chunks},
+		{`{foo="bar"} != "trace_id=abc"`, chunks},
+		{`{foo="bar"} |= "trace_id=abc"`, []*LazyChunk{withNeedle, withoutBloom}},
+		{`{foo="bar"} |= "msg=done" |= "trace_id=abc"`, []*LazyChunk{withNeedle, withoutBloom}},
+		{`{foo="bar"} | line_format "trace_id=abc" |= "trace_id=abc"`, chunks},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			expr, err := logql.ParseLogSelector(tc.query, true)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, newBloomChunkFilter(bloomStore, expr).filter(context.Background(), chunks))
+		})
+	}
+
+	// without bloom filters no chunk is skipped.
+	expr, err := logql.ParseLogSelector(`{foo="bar"} |= "trace_id=abc"`, true)
+	require.NoError(t, err)
+	require.Equal(t, chunks, newBloomChunkFilter(nil, expr).filter(context.Background(), chunks))
+}
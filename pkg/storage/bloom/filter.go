@@ -0,0 +1,137 @@
+package bloom
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+const (
+	filterFormatV1 = byte(1)
+
+	// filterHeaderSize is the size of the format, the length of the n-grams, the number of hash functions and the number of bits.
+	filterHeaderSize = 1 + 1 + 4 + 8
+)
+
+var errInvalidFilter = errors.New("invalid bloom filter")
+
+// Filter is a bloom filter of the n-grams of the lines of a chunk.
+// It can tell for sure that a chunk does not contain a string, but may give false positives.
+type Filter struct {
+	bits []uint64
+	// m is the number of bits of the filter and k the number of hash functions.
+	m uint64
+	k uint32
+	// ngramLength is the length of the n-grams added to the filter, needed for testing strings against it.
+	ngramLength int
+}
+
+// NewFilter creates a filter of n-grams of the given length, sized for n distinct n-grams with the given false positive rate.
+func NewFilter(ngramLength, n int, falsePositiveRate float64) *Filter {
+	if n < 1 {
+		n = 1
+	}
+
+	m := uint64(math.Ceil(-float64(n) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	if m < 64 {
+		m = 64
+	}
+	k := uint32(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+
+	return &Filter{
+		bits:        make([]uint64, (m+63)/64),
+		m:           m,
+		k:           k,
+		ngramLength: ngramLength,
+	}
+}
+
+// Add adds the token to the filter.
+func (f *Filter) Add(token []byte) {
+	f.addHash(xxhash.Sum64(token))
+}
+
+// Test returns false if the token was never added to the filter, and true if it may have been.
+func (f *Filter) Test(token []byte) bool {
+	return f.testHash(xxhash.Sum64(token))
+}
+
+// addHash and testHash derive the k locations of a token from its hash using double hashing.
+func (f *Filter) addHash(h uint64) {
+	h1, h2 := h&math.MaxUint32, h>>32
+	for i := uint64(0); i < uint64(f.k); i++ {
+		loc := (h1 + i*h2) % f.m
+		f.bits[loc/64] |= 1 << (loc % 64)
+	}
+}
+
+func (f *Filter) testHash(h uint64) bool {
+	h1, h2 := h&math.MaxUint32, h>>32
+	for i := uint64(0); i < uint64(f.k); i++ {
+		loc := (h1 + i*h2) % f.m
+		if f.bits[loc/64]&(1<<(loc%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Marshal encodes the filter.
+func (f *Filter) Marshal() []byte {
+	buf := make([]byte, filterHeaderSize+8*len(f.bits))
+	buf[0] = filterFormatV1
+	buf[1] = byte(f.ngramLength)
+	binary.BigEndian.PutUint32(buf[2:], f.k)
+	binary.BigEndian.PutUint64(buf[6:], f.m)
+	for i, word := range f.bits {
+		binary.BigEndian.PutUint64(buf[filterHeaderSize+8*i:], word)
+	}
+	return buf
+}
+
+// UnmarshalFilter decodes a filter encoded with Marshal.
+func UnmarshalFilter(buf []byte) (*Filter, error) {
+	if len(buf) < filterHeaderSize || buf[0] != filterFormatV1 {
+		return nil, errInvalidFilter
+	}
+
+	f := &Filter{
+		ngramLength: int(buf[1]),
+		k:           binary.BigEndian.Uint32(buf[2:]),
+		m:           binary.BigEndian.Uint64(buf[6:]),
+	}
+	if f.ngramLength == 0 || f.k == 0 || f.m == 0 || uint64(len(buf)-filterHeaderSize) != 8*((f.m+63)/64) {
+		return nil, errInvalidFilter
+	}
+
+	f.bits = make([]uint64, (f.m+63)/64)
+	for i := range f.bits {
+		f.bits[i] = binary.BigEndian.Uint64(buf[filterHeaderSize+8*i:])
+	}
+	return f, nil
+}
+
+// forEachNGram calls fn for each n-gram of the string, i.e. each of its substrings of n bytes.
+func forEachNGram(s string, n int, fn func(token []byte)) {
+	b := []byte(s)
+	for i := 0; i+n <= len(b); i++ {
+		fn(b[i : i+n])
+	}
+}
+
+// MayContain returns false if none of the lines of the chunk can contain the string, i.e. if any of its n-grams is missing from the filter.
+// Strings shorter than the n-grams cannot be tested and may always be contained.
+func (f *Filter) MayContain(s string) bool {
+	contained := true
+	forEachNGram(s, f.ngramLength, func(token []byte) {
+		if contained && !f.Test(token) {
+			contained = false
+		}
+	})
+	return contained
+}
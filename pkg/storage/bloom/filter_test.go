@@ -0,0 +1,44 @@
+package bloom
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilter(t *testing.T) {
+	filter := NewFilter(4, 1000, 0.01)
+	for i := 0; i < 100; i++ {
+		forEachNGram(fmt.Sprintf("level=info msg=\"request done\" trace_id=%08d", i), 4, filter.Add)
+	}
+
+	for _, s := range []string{"trace_id=00000042", "request done", "level=info", "abc"} {
+		require.True(t, filter.MayContain(s), s)
+	}
+	require.False(t, filter.MayContain("level=error"))
+	require.False(t, filter.MayContain("trace_id=abcdef"))
+
+	decoded, err := UnmarshalFilter(filter.Marshal())
+	require.NoError(t, err)
+	require.Equal(t, filter, decoded)
+
+	_, err = UnmarshalFilter(filter.Marshal()[:20])
+	require.Equal(t, errInvalidFilter, err)
+}
+
+func TestFilter_FalsePositiveRate(t *testing.T) {
+	const n = 10000
+	filter := NewFilter(4, n, 0.01)
+	for i := 0; i < n; i++ {
+		filter.Add([]byte(fmt.Sprintf("in-%d", i)))
+	}
+
+	var falsePositives int
+	for i := 0; i < n; i++ {
+		if filter.Test([]byte(fmt.Sprintf("out-%d", i))) {
+			falsePositives++
+		}
+	}
+	require.Less(t, float64(falsePositives)/n, 0.02)
+}
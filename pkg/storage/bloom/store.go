@@ -0,0 +1,339 @@
+package bloom
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/concurrency"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/loki/pkg/chunkenc"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql/log"
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/storage/chunk/cache"
+	shipper_util "github.com/grafana/loki/pkg/storage/stores/shipper/util"
+	util_log "github.com/grafana/loki/pkg/util/log"
+)
+
+// Config for the bloom filters of the chunks.
+type Config struct {
+	Enabled              bool         `yaml:"enabled"`
+	SharedStoreType      string       `yaml:"shared_store"`
+	SharedStoreKeyPrefix string       `yaml:"shared_store_key_prefix"`
+	NGramLength          int          `yaml:"ngram_length"`
+	FalsePositiveRate    float64      `yaml:"false_positive_rate"`
+	MaxFetchConcurrency  int          `yaml:"max_fetch_concurrency"`
+	CacheConfig          cache.Config `yaml:"cache_config"`
+}
+
+// RegisterFlags registers flags.
+func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "store.bloom-filters.enabled", false, "Build bloom filters of the lines of the chunks when flushing them, and use them for skipping the chunks which cannot match the line filters of the queries.")
+	f.StringVar(&cfg.SharedStoreType, "store.bloom-filters.shared-store", "", "Store for keeping the bloom filters. Supported types: gcs, s3, azure, filesystem")
+	f.StringVar(&cfg.SharedStoreKeyPrefix, "store.bloom-filters.shared-store.key-prefix", "blooms/", "Prefix to add to Object Keys in Shared store. Path separator(if any) should always be a '/'. Prefix should never start with a separator but should always end with it")
+	f.IntVar(&cfg.NGramLength, "store.bloom-filters.ngram-length", 4, "Length in bytes of the n-grams of the lines added to the bloom filters. Line filters shorter than this can't be checked against the bloom filters.")
+	f.Float64Var(&cfg.FalsePositiveRate, "store.bloom-filters.false-positive-rate", 0.01, "Target rate of false positives of the bloom filters. Lower rates skip more chunks at the cost of bigger bloom filters.")
+	f.IntVar(&cfg.MaxFetchConcurrency, "store.bloom-filters.max-fetch-concurrency", 16, "Maximum number of bloom filters fetched concurrently for a batch of chunks.")
+	cfg.CacheConfig.RegisterFlagsWithPrefix("store.bloom-filters-cache.", "Cache config for bloom filters. ", f)
+}
+
+func (cfg *Config) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.SharedStoreType == "" {
+		return errors.New("store.bloom-filters.shared-store must be set when bloom filters are enabled")
+	}
+	if cfg.NGramLength < 1 || cfg.NGramLength > 255 {
+		return errors.New("store.bloom-filters.ngram-length must be between 1 and 255")
+	}
+	if cfg.FalsePositiveRate <= 0 || cfg.FalsePositiveRate >= 1 {
+		return errors.New("store.bloom-filters.false-positive-rate must be between 0 and 1")
+	}
+	if cfg.MaxFetchConcurrency < 1 {
+		return errors.New("store.bloom-filters.max-fetch-concurrency must be greater than 0")
+	}
+	return shipper_util.ValidateSharedStoreKeyPrefix(cfg.SharedStoreKeyPrefix)
+}
+
+// Key returns the key in the object store of the bloom filter of the chunk with the external key.
+func (cfg Config) Key(externalKey string) string {
+	return cfg.SharedStoreKeyPrefix + externalKey
+}
+
+type metrics struct {
+	filtersBuilt    *prometheus.CounterVec
+	filterBytes     prometheus.Histogram
+	chunksChecked   *prometheus.CounterVec
+	filterFetchTime prometheus.Histogram
+}
+
+func newMetrics(r prometheus.Registerer) *metrics {
+	return &metrics{
+		filtersBuilt: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki",
+			Subsystem: "bloom_filters",
+			Name:      "built_total",
+			Help:      "Total number of bloom filters built for flushed chunks, partitioned by status.",
+		}, []string{"status"}),
+		filterBytes: promauto.With(r).NewHistogram(prometheus.HistogramOpts{
+			Namespace: "loki",
+			Subsystem: "bloom_filters",
+			Name:      "size_bytes",
+			Help:      "Size of the bloom filters built for flushed chunks.",
+			Buckets:   prometheus.ExponentialBuckets(1024, 4, 8),
+		}),
+		chunksChecked: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki",
+			Subsystem: "bloom_filters",
+			Name:      "chunks_checked_total",
+			Help:      "Total number of chunks checked against the line filters of the queries, partitioned by whether they were skipped, kept or had no bloom filter.",
+		}, []string{"status"}),
+		filterFetchTime: promauto.With(r).NewHistogram(prometheus.HistogramOpts{
+			Namespace: "loki",
+			Subsystem: "bloom_filters",
+			Name:      "fetch_duration_seconds",
+			Help:      "Time taken for fetching the bloom filters of a batch of chunks.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+	}
+}
+
+const (
+	statusSuccess = "success"
+	statusFailure = "failure"
+
+	statusSkipped  = "skipped"
+	statusKept     = "kept"
+	statusNoFilter = "no_filter"
+)
+
+// Store builds the bloom filters of the n-grams of the lines of the flushed chunks, and stores them in the object store next to the index.
+// Queries use them for skipping the chunks which cannot contain the strings of their line filters without downloading them.
+// A chunk without a bloom filter, e.g. one flushed before the bloom filters were enabled, is never skipped.
+type Store struct {
+	cfg         Config
+	schemaCfg   chunk.SchemaConfig
+	client      chunk.ObjectClient
+	filterCache cache.Cache
+	metrics     *metrics
+}
+
+// NewStore creates a Store keeping the bloom filters in the object store.
+func NewStore(cfg Config, schemaCfg chunk.SchemaConfig, client chunk.ObjectClient, filterCache cache.Cache, registerer prometheus.Registerer) *Store {
+	return &Store{
+		cfg:         cfg,
+		schemaCfg:   schemaCfg,
+		client:      client,
+		filterCache: filterCache,
+		metrics:     newMetrics(registerer),
+	}
+}
+
+func (s *Store) key(c chunk.Chunk) string {
+	return s.cfg.Key(s.schemaCfg.ExternalKey(c))
+}
+
+// Put builds and stores the bloom filters of the chunks.
+// Failing to do so only makes the queries download the chunks, so errors are logged rather than returned.
+func (s *Store) Put(ctx context.Context, chunks []chunk.Chunk) {
+	for _, c := range chunks {
+		if err := s.putOne(ctx, c); err != nil {
+			s.metrics.filtersBuilt.WithLabelValues(statusFailure).Inc()
+			level.Warn(util_log.WithContext(ctx, util_log.Logger)).Log("msg", "failed to store bloom filter of chunk", "chunk", s.schemaCfg.ExternalKey(c), "err", err)
+			continue
+		}
+		s.metrics.filtersBuilt.WithLabelValues(statusSuccess).Inc()
+	}
+}
+
+func (s *Store) putOne(ctx context.Context, c chunk.Chunk) error {
+	filter, err := buildFilter(ctx, c, s.cfg.NGramLength, s.cfg.FalsePositiveRate)
+	if err != nil {
+		return err
+	}
+
+	buf := filter.Marshal()
+	s.metrics.filterBytes.Observe(float64(len(buf)))
+
+	key := s.key(c)
+	if err := s.client.PutObject(ctx, key, bytes.NewReader(buf)); err != nil {
+		return err
+	}
+	if err := s.filterCache.Store(ctx, []string{key}, [][]byte{buf}); err != nil {
+		level.Warn(util_log.WithContext(ctx, util_log.Logger)).Log("msg", "failed to cache bloom filter", "key", key, "err", err)
+	}
+	return nil
+}
+
+// buildFilter builds the bloom filter of the n-grams of the lines of the chunk.
+func buildFilter(ctx context.Context, c chunk.Chunk, ngramLength int, falsePositiveRate float64) (*Filter, error) {
+	facade, ok := c.Data.(*chunkenc.Facade)
+	if !ok {
+		return nil, fmt.Errorf("unexpected chunk encoding %T", c.Data)
+	}
+
+	lokiChunk := facade.LokiChunk()
+	from, through := lokiChunk.Bounds()
+	it, err := lokiChunk.Iterator(ctx, from, through.Add(time.Nanosecond), logproto.FORWARD, log.NewNoopPipeline().ForStream(c.Metric))
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+
+	// the n-grams are deduped before sizing the filter since the lines of a stream usually share most of them.
+	hashes := map[uint64]struct{}{}
+	for it.Next() {
+		forEachNGram(it.Entry().Line, ngramLength, func(token []byte) {
+			hashes[xxhash.Sum64(token)] = struct{}{}
+		})
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+
+	filter := NewFilter(ngramLength, len(hashes), falsePositiveRate)
+	for h := range hashes {
+		filter.addHash(h)
+	}
+	return filter, nil
+}
+
+// MayContain returns for each chunk whether it may contain lines including all the strings.
+// Chunks whose bloom filter can't be fetched are assumed to contain them.
+func (s *Store) MayContain(ctx context.Context, chunks []chunk.Chunk, strs []string) []bool {
+	res := make([]bool, len(chunks))
+	for i := range res {
+		res[i] = true
+	}
+	if len(chunks) == 0 || len(strs) == 0 {
+		return res
+	}
+
+	filters := s.fetchFilters(ctx, chunks)
+
+	var skipped, kept, noFilter int
+	for i, filter := range filters {
+		if filter == nil {
+			noFilter++
+			continue
+		}
+		for _, str := range strs {
+			if !filter.MayContain(str) {
+				res[i] = false
+				break
+			}
+		}
+		if res[i] {
+			kept++
+		} else {
+			skipped++
+		}
+	}
+
+	s.metrics.chunksChecked.WithLabelValues(statusSkipped).Add(float64(skipped))
+	s.metrics.chunksChecked.WithLabelValues(statusKept).Add(float64(kept))
+	s.metrics.chunksChecked.WithLabelValues(statusNoFilter).Add(float64(noFilter))
+	return res
+}
+
+// fetchFilters fetches the bloom filters of the chunks from the cache, or else from the object store.
+// The filter of a chunk is nil if it doesn't exist or can't be fetched.
+func (s *Store) fetchFilters(ctx context.Context, chunks []chunk.Chunk) []*Filter {
+	start := time.Now()
+	defer func() {
+		s.metrics.filterFetchTime.Observe(time.Since(start).Seconds())
+	}()
+
+	logger := util_log.WithContext(ctx, util_log.Logger)
+	filters := make([]*Filter, len(chunks))
+
+	keys := make([]string, 0, len(chunks))
+	indexes := make(map[string][]int, len(chunks))
+	for i, c := range chunks {
+		key := s.key(c)
+		if _, ok := indexes[key]; !ok {
+			keys = append(keys, key)
+		}
+		indexes[key] = append(indexes[key], i)
+	}
+
+	set := func(key string, buf []byte) bool {
+		filter, err := UnmarshalFilter(buf)
+		if err != nil {
+			level.Warn(logger).Log("msg", "failed to decode bloom filter", "key", key, "err", err)
+			return false
+		}
+		for _, i := range indexes[key] {
+			filters[i] = filter
+		}
+		return true
+	}
+
+	found, bufs, missing, err := s.filterCache.Fetch(ctx, keys)
+	if err != nil {
+		level.Warn(logger).Log("msg", "failed to fetch bloom filters from cache", "err", err)
+		found, bufs, missing = nil, nil, keys
+	}
+	for i, key := range found {
+		if !set(key, bufs[i]) {
+			missing = append(missing, key)
+		}
+	}
+
+	var (
+		mtx         sync.Mutex
+		fetchedKeys []string
+		fetchedBufs [][]byte
+	)
+	_ = concurrency.ForEach(ctx, concurrency.CreateJobsFromStrings(missing), s.cfg.MaxFetchConcurrency, func(ctx context.Context, job interface{}) error {
+		key := job.(string)
+		buf, err := s.getObject(ctx, key)
+		if err != nil {
+			if !s.client.IsObjectNotFoundErr(err) {
+				level.Warn(logger).Log("msg", "failed to fetch bloom filter", "key", key, "err", err)
+			}
+			return nil
+		}
+
+		mtx.Lock()
+		defer mtx.Unlock()
+		if set(key, buf) {
+			fetchedKeys = append(fetchedKeys, key)
+			fetchedBufs = append(fetchedBufs, buf)
+		}
+		return nil
+	})
+
+	if len(fetchedKeys) > 0 {
+		if err := s.filterCache.Store(ctx, fetchedKeys, fetchedBufs); err != nil {
+			level.Warn(logger).Log("msg", "failed to cache bloom filters", "err", err)
+		}
+	}
+	return filters
+}
+
+func (s *Store) getObject(ctx context.Context, key string) ([]byte, error) {
+	readCloser, _, err := s.client.GetObject(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer readCloser.Close()
+
+	return ioutil.ReadAll(readCloser)
+}
+
+func (s *Store) Stop() {
+	s.filterCache.Stop()
+	s.client.Stop()
+}
@@ -0,0 +1,75 @@
+package bloom
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/chunkenc"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/storage/chunk/cache"
+	"github.com/grafana/loki/pkg/storage/chunk/local"
+)
+
+func newTestChunk(t *testing.T, lines ...string) chunk.Chunk {
+	lbs := labels.FromStrings(labels.MetricName, "logs", "app", "foo")
+	memChunk := chunkenc.NewMemChunk(chunkenc.EncSnappy, chunkenc.UnorderedHeadBlockFmt, 256*1024, 0)
+	start := time.Unix(0, 0)
+	for i, line := range lines {
+		require.NoError(t, memChunk.Append(&logproto.Entry{Timestamp: start.Add(time.Duration(i) * time.Second), Line: line}))
+	}
+	require.NoError(t, memChunk.Close())
+
+	c := chunk.NewChunk("fake", 42, lbs, chunkenc.NewFacade(memChunk, 0, 0), 0, model.TimeFromUnix(int64(len(lines))))
+	require.NoError(t, c.Encode())
+	return c
+}
+
+func TestStore(t *testing.T) {
+	objectClient, err := local.NewFSObjectClient(local.FSConfig{Directory: filepath.Join(t.TempDir(), "objects")})
+	require.NoError(t, err)
+
+	cfg := Config{
+		Enabled:              true,
+		SharedStoreKeyPrefix: "blooms/",
+		NGramLength:          4,
+		FalsePositiveRate:    0.01,
+		MaxFetchConcurrency:  2,
+	}
+	schemaCfg := chunk.SchemaConfig{Configs: []chunk.PeriodConfig{{Schema: "v11", RowShards: 16}}}
+	store := NewStore(cfg, schemaCfg, objectClient, cache.NewNoopCache(), nil)
+
+	var lines []string
+	for i := 0; i < 100; i++ {
+		lines = append(lines, fmt.Sprintf("level=info msg=\"request done\" trace_id=%08d", i))
+	}
+	withBloom := newTestChunk(t, lines...)
+	withoutBloom := newTestChunk(t, "level=error msg=\"request failed\"")
+
+	store.Put(context.Background(), []chunk.Chunk{withBloom})
+
+	chunks := []chunk.Chunk{withBloom, withoutBloom}
+	for _, tc := range []struct {
+		filters  []string
+		expected []bool
+	}{
+		{nil, []bool{true, true}},
+		{[]string{"trace_id=00000042"}, []bool{true, true}},
+		{[]string{"trace_id=00000042", "request done"}, []bool{true, true}},
+		{[]string{"level=error"}, []bool{false, true}},
+		{[]string{"request done", "trace_id=abcdef"}, []bool{false, true}},
+		// too short to be checked.
+		{[]string{"abc"}, []bool{true, true}},
+	} {
+		t.Run(fmt.Sprint(tc.filters), func(t *testing.T) {
+			require.Equal(t, tc.expected, store.MayContain(context.Background(), chunks, tc.filters))
+		})
+	}
+}
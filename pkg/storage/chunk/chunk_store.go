@@ -160,7 +160,7 @@ func (c *store) PutOne(ctx context.Context, from, through model.Time, chunk Chun
 		return err
 	}
 
-	if cacheErr := c.fetcher.writeBackCache(ctx, chunks); cacheErr != nil {
+	if cacheErr := c.fetcher.WriteBackCache(ctx, chunks); cacheErr != nil {
 		level.Warn(log).Log("msg", "could not store chunks in chunk cache", "err", cacheErr)
 	}
 
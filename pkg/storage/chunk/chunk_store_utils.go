@@ -12,6 +12,7 @@ import (
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/promql"
 
+	"github.com/grafana/loki/pkg/logqlmodel/stats"
 	"github.com/grafana/loki/pkg/storage/chunk/cache"
 	util_log "github.com/grafana/loki/pkg/util/log"
 	"github.com/grafana/loki/pkg/util/spanlogger"
@@ -168,7 +169,7 @@ func (c *Fetcher) asyncWriteBackCacheQueueProcessLoop() {
 		select {
 		case fromStorage := <-c.asyncQueue:
 			chunkFetcherCacheQueueDequeue.Add(float64(len(fromStorage)))
-			cacheErr := c.writeBackCache(context.Background(), fromStorage)
+			cacheErr := c.WriteBackCache(context.Background(), fromStorage)
 			if cacheErr != nil {
 				level.Warn(util_log.Logger).Log("msg", "could not write fetched chunks from storage into chunk cache", "err", cacheErr)
 			}
@@ -219,6 +220,9 @@ func (c *Fetcher) FetchChunks(ctx context.Context, chunks []Chunk, keys []string
 	if err != nil {
 		level.Warn(log).Log("msg", "error process response from cache", "err", err)
 	}
+	st := stats.FromContext(ctx)
+	st.AddCacheEntriesRequested(stats.ChunkCache, len(keys))
+	st.AddCacheEntriesFound(stats.ChunkCache, len(fromCache))
 
 	var fromStorage []Chunk
 	if len(missing) > 0 {
@@ -242,7 +246,8 @@ func (c *Fetcher) FetchChunks(ctx context.Context, chunks []Chunk, keys []string
 	return allChunks, nil
 }
 
-func (c *Fetcher) writeBackCache(ctx context.Context, chunks []Chunk) error {
+// WriteBackCache stores the chunks in the chunks cache.
+func (c *Fetcher) WriteBackCache(ctx context.Context, chunks []Chunk) error {
 	keys := make([]string, 0, len(chunks))
 	bufs := make([][]byte, 0, len(chunks))
 	for i := range chunks {
@@ -74,6 +74,11 @@ func (c *CompositeStore) AddPeriod(storeCfg StoreConfig, cfg PeriodConfig, index
 	return c.addSchema(storeCfg, SchemaConfig{Configs: []PeriodConfig{cfg}}, schema, cfg.From.Time, index, chunks, limits, chunksCache, writeDedupeCache)
 }
 
+// AddStore adds a Store for a period of time starting at start to the CompositeStore
+func (c *CompositeStore) AddStore(start model.Time, store Store) {
+	c.stores = append(c.stores, compositeStoreEntry{start: start, Store: store})
+}
+
 func (c *CompositeStore) addSchema(storeCfg StoreConfig, schemaCfg SchemaConfig, schema BaseSchema, start model.Time, index IndexClient, chunks Client, limits StoreLimits, chunksCache, writeDedupeCache cache.Cache) error {
 	var (
 		err   error
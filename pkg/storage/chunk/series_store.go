@@ -523,7 +523,7 @@ func (c *seriesStore) PutOne(ctx context.Context, from, through model.Time, chun
 
 	// we already have the chunk in the cache so don't write it back to the cache.
 	if writeChunk {
-		if cacheErr := c.fetcher.writeBackCache(ctx, chunks); cacheErr != nil {
+		if cacheErr := c.fetcher.WriteBackCache(ctx, chunks); cacheErr != nil {
 			level.Warn(log).Log("msg", "could not store chunks in chunk cache", "err", cacheErr)
 		}
 	}
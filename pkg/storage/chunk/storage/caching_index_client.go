@@ -11,6 +11,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 
+	"github.com/grafana/loki/pkg/logqlmodel/stats"
 	"github.com/grafana/loki/pkg/storage/chunk"
 	"github.com/grafana/loki/pkg/storage/chunk/cache"
 	chunk_util "github.com/grafana/loki/pkg/storage/chunk/util"
@@ -82,6 +83,8 @@ func (s *cachingIndexClient) QueryPages(ctx context.Context, queries []chunk.Ind
 		return nil
 	}
 
+	stats.FromContext(ctx).AddIndexQueries(int64(len(queries)))
+
 	if isChunksQuery(queries[0]) || !s.disableBroadQueries {
 		return s.doBroadQueries(ctx, queries, callback)
 	}
@@ -111,6 +114,9 @@ func (s *cachingIndexClient) queryPages(ctx context.Context, queries []chunk.Ind
 	}
 
 	batches, misses := s.cacheFetch(ctx, keys)
+	st := stats.FromContext(ctx)
+	st.AddCacheEntriesRequested(stats.IndexCache, len(keys))
+	st.AddCacheEntriesFound(stats.IndexCache, len(batches))
 	for _, batch := range batches {
 		if cardinalityLimit > 0 && batch.Cardinality > cardinalityLimit {
 			return chunk.CardinalityExceededError{
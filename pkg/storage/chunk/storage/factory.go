@@ -62,7 +62,13 @@ type IndexClientFactoryFunc func() (chunk.IndexClient, error)
 // TableClientFactoryFunc defines signature of function which creates chunk.TableClient for managing tables in index store
 type TableClientFactoryFunc func() (chunk.TableClient, error)
 
-var customIndexStores = map[string]indexStoreFactories{}
+// StoreFactoryFunc defines signature of function which creates chunk.Store for index types which do not use a chunk.IndexClient
+type StoreFactoryFunc func(storeCfg chunk.StoreConfig, periodCfg chunk.PeriodConfig, chunks chunk.Client, limits StoreLimits, chunksCache cache.Cache) (chunk.Store, error)
+
+var (
+	customIndexStores = map[string]indexStoreFactories{}
+	customStores      = map[string]StoreFactoryFunc{}
+)
 
 // RegisterIndexStore is used for registering a custom index type.
 // When an index type is registered here with same name as existing types, the registered one takes the precedence.
@@ -70,6 +76,12 @@ func RegisterIndexStore(name string, indexClientFactory IndexClientFactoryFunc,
 	customIndexStores[name] = indexStoreFactories{indexClientFactory, tableClientFactory}
 }
 
+// RegisterStore is used for registering a custom index type which manages the index of the chunks by itself.
+// Periods using the index type get their chunk.Store created by the registered factory.
+func RegisterStore(name string, storeFactory StoreFactoryFunc) {
+	customStores[name] = storeFactory
+}
+
 // StoreLimits helps get Limits specific to Queries for Stores
 type StoreLimits interface {
 	CardinalityLimit(userID string) int
@@ -209,15 +221,6 @@ func NewStore(
 	stores := chunk.NewCompositeStore(cacheGenNumLoader)
 
 	for _, s := range schemaCfg.Configs {
-		indexClientReg := prometheus.WrapRegistererWith(
-			prometheus.Labels{"component": "index-store-" + s.From.String()}, reg)
-
-		index, err := NewIndexClient(s.IndexType, cfg, schemaCfg, indexClientReg)
-		if err != nil {
-			return nil, errors.Wrap(err, "error creating index client")
-		}
-		index = newCachingIndexClient(index, indexReadCache, cfg.IndexCacheValidity, limits, logger, cfg.DisableBroadIndexQueries)
-
 		objectStoreType := s.ObjectType
 		if objectStoreType == "" {
 			objectStoreType = s.IndexType
@@ -233,6 +236,24 @@ func NewStore(
 
 		chunks = newMetricsChunkClient(chunks, chunkMetrics)
 
+		if storeFactory, ok := customStores[s.IndexType]; ok {
+			store, err := storeFactory(storeCfg, s, chunks, limits, chunksCache)
+			if err != nil {
+				return nil, errors.Wrap(err, "error creating store")
+			}
+			stores.AddStore(s.From.Time, store)
+			continue
+		}
+
+		indexClientReg := prometheus.WrapRegistererWith(
+			prometheus.Labels{"component": "index-store-" + s.From.String()}, reg)
+
+		index, err := NewIndexClient(s.IndexType, cfg, schemaCfg, indexClientReg)
+		if err != nil {
+			return nil, errors.Wrap(err, "error creating index client")
+		}
+		index = newCachingIndexClient(index, indexReadCache, cfg.IndexCacheValidity, limits, logger, cfg.DisableBroadIndexQueries)
+
 		err = stores.AddPeriod(storeCfg, s, index, chunks, limits, chunksCache, writeDedupeCache)
 		if err != nil {
 			return nil, err
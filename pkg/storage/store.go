@@ -19,10 +19,13 @@ import (
 	"github.com/grafana/loki/pkg/logql"
 	"github.com/grafana/loki/pkg/logqlmodel/stats"
 	"github.com/grafana/loki/pkg/querier/astmapper"
+	"github.com/grafana/loki/pkg/storage/bloom"
 	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/storage/chunk/cache"
 	chunk_local "github.com/grafana/loki/pkg/storage/chunk/local"
 	"github.com/grafana/loki/pkg/storage/chunk/storage"
 	"github.com/grafana/loki/pkg/storage/stores/shipper"
+	"github.com/grafana/loki/pkg/storage/tsdb"
 	"github.com/grafana/loki/pkg/tenant"
 	"github.com/grafana/loki/pkg/util"
 )
@@ -31,6 +34,9 @@ var (
 	errCurrentBoltdbShipperNon24Hours  = errors.New("boltdb-shipper works best with 24h periodic index config. Either add a new config with future date set to 24h to retain the existing index or change the existing config to use 24h period")
 	errUpcomingBoltdbShipperNon24Hours = errors.New("boltdb-shipper with future date must always have periodic config for index set to 24h")
 	errZeroLengthConfig                = errors.New("must specify at least one schema configuration")
+	errTSDBNon24Hours                  = errors.New("tsdb index must always have periodic config for index set to 24h")
+	errTSDBNoObjectStore               = errors.New("tsdb index must always have an object_store set, it does not store the chunks")
+	errTSDBNoIndexClient               = errors.New("tsdb index has no index client, its stores are built by the tsdb index shipper")
 )
 
 // Config is the loki storage configuration
@@ -38,12 +44,16 @@ type Config struct {
 	storage.Config      `yaml:",inline"`
 	MaxChunkBatchSize   int            `yaml:"max_chunk_batch_size"`
 	BoltDBShipperConfig shipper.Config `yaml:"boltdb_shipper"`
+	TSDBShipperConfig   tsdb.Config    `yaml:"tsdb_shipper"`
+	BloomFilters        bloom.Config   `yaml:"bloom_filters"`
 }
 
 // RegisterFlags adds the flags required to configure this flag set.
 func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	cfg.Config.RegisterFlags(f)
 	cfg.BoltDBShipperConfig.RegisterFlags(f)
+	cfg.TSDBShipperConfig.RegisterFlags(f)
+	cfg.BloomFilters.RegisterFlags(f)
 	f.IntVar(&cfg.MaxChunkBatchSize, "store.max-chunk-batch-size", 50, "The maximum number of chunks to fetch per batch.")
 }
 
@@ -69,6 +79,20 @@ func (cfg *SchemaConfig) Validate() error {
 		return errUpcomingBoltdbShipperNon24Hours
 	}
 
+	for _, periodCfg := range cfg.Configs {
+		if periodCfg.IndexType != tsdb.TSDBType {
+			continue
+		}
+		// tsdb index files are always downloaded by day, so the period must always be set to 24 hours.
+		if periodCfg.IndexTables.Period != 24*time.Hour {
+			return errTSDBNon24Hours
+		}
+		// the chunks are stored in the object store of the period, which defaults to the index type.
+		if periodCfg.ObjectType == "" {
+			return errTSDBNoObjectStore
+		}
+	}
+
 	return cfg.SchemaConfig.Validate()
 }
 
@@ -102,6 +126,7 @@ type Store interface {
 	GetSeries(ctx context.Context, req logql.SelectLogParams) ([]logproto.SeriesIdentifier, error)
 	GetSchemaConfigs() []chunk.PeriodConfig
 	SetChunkFilterer(chunkFilter RequestChunkFilterer)
+	SetBloomStore(bloomStore *bloom.Store)
 }
 
 // RequestChunkFilterer creates ChunkFilterer for a given request context.
@@ -121,6 +146,7 @@ type store struct {
 	schemaCfg    SchemaConfig
 
 	chunkFilterer RequestChunkFilterer
+	bloomStore    *bloom.Store
 }
 
 // NewStore creates a new Loki Store using configuration supplied.
@@ -195,6 +221,29 @@ func (s *store) SetChunkFilterer(chunkFilterer RequestChunkFilterer) {
 	s.chunkFilterer = chunkFilterer
 }
 
+// SetBloomStore makes the store build bloom filters of the chunks it stores, and use them for skipping chunks in queries.
+func (s *store) SetBloomStore(bloomStore *bloom.Store) {
+	s.bloomStore = bloomStore
+}
+
+// Put stores the chunks along with their bloom filters.
+func (s *store) Put(ctx context.Context, chunks []chunk.Chunk) error {
+	if err := s.Store.Put(ctx, chunks); err != nil {
+		return err
+	}
+	if s.bloomStore != nil {
+		s.bloomStore.Put(ctx, chunks)
+	}
+	return nil
+}
+
+func (s *store) Stop() {
+	s.Store.Stop()
+	if s.bloomStore != nil {
+		s.bloomStore.Stop()
+	}
+}
+
 // lazyChunks is an internal function used to resolve a set of lazy chunks from the store without actually loading them. It's used internally by `LazyQuery` and `GetSeries`
 func (s *store) lazyChunks(ctx context.Context, matchers []*labels.Matcher, from, through model.Time) ([]*LazyChunk, error) {
 	userID, err := tenant.TenantID(ctx)
@@ -204,10 +253,12 @@ func (s *store) lazyChunks(ctx context.Context, matchers []*labels.Matcher, from
 
 	stats := stats.FromContext(ctx)
 
+	start := time.Now()
 	chks, fetchers, err := s.GetChunkRefs(ctx, userID, from, through, matchers...)
 	if err != nil {
 		return nil, err
 	}
+	stats.AddIndexLookupTime(time.Since(start))
 
 	var prefiltered int
 	var filtered int
@@ -357,7 +408,7 @@ func (s *store) SelectLogs(ctx context.Context, req logql.SelectLogParams) (iter
 		chunkFilterer = s.chunkFilterer.ForRequest(ctx)
 	}
 
-	return newLogBatchIterator(ctx, s.schemaCfg.SchemaConfig, s.chunkMetrics, lazyChunks, s.cfg.MaxChunkBatchSize, matchers, pipeline, req.Direction, req.Start, req.End, chunkFilterer)
+	return newLogBatchIterator(ctx, s.schemaCfg.SchemaConfig, s.chunkMetrics, lazyChunks, s.cfg.MaxChunkBatchSize, matchers, pipeline, req.Direction, req.Start, req.End, chunkFilterer, newBloomChunkFilter(s.bloomStore, expr))
 }
 
 func (s *store) SelectSamples(ctx context.Context, req logql.SelectSampleParams) (iter.SampleIterator, error) {
@@ -389,7 +440,7 @@ func (s *store) SelectSamples(ctx context.Context, req logql.SelectSampleParams)
 		chunkFilterer = s.chunkFilterer.ForRequest(ctx)
 	}
 
-	return newSampleBatchIterator(ctx, s.schemaCfg.SchemaConfig, s.chunkMetrics, lazyChunks, s.cfg.MaxChunkBatchSize, matchers, extractor, req.Start, req.End, chunkFilterer)
+	return newSampleBatchIterator(ctx, s.schemaCfg.SchemaConfig, s.chunkMetrics, lazyChunks, s.cfg.MaxChunkBatchSize, matchers, extractor, req.Start, req.End, chunkFilterer, newBloomChunkFilter(s.bloomStore, expr.Selector()))
 }
 
 func (s *store) GetSchemaConfigs() []chunk.PeriodConfig {
@@ -444,6 +495,36 @@ func RegisterCustomIndexClients(cfg *Config, cm storage.ClientMetrics, registere
 
 		return shipper.NewBoltDBShipperTableClient(objectClient, cfg.BoltDBShipperConfig.SharedStoreKeyPrefix), nil
 	})
+
+	// The TSDB index shipper is also a singleton, shared by the stores of all the periods using the tsdb index.
+	var tsdbIndexShipper *tsdb.IndexShipper
+
+	storage.RegisterStore(tsdb.TSDBType, func(storeCfg chunk.StoreConfig, periodCfg chunk.PeriodConfig, chunks chunk.Client, limits storage.StoreLimits, chunksCache cache.Cache) (chunk.Store, error) {
+		if tsdbIndexShipper == nil {
+			objectClient, err := storage.NewObjectClient(cfg.TSDBShipperConfig.SharedStoreType, cfg.Config, cm)
+			if err != nil {
+				return nil, err
+			}
+
+			tsdbIndexShipper, err = tsdb.NewIndexShipper(cfg.TSDBShipperConfig, objectClient, registerer)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return tsdb.NewStore(tsdbIndexShipper, storeCfg, periodCfg, chunks, limits, chunksCache)
+	})
+	// The stores of the periods using the tsdb index are built above, without any index client.
+	storage.RegisterIndexStore(tsdb.TSDBType, func() (chunk.IndexClient, error) {
+		return nil, errTSDBNoIndexClient
+	}, func() (client chunk.TableClient, e error) {
+		objectClient, err := storage.NewObjectClient(cfg.TSDBShipperConfig.SharedStoreType, cfg.Config, cm)
+		if err != nil {
+			return nil, err
+		}
+
+		return shipper.NewBoltDBShipperTableClient(objectClient, cfg.TSDBShipperConfig.SharedStoreKeyPrefix), nil
+	})
 }
 
 // ActivePeriodConfig returns index of active PeriodicConfig which would be applicable to logs that would be pushed starting now.
@@ -461,9 +542,18 @@ func ActivePeriodConfig(configs []chunk.PeriodConfig) int {
 
 // UsingBoltdbShipper checks whether current or the next index type is boltdb-shipper, returns true if yes.
 func UsingBoltdbShipper(configs []chunk.PeriodConfig) bool {
+	return usingIndexType(configs, shipper.BoltDBShipperType)
+}
+
+// UsingTSDBShipper checks whether current or the next index type is tsdb, returns true if yes.
+func UsingTSDBShipper(configs []chunk.PeriodConfig) bool {
+	return usingIndexType(configs, tsdb.TSDBType)
+}
+
+func usingIndexType(configs []chunk.PeriodConfig, indexType string) bool {
 	activePCIndex := ActivePeriodConfig(configs)
-	if configs[activePCIndex].IndexType == shipper.BoltDBShipperType ||
-		(len(configs)-1 > activePCIndex && configs[activePCIndex+1].IndexType == shipper.BoltDBShipperType) {
+	if configs[activePCIndex].IndexType == indexType ||
+		(len(configs)-1 > activePCIndex && configs[activePCIndex+1].IndexType == indexType) {
 		return true
 	}
 
@@ -1103,6 +1103,43 @@ func TestSchemaConfig_Validate(t *testing.T) {
 			}},
 			err: errUpcomingBoltdbShipperNon24Hours,
 		},
+		{
+			name: "tsdb with 1 day periodic config and an object store",
+			configs: []chunk.PeriodConfig{{
+				From:       chunk.DayTime{Time: model.Now().Add(-24 * time.Hour)},
+				IndexType:  "tsdb",
+				ObjectType: "filesystem",
+				Schema:     "v11",
+				IndexTables: chunk.PeriodicTableConfig{
+					Period: 24 * time.Hour,
+				},
+			}},
+		},
+		{
+			name: "tsdb with 7 days periodic config",
+			configs: []chunk.PeriodConfig{{
+				From:       chunk.DayTime{Time: model.Now().Add(-24 * time.Hour)},
+				IndexType:  "tsdb",
+				ObjectType: "filesystem",
+				Schema:     "v11",
+				IndexTables: chunk.PeriodicTableConfig{
+					Period: 7 * 24 * time.Hour,
+				},
+			}},
+			err: errTSDBNon24Hours,
+		},
+		{
+			name: "tsdb without an object store",
+			configs: []chunk.PeriodConfig{{
+				From:      chunk.DayTime{Time: model.Now().Add(-24 * time.Hour)},
+				IndexType: "tsdb",
+				Schema:    "v11",
+				IndexTables: chunk.PeriodicTableConfig{
+					Period: 24 * time.Hour,
+				},
+			}},
+			err: errTSDBNoObjectStore,
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			cfg := SchemaConfig{chunk.SchemaConfig{Configs: tc.configs}}
@@ -18,12 +18,15 @@ import (
 	"github.com/prometheus/common/model"
 
 	loki_storage "github.com/grafana/loki/pkg/storage"
+	"github.com/grafana/loki/pkg/storage/bloom"
+	"github.com/grafana/loki/pkg/storage/chunk"
 	"github.com/grafana/loki/pkg/storage/chunk/local"
 	"github.com/grafana/loki/pkg/storage/chunk/objectclient"
 	"github.com/grafana/loki/pkg/storage/chunk/storage"
 	chunk_util "github.com/grafana/loki/pkg/storage/chunk/util"
 	"github.com/grafana/loki/pkg/storage/stores/shipper/compactor/deletion"
 	"github.com/grafana/loki/pkg/storage/stores/shipper/compactor/retention"
+	"github.com/grafana/loki/pkg/storage/stores/shipper/compactor/retentionrules"
 	shipper_storage "github.com/grafana/loki/pkg/storage/stores/shipper/storage"
 	shipper_util "github.com/grafana/loki/pkg/storage/stores/shipper/util"
 	"github.com/grafana/loki/pkg/util"
@@ -93,6 +96,12 @@ func (cfg *Config) Validate() error {
 	return shipper_util.ValidateSharedStoreKeyPrefix(cfg.SharedStoreKeyPrefix)
 }
 
+// Limits are the limits the compactor needs for applying retention.
+type Limits interface {
+	retention.Limits
+	retentionrules.Limits
+}
+
 type Compactor struct {
 	services.Service
 
@@ -103,6 +112,7 @@ type Compactor struct {
 	deleteRequestsStore   deletion.DeleteRequestsStore
 	DeleteRequestsHandler *deletion.DeleteRequestHandler
 	deleteRequestsManager *deletion.DeleteRequestsManager
+	RetentionRulesHandler *retentionrules.RulesHandler
 	expirationChecker     retention.ExpirationChecker
 	metrics               *metrics
 	running               bool
@@ -118,7 +128,7 @@ type Compactor struct {
 	subservicesWatcher *services.FailureWatcher
 }
 
-func NewCompactor(cfg Config, storageConfig storage.Config, schemaConfig loki_storage.SchemaConfig, limits retention.Limits, clientMetrics storage.ClientMetrics, r prometheus.Registerer) (*Compactor, error) {
+func NewCompactor(cfg Config, storageConfig storage.Config, schemaConfig loki_storage.SchemaConfig, bloomConfig bloom.Config, limits Limits, clientMetrics storage.ClientMetrics, r prometheus.Registerer) (*Compactor, error) {
 	if cfg.SharedStoreType == "" {
 		return nil, errors.New("compactor shared_store_type must be specified")
 	}
@@ -167,7 +177,7 @@ func NewCompactor(cfg Config, storageConfig storage.Config, schemaConfig loki_st
 	compactor.subservicesWatcher = services.NewFailureWatcher()
 	compactor.subservicesWatcher.WatchManager(compactor.subservices)
 
-	if err := compactor.init(storageConfig, schemaConfig, limits, clientMetrics, r); err != nil {
+	if err := compactor.init(storageConfig, schemaConfig, bloomConfig, limits, clientMetrics, r); err != nil {
 		return nil, err
 	}
 
@@ -175,7 +185,7 @@ func NewCompactor(cfg Config, storageConfig storage.Config, schemaConfig loki_st
 	return compactor, nil
 }
 
-func (c *Compactor) init(storageConfig storage.Config, schemaConfig loki_storage.SchemaConfig, limits retention.Limits, clientMetrics storage.ClientMetrics, r prometheus.Registerer) error {
+func (c *Compactor) init(storageConfig storage.Config, schemaConfig loki_storage.SchemaConfig, bloomConfig bloom.Config, limits Limits, clientMetrics storage.ClientMetrics, r prometheus.Registerer) error {
 	objectClient, err := storage.NewObjectClient(c.cfg.SharedStoreType, storageConfig, clientMetrics)
 	if err != nil {
 		return err
@@ -196,8 +206,17 @@ func (c *Compactor) init(storageConfig storage.Config, schemaConfig loki_storage
 
 		chunkClient := objectclient.NewClient(objectClient, encoder, schemaConfig.SchemaConfig)
 
+		var deleteClient retention.ChunkClient = chunkClient
+		if bloomConfig.Enabled {
+			bloomObjectClient, err := storage.NewObjectClient(bloomConfig.SharedStoreType, storageConfig, clientMetrics)
+			if err != nil {
+				return err
+			}
+			deleteClient = &bloomChunkClient{ChunkClient: chunkClient, bloomConfig: bloomConfig, bloomClient: bloomObjectClient}
+		}
+
 		retentionWorkDir := filepath.Join(c.cfg.WorkingDirectory, "retention")
-		c.sweeper, err = retention.NewSweeper(retentionWorkDir, chunkClient, c.cfg.RetentionDeleteWorkCount, c.cfg.RetentionDeleteDelay, r)
+		c.sweeper, err = retention.NewSweeper(retentionWorkDir, deleteClient, c.cfg.RetentionDeleteWorkCount, c.cfg.RetentionDeleteDelay, r)
 		if err != nil {
 			return err
 		}
@@ -212,7 +231,10 @@ func (c *Compactor) init(storageConfig storage.Config, schemaConfig loki_storage
 		c.DeleteRequestsHandler = deletion.NewDeleteRequestHandler(c.deleteRequestsStore, time.Hour, r)
 		c.deleteRequestsManager = deletion.NewDeleteRequestsManager(c.deleteRequestsStore, c.cfg.DeleteRequestCancelPeriod, r)
 
-		c.expirationChecker = newExpirationChecker(retention.NewExpirationChecker(limits), c.deleteRequestsManager)
+		retentionRulesStore := retentionrules.NewRulesStore(c.indexStorageClient, limits)
+		c.RetentionRulesHandler = retentionrules.NewRulesHandler(retentionRulesStore, limits)
+
+		c.expirationChecker = newExpirationChecker(retention.NewExpirationChecker(limits, retentionRulesStore), c.deleteRequestsManager)
 
 		c.tableMarker, err = retention.NewMarker(retentionWorkDir, schemaConfig, c.expirationChecker, chunkClient, r)
 		if err != nil {
@@ -223,6 +245,26 @@ func (c *Compactor) init(storageConfig storage.Config, schemaConfig loki_storage
 	return nil
 }
 
+// bloomChunkClient deletes the bloom filters of the chunks along with the chunks, for the chunks removed by retention
+// and delete requests.
+type bloomChunkClient struct {
+	retention.ChunkClient
+	bloomConfig bloom.Config
+	bloomClient chunk.ObjectClient
+}
+
+func (c *bloomChunkClient) DeleteChunk(ctx context.Context, userID, chunkID string) error {
+	// A chunk which is already deleted still gets its bloom filter deleted, in case deleting it failed before.
+	chunkErr := c.ChunkClient.DeleteChunk(ctx, userID, chunkID)
+	if chunkErr != nil && !c.IsChunkNotFoundErr(chunkErr) {
+		return chunkErr
+	}
+	if err := c.bloomClient.DeleteObject(ctx, c.bloomConfig.Key(chunkID)); err != nil && !c.bloomClient.IsObjectNotFoundErr(err) {
+		return err
+	}
+	return chunkErr
+}
+
 func (c *Compactor) starting(ctx context.Context) (err error) {
 	// In case this function will return error we want to unregister the instance
 	// from the ring. We do it ensuring dependencies are gracefully stopped if they
@@ -489,8 +531,8 @@ func (c *Compactor) RunCompaction(ctx context.Context, applyRetention bool) erro
 
 	go func() {
 		for _, tableName := range tables {
-			if tableName == deletion.DeleteRequestsTableName {
-				// we do not want to compact or apply retention on delete requests table
+			if tableName == deletion.DeleteRequestsTableName || tableName == retentionrules.RetentionRulesTableName {
+				// we do not want to compact or apply retention on delete requests and retention rules tables
 				continue
 			}
 
@@ -1,7 +1,9 @@
 package compactor
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"io/ioutil"
 	"path/filepath"
 	"strings"
@@ -11,6 +13,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	loki_storage "github.com/grafana/loki/pkg/storage"
+	"github.com/grafana/loki/pkg/storage/bloom"
 	"github.com/grafana/loki/pkg/storage/chunk/local"
 	"github.com/grafana/loki/pkg/storage/chunk/storage"
 	"github.com/grafana/loki/pkg/storage/stores/shipper/testutil"
@@ -30,7 +33,7 @@ func setupTestCompactor(t *testing.T, tempDir string, clientMetrics storage.Clie
 
 	require.NoError(t, cfg.Validate())
 
-	c, err := NewCompactor(cfg, storage.Config{FSConfig: local.FSConfig{Directory: tempDir}}, loki_storage.SchemaConfig{}, nil, clientMetrics, nil)
+	c, err := NewCompactor(cfg, storage.Config{FSConfig: local.FSConfig{Directory: tempDir}}, loki_storage.SchemaConfig{}, bloom.Config{}, nil, clientMetrics, nil)
 	require.NoError(t, err)
 
 	return c
@@ -121,3 +124,50 @@ func TestCompactor_RunCompaction(t *testing.T) {
 		compareCompactedTable(t, filepath.Join(tablesPath, name), filepath.Join(tablesCopyPath, name))
 	}
 }
+
+var errChunkNotFound = errors.New("chunk not found")
+
+type mockChunkClient struct {
+	chunks map[string]bool
+}
+
+func (m *mockChunkClient) DeleteChunk(_ context.Context, _, chunkID string) error {
+	if !m.chunks[chunkID] {
+		return errChunkNotFound
+	}
+	delete(m.chunks, chunkID)
+	return nil
+}
+
+func (m *mockChunkClient) IsChunkNotFoundErr(err error) bool {
+	return err == errChunkNotFound
+}
+
+func TestBloomChunkClient_DeleteChunk(t *testing.T) {
+	bloomClient, err := local.NewFSObjectClient(local.FSConfig{Directory: t.TempDir()})
+	require.NoError(t, err)
+	bloomConfig := bloom.Config{Enabled: true, SharedStoreKeyPrefix: "blooms/"}
+	chunks := &mockChunkClient{chunks: map[string]bool{"fake/1": true}}
+	client := &bloomChunkClient{ChunkClient: chunks, bloomConfig: bloomConfig, bloomClient: bloomClient}
+
+	ctx := context.Background()
+	for _, chunkID := range []string{"fake/1", "fake/2"} {
+		require.NoError(t, bloomClient.PutObject(ctx, bloomConfig.Key(chunkID), bytes.NewReader([]byte("filter"))))
+	}
+
+	require.NoError(t, client.DeleteChunk(ctx, "fake", "fake/1"))
+	require.Empty(t, chunks.chunks)
+	_, _, err = bloomClient.GetObject(ctx, bloomConfig.Key("fake/1"))
+	require.True(t, bloomClient.IsObjectNotFoundErr(err))
+
+	// the bloom filter of a chunk which is already deleted is deleted too.
+	err = client.DeleteChunk(ctx, "fake", "fake/2")
+	require.True(t, client.IsChunkNotFoundErr(err))
+	_, _, err = bloomClient.GetObject(ctx, bloomConfig.Key("fake/2"))
+	require.True(t, bloomClient.IsObjectNotFoundErr(err))
+
+	// a chunk without a bloom filter is deleted.
+	chunks.chunks["fake/3"] = true
+	require.NoError(t, client.DeleteChunk(ctx, "fake", "fake/3"))
+	require.Empty(t, chunks.chunks)
+}
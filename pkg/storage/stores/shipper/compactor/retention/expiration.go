@@ -1,6 +1,7 @@
 package retention
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -23,6 +24,7 @@ type ExpirationChecker interface {
 
 type expirationChecker struct {
 	tenantsRetention         *TenantsRetention
+	rules                    StreamRetentionRules
 	latestRetentionStartTime latestRetentionStartTime
 }
 
@@ -33,9 +35,18 @@ type Limits interface {
 	DefaultLimits() *validation.Limits
 }
 
-func NewExpirationChecker(limits Limits) ExpirationChecker {
+// StreamRetentionRules provides per-stream retention rules of all tenants which are defined outside of the limits,
+// i.e. through the compactor retention rules API. They are merged with the `retention_stream` of each tenant.
+type StreamRetentionRules interface {
+	AllStreamRetention(ctx context.Context) (map[string][]validation.StreamRetention, error)
+}
+
+// NewExpirationChecker creates an ExpirationChecker applying the retention from the limits and the given rules.
+// rules can be nil when only the limits should be considered.
+func NewExpirationChecker(limits Limits, rules StreamRetentionRules) ExpirationChecker {
 	return &expirationChecker{
 		tenantsRetention: NewTenantsRetention(limits),
+		rules:            rules,
 	}
 }
 
@@ -56,7 +67,16 @@ func (e *expirationChecker) DropFromIndex(ref ChunkEntry, tableEndTime model.Tim
 }
 
 func (e *expirationChecker) MarkPhaseStarted() {
-	e.latestRetentionStartTime = findLatestRetentionStartTime(model.Now(), e.tenantsRetention.limits)
+	if e.rules != nil {
+		// rules are only reloaded at the start of the phase to keep them consistent while the tables are being processed.
+		rules, err := e.rules.AllStreamRetention(context.Background())
+		if err != nil {
+			level.Error(util_log.Logger).Log("msg", "failed to load retention rules, using the previously loaded ones", "err", err)
+		} else {
+			e.tenantsRetention.rules = rules
+		}
+	}
+	e.latestRetentionStartTime = findLatestRetentionStartTime(model.Now(), e.tenantsRetention.limits, e.tenantsRetention.rules)
 	level.Info(util_log.Logger).Log("msg", fmt.Sprintf("overall smallest retention period %v, default smallest retention period %v",
 		e.latestRetentionStartTime.overall, e.latestRetentionStartTime.defaults))
 }
@@ -83,6 +103,8 @@ func (e *expirationChecker) IntervalMayHaveExpiredChunks(interval model.Interval
 
 type TenantsRetention struct {
 	limits Limits
+	// rules holds the per-stream retention rules by user defined in addition to the limits.
+	rules map[string][]validation.StreamRetention
 }
 
 func NewTenantsRetention(l Limits) *TenantsRetention {
@@ -92,12 +114,20 @@ func NewTenantsRetention(l Limits) *TenantsRetention {
 }
 
 func (tr *TenantsRetention) RetentionPeriodFor(userID string, lbs labels.Labels) time.Duration {
-	streamRetentions := tr.limits.StreamRetention(userID)
-	globalRetention := tr.limits.RetentionPeriod(userID)
 	var (
 		matchedRule validation.StreamRetention
 		found       bool
 	)
+	matchedRule, found = matchStreamRetention(tr.limits.StreamRetention(userID), lbs, matchedRule, found)
+	matchedRule, found = matchStreamRetention(tr.rules[userID], lbs, matchedRule, found)
+	if found {
+		return time.Duration(matchedRule.Period)
+	}
+	return tr.limits.RetentionPeriod(userID)
+}
+
+// matchStreamRetention returns the rule to apply to lbs amongst the given ones and the previously matched rule, if any.
+func matchStreamRetention(streamRetentions []validation.StreamRetention, lbs labels.Labels, matchedRule validation.StreamRetention, found bool) (validation.StreamRetention, bool) {
 Outer:
 	for _, streamRetention := range streamRetentions {
 		for _, m := range streamRetention.Matchers {
@@ -119,10 +149,7 @@ Outer:
 		found = true
 		matchedRule = streamRetention
 	}
-	if found {
-		return time.Duration(matchedRule.Period)
-	}
-	return globalRetention
+	return matchedRule, found
 }
 
 type latestRetentionStartTime struct {
@@ -138,7 +165,8 @@ type latestRetentionStartTime struct {
 }
 
 // findLatestRetentionStartTime returns the latest retention start time overall, just default config and by each user.
-func findLatestRetentionStartTime(now model.Time, limits Limits) latestRetentionStartTime {
+// rules holds the retention rules by user defined in addition to the limits.
+func findLatestRetentionStartTime(now model.Time, limits Limits, rules map[string][]validation.StreamRetention) latestRetentionStartTime {
 	// find the smallest retention period from default limits
 	defaultLimits := limits.DefaultLimits()
 	smallestDefaultRetentionPeriod := defaultLimits.RetentionPeriod
@@ -152,7 +180,7 @@ func findLatestRetentionStartTime(now model.Time, limits Limits) latestRetention
 
 	// find the smallest retention period by user
 	limitsByUserID := limits.AllByUserID()
-	smallestRetentionPeriodByUser := make(map[string]model.Time, len(limitsByUserID))
+	smallestRetentionPeriodByUser := make(map[string]model.Duration, len(limitsByUserID))
 	for userID, limit := range limitsByUserID {
 		smallestRetentionPeriodForUser := limit.RetentionPeriod
 		for _, streamRetention := range limit.StreamRetention {
@@ -160,9 +188,27 @@ func findLatestRetentionStartTime(now model.Time, limits Limits) latestRetention
 				smallestRetentionPeriodForUser = streamRetention.Period
 			}
 		}
+		smallestRetentionPeriodByUser[userID] = smallestRetentionPeriodForUser
+	}
+
+	// users with retention rules might have a smaller retention period than the one from their limits.
+	for userID, userRules := range rules {
+		smallestRetentionPeriodForUser, ok := smallestRetentionPeriodByUser[userID]
+		if !ok {
+			smallestRetentionPeriodForUser = smallestDefaultRetentionPeriod
+		}
+		for _, streamRetention := range userRules {
+			if streamRetention.Period < smallestRetentionPeriodForUser {
+				smallestRetentionPeriodForUser = streamRetention.Period
+			}
+		}
+		smallestRetentionPeriodByUser[userID] = smallestRetentionPeriodForUser
+	}
 
+	latestRetentionStartTimeByUser := make(map[string]model.Time, len(smallestRetentionPeriodByUser))
+	for userID, smallestRetentionPeriodForUser := range smallestRetentionPeriodByUser {
 		// update the overallSmallestRetentionPeriod if this user has smaller value
-		smallestRetentionPeriodByUser[userID] = now.Add(time.Duration(-smallestRetentionPeriodForUser))
+		latestRetentionStartTimeByUser[userID] = now.Add(time.Duration(-smallestRetentionPeriodForUser))
 		if smallestRetentionPeriodForUser < overallSmallestRetentionPeriod {
 			overallSmallestRetentionPeriod = smallestRetentionPeriodForUser
 		}
@@ -171,6 +217,6 @@ func findLatestRetentionStartTime(now model.Time, limits Limits) latestRetention
 	return latestRetentionStartTime{
 		defaults: now.Add(time.Duration(-smallestDefaultRetentionPeriod)),
 		overall:  now.Add(time.Duration(-overallSmallestRetentionPeriod)),
-		byUser:   smallestRetentionPeriodByUser,
+		byUser:   latestRetentionStartTimeByUser,
 	}
 }
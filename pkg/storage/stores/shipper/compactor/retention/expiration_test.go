@@ -1,6 +1,7 @@
 package retention
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -66,7 +67,7 @@ func Test_expirationChecker_Expired(t *testing.T) {
 				},
 			},
 		},
-	})
+	}, nil)
 	tests := []struct {
 		name string
 		ref  ChunkEntry
@@ -88,12 +89,56 @@ func Test_expirationChecker_Expired(t *testing.T) {
 	}
 }
 
+type fakeStreamRetentionRules map[string][]validation.StreamRetention
+
+func (f fakeStreamRetentionRules) AllStreamRetention(_ context.Context) (map[string][]validation.StreamRetention, error) {
+	return f, nil
+}
+
+func Test_expirationChecker_ExpiredWithRules(t *testing.T) {
+	e := NewExpirationChecker(&fakeLimits{
+		perTenant: map[string]retentionLimit{
+			"1": {
+				retentionPeriod: 24 * time.Hour,
+				streamRetention: []validation.StreamRetention{
+					{Period: model.Duration(2 * time.Hour), Priority: 1, Matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "foo", "bar")}},
+				},
+			},
+		},
+	}, fakeStreamRetentionRules{
+		"1": {
+			{Period: model.Duration(time.Hour), Priority: 1, Matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "foo", "bar")}},
+			{Period: model.Duration(4 * time.Hour), Priority: 1, Matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "foo", "buzz")}},
+		},
+	})
+	e.MarkPhaseStarted()
+
+	tests := []struct {
+		name string
+		ref  ChunkEntry
+		want bool
+	}{
+		{"expired by rule with lowest period", newChunkEntry("1", `{foo="bar"}`, model.Now().Add(-3*time.Hour), model.Now().Add(-90*time.Minute)), true},
+		{"not expired by rule", newChunkEntry("1", `{foo="buzz"}`, model.Now().Add(-4*time.Hour), model.Now().Add(-3*time.Hour)), false},
+		{"expired by rule", newChunkEntry("1", `{foo="buzz"}`, model.Now().Add(-6*time.Hour), model.Now().Add(-5*time.Hour)), true},
+		{"not expired tenant", newChunkEntry("1", `{foo="fizz"}`, model.Now().Add(-6*time.Hour), model.Now().Add(-5*time.Hour)), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, nonDeletedIntervals := e.Expired(tt.ref, model.Now())
+			require.Equal(t, tt.want, actual)
+			require.Nil(t, nonDeletedIntervals)
+		})
+	}
+}
+
 func TestFindLatestRetentionStartTime(t *testing.T) {
 	const dayDuration = 24 * time.Hour
 	now := model.Now()
 	for _, tc := range []struct {
 		name                             string
 		limit                            fakeLimits
+		rules                            map[string][]validation.StreamRetention
 		expectedLatestRetentionStartTime latestRetentionStartTime
 	}{
 		{
@@ -237,9 +282,42 @@ func TestFindLatestRetentionStartTime(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "retention rules smallest",
+			limit: fakeLimits{
+				defaultLimit: retentionLimit{
+					retentionPeriod: 7 * dayDuration,
+				},
+				perTenant: map[string]retentionLimit{
+					"0": {
+						retentionPeriod: 10 * dayDuration,
+					},
+				},
+			},
+			rules: map[string][]validation.StreamRetention{
+				"0": {
+					{
+						Period: model.Duration(5 * dayDuration),
+					},
+				},
+				"1": {
+					{
+						Period: model.Duration(3 * dayDuration),
+					},
+				},
+			},
+			expectedLatestRetentionStartTime: latestRetentionStartTime{
+				overall:  now.Add(-3 * dayDuration),
+				defaults: now.Add(-7 * dayDuration),
+				byUser: map[string]model.Time{
+					"0": now.Add(-5 * dayDuration),
+					"1": now.Add(-3 * dayDuration),
+				},
+			},
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			latestRetentionStartTime := findLatestRetentionStartTime(now, tc.limit)
+			latestRetentionStartTime := findLatestRetentionStartTime(now, tc.limit, tc.rules)
 			require.Equal(t, tc.expectedLatestRetentionStartTime, latestRetentionStartTime)
 		})
 	}
@@ -144,7 +144,7 @@ func Test_Retention(t *testing.T) {
 			store.Stop()
 
 			// marks and sweep
-			expiration := NewExpirationChecker(tt.limits)
+			expiration := NewExpirationChecker(tt.limits, nil)
 			workDir := filepath.Join(t.TempDir(), "retention")
 			chunkClient := &mockChunkClient{deletedChunks: map[string]struct{}{}}
 			sweep, err := NewSweeper(workDir, chunkClient, 10, 0, nil)
@@ -215,7 +215,7 @@ func Test_EmptyTable(t *testing.T) {
 		it, err := newChunkIndexIterator(tx.Bucket(local.IndexBucketName), schema.config)
 		require.NoError(t, err)
 		empty, _, err := markforDelete(context.Background(), tables[0].name, noopWriter{}, it, noopCleaner{},
-			NewExpirationChecker(&fakeLimits{perTenant: map[string]retentionLimit{"1": {retentionPeriod: 0}, "2": {retentionPeriod: 0}}}), nil)
+			NewExpirationChecker(&fakeLimits{perTenant: map[string]retentionLimit{"1": {retentionPeriod: 0}, "2": {retentionPeriod: 0}}}, nil), nil)
 		require.NoError(t, err)
 		require.True(t, empty)
 		return nil
@@ -233,7 +233,7 @@ func Test_EmptyTable(t *testing.T) {
 		it, err := newChunkIndexIterator(bucket, schema.config)
 		require.NoError(t, err)
 		_, _, err = markforDelete(context.Background(), tables[0].name, noopWriter{}, it, noopCleaner{},
-			NewExpirationChecker(&fakeLimits{}), nil)
+			NewExpirationChecker(&fakeLimits{}, nil), nil)
 		require.Equal(t, err, errNoChunksFound)
 		return nil
 	})
@@ -725,7 +725,7 @@ func TestMarkForDelete_DropChunkFromIndex(t *testing.T) {
 			it, err := newChunkIndexIterator(tx.Bucket(local.IndexBucketName), schema.config)
 			require.NoError(t, err)
 			empty, _, err := markforDelete(context.Background(), table.name, noopWriter{}, it, noopCleaner{},
-				NewExpirationChecker(fakeLimits{perTenant: map[string]retentionLimit{"1": {retentionPeriod: retentionPeriod}}}), nil)
+				NewExpirationChecker(fakeLimits{perTenant: map[string]retentionLimit{"1": {retentionPeriod: retentionPeriod}}}, nil), nil)
 			require.NoError(t, err)
 			if i == 7 {
 				require.False(t, empty)
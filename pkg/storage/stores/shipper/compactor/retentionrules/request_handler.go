@@ -0,0 +1,224 @@
+package retentionrules
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/loki/pkg/tenant"
+	util_log "github.com/grafana/loki/pkg/util/log"
+	serverutil "github.com/grafana/loki/pkg/util/server"
+)
+
+// RulesHandler provides handlers for managing the retention rules of a tenant.
+type RulesHandler struct {
+	rulesStore RulesStore
+	limits     Limits
+}
+
+// NewRulesHandler creates a RulesHandler
+func NewRulesHandler(rulesStore RulesStore, limits Limits) *RulesHandler {
+	return &RulesHandler{
+		rulesStore: rulesStore,
+		limits:     limits,
+	}
+}
+
+// errBadRequest wraps errors caused by the parameters of the request.
+type errBadRequest struct {
+	err error
+}
+
+func (e errBadRequest) Error() string {
+	return e.err.Error()
+}
+
+// GetRulesHandler handles listing the retention rules of a tenant
+func (h *RulesHandler) GetRulesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		serverutil.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rules, err := h.rulesStore.GetRules(ctx, userID)
+	if err != nil {
+		level.Error(util_log.Logger).Log("msg", "error getting retention rules from the store", "err", err)
+		serverutil.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(rules); err != nil {
+		level.Error(util_log.Logger).Log("msg", "error marshalling response", "err", err)
+		serverutil.JSONError(w, http.StatusInternalServerError, "error marshalling response: %v", err)
+	}
+}
+
+// AddRuleHandler handles the addition of a new retention rule
+func (h *RulesHandler) AddRuleHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		serverutil.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rule, err := parseRule(r)
+	if err != nil {
+		serverutil.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := rule.Validate(userID, h.limits); err != nil {
+		serverutil.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rule.RuleID = generateRuleID(userID, rule.Selector)
+	rule.CreatedAt = model.Now()
+	rule.UpdatedAt = rule.CreatedAt
+
+	err = h.rulesStore.UpdateRules(ctx, userID, func(rules []Rule) ([]Rule, error) {
+		if maxCount := h.limits.RetentionRulesMaxCount(userID); len(rules) >= maxCount {
+			return nil, errBadRequest{fmt.Errorf("the maximum number of retention rules (%d) has been reached", maxCount)}
+		}
+		return append(rules, rule), nil
+	})
+	if err != nil {
+		writeUpdateError(w, err, "error adding retention rule to the store")
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(rule); err != nil {
+		level.Error(util_log.Logger).Log("msg", "error marshalling response", "err", err)
+		serverutil.JSONError(w, http.StatusInternalServerError, "error marshalling response: %v", err)
+	}
+}
+
+// UpdateRuleHandler handles updating an existing retention rule
+func (h *RulesHandler) UpdateRuleHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		serverutil.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ruleID := r.URL.Query().Get("rule_id")
+	if ruleID == "" {
+		serverutil.JSONError(w, http.StatusBadRequest, "rule_id not set")
+		return
+	}
+
+	rule, err := parseRule(r)
+	if err != nil {
+		serverutil.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := rule.Validate(userID, h.limits); err != nil {
+		serverutil.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = h.rulesStore.UpdateRules(ctx, userID, func(rules []Rule) ([]Rule, error) {
+		for i := range rules {
+			if rules[i].RuleID != ruleID {
+				continue
+			}
+			rule.RuleID = ruleID
+			rule.CreatedAt = rules[i].CreatedAt
+			rule.UpdatedAt = model.Now()
+			rules[i] = rule
+			return rules, nil
+		}
+		return nil, ErrRuleNotFound
+	})
+	if err != nil {
+		writeUpdateError(w, err, "error updating retention rule in the store")
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(rule); err != nil {
+		level.Error(util_log.Logger).Log("msg", "error marshalling response", "err", err)
+		serverutil.JSONError(w, http.StatusInternalServerError, "error marshalling response: %v", err)
+	}
+}
+
+// RemoveRuleHandler handles the removal of a retention rule
+func (h *RulesHandler) RemoveRuleHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		serverutil.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ruleID := r.URL.Query().Get("rule_id")
+	if ruleID == "" {
+		serverutil.JSONError(w, http.StatusBadRequest, "rule_id not set")
+		return
+	}
+
+	err = h.rulesStore.UpdateRules(ctx, userID, func(rules []Rule) ([]Rule, error) {
+		for i := range rules {
+			if rules[i].RuleID == ruleID {
+				return append(rules[:i], rules[i+1:]...), nil
+			}
+		}
+		return nil, ErrRuleNotFound
+	})
+	if err != nil {
+		writeUpdateError(w, err, "error removing retention rule from the store")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func parseRule(r *http.Request) (Rule, error) {
+	params := r.URL.Query()
+
+	rule := Rule{
+		Selector: params.Get("selector"),
+	}
+	if rule.Selector == "" {
+		return rule, fmt.Errorf("selector not set")
+	}
+
+	periodParam := params.Get("period")
+	if periodParam == "" {
+		return rule, fmt.Errorf("period not set")
+	}
+	period, err := model.ParseDuration(periodParam)
+	if err != nil {
+		return rule, fmt.Errorf("invalid period: %w", err)
+	}
+	rule.Period = period
+
+	if priorityParam := params.Get("priority"); priorityParam != "" {
+		rule.Priority, err = strconv.Atoi(priorityParam)
+		if err != nil {
+			return rule, fmt.Errorf("invalid priority: %w", err)
+		}
+	}
+
+	return rule, nil
+}
+
+func writeUpdateError(w http.ResponseWriter, err error, msg string) {
+	var badRequestErr errBadRequest
+	switch {
+	case errors.As(err, &badRequestErr):
+		serverutil.JSONError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrRuleNotFound):
+		serverutil.JSONError(w, http.StatusNotFound, err.Error())
+	default:
+		level.Error(util_log.Logger).Log("msg", msg, "err", err)
+		serverutil.JSONError(w, http.StatusInternalServerError, err.Error())
+	}
+}
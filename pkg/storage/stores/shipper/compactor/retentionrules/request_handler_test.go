@@ -0,0 +1,88 @@
+package retentionrules
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+)
+
+func TestRulesHandler(t *testing.T) {
+	limits := fakeLimits{maxCount: 2, maxPeriod: 30 * 24 * time.Hour}
+	handler := NewRulesHandler(newTestRulesStore(t, limits), limits)
+
+	do := func(f http.HandlerFunc, method, query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/loki/api/admin/retention_rules?"+query, nil)
+		req = req.WithContext(user.InjectOrgID(req.Context(), "user1"))
+		w := httptest.NewRecorder()
+		f(w, req)
+		return w
+	}
+	getRules := func() []Rule {
+		w := do(handler.GetRulesHandler, http.MethodGet, "")
+		require.Equal(t, http.StatusOK, w.Code)
+		var rules []Rule
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rules))
+		return rules
+	}
+
+	for _, tc := range []struct {
+		name  string
+		query string
+	}{
+		{"missing selector", "period=48h"},
+		{"invalid selector", "selector=foo&period=48h"},
+		{"missing period", "selector={foo=\"bar\"}"},
+		{"period too short", "selector={foo=\"bar\"}&period=1h"},
+		{"period too long", "selector={foo=\"bar\"}&period=31d"},
+		{"invalid priority", "selector={foo=\"bar\"}&period=48h&priority=high"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := do(handler.AddRuleHandler, http.MethodPost, tc.query)
+			require.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+	require.Empty(t, getRules())
+
+	w := do(handler.AddRuleHandler, http.MethodPost, `selector={foo="bar"}&period=48h&priority=2`)
+	require.Equal(t, http.StatusOK, w.Code)
+	var added Rule
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &added))
+	require.NotEmpty(t, added.RuleID)
+	require.Equal(t, model.Duration(48*time.Hour), added.Period)
+	require.Equal(t, 2, added.Priority)
+
+	w = do(handler.AddRuleHandler, http.MethodPost, `selector={foo="buzz"}&period=30d`)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// the maximum number of rules is reached.
+	w = do(handler.AddRuleHandler, http.MethodPost, `selector={foo="fizz"}&period=30d`)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Len(t, getRules(), 2)
+
+	w = do(handler.UpdateRuleHandler, http.MethodPut, `rule_id=unknown&selector={foo="bar"}&period=72h`)
+	require.Equal(t, http.StatusNotFound, w.Code)
+
+	w = do(handler.UpdateRuleHandler, http.MethodPut, `rule_id=`+added.RuleID+`&selector={foo="bar"}&period=72h`)
+	require.Equal(t, http.StatusOK, w.Code)
+	rules := getRules()
+	require.Len(t, rules, 2)
+	require.Equal(t, added.RuleID, rules[0].RuleID)
+	require.Equal(t, model.Duration(72*time.Hour), rules[0].Period)
+	require.Equal(t, 0, rules[0].Priority)
+	require.Equal(t, added.CreatedAt, rules[0].CreatedAt)
+
+	w = do(handler.RemoveRuleHandler, http.MethodDelete, `rule_id=`+added.RuleID)
+	require.Equal(t, http.StatusNoContent, w.Code)
+	rules = getRules()
+	require.Len(t, rules, 1)
+	require.Equal(t, `{foo="buzz"}`, rules[0].Selector)
+
+	w = do(handler.RemoveRuleHandler, http.MethodDelete, `rule_id=`+added.RuleID)
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,95 @@
+package retentionrules
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/validation"
+)
+
+// minRetentionPeriod is the minimum period of a retention rule, same as for `retention_stream` in the limits.
+const minRetentionPeriod = 24 * time.Hour
+
+// Limits are the limits enforced on the retention rules of a user.
+type Limits interface {
+	RetentionRulesMaxCount(userID string) int
+	RetentionRulesMaxPeriod(userID string) time.Duration
+}
+
+// Rule holds all the details about a per-stream retention rule defined through the API.
+type Rule struct {
+	RuleID    string         `json:"rule_id"`
+	Selector  string         `json:"selector"`
+	Period    model.Duration `json:"period"`
+	Priority  int            `json:"priority"`
+	CreatedAt model.Time     `json:"created_at"`
+	UpdatedAt model.Time     `json:"updated_at"`
+}
+
+// Validate checks that the rule has a valid selector and a period within the bounds allowed for the user.
+func (r *Rule) Validate(userID string, limits Limits) error {
+	if _, err := logql.ParseMatchers(r.Selector); err != nil {
+		return fmt.Errorf("invalid labels matchers: %w", err)
+	}
+	if time.Duration(r.Period) < minRetentionPeriod {
+		return fmt.Errorf("retention period must be >= %s was %s", model.Duration(minRetentionPeriod), r.Period)
+	}
+	if maxPeriod := capPeriod(limits.RetentionRulesMaxPeriod(userID)); maxPeriod > 0 && time.Duration(r.Period) > maxPeriod {
+		return fmt.Errorf("retention period must be <= %s was %s", model.Duration(maxPeriod), r.Period)
+	}
+	return nil
+}
+
+// toStreamRetention converts the rule to a validation.StreamRetention with the period capped to maxPeriod.
+// A maxPeriod of 0 does not cap the period.
+func (r *Rule) toStreamRetention(maxPeriod time.Duration) (validation.StreamRetention, error) {
+	matchers, err := logql.ParseMatchers(r.Selector)
+	if err != nil {
+		return validation.StreamRetention{}, err
+	}
+
+	period := r.Period
+	if maxPeriod = capPeriod(maxPeriod); maxPeriod > 0 && time.Duration(period) > maxPeriod {
+		period = model.Duration(maxPeriod)
+	}
+
+	return validation.StreamRetention{
+		Period:   period,
+		Priority: r.Priority,
+		Selector: r.Selector,
+		Matchers: matchers,
+	}, nil
+}
+
+// capPeriod returns the period the rules are capped to for the maximum period of the limits:
+// 0, which is unlimited retention, does not cap the rules and the cap is never below minRetentionPeriod.
+func capPeriod(maxPeriod time.Duration) time.Duration {
+	if maxPeriod <= 0 {
+		return 0
+	}
+	if maxPeriod < minRetentionPeriod {
+		return minRetentionPeriod
+	}
+	return maxPeriod
+}
+
+// generateRuleID generates an id which is unique amongst the rules of a user.
+func generateRuleID(userID, selector string) string {
+	uniqueID := fnv.New32()
+	_, _ = uniqueID.Write([]byte(userID))
+
+	timeNow := make([]byte, 8)
+	binary.LittleEndian.PutUint64(timeNow, uint64(time.Now().UnixNano()))
+	_, _ = uniqueID.Write(timeNow)
+	_, _ = uniqueID.Write([]byte(selector))
+
+	idBytes := make([]byte, 4)
+	binary.BigEndian.PutUint32(idBytes, uniqueID.Sum32())
+	return hex.EncodeToString(idBytes)
+}
@@ -0,0 +1,170 @@
+package retentionrules
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"github.com/go-kit/log/level"
+
+	"github.com/grafana/loki/pkg/storage/stores/shipper/storage"
+	util_log "github.com/grafana/loki/pkg/util/log"
+	"github.com/grafana/loki/pkg/validation"
+)
+
+const (
+	// RetentionRulesTableName is the name of the table in the object store under which the rules of each user are stored.
+	RetentionRulesTableName = "retention_rules"
+
+	rulesFileSuffix = ".json"
+)
+
+var ErrRuleNotFound = errors.New("could not find matching retention rule")
+
+type RulesStore interface {
+	GetRules(ctx context.Context, userID string) ([]Rule, error)
+	// UpdateRules atomically replaces the rules of a user with the ones returned by update.
+	// The rules are left untouched when update returns an error.
+	UpdateRules(ctx context.Context, userID string, update func(rules []Rule) ([]Rule, error)) error
+	// AllStreamRetention returns the rules of all the users converted to validation.StreamRetention,
+	// after applying the current limits of each user.
+	AllStreamRetention(ctx context.Context) (map[string][]validation.StreamRetention, error)
+}
+
+type rulesFile struct {
+	Rules []Rule `json:"rules"`
+}
+
+// rulesStore stores the rules of each user as a single json file in the object store.
+type rulesStore struct {
+	indexStorageClient storage.Client
+	limits             Limits
+
+	// updatesMtx serializes the read-modify-write of the rules files.
+	updatesMtx sync.Mutex
+}
+
+// NewRulesStore creates a store for managing retention rules.
+func NewRulesStore(indexStorageClient storage.Client, limits Limits) RulesStore {
+	return &rulesStore{
+		indexStorageClient: indexStorageClient,
+		limits:             limits,
+	}
+}
+
+// GetRules returns all the rules of a user.
+func (s *rulesStore) GetRules(ctx context.Context, userID string) ([]Rule, error) {
+	rules, err := s.readRulesFile(ctx, userID+rulesFileSuffix)
+	if err != nil {
+		return nil, err
+	}
+
+	if rules == nil {
+		return []Rule{}, nil
+	}
+	return rules, nil
+}
+
+// UpdateRules replaces the rules of a user with the ones returned by update.
+func (s *rulesStore) UpdateRules(ctx context.Context, userID string, update func(rules []Rule) ([]Rule, error)) error {
+	s.updatesMtx.Lock()
+	defer s.updatesMtx.Unlock()
+
+	rules, err := s.GetRules(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	rules, err = update(rules)
+	if err != nil {
+		return err
+	}
+
+	fileName := userID + rulesFileSuffix
+	if len(rules) == 0 {
+		err := s.indexStorageClient.DeleteFile(ctx, RetentionRulesTableName, fileName)
+		if err != nil && !s.indexStorageClient.IsFileNotFoundErr(err) {
+			return err
+		}
+		return nil
+	}
+
+	buf, err := json.Marshal(rulesFile{Rules: rules})
+	if err != nil {
+		return err
+	}
+
+	return s.indexStorageClient.PutFile(ctx, RetentionRulesTableName, fileName, bytes.NewReader(buf))
+}
+
+// AllStreamRetention returns the rules of all the users.
+// Rules which are over the current limits of the user are capped to the limits,
+// so that lowering the limits applies to the rules which were already created.
+func (s *rulesStore) AllStreamRetention(ctx context.Context) (map[string][]validation.StreamRetention, error) {
+	files, _, err := s.indexStorageClient.ListFiles(ctx, RetentionRulesTableName)
+	if err != nil {
+		return nil, err
+	}
+
+	streamRetentionByUser := make(map[string][]validation.StreamRetention, len(files))
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name, rulesFileSuffix) {
+			continue
+		}
+		userID := strings.TrimSuffix(file.Name, rulesFileSuffix)
+
+		rules, err := s.readRulesFile(ctx, file.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		maxCount := s.limits.RetentionRulesMaxCount(userID)
+		if len(rules) > maxCount {
+			rules = rules[:maxCount]
+		}
+		if len(rules) == 0 {
+			continue
+		}
+
+		maxPeriod := s.limits.RetentionRulesMaxPeriod(userID)
+		streamRetention := make([]validation.StreamRetention, 0, len(rules))
+		for _, rule := range rules {
+			sr, err := rule.toStreamRetention(maxPeriod)
+			if err != nil {
+				level.Warn(util_log.Logger).Log("msg", "skipping invalid retention rule", "user", userID, "rule_id", rule.RuleID, "err", err)
+				continue
+			}
+			streamRetention = append(streamRetention, sr)
+		}
+		streamRetentionByUser[userID] = streamRetention
+	}
+
+	return streamRetentionByUser, nil
+}
+
+func (s *rulesStore) readRulesFile(ctx context.Context, fileName string) ([]Rule, error) {
+	reader, err := s.indexStorageClient.GetFile(ctx, RetentionRulesTableName, fileName)
+	if err != nil {
+		if s.indexStorageClient.IsFileNotFoundErr(err) {
+			// the user has no rules, or they were removed since we listed the table.
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer reader.Close()
+
+	buf, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	var file rulesFile
+	if err := json.Unmarshal(buf, &file); err != nil {
+		return nil, err
+	}
+	return file.Rules, nil
+}
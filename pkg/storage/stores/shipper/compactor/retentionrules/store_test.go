@@ -0,0 +1,132 @@
+package retentionrules
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/storage/chunk/local"
+	"github.com/grafana/loki/pkg/storage/stores/shipper/storage"
+	"github.com/grafana/loki/pkg/validation"
+)
+
+type fakeLimits struct {
+	maxCount  int
+	maxPeriod time.Duration
+}
+
+func (f fakeLimits) RetentionRulesMaxCount(_ string) int {
+	return f.maxCount
+}
+
+func (f fakeLimits) RetentionRulesMaxPeriod(_ string) time.Duration {
+	return f.maxPeriod
+}
+
+func newTestRulesStore(t *testing.T, limits Limits) RulesStore {
+	objectClient, err := local.NewFSObjectClient(local.FSConfig{
+		Directory: filepath.Join(t.TempDir(), "object-store"),
+	})
+	require.NoError(t, err)
+
+	return NewRulesStore(storage.NewIndexStorageClient(objectClient, ""), limits)
+}
+
+func TestRulesStore(t *testing.T) {
+	store := newTestRulesStore(t, fakeLimits{maxCount: 2, maxPeriod: 30 * 24 * time.Hour})
+	ctx := context.Background()
+
+	// no rules set yet
+	rules, err := store.GetRules(ctx, "user1")
+	require.NoError(t, err)
+	require.Empty(t, rules)
+
+	user1Rules := []Rule{
+		{RuleID: "1", Selector: `{foo="bar"}`, Period: model.Duration(48 * time.Hour), Priority: 1},
+		{RuleID: "2", Selector: `{foo="buzz"}`, Period: model.Duration(60 * 24 * time.Hour), Priority: 2},
+		{RuleID: "3", Selector: `{foo="fizz"}`, Period: model.Duration(72 * time.Hour)},
+	}
+	require.NoError(t, store.UpdateRules(ctx, "user1", func(rules []Rule) ([]Rule, error) {
+		return append(rules, user1Rules...), nil
+	}))
+	require.NoError(t, store.UpdateRules(ctx, "user2", func(rules []Rule) ([]Rule, error) {
+		return append(rules, Rule{RuleID: "1", Selector: `{foo="bar"}`, Period: model.Duration(24 * time.Hour)}), nil
+	}))
+
+	rules, err = store.GetRules(ctx, "user1")
+	require.NoError(t, err)
+	require.Equal(t, user1Rules, rules)
+
+	// a failed update leaves the rules untouched
+	require.Error(t, store.UpdateRules(ctx, "user1", func(rules []Rule) ([]Rule, error) {
+		return nil, ErrRuleNotFound
+	}))
+	rules, err = store.GetRules(ctx, "user1")
+	require.NoError(t, err)
+	require.Equal(t, user1Rules, rules)
+
+	// rules over the limits are capped
+	streamRetention, err := store.AllStreamRetention(ctx)
+	require.NoError(t, err)
+	require.Len(t, streamRetention, 2)
+	require.Len(t, streamRetention["user1"], 2)
+	require.Equal(t, model.Duration(48*time.Hour), streamRetention["user1"][0].Period)
+	require.Equal(t, 1, streamRetention["user1"][0].Priority)
+	require.Len(t, streamRetention["user1"][0].Matchers, 1)
+	require.Equal(t, model.Duration(30*24*time.Hour), streamRetention["user1"][1].Period)
+	require.Len(t, streamRetention["user2"], 1)
+
+	// removing all the rules of a user removes the user
+	require.NoError(t, store.UpdateRules(ctx, "user2", func(_ []Rule) ([]Rule, error) {
+		return nil, nil
+	}))
+	rules, err = store.GetRules(ctx, "user2")
+	require.NoError(t, err)
+	require.Empty(t, rules)
+
+	streamRetention, err = store.AllStreamRetention(ctx)
+	require.NoError(t, err)
+	require.Len(t, streamRetention, 1)
+	require.Contains(t, streamRetention, "user1")
+}
+
+func TestRulesStore_UnlimitedRetention(t *testing.T) {
+	// A tenant with unlimited retention and no retention_rules_max_period does not have its rules capped.
+	limits, err := validation.NewOverrides(validation.Limits{RetentionRulesMaxCount: 2, RetentionPeriod: 0}, nil)
+	require.NoError(t, err)
+	store := newTestRulesStore(t, limits)
+	ctx := context.Background()
+
+	rule := Rule{RuleID: "1", Selector: `{foo="bar"}`, Period: model.Duration(365 * 24 * time.Hour)}
+	require.NoError(t, rule.Validate("user1", limits))
+	require.NoError(t, store.UpdateRules(ctx, "user1", func(rules []Rule) ([]Rule, error) {
+		return append(rules, rule), nil
+	}))
+
+	streamRetention, err := store.AllStreamRetention(ctx)
+	require.NoError(t, err)
+	require.Len(t, streamRetention["user1"], 1)
+	require.Equal(t, rule.Period, streamRetention["user1"][0].Period)
+}
+
+func TestRulesStore_MinRetentionPeriod(t *testing.T) {
+	// Rules are never capped below the minimum retention period.
+	limits := fakeLimits{maxCount: 2, maxPeriod: time.Hour}
+	store := newTestRulesStore(t, limits)
+	ctx := context.Background()
+
+	rule := Rule{RuleID: "1", Selector: `{foo="bar"}`, Period: model.Duration(48 * time.Hour)}
+	require.NoError(t, store.UpdateRules(ctx, "user1", func(rules []Rule) ([]Rule, error) {
+		return append(rules, rule), nil
+	}))
+
+	streamRetention, err := store.AllStreamRetention(ctx)
+	require.NoError(t, err)
+	require.Len(t, streamRetention["user1"], 1)
+	require.Equal(t, model.Duration(minRetentionPeriod), streamRetention["user1"][0].Period)
+	require.NoError(t, (&Rule{Selector: `{foo="bar"}`, Period: model.Duration(minRetentionPeriod)}).Validate("user1", limits))
+}
@@ -35,6 +35,7 @@ type IndexSet interface {
 	UpdateLastUsedAt()
 	Sync(ctx context.Context) (err error)
 	AwaitReady(ctx context.Context) error
+	ForEach(ctx context.Context, callback func(IndexFile) error) error
 }
 
 // indexSet is a collection of multiple files created for a same table by various ingesters.
@@ -45,10 +46,11 @@ type indexSet struct {
 	cacheLocation     string
 	metrics           *metrics
 	boltDBIndexClient BoltDBIndexClient
+	openIndexFileFunc OpenIndexFileFunc
 	logger            log.Logger
 
 	lastUsedAt time.Time
-	dbs        map[string]*bbolt.DB
+	dbs        map[string]IndexFile
 	dbsMtx     *mtxWithReadiness
 	err        error
 
@@ -56,7 +58,7 @@ type indexSet struct {
 }
 
 func NewIndexSet(tableName, userID, cacheLocation string, baseIndexSet storage.IndexSet,
-	boltDBIndexClient BoltDBIndexClient, logger log.Logger, metrics *metrics) (IndexSet, error) {
+	boltDBIndexClient BoltDBIndexClient, openIndexFileFunc OpenIndexFileFunc, logger log.Logger, metrics *metrics) (IndexSet, error) {
 	if baseIndexSet.IsUserBasedIndexSet() && userID == "" {
 		return nil, fmt.Errorf("userID must not be empty")
 	} else if !baseIndexSet.IsUserBasedIndexSet() && userID != "" {
@@ -68,6 +70,10 @@ func NewIndexSet(tableName, userID, cacheLocation string, baseIndexSet storage.I
 		return nil, err
 	}
 
+	if openIndexFileFunc == nil {
+		openIndexFileFunc = OpenBoltdbFile
+	}
+
 	is := indexSet{
 		baseIndexSet:      baseIndexSet,
 		tableName:         tableName,
@@ -75,9 +81,10 @@ func NewIndexSet(tableName, userID, cacheLocation string, baseIndexSet storage.I
 		cacheLocation:     cacheLocation,
 		metrics:           metrics,
 		boltDBIndexClient: boltDBIndexClient,
+		openIndexFileFunc: openIndexFileFunc,
 		logger:            logger,
 		lastUsedAt:        time.Now(),
-		dbs:               map[string]*bbolt.DB{},
+		dbs:               map[string]IndexFile{},
 		dbsMtx:            newMtxWithReadiness(),
 		cancelFunc:        func() {},
 	}
@@ -123,20 +130,20 @@ func (t *indexSet) Init() (err error) {
 		}
 
 		fullPath := filepath.Join(t.cacheLocation, fileInfo.Name())
-		// if we fail to open a boltdb file, lets skip it and let sync operation re-download the file from storage.
-		boltdb, err := shipper_util.SafeOpenBoltdbFile(fullPath)
+		// if we fail to open an index file, lets skip it and let sync operation re-download the file from storage.
+		indexFile, err := t.openIndexFileFunc(fullPath)
 		if err != nil {
-			level.Error(util_log.Logger).Log("msg", fmt.Sprintf("failed to open existing boltdb file %s, removing the file and continuing without it to let the sync operation catch up", fullPath), "err", err)
+			level.Error(util_log.Logger).Log("msg", fmt.Sprintf("failed to open existing index file %s, removing the file and continuing without it to let the sync operation catch up", fullPath), "err", err)
 			// Sometimes files get corrupted when the process gets killed in the middle of a download operation which causes boltdb client to panic.
 			// We already recover the panic but the lock on the file is not released by boltdb client which causes the reopening of the file to fail when the sync operation tries it.
 			// We want to remove the file failing to open to get rid of the lock.
 			if err := os.Remove(fullPath); err != nil {
-				level.Error(util_log.Logger).Log("msg", fmt.Sprintf("failed to remove boltdb file %s which failed to open", fullPath))
+				level.Error(util_log.Logger).Log("msg", fmt.Sprintf("failed to remove index file %s which failed to open", fullPath))
 			}
 			continue
 		}
 
-		t.dbs[fileInfo.Name()] = boltdb
+		t.dbs[fileInfo.Name()] = indexFile
 	}
 
 	level.Debug(logger).Log("msg", fmt.Sprintf("opened %d local files, now starting sync operation", len(t.dbs)))
@@ -173,7 +180,7 @@ func (t *indexSet) Close() {
 		}
 	}
 
-	t.dbs = map[string]*bbolt.DB{}
+	t.dbs = map[string]IndexFile{}
 }
 
 // MultiQueries runs multiple queries without having to take lock multiple times for each query.
@@ -200,7 +207,12 @@ func (t *indexSet) MultiQueries(ctx context.Context, queries []chunk.IndexQuery,
 	logger := util_log.WithContext(ctx, t.logger)
 	level.Debug(logger).Log("table-name", t.tableName, "query-count", len(queries))
 
-	for name, db := range t.dbs {
+	for name, indexFile := range t.dbs {
+		db, ok := indexFile.(*bbolt.DB)
+		if !ok {
+			return fmt.Errorf("index file %s does not support index queries", name)
+		}
+
 		err := db.View(func(tx *bbolt.Tx) error {
 			bucket := tx.Bucket(userIDBytes)
 			if bucket == nil {
@@ -228,6 +240,29 @@ func (t *indexSet) MultiQueries(ctx context.Context, queries []chunk.IndexQuery,
 	return nil
 }
 
+// ForEach runs the callback for each of the index files without having to take lock multiple times.
+func (t *indexSet) ForEach(ctx context.Context, callback func(IndexFile) error) error {
+	err := t.dbsMtx.rLock(ctx)
+	if err != nil {
+		return err
+	}
+	defer t.dbsMtx.rUnlock()
+
+	if t.err != nil {
+		return t.err
+	}
+
+	t.lastUsedAt = time.Now()
+
+	for _, indexFile := range t.dbs {
+		if err := callback(indexFile); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // DropAllDBs closes reference to all the open dbs and removes the local files.
 func (t *indexSet) DropAllDBs() error {
 	err := t.dbsMtx.lock(context.Background())
@@ -315,12 +350,12 @@ func (t *indexSet) sync(ctx context.Context, lock bool) (err error) {
 
 	for _, fileName := range downloadedFiles {
 		filePath := filepath.Join(t.cacheLocation, fileName)
-		boltdb, err := shipper_util.SafeOpenBoltdbFile(filePath)
+		indexFile, err := t.openIndexFileFunc(filePath)
 		if err != nil {
 			return err
 		}
 
-		t.dbs[fileName] = boltdb
+		t.dbs[fileName] = indexFile
 	}
 
 	for _, db := range toDelete {
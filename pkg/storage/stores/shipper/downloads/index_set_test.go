@@ -23,7 +23,7 @@ func buildTestIndexSet(t *testing.T, userID, path string) (*indexSet, stopFunc)
 
 	baseIndexSet := storage.NewIndexSet(storageClient, userID != "")
 	idxSet, err := NewIndexSet(tableName, userID, filepath.Join(cachePath, tableName, userID), baseIndexSet,
-		boltDBIndexClient, util_log.Logger, newMetrics(nil))
+		boltDBIndexClient, nil, util_log.Logger, newMetrics(nil))
 	require.NoError(t, err)
 
 	require.NoError(t, idxSet.Init())
@@ -10,6 +10,8 @@ import (
 const (
 	statusFailure = "failure"
 	statusSuccess = "success"
+
+	defaultMetricsNamespace = "loki_boltdb_shipper"
 )
 
 type downloadTableDurationMetric struct {
@@ -39,16 +41,20 @@ type metrics struct {
 }
 
 func newMetrics(r prometheus.Registerer) *metrics {
+	return newMetricsWithNamespace(defaultMetricsNamespace, r)
+}
+
+func newMetricsWithNamespace(namespace string, r prometheus.Registerer) *metrics {
 	m := &metrics{
 		tablesDownloadDurationSeconds: &downloadTableDurationMetric{
 			periods: map[string]float64{},
 			gauge: promauto.With(r).NewGauge(prometheus.GaugeOpts{
-				Namespace: "loki_boltdb_shipper",
+				Namespace: namespace,
 				Name:      "initial_tables_download_duration_seconds",
 				Help:      "Time (in seconds) spent in downloading of files per table, initially i.e for the first time",
 			})},
 		tablesSyncOperationTotal: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
-			Namespace: "loki_boltdb_shipper",
+			Namespace: namespace,
 			Name:      "tables_sync_operation_total",
 			Help:      "Total number of tables sync operations done by status",
 		}, []string{"status"}),
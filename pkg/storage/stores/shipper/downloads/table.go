@@ -18,6 +18,7 @@ import (
 	"github.com/grafana/loki/pkg/storage/chunk"
 	chunk_util "github.com/grafana/loki/pkg/storage/chunk/util"
 	"github.com/grafana/loki/pkg/storage/stores/shipper/storage"
+	shipper_util "github.com/grafana/loki/pkg/storage/stores/shipper/util"
 	"github.com/grafana/loki/pkg/tenant"
 	util_log "github.com/grafana/loki/pkg/util/log"
 )
@@ -32,6 +33,20 @@ type BoltDBIndexClient interface {
 	QueryWithCursor(_ context.Context, c *bbolt.Cursor, query chunk.IndexQuery, callback func(chunk.IndexQuery, chunk.ReadBatch) (shouldContinue bool)) error
 }
 
+// IndexFile is an index file downloaded from the storage.
+type IndexFile interface {
+	Path() string
+	Close() error
+}
+
+// OpenIndexFileFunc opens an index file downloaded at the given path.
+type OpenIndexFileFunc func(path string) (IndexFile, error)
+
+// OpenBoltdbFile is the OpenIndexFileFunc used for boltdb index files.
+func OpenBoltdbFile(path string) (IndexFile, error) {
+	return shipper_util.SafeOpenBoltdbFile(path)
+}
+
 type StorageClient interface {
 	ListTables(ctx context.Context) ([]string, error)
 	ListFiles(ctx context.Context, tableName string) ([]storage.IndexFile, error)
@@ -48,6 +63,7 @@ type Table struct {
 	metrics           *metrics
 	storageClient     storage.Client
 	boltDBIndexClient BoltDBIndexClient
+	openIndexFileFunc OpenIndexFileFunc
 
 	baseUserIndexSet, baseCommonIndexSet storage.IndexSet
 
@@ -58,7 +74,8 @@ type Table struct {
 
 // NewTable just creates an instance of Table without trying to load files from local storage or object store.
 // It is used for initializing table at query time.
-func NewTable(name, cacheLocation string, storageClient storage.Client, boltDBIndexClient BoltDBIndexClient, metrics *metrics) *Table {
+func NewTable(name, cacheLocation string, storageClient storage.Client, boltDBIndexClient BoltDBIndexClient,
+	openIndexFileFunc OpenIndexFileFunc, metrics *metrics) *Table {
 	table := Table{
 		name:               name,
 		cacheLocation:      cacheLocation,
@@ -68,6 +85,7 @@ func NewTable(name, cacheLocation string, storageClient storage.Client, boltDBIn
 		baseCommonIndexSet: storage.NewIndexSet(storageClient, false),
 		logger:             log.With(util_log.Logger, "table-name", name),
 		boltDBIndexClient:  boltDBIndexClient,
+		openIndexFileFunc:  openIndexFileFunc,
 		indexSets:          map[string]IndexSet{},
 	}
 
@@ -76,7 +94,8 @@ func NewTable(name, cacheLocation string, storageClient storage.Client, boltDBIn
 
 // LoadTable loads a table from local storage(syncs the table too if we have it locally) or downloads it from the shared store.
 // It is used for loading and initializing table at startup. It would initialize index sets which already had files locally.
-func LoadTable(name, cacheLocation string, storageClient storage.Client, boltDBIndexClient BoltDBIndexClient, metrics *metrics) (*Table, error) {
+func LoadTable(name, cacheLocation string, storageClient storage.Client, boltDBIndexClient BoltDBIndexClient,
+	openIndexFileFunc OpenIndexFileFunc, metrics *metrics) (*Table, error) {
 	err := chunk_util.EnsureDirectory(cacheLocation)
 	if err != nil {
 		return nil, err
@@ -96,6 +115,7 @@ func LoadTable(name, cacheLocation string, storageClient storage.Client, boltDBI
 		baseCommonIndexSet: storage.NewIndexSet(storageClient, false),
 		logger:             log.With(util_log.Logger, "table-name", name),
 		boltDBIndexClient:  boltDBIndexClient,
+		openIndexFileFunc:  openIndexFileFunc,
 		indexSets:          map[string]IndexSet{},
 	}
 
@@ -107,7 +127,7 @@ func LoadTable(name, cacheLocation string, storageClient storage.Client, boltDBI
 		}
 
 		userIndexSet, err := NewIndexSet(name, fileInfo.Name(), filepath.Join(cacheLocation, fileInfo.Name()),
-			table.baseUserIndexSet, boltDBIndexClient, table.logger, metrics)
+			table.baseUserIndexSet, boltDBIndexClient, openIndexFileFunc, table.logger, metrics)
 		if err != nil {
 			return nil, err
 		}
@@ -121,7 +141,7 @@ func LoadTable(name, cacheLocation string, storageClient storage.Client, boltDBI
 	}
 
 	commonIndexSet, err := NewIndexSet(name, "", cacheLocation, table.baseCommonIndexSet,
-		boltDBIndexClient, table.logger, metrics)
+		boltDBIndexClient, openIndexFileFunc, table.logger, metrics)
 	if err != nil {
 		return nil, err
 	}
@@ -183,6 +203,36 @@ func (t *Table) MultiQueries(ctx context.Context, queries []chunk.IndexQuery, ca
 	return nil
 }
 
+// ForEach runs the callback for each of the index files of the user, including the common index files.
+func (t *Table) ForEach(ctx context.Context, userID string, callback func(IndexFile) error) error {
+	for _, uid := range []string{userID, ""} {
+		indexSet, err := t.getOrCreateIndexSet(uid)
+		if err != nil {
+			return err
+		}
+
+		if indexSet.Err() != nil {
+			level.Error(util_log.WithContext(ctx, t.logger)).Log("msg", fmt.Sprintf("index set %s has some problem, cleaning it up", uid), "err", indexSet.Err())
+			if err := indexSet.DropAllDBs(); err != nil {
+				level.Error(t.logger).Log("msg", fmt.Sprintf("failed to cleanup broken index set %s", uid), "err", err)
+			}
+
+			t.indexSetsMtx.Lock()
+			delete(t.indexSets, uid)
+			t.indexSetsMtx.Unlock()
+
+			return indexSet.Err()
+		}
+
+		err = indexSet.ForEach(ctx, callback)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // DropUnusedIndex drops the index set if it has not been queried for at least ttl duration.
 // It returns true if the whole table gets dropped.
 func (t *Table) DropUnusedIndex(ttl time.Duration, now time.Time) (bool, error) {
@@ -258,7 +308,7 @@ func (t *Table) getOrCreateIndexSet(id string) (IndexSet, error) {
 	}
 
 	// instantiate the index set, add it to the map
-	indexSet, err = NewIndexSet(t.name, id, filepath.Join(t.cacheLocation, id), baseIndexSet, t.boltDBIndexClient, t.logger, t.metrics)
+	indexSet, err = NewIndexSet(t.name, id, filepath.Join(t.cacheLocation, id), baseIndexSet, t.boltDBIndexClient, t.openIndexFileFunc, t.logger, t.metrics)
 	if err != nil {
 		return nil, err
 	}
@@ -30,6 +30,10 @@ type Config struct {
 	SyncInterval      time.Duration
 	CacheTTL          time.Duration
 	QueryReadyNumDays int
+	// OpenIndexFileFunc opens the downloaded index files. Defaults to opening boltdb files when not set.
+	OpenIndexFileFunc OpenIndexFileFunc
+	// MetricsNamespace is the namespace of the metrics. Defaults to the one used by boltdb-shipper when not set.
+	MetricsNamespace string
 }
 
 type TableManager struct {
@@ -51,13 +55,18 @@ func NewTableManager(cfg Config, boltIndexClient BoltDBIndexClient, indexStorage
 		return nil, err
 	}
 
+	metricsNamespace := cfg.MetricsNamespace
+	if metricsNamespace == "" {
+		metricsNamespace = defaultMetricsNamespace
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	tm := &TableManager{
 		cfg:                cfg,
 		boltIndexClient:    boltIndexClient,
 		indexStorageClient: indexStorageClient,
 		tables:             make(map[string]*Table),
-		metrics:            newMetrics(registerer),
+		metrics:            newMetricsWithNamespace(metricsNamespace, registerer),
 		ctx:                ctx,
 		cancel:             cancel,
 	}
@@ -152,6 +161,16 @@ func (tm *TableManager) query(ctx context.Context, tableName string, queries []c
 	return util.DoParallelQueries(ctx, table, queries, callback)
 }
 
+// ForEach runs the callback for each of the index files of the user in the table.
+func (tm *TableManager) ForEach(ctx context.Context, tableName, userID string, callback func(IndexFile) error) error {
+	table, err := tm.getOrCreateTable(tableName)
+	if err != nil {
+		return err
+	}
+
+	return table.ForEach(ctx, userID, callback)
+}
+
 func (tm *TableManager) getOrCreateTable(tableName string) (*Table, error) {
 	// if table is already there, use it.
 	tm.tablesMtx.RLock()
@@ -172,7 +191,7 @@ func (tm *TableManager) getOrCreateTable(tableName string) (*Table, error) {
 				return nil, err
 			}
 
-			table = NewTable(tableName, filepath.Join(tm.cfg.CacheDir, tableName), tm.indexStorageClient, tm.boltIndexClient, tm.metrics)
+			table = NewTable(tableName, filepath.Join(tm.cfg.CacheDir, tableName), tm.indexStorageClient, tm.boltIndexClient, tm.cfg.OpenIndexFileFunc, tm.metrics)
 			tm.tables[tableName] = table
 		}
 		tm.tablesMtx.Unlock()
@@ -268,7 +287,7 @@ func (tm *TableManager) ensureQueryReadiness() error {
 			return err
 		}
 
-		table, err = LoadTable(tableName, filepath.Join(tm.cfg.CacheDir, tableName), tm.indexStorageClient, tm.boltIndexClient, tm.metrics)
+		table, err = LoadTable(tableName, filepath.Join(tm.cfg.CacheDir, tableName), tm.indexStorageClient, tm.boltIndexClient, tm.cfg.OpenIndexFileFunc, tm.metrics)
 		if err != nil {
 			return err
 		}
@@ -337,7 +356,7 @@ func (tm *TableManager) loadLocalTables() error {
 
 		level.Info(util_log.Logger).Log("msg", fmt.Sprintf("loading local table %s", fileInfo.Name()))
 
-		table, err := LoadTable(fileInfo.Name(), filepath.Join(tm.cfg.CacheDir, fileInfo.Name()), tm.indexStorageClient, tm.boltIndexClient, tm.metrics)
+		table, err := LoadTable(fileInfo.Name(), filepath.Join(tm.cfg.CacheDir, fileInfo.Name()), tm.indexStorageClient, tm.boltIndexClient, tm.cfg.OpenIndexFileFunc, tm.metrics)
 		if err != nil {
 			return err
 		}
@@ -72,7 +72,7 @@ func buildTestTable(t *testing.T, path string) (*Table, *local.BoltIndexClient,
 	boltDBIndexClient, storageClient := buildTestClients(t, path)
 	cachePath := filepath.Join(path, cacheDirName)
 
-	table := NewTable(tableName, cachePath, storageClient, boltDBIndexClient, newMetrics(nil))
+	table := NewTable(tableName, cachePath, storageClient, boltDBIndexClient, nil, newMetrics(nil))
 	require.NoError(t, table.EnsureQueryReadiness(context.Background()))
 
 	return table, boltDBIndexClient, func() {
@@ -500,7 +500,7 @@ func TestLoadTable(t *testing.T) {
 	storageClient = newStorageClientWithFakeObjectsInList(storageClient)
 
 	// try loading the table.
-	table, err := LoadTable(tableName, tablePathInCache, storageClient, boltDBIndexClient, newMetrics(nil))
+	table, err := LoadTable(tableName, tablePathInCache, storageClient, boltDBIndexClient, nil, newMetrics(nil))
 	require.NoError(t, err)
 	require.NotNil(t, table)
 
@@ -540,7 +540,7 @@ func TestLoadTable(t *testing.T) {
 	testutil.SetupDBsAtPath(t, filepath.Join(tablePathInStorage, userID), userDBs, nil)
 
 	// try loading the table, it should skip loading corrupt file and reload it from storage.
-	table, err = LoadTable(tableName, tablePathInCache, storageClient, boltDBIndexClient, newMetrics(nil))
+	table, err = LoadTable(tableName, tablePathInCache, storageClient, boltDBIndexClient, nil, newMetrics(nil))
 	require.NoError(t, err)
 	require.NotNil(t, table)
 
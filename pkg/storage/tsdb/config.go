@@ -0,0 +1,47 @@
+package tsdb
+
+import (
+	"errors"
+	"flag"
+	"time"
+
+	shipper_util "github.com/grafana/loki/pkg/storage/stores/shipper/util"
+)
+
+// TSDBType holds the index type for using the TSDB index shipped to a shared storage.
+const TSDBType = "tsdb"
+
+type Config struct {
+	ActiveIndexDirectory string        `yaml:"active_index_directory"`
+	SharedStoreType      string        `yaml:"shared_store"`
+	SharedStoreKeyPrefix string        `yaml:"shared_store_key_prefix"`
+	CacheLocation        string        `yaml:"cache_location"`
+	CacheTTL             time.Duration `yaml:"cache_ttl"`
+	ResyncInterval       time.Duration `yaml:"resync_interval"`
+	QueryReadyNumDays    int           `yaml:"query_ready_num_days"`
+	BuildInterval        time.Duration `yaml:"build_interval"`
+	IngesterName         string        `yaml:"-"`
+	Mode                 int           `yaml:"-"`
+	// IngesterIndexRetainPeriod is how long the ingesters keep querying the index files they built and uploaded,
+	// to give the queriers time to download them.
+	IngesterIndexRetainPeriod time.Duration `yaml:"-"`
+}
+
+// RegisterFlags registers flags.
+func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
+	f.StringVar(&cfg.ActiveIndexDirectory, "tsdb.shipper.active-index-directory", "", "Directory where ingesters would write the WAL and the index files which would then be uploaded by shipper to configured storage")
+	f.StringVar(&cfg.SharedStoreType, "tsdb.shipper.shared-store", "", "Shared store for keeping index files. Supported types: gcs, s3, azure, filesystem")
+	f.StringVar(&cfg.SharedStoreKeyPrefix, "tsdb.shipper.shared-store.key-prefix", "tsdb-index/", "Prefix to add to Object Keys in Shared store. Path separator(if any) should always be a '/'. Prefix should never start with a separator but should always end with it")
+	f.StringVar(&cfg.CacheLocation, "tsdb.shipper.cache-location", "", "Cache location for restoring index files for queries")
+	f.DurationVar(&cfg.CacheTTL, "tsdb.shipper.cache-ttl", 24*time.Hour, "TTL for index files restored in cache for queries")
+	f.DurationVar(&cfg.ResyncInterval, "tsdb.shipper.resync-interval", 5*time.Minute, "Resync downloaded files with the storage")
+	f.IntVar(&cfg.QueryReadyNumDays, "tsdb.shipper.query-ready-num-days", 0, "Number of days of index to be kept downloaded for queries. Works only with tables created with 24h period.")
+	f.DurationVar(&cfg.BuildInterval, "tsdb.shipper.build-interval", 15*time.Minute, "Interval at which the index kept in memory by the ingesters is built into index files and uploaded")
+}
+
+func (cfg *Config) Validate() error {
+	if cfg.BuildInterval <= 0 {
+		return errors.New("tsdb.shipper.build-interval must be greater than 0")
+	}
+	return shipper_util.ValidateSharedStoreKeyPrefix(cfg.SharedStoreKeyPrefix)
+}
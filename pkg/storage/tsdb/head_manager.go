@@ -0,0 +1,438 @@
+package tsdb
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+
+	chunk_util "github.com/grafana/loki/pkg/storage/chunk/util"
+	"github.com/grafana/loki/pkg/storage/stores/shipper/storage"
+	shipper_util "github.com/grafana/loki/pkg/storage/stores/shipper/util"
+	"github.com/grafana/loki/pkg/storage/tsdb/index"
+	util_log "github.com/grafana/loki/pkg/util/log"
+)
+
+const (
+	walDirName   = "wal"
+	builtDirName = "built"
+
+	indexFileSuffix = ".tsdb"
+)
+
+// tenantHeads holds the in-memory index of each user, by table.
+type tenantHeads map[string]map[string]*index.Builder
+
+func (t tenantHeads) add(rec walRecord) {
+	users, ok := t[rec.Table]
+	if !ok {
+		users = map[string]*index.Builder{}
+		t[rec.Table] = users
+	}
+
+	head, ok := users[rec.User]
+	if !ok {
+		head = index.NewBuilder()
+		users[rec.User] = head
+	}
+
+	head.AddSeries(rec.Labels, rec.Fingerprint, rec.Chunk)
+}
+
+// rotatedHeads are heads which don't accept new chunks anymore and are waiting to be built into index files.
+type rotatedHeads struct {
+	heads tenantHeads
+	// segments are the wal segments holding the records of the heads.
+	segments []string
+}
+
+// builtIndex is an index file built from a head, kept open for queries until the queriers had time to download it.
+type builtIndex struct {
+	table, user string
+	file        *index.TSDBFile
+	uploadedAt  time.Time
+}
+
+// headManager keeps the index of the chunks flushed by the ingester in memory,
+// and periodically builds it into per-tenant index files which are uploaded to the shared store.
+type headManager struct {
+	cfg                Config
+	indexStorageClient storage.Client
+	walDir, builtDir   string
+
+	mtx     sync.RWMutex
+	active  tenantHeads
+	segment *walSegment
+	rotated []*rotatedHeads
+	built   []*builtIndex
+
+	// buildMtx serializes the builds of the rotated heads.
+	buildMtx sync.Mutex
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newHeadManager(cfg Config, indexStorageClient storage.Client) (*headManager, error) {
+	m := &headManager{
+		cfg:                cfg,
+		indexStorageClient: indexStorageClient,
+		walDir:             filepath.Join(cfg.ActiveIndexDirectory, walDirName),
+		builtDir:           filepath.Join(cfg.ActiveIndexDirectory, builtDirName),
+		active:             tenantHeads{},
+	}
+
+	if err := chunk_util.EnsureDirectory(m.walDir); err != nil {
+		return nil, err
+	}
+
+	// the index files built before a restart are either uploaded already or rebuilt from the wal segments which are not removed until upload.
+	if err := os.RemoveAll(m.builtDir); err != nil {
+		return nil, err
+	}
+
+	if err := m.replayWAL(); err != nil {
+		return nil, err
+	}
+
+	var err error
+	m.segment, err = newWALSegment(m.walDir, fmt.Sprint(time.Now().UnixNano()))
+	if err != nil {
+		return nil, err
+	}
+
+	m.ctx, m.cancel = context.WithCancel(context.Background())
+	m.wg.Add(1)
+	go m.loop()
+
+	return m, nil
+}
+
+// replayWAL rebuilds the heads from the wal segments left by a previous run. They are built on the next build.
+func (m *headManager) replayWAL() error {
+	segments, err := listWALSegments(m.walDir)
+	if err != nil || len(segments) == 0 {
+		return err
+	}
+
+	rotated := &rotatedHeads{heads: tenantHeads{}, segments: segments}
+	for _, segment := range segments {
+		level.Info(util_log.Logger).Log("msg", "replaying wal segment", "path", segment)
+		if err := replayWALSegment(segment, rotated.heads.add); err != nil {
+			return err
+		}
+	}
+
+	m.rotated = append(m.rotated, rotated)
+	return nil
+}
+
+func (m *headManager) loop() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.cfg.BuildInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := m.rotateAndBuild(m.ctx); err != nil {
+				level.Error(util_log.Logger).Log("msg", "error building index files", "err", err)
+			}
+			m.cleanupBuiltIndexes(time.Now())
+		case <-m.ctx.Done():
+			return
+		}
+	}
+}
+
+// Append adds a chunk to the index of the table for the user.
+func (m *headManager) Append(tableName, userID string, ls labels.Labels, fp model.Fingerprint, chk index.ChunkMeta) error {
+	rec := walRecord{
+		Table:       tableName,
+		User:        userID,
+		Labels:      ls,
+		Fingerprint: fp,
+		Chunk:       chk,
+	}
+
+	m.mtx.Lock()
+	segment := m.segment
+	n, err := segment.write(rec)
+	if err != nil {
+		m.mtx.Unlock()
+		return err
+	}
+	m.active.add(rec)
+	m.mtx.Unlock()
+
+	// the record is synced outside of the lock, so that the concurrent appends share the fsyncs.
+	return segment.sync(n)
+}
+
+// ForIndexes calls fn for the heads and built index files of the table for the user.
+func (m *headManager) ForIndexes(tableName, userID string, fn func(index.Index) error) error {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	if head, ok := m.active[tableName][userID]; ok {
+		if err := fn(head); err != nil {
+			return err
+		}
+	}
+
+	for _, r := range m.rotated {
+		if head, ok := r.heads[tableName][userID]; ok {
+			if err := fn(head); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, b := range m.built {
+		if b.table != tableName || b.user != userID {
+			continue
+		}
+		if err := fn(b.file); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rotateAndBuild stops adding chunks to the active heads and builds them, along with the ones which previously failed to build.
+func (m *headManager) rotateAndBuild(ctx context.Context) error {
+	if err := m.rotate(); err != nil {
+		return err
+	}
+
+	m.buildMtx.Lock()
+	defer m.buildMtx.Unlock()
+
+	m.mtx.RLock()
+	rotated := append([]*rotatedHeads(nil), m.rotated...)
+	m.mtx.RUnlock()
+
+	for _, r := range rotated {
+		if err := m.buildRotatedHeads(ctx, r); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *headManager) rotate() error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if len(m.active) == 0 {
+		return nil
+	}
+
+	segment, err := newWALSegment(m.walDir, fmt.Sprint(time.Now().UnixNano()))
+	if err != nil {
+		return err
+	}
+
+	if err := m.segment.Close(); err != nil {
+		level.Error(util_log.Logger).Log("msg", "failed to close wal segment", "path", m.segment.path, "err", err)
+	}
+
+	m.rotated = append(m.rotated, &rotatedHeads{heads: m.active, segments: []string{m.segment.path}})
+	m.active = tenantHeads{}
+	m.segment = segment
+	return nil
+}
+
+// buildRotatedHeads builds and uploads the index files of the heads, then removes their wal segments.
+// When it fails partway, the wal segments are rewritten with the heads left to build.
+func (m *headManager) buildRotatedHeads(ctx context.Context, r *rotatedHeads) error {
+	var builtAny bool
+	for tableName, users := range r.heads {
+		for userID, head := range users {
+			built, err := m.buildAndUpload(ctx, tableName, userID, head)
+			if err != nil {
+				if builtAny {
+					m.rewriteWAL(r)
+				}
+				return err
+			}
+			builtAny = true
+
+			m.mtx.Lock()
+			m.built = append(m.built, built)
+			delete(users, userID)
+			m.mtx.Unlock()
+		}
+	}
+
+	m.mtx.Lock()
+	for i := range m.rotated {
+		if m.rotated[i] == r {
+			m.rotated = append(m.rotated[:i], m.rotated[i+1:]...)
+			break
+		}
+	}
+	m.mtx.Unlock()
+
+	for _, segment := range r.segments {
+		if err := os.Remove(segment); err != nil {
+			level.Error(util_log.Logger).Log("msg", "failed to remove wal segment", "path", segment, "err", err)
+		}
+	}
+
+	return nil
+}
+
+// rewriteWAL replaces the wal segments of the rotated heads with a segment holding only the heads left to build,
+// so that the heads already built aren't rebuilt after a restart. The segments are kept if it fails.
+func (m *headManager) rewriteWAL(r *rotatedHeads) {
+	segment, err := newWALSegment(m.walDir, fmt.Sprint(time.Now().UnixNano()))
+	if err != nil {
+		level.Error(util_log.Logger).Log("msg", "failed to create wal segment", "err", err)
+		return
+	}
+
+	err = func() error {
+		for tableName, users := range r.heads {
+			for userID, head := range users {
+				var err error
+				head.ForEachSeries(func(ls labels.Labels, fp model.Fingerprint, chks []index.ChunkMeta) {
+					for _, chk := range chks {
+						if err == nil {
+							_, err = segment.write(walRecord{Table: tableName, User: userID, Labels: ls, Fingerprint: fp, Chunk: chk})
+						}
+					}
+				})
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return segment.Close()
+	}()
+	if err != nil {
+		level.Error(util_log.Logger).Log("msg", "failed to rewrite wal segments", "path", segment.path, "err", err)
+		_ = segment.f.Close()
+		if err := os.Remove(segment.path); err != nil {
+			level.Error(util_log.Logger).Log("msg", "failed to remove wal segment", "path", segment.path, "err", err)
+		}
+		return
+	}
+
+	m.mtx.Lock()
+	segments := r.segments
+	r.segments = []string{segment.path}
+	m.mtx.Unlock()
+
+	for _, path := range segments {
+		if err := os.Remove(path); err != nil {
+			level.Error(util_log.Logger).Log("msg", "failed to remove wal segment", "path", path, "err", err)
+		}
+	}
+}
+
+func (m *headManager) buildAndUpload(ctx context.Context, tableName, userID string, head *index.Builder) (*builtIndex, error) {
+	fileName := fmt.Sprintf("%s-%d%s", m.cfg.IngesterName, time.Now().UnixNano(), indexFileSuffix)
+	filePath := filepath.Join(m.builtDir, tableName, userID, fileName)
+
+	if err := head.Build(ctx, filePath); err != nil {
+		return nil, err
+	}
+
+	if err := m.upload(ctx, tableName, userID, fileName, filePath); err != nil {
+		if err := os.Remove(filePath); err != nil {
+			level.Error(util_log.Logger).Log("msg", "failed to remove index file", "path", filePath, "err", err)
+		}
+		return nil, err
+	}
+
+	file, err := index.OpenTSDBFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &builtIndex{
+		table:      tableName,
+		user:       userID,
+		file:       file,
+		uploadedAt: time.Now(),
+	}, nil
+}
+
+func (m *headManager) upload(ctx context.Context, tableName, userID, fileName, filePath string) error {
+	level.Debug(util_log.Logger).Log("msg", "uploading index file", "table", tableName, "user", userID, "path", filePath)
+
+	compressedPath := filePath + ".gz"
+	if err := shipper_util.CompressFile(filePath, compressedPath, true); err != nil {
+		return err
+	}
+	defer func() {
+		if err := os.Remove(compressedPath); err != nil {
+			level.Error(util_log.Logger).Log("msg", "failed to remove compressed index file", "path", compressedPath, "err", err)
+		}
+	}()
+
+	f, err := os.Open(compressedPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return m.indexStorageClient.PutUserFile(ctx, tableName, userID, fileName+".gz", f)
+}
+
+// cleanupBuiltIndexes removes the index files which were uploaded long enough ago to have been downloaded by the queriers.
+func (m *headManager) cleanupBuiltIndexes(now time.Time) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	kept := m.built[:0]
+	for _, b := range m.built {
+		if now.Sub(b.uploadedAt) < m.cfg.IngesterIndexRetainPeriod {
+			kept = append(kept, b)
+			continue
+		}
+
+		if err := b.file.Close(); err != nil {
+			level.Error(util_log.Logger).Log("msg", "failed to close index file", "path", b.file.Path(), "err", err)
+		}
+		if err := os.Remove(b.file.Path()); err != nil {
+			level.Error(util_log.Logger).Log("msg", "failed to remove index file", "path", b.file.Path(), "err", err)
+		}
+	}
+	m.built = kept
+}
+
+// Stop builds and uploads all the heads before releasing the files.
+func (m *headManager) Stop() {
+	m.cancel()
+	m.wg.Wait()
+
+	if err := m.rotateAndBuild(context.Background()); err != nil {
+		level.Error(util_log.Logger).Log("msg", "error building index files", "err", err)
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if err := m.segment.Close(); err != nil {
+		level.Error(util_log.Logger).Log("msg", "failed to close wal segment", "path", m.segment.path, "err", err)
+	}
+
+	for _, b := range m.built {
+		if err := b.file.Close(); err != nil {
+			level.Error(util_log.Logger).Log("msg", "failed to close index file", "path", b.file.Path(), "err", err)
+		}
+	}
+	m.built = nil
+}
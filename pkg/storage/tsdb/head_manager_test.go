@@ -0,0 +1,171 @@
+package tsdb
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/storage/chunk/local"
+	"github.com/grafana/loki/pkg/storage/stores/shipper/storage"
+	"github.com/grafana/loki/pkg/storage/tsdb/index"
+)
+
+func newTestHeadManager(t *testing.T, activeIndexDirectory string, indexStorageClient storage.Client) *headManager {
+	m, err := newHeadManager(Config{
+		ActiveIndexDirectory:      activeIndexDirectory,
+		BuildInterval:             time.Hour,
+		IngesterName:              "ingester-0",
+		IngesterIndexRetainPeriod: time.Minute,
+	}, indexStorageClient)
+	require.NoError(t, err)
+	return m
+}
+
+func countChunkRefs(t *testing.T, m *headManager, tableName, userID string) int {
+	var count int
+	require.NoError(t, m.ForIndexes(tableName, userID, func(idx index.Index) error {
+		refs, err := idx.GetChunkRefs(context.Background(), userID, 0, 100, nil, labels.MustNewMatcher(labels.MatchEqual, "app", "foo"))
+		count += len(refs)
+		return err
+	}))
+	return count
+}
+
+func TestHeadManager(t *testing.T) {
+	tempDir := t.TempDir()
+	objectClient, err := local.NewFSObjectClient(local.FSConfig{Directory: filepath.Join(tempDir, "objects")})
+	require.NoError(t, err)
+	indexStorageClient := storage.NewIndexStorageClient(objectClient, "index/")
+
+	activeIndexDirectory := filepath.Join(tempDir, "active")
+	ls := labels.FromStrings(labels.MetricName, "logs", "app", "foo")
+
+	m := newTestHeadManager(t, activeIndexDirectory, indexStorageClient)
+	require.NoError(t, m.Append("table_1", "user1", ls, 1, index.ChunkMeta{Checksum: 1, MinTime: 0, MaxTime: 10, Size: 100}))
+	require.NoError(t, m.Append("table_1", "user1", ls, 1, index.ChunkMeta{Checksum: 2, MinTime: 10, MaxTime: 20, Size: 100}))
+	require.NoError(t, m.Append("table_1", "user2", ls, 1, index.ChunkMeta{Checksum: 3, MinTime: 0, MaxTime: 10, Size: 100}))
+	require.Equal(t, 2, countChunkRefs(t, m, "table_1", "user1"))
+
+	// simulate a crash by closing the wal segment without building the heads.
+	m.cancel()
+	m.wg.Wait()
+	require.NoError(t, m.segment.Close())
+
+	// the heads are rebuilt from the wal.
+	m = newTestHeadManager(t, activeIndexDirectory, indexStorageClient)
+	require.Equal(t, 2, countChunkRefs(t, m, "table_1", "user1"))
+	require.Equal(t, 1, countChunkRefs(t, m, "table_1", "user2"))
+
+	require.NoError(t, m.rotateAndBuild(context.Background()))
+	require.Len(t, m.rotated, 0)
+	require.Len(t, m.built, 2)
+	segments, err := listWALSegments(m.walDir)
+	require.NoError(t, err)
+	require.Len(t, segments, 1)
+
+	// the built index files are still queried until they are old enough.
+	require.Equal(t, 2, countChunkRefs(t, m, "table_1", "user1"))
+	m.cleanupBuiltIndexes(time.Now().Add(2 * time.Minute))
+	require.Equal(t, 0, countChunkRefs(t, m, "table_1", "user1"))
+
+	for _, userID := range []string{"user1", "user2"} {
+		files, err := indexStorageClient.ListUserFiles(context.Background(), "table_1", userID)
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+	}
+
+	m.Stop()
+}
+
+// failingUploadClient fails the uploads of the index files after the first one.
+type failingUploadClient struct {
+	storage.Client
+	uploads    int
+	failedUser string
+}
+
+func (c *failingUploadClient) PutUserFile(ctx context.Context, tableName, userID, fileName string, file io.ReadSeeker) error {
+	if c.uploads++; c.uploads > 1 {
+		c.failedUser = userID
+		return errors.New("upload failed")
+	}
+	return c.Client.PutUserFile(ctx, tableName, userID, fileName, file)
+}
+
+func TestHeadManager_PartialBuildRewritesWAL(t *testing.T) {
+	tempDir := t.TempDir()
+	objectClient, err := local.NewFSObjectClient(local.FSConfig{Directory: filepath.Join(tempDir, "objects")})
+	require.NoError(t, err)
+	indexStorageClient := storage.NewIndexStorageClient(objectClient, "index/")
+
+	activeIndexDirectory := filepath.Join(tempDir, "active")
+	ls := labels.FromStrings(labels.MetricName, "logs", "app", "foo")
+
+	failingClient := &failingUploadClient{Client: indexStorageClient}
+	m := newTestHeadManager(t, activeIndexDirectory, failingClient)
+	for _, userID := range []string{"user1", "user2", "user3"} {
+		require.NoError(t, m.Append("table_1", userID, ls, 1, index.ChunkMeta{Checksum: 1, MinTime: 0, MaxTime: 10, Size: 100}))
+	}
+	require.Error(t, m.rotateAndBuild(context.Background()))
+
+	// only the heads left to build are in the wal.
+	var users []string
+	require.Len(t, m.rotated, 1)
+	require.Len(t, m.rotated[0].segments, 1)
+	require.NoError(t, replayWALSegment(m.rotated[0].segments[0], func(rec walRecord) {
+		users = append(users, rec.User)
+	}))
+	require.Contains(t, users, failingClient.failedUser)
+	require.Len(t, users, 2)
+	segments, err := listWALSegments(m.walDir)
+	require.NoError(t, err)
+	require.Len(t, segments, 2)
+
+	m.cancel()
+	m.wg.Wait()
+	require.NoError(t, m.segment.Close())
+
+	// the heads left to build are rebuilt from the wal after a restart.
+	m = newTestHeadManager(t, activeIndexDirectory, indexStorageClient)
+	require.NoError(t, m.rotateAndBuild(context.Background()))
+	for _, userID := range []string{"user1", "user2", "user3"} {
+		files, err := indexStorageClient.ListUserFiles(context.Background(), "table_1", userID)
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+	}
+	m.Stop()
+}
+
+func TestReplayWALSegment_CorruptedTail(t *testing.T) {
+	dir := t.TempDir()
+	segment, err := newWALSegment(dir, "1")
+	require.NoError(t, err)
+	ls := labels.FromStrings(labels.MetricName, "logs", "app", "foo")
+	for i := 0; i < 2; i++ {
+		n, err := segment.write(walRecord{Table: "table_1", User: "user1", Labels: ls, Fingerprint: 1, Chunk: index.ChunkMeta{Checksum: uint32(i)}})
+		require.NoError(t, err)
+		require.NoError(t, segment.sync(n))
+	}
+	require.NoError(t, segment.Close())
+
+	buf, err := ioutil.ReadFile(segment.path)
+	require.NoError(t, err)
+	// corrupt the second record, and tear a third one.
+	buf[len(buf)-3] = '{'
+	require.NoError(t, ioutil.WriteFile(segment.path, append(buf, `{"table":"tab`...), os.ModePerm))
+
+	var records []walRecord
+	require.NoError(t, replayWALSegment(segment.path, func(rec walRecord) {
+		records = append(records, rec)
+	}))
+	require.Len(t, records, 1)
+	require.Equal(t, uint32(0), records[0].Chunk.Checksum)
+}
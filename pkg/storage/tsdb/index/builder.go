@@ -0,0 +1,153 @@
+package index
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	tsdb_index "github.com/prometheus/prometheus/tsdb/index"
+
+	"github.com/grafana/loki/pkg/querier/astmapper"
+)
+
+// Builder accumulates the chunks of the series of a tenant in memory and builds them into an index file.
+// It also implements Index for querying the accumulated chunks before they are built.
+// It is not safe for concurrent use.
+type Builder struct {
+	seriesIndex
+	streams map[string]*stream
+}
+
+type stream struct {
+	labels labels.Labels
+	fp     model.Fingerprint
+	chunks ChunkMetas
+	// added are the chunks in chunks, for ignoring the chunks added again.
+	added map[ChunkMeta]struct{}
+}
+
+// NewBuilder creates an empty Builder.
+func NewBuilder() *Builder {
+	b := &Builder{streams: map[string]*stream{}}
+	b.seriesIndex = seriesIndex{forSeries: b.forSeries}
+	return b
+}
+
+// AddSeries adds the chunks of a series. The labels are expected to be sorted.
+// Chunks which were already added, for instance when flushed by multiple replicas, are ignored.
+func (b *Builder) AddSeries(ls labels.Labels, fp model.Fingerprint, chks ...ChunkMeta) {
+	id := ls.String()
+	s, ok := b.streams[id]
+	if !ok {
+		s = &stream{labels: ls, fp: fp, added: map[ChunkMeta]struct{}{}}
+		b.streams[id] = s
+	}
+
+	for _, chk := range chks {
+		if _, ok := s.added[chk]; ok {
+			continue
+		}
+		s.added[chk] = struct{}{}
+		s.chunks = append(s.chunks, chk)
+	}
+}
+
+// ForEachSeries calls fn with the chunks of each series added to the builder.
+func (b *Builder) ForEachSeries(fn func(ls labels.Labels, fp model.Fingerprint, chks []ChunkMeta)) {
+	for _, s := range b.streams {
+		fn(s.labels, s.fp, s.chunks)
+	}
+}
+
+// Empty returns true when no series have been added to the builder.
+func (b *Builder) Empty() bool {
+	return len(b.streams) == 0
+}
+
+// Build writes the index file at the given path.
+// It does not modify the builder, which can keep being queried while the file is built.
+func (b *Builder) Build(ctx context.Context, path string) (err error) {
+	type series struct {
+		labels labels.Labels
+		chunks ChunkMetas
+	}
+
+	allSeries := make([]series, 0, len(b.streams))
+	symbols := map[string]struct{}{}
+	for _, s := range b.streams {
+		ls := indexLabels(s.labels, s.fp)
+		for _, l := range ls {
+			symbols[l.Name] = struct{}{}
+			symbols[l.Value] = struct{}{}
+		}
+		allSeries = append(allSeries, series{labels: ls, chunks: append(ChunkMetas(nil), s.chunks...).finalize()})
+	}
+	sort.Slice(allSeries, func(i, j int) bool {
+		return labels.Compare(allSeries[i].labels, allSeries[j].labels) < 0
+	})
+
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return err
+	}
+
+	// build the index under a temporary name so that a partially written file is never mistaken for a complete one.
+	tmpPath := path + ".tmp"
+	writer, err := tsdb_index.NewWriter(ctx, tmpPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = writer.Close()
+			_ = os.Remove(tmpPath)
+		}
+	}()
+
+	for _, symbol := range sortedKeys(symbols) {
+		if err := writer.AddSymbol(symbol); err != nil {
+			return err
+		}
+	}
+
+	promChunks := make([]chunks.Meta, 0)
+	for i, s := range allSeries {
+		promChunks = promChunks[:0]
+		for _, chk := range s.chunks {
+			promChunks = append(promChunks, chk.toPrometheus())
+		}
+		if err := writer.AddSeries(storage.SeriesRef(i), s.labels, promChunks...); err != nil {
+			return err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+func (b *Builder) forSeries(ctx context.Context, shard *astmapper.ShardAnnotation, matchers []*labels.Matcher,
+	fn func(ls labels.Labels, fp model.Fingerprint, chks []ChunkMeta)) error {
+outer:
+	for _, s := range b.streams {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !inShard(shard, s.fp) {
+			continue
+		}
+		for _, m := range matchers {
+			if !m.Matches(s.labels.Get(m.Name)) {
+				continue outer
+			}
+		}
+		fn(s.labels, s.fp, s.chunks)
+	}
+	return nil
+}
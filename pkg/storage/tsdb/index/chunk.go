@@ -0,0 +1,82 @@
+package index
+
+import (
+	"sort"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+)
+
+// ChunkMeta holds the details of a chunk which are stored in the index.
+// Together with the user and fingerprint of the series, they are enough for building the external key of the chunk.
+type ChunkMeta struct {
+	Checksum uint32 `json:"checksum"`
+	MinTime  int64  `json:"min_time"`
+	MaxTime  int64  `json:"max_time"`
+	// Size of the encoded chunk in bytes.
+	Size uint32 `json:"size"`
+}
+
+// toPrometheus packs the checksum and size of the chunk in the reference of the prometheus chunk meta.
+func (c ChunkMeta) toPrometheus() chunks.Meta {
+	return chunks.Meta{
+		Ref:     chunks.ChunkRef(uint64(c.Checksum)<<32 | uint64(c.Size)),
+		MinTime: c.MinTime,
+		MaxTime: c.MaxTime,
+	}
+}
+
+func chunkMetaFromPrometheus(m chunks.Meta) ChunkMeta {
+	return ChunkMeta{
+		Checksum: uint32(uint64(m.Ref) >> 32),
+		Size:     uint32(m.Ref),
+		MinTime:  m.MinTime,
+		MaxTime:  m.MaxTime,
+	}
+}
+
+func (c ChunkMeta) overlaps(from, through model.Time) bool {
+	return c.MinTime <= int64(through) && int64(from) <= c.MaxTime
+}
+
+// ChunkMetas is a list of chunks sorted by time, as required by the index format.
+type ChunkMetas []ChunkMeta
+
+func (c ChunkMetas) Len() int      { return len(c) }
+func (c ChunkMetas) Swap(i, j int) { c[i], c[j] = c[j], c[i] }
+func (c ChunkMetas) Less(i, j int) bool {
+	if c[i].MinTime != c[j].MinTime {
+		return c[i].MinTime < c[j].MinTime
+	}
+	if c[i].MaxTime != c[j].MaxTime {
+		return c[i].MaxTime < c[j].MaxTime
+	}
+	return c[i].Checksum < c[j].Checksum
+}
+
+// finalize sorts the chunks in the order expected by the index format and removes any duplicates.
+func (c ChunkMetas) finalize() ChunkMetas {
+	sort.Sort(c)
+	if len(c) < 2 {
+		return c
+	}
+
+	res := c[:1]
+	for _, chk := range c[1:] {
+		if chk != res[len(res)-1] {
+			res = append(res, chk)
+		}
+	}
+	return res
+}
+
+// ChunkRef is a chunk matching a query with all the details needed for fetching it from the store.
+type ChunkRef struct {
+	User        string
+	Fingerprint model.Fingerprint
+	Labels      labels.Labels
+	Start       model.Time
+	End         model.Time
+	Checksum    uint32
+}
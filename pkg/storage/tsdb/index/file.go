@@ -0,0 +1,93 @@
+package index
+
+import (
+	"context"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	tsdb_index "github.com/prometheus/prometheus/tsdb/index"
+
+	"github.com/grafana/loki/pkg/querier/astmapper"
+)
+
+// TSDBFile is an index file built by a Builder, in the Prometheus TSDB index format.
+type TSDBFile struct {
+	seriesIndex
+	path   string
+	reader *tsdb_index.Reader
+}
+
+// OpenTSDBFile opens the index file at the given path.
+func OpenTSDBFile(path string) (*TSDBFile, error) {
+	reader, err := tsdb_index.NewFileReader(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &TSDBFile{
+		path:   path,
+		reader: reader,
+	}
+	f.seriesIndex = seriesIndex{forSeries: f.forSeries}
+	return f, nil
+}
+
+// Path returns the path of the index file.
+func (f *TSDBFile) Path() string {
+	return f.path
+}
+
+// Close releases the index file.
+func (f *TSDBFile) Close() error {
+	return f.reader.Close()
+}
+
+func (f *TSDBFile) forSeries(ctx context.Context, shard *astmapper.ShardAnnotation, matchers []*labels.Matcher,
+	fn func(ls labels.Labels, fp model.Fingerprint, chks []ChunkMeta)) error {
+	var (
+		p   tsdb_index.Postings
+		err error
+	)
+	if len(matchers) == 0 {
+		p, err = f.reader.Postings(tsdb_index.AllPostingsKey())
+	} else {
+		p, err = tsdb.PostingsForMatchers(f.reader, matchers...)
+	}
+	if err != nil {
+		return err
+	}
+
+	var (
+		ls         labels.Labels
+		promChunks []chunks.Meta
+		chks       []ChunkMeta
+	)
+	for p.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := f.reader.Series(storage.SeriesRef(p.At()), &ls, &promChunks); err != nil {
+			return err
+		}
+
+		seriesLabels, fp, err := seriesFingerprint(ls)
+		if err != nil {
+			return err
+		}
+		if !inShard(shard, fp) {
+			continue
+		}
+
+		chks = chks[:0]
+		for _, chk := range promChunks {
+			chks = append(chks, chunkMetaFromPrometheus(chk))
+		}
+		fn(seriesLabels, fp, chks)
+	}
+
+	return p.Err()
+}
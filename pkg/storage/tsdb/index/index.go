@@ -0,0 +1,158 @@
+package index
+
+import (
+	"context"
+	"sort"
+	"strconv"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/pkg/querier/astmapper"
+)
+
+// fingerprintLabel holds the fingerprint of a series when it differs from the hash of its labels,
+// which happens when the ingesters had to remap the fingerprint of colliding series.
+// It is never returned to the callers of the index.
+const fingerprintLabel = "__loki_fingerprint__"
+
+// Index is implemented by the index files and the in-memory heads which are not yet built into files.
+type Index interface {
+	// GetChunkRefs returns the chunks of the series matching all the matchers, which overlap the time range.
+	// Only the series belonging to the shard are considered when the shard is not nil.
+	GetChunkRefs(ctx context.Context, userID string, from, through model.Time, shard *astmapper.ShardAnnotation, matchers ...*labels.Matcher) ([]ChunkRef, error)
+	// LabelNames returns the label names of the series matching all the matchers which have chunks overlapping the time range.
+	LabelNames(ctx context.Context, userID string, from, through model.Time, matchers ...*labels.Matcher) ([]string, error)
+	// LabelValues returns the values of the label for the series matching all the matchers which have chunks overlapping the time range.
+	LabelValues(ctx context.Context, userID string, from, through model.Time, name string, matchers ...*labels.Matcher) ([]string, error)
+}
+
+// forSeriesFunc calls fn for each series matching all the matchers and the shard.
+// The labels passed to fn must not be retained after fn returns.
+type forSeriesFunc func(ctx context.Context, shard *astmapper.ShardAnnotation, matchers []*labels.Matcher,
+	fn func(ls labels.Labels, fp model.Fingerprint, chks []ChunkMeta)) error
+
+// seriesIndex implements Index on top of a forSeriesFunc.
+type seriesIndex struct {
+	forSeries forSeriesFunc
+}
+
+func (i seriesIndex) GetChunkRefs(ctx context.Context, userID string, from, through model.Time, shard *astmapper.ShardAnnotation, matchers ...*labels.Matcher) ([]ChunkRef, error) {
+	var refs []ChunkRef
+	err := i.forSeries(ctx, shard, matchers, func(ls labels.Labels, fp model.Fingerprint, chks []ChunkMeta) {
+		var seriesLabels labels.Labels
+		for _, chk := range chks {
+			if !chk.overlaps(from, through) {
+				continue
+			}
+			if seriesLabels == nil {
+				seriesLabels = copyLabels(ls)
+			}
+			refs = append(refs, ChunkRef{
+				User:        userID,
+				Fingerprint: fp,
+				Labels:      seriesLabels,
+				Start:       model.Time(chk.MinTime),
+				End:         model.Time(chk.MaxTime),
+				Checksum:    chk.Checksum,
+			})
+		}
+	})
+	return refs, err
+}
+
+func (i seriesIndex) LabelNames(ctx context.Context, _ string, from, through model.Time, matchers ...*labels.Matcher) ([]string, error) {
+	names := map[string]struct{}{}
+	err := i.forSeries(ctx, nil, matchers, func(ls labels.Labels, _ model.Fingerprint, chks []ChunkMeta) {
+		if !anyOverlaps(chks, from, through) {
+			return
+		}
+		for _, l := range ls {
+			if _, ok := names[l.Name]; !ok && l.Name != labels.MetricName {
+				names[copyString(l.Name)] = struct{}{}
+			}
+		}
+	})
+	return sortedKeys(names), err
+}
+
+func (i seriesIndex) LabelValues(ctx context.Context, _ string, from, through model.Time, name string, matchers ...*labels.Matcher) ([]string, error) {
+	values := map[string]struct{}{}
+	err := i.forSeries(ctx, nil, matchers, func(ls labels.Labels, _ model.Fingerprint, chks []ChunkMeta) {
+		if !anyOverlaps(chks, from, through) {
+			return
+		}
+		if value := ls.Get(name); value != "" {
+			if _, ok := values[value]; !ok {
+				values[copyString(value)] = struct{}{}
+			}
+		}
+	})
+	return sortedKeys(values), err
+}
+
+// seriesFingerprint removes the fingerprint label from the labels written in the index and returns the fingerprint of the series.
+func seriesFingerprint(ls labels.Labels) (labels.Labels, model.Fingerprint, error) {
+	for i, l := range ls {
+		if l.Name != fingerprintLabel {
+			continue
+		}
+		fp, err := strconv.ParseUint(l.Value, 16, 64)
+		if err != nil {
+			return nil, 0, err
+		}
+		return append(ls[:i:i], ls[i+1:]...), model.Fingerprint(fp), nil
+	}
+	return ls, labelsFingerprint(ls), nil
+}
+
+// indexLabels returns the labels to write in the index for a series.
+func indexLabels(ls labels.Labels, fp model.Fingerprint) labels.Labels {
+	if labelsFingerprint(ls) == fp {
+		return ls
+	}
+	lb := labels.NewBuilder(ls)
+	lb.Set(fingerprintLabel, strconv.FormatUint(uint64(fp), 16))
+	return lb.Labels()
+}
+
+// labelsFingerprint computes the fingerprint of the labels the same way as the ingesters, ignoring the metric name.
+func labelsFingerprint(ls labels.Labels) model.Fingerprint {
+	fp, _ := ls.HashWithoutLabels(nil)
+	return model.Fingerprint(fp)
+}
+
+func inShard(shard *astmapper.ShardAnnotation, fp model.Fingerprint) bool {
+	return shard == nil || uint64(fp)%uint64(shard.Of) == uint64(shard.Shard)
+}
+
+func anyOverlaps(chks []ChunkMeta, from, through model.Time) bool {
+	for _, chk := range chks {
+		if chk.overlaps(from, through) {
+			return true
+		}
+	}
+	return false
+}
+
+// copyLabels makes a deep copy of labels which might reference the memory mapped index file.
+func copyLabels(ls labels.Labels) labels.Labels {
+	res := make(labels.Labels, len(ls))
+	for i, l := range ls {
+		res[i] = labels.Label{Name: copyString(l.Name), Value: copyString(l.Value)}
+	}
+	return res
+}
+
+func copyString(s string) string {
+	return string(append([]byte(nil), s...))
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	res := make([]string, 0, len(m))
+	for k := range m {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,114 @@
+package index
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/querier/astmapper"
+)
+
+func buildTestIndexes(t *testing.T) map[string]Index {
+	b := NewBuilder()
+
+	lsFoo := labels.FromStrings(labels.MetricName, "logs", "app", "foo", "env", "prod")
+	lsBar := labels.FromStrings(labels.MetricName, "logs", "app", "bar", "env", "dev")
+	b.AddSeries(lsFoo, labelsFingerprint(lsFoo),
+		ChunkMeta{Checksum: 3, MinTime: 20, MaxTime: 30, Size: 100},
+		ChunkMeta{Checksum: 1, MinTime: 0, MaxTime: 10, Size: 200},
+	)
+	// a chunk flushed again by another replica, which should be deduped.
+	b.AddSeries(lsFoo, labelsFingerprint(lsFoo), ChunkMeta{Checksum: 1, MinTime: 0, MaxTime: 10, Size: 200})
+	// an overlapping chunk.
+	b.AddSeries(lsFoo, labelsFingerprint(lsFoo), ChunkMeta{Checksum: 2, MinTime: 5, MaxTime: 25, Size: 300})
+	// a series with a remapped fingerprint.
+	b.AddSeries(lsBar, 42, ChunkMeta{Checksum: 4, MinTime: 40, MaxTime: 50, Size: 400})
+
+	path := filepath.Join(t.TempDir(), "index.tsdb")
+	require.NoError(t, b.Build(context.Background(), path))
+
+	f, err := OpenTSDBFile(path)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, f.Close())
+	})
+
+	return map[string]Index{
+		"builder": b,
+		"file":    f,
+	}
+}
+
+func TestIndex_GetChunkRefs(t *testing.T) {
+	lsFoo := labels.FromStrings(labels.MetricName, "logs", "app", "foo", "env", "prod")
+	lsBar := labels.FromStrings(labels.MetricName, "logs", "app", "bar", "env", "dev")
+
+	for name, idx := range buildTestIndexes(t) {
+		t.Run(name, func(t *testing.T) {
+			refs, err := idx.GetChunkRefs(context.Background(), "fake", 0, 100, nil,
+				labels.MustNewMatcher(labels.MatchEqual, "app", "foo"))
+			require.NoError(t, err)
+			require.Len(t, refs, 3)
+			if name == "file" {
+				require.Equal(t, ChunkRef{User: "fake", Fingerprint: labelsFingerprint(lsFoo), Labels: lsFoo, Start: 0, End: 10, Checksum: 1}, refs[0])
+				require.Equal(t, ChunkRef{User: "fake", Fingerprint: labelsFingerprint(lsFoo), Labels: lsFoo, Start: 5, End: 25, Checksum: 2}, refs[1])
+				require.Equal(t, ChunkRef{User: "fake", Fingerprint: labelsFingerprint(lsFoo), Labels: lsFoo, Start: 20, End: 30, Checksum: 3}, refs[2])
+			}
+
+			// only the chunks overlapping the time range are returned.
+			refs, err = idx.GetChunkRefs(context.Background(), "fake", 26, 100, nil,
+				labels.MustNewMatcher(labels.MatchRegexp, "app", ".+"))
+			require.NoError(t, err)
+			require.Len(t, refs, 2)
+			checksums := []uint32{refs[0].Checksum, refs[1].Checksum}
+			require.ElementsMatch(t, []uint32{3, 4}, checksums)
+
+			// the remapped fingerprint is preserved.
+			refs, err = idx.GetChunkRefs(context.Background(), "fake", 0, 100, nil,
+				labels.MustNewMatcher(labels.MatchEqual, "app", "bar"))
+			require.NoError(t, err)
+			require.Len(t, refs, 1)
+			require.Equal(t, model.Fingerprint(42), refs[0].Fingerprint)
+			require.Equal(t, lsBar, refs[0].Labels)
+
+			// sharding by fingerprint.
+			var total int
+			for i := 0; i < 2; i++ {
+				refs, err = idx.GetChunkRefs(context.Background(), "fake", 40, 100, &astmapper.ShardAnnotation{Shard: i, Of: 2})
+				require.NoError(t, err)
+				for _, ref := range refs {
+					require.Equal(t, uint64(i), uint64(ref.Fingerprint)%2)
+				}
+				total += len(refs)
+			}
+			require.Equal(t, 1, total)
+		})
+	}
+}
+
+func TestIndex_Labels(t *testing.T) {
+	for name, idx := range buildTestIndexes(t) {
+		t.Run(name, func(t *testing.T) {
+			names, err := idx.LabelNames(context.Background(), "fake", 0, 100)
+			require.NoError(t, err)
+			require.Equal(t, []string{"app", "env"}, names)
+
+			values, err := idx.LabelValues(context.Background(), "fake", 0, 100, "app")
+			require.NoError(t, err)
+			require.Equal(t, []string{"bar", "foo"}, values)
+
+			values, err = idx.LabelValues(context.Background(), "fake", 0, 35, "app")
+			require.NoError(t, err)
+			require.Equal(t, []string{"foo"}, values)
+
+			values, err = idx.LabelValues(context.Background(), "fake", 0, 100, "env",
+				labels.MustNewMatcher(labels.MatchNotEqual, "app", "foo"))
+			require.NoError(t, err)
+			require.Equal(t, []string{"dev"}, values)
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package tsdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/storage/stores/shipper"
+	"github.com/grafana/loki/pkg/storage/stores/shipper/downloads"
+	"github.com/grafana/loki/pkg/storage/stores/shipper/storage"
+	"github.com/grafana/loki/pkg/storage/tsdb/index"
+	util_log "github.com/grafana/loki/pkg/util/log"
+)
+
+var errReadOnly = errors.New("tsdb index shipper is running in read-only mode")
+
+// IndexShipper manages the index files of the TSDB index.
+// In ingesters, it keeps the index of the flushed chunks in memory, builds it into index files and uploads them to the shared store.
+// In queriers, it downloads the index files from the shared store using the same downloads manager as boltdb-shipper.
+type IndexShipper struct {
+	cfg              Config
+	headManager      *headManager
+	downloadsManager *downloads.TableManager
+
+	stopOnce sync.Once
+}
+
+// NewIndexShipper creates a shipper for syncing the TSDB index files with the shared store.
+func NewIndexShipper(cfg Config, storageClient chunk.ObjectClient, registerer prometheus.Registerer) (*IndexShipper, error) {
+	indexStorageClient := storage.NewIndexStorageClient(storageClient, cfg.SharedStoreKeyPrefix)
+
+	s := &IndexShipper{cfg: cfg}
+
+	if cfg.Mode != shipper.ModeReadOnly {
+		headManager, err := newHeadManager(cfg, indexStorageClient)
+		if err != nil {
+			return nil, err
+		}
+		s.headManager = headManager
+	}
+
+	if cfg.Mode != shipper.ModeWriteOnly {
+		downloadsManager, err := downloads.NewTableManager(downloads.Config{
+			CacheDir:          cfg.CacheLocation,
+			SyncInterval:      cfg.ResyncInterval,
+			CacheTTL:          cfg.CacheTTL,
+			QueryReadyNumDays: cfg.QueryReadyNumDays,
+			OpenIndexFileFunc: func(path string) (downloads.IndexFile, error) {
+				return index.OpenTSDBFile(path)
+			},
+			MetricsNamespace: "loki_tsdb_shipper",
+		}, nil, indexStorageClient, registerer)
+		if err != nil {
+			s.Stop()
+			return nil, err
+		}
+		s.downloadsManager = downloadsManager
+	}
+
+	level.Info(util_log.Logger).Log("msg", fmt.Sprintf("starting tsdb shipper in %d mode", cfg.Mode))
+
+	return s, nil
+}
+
+// Append adds a chunk to the index of the table for the user.
+func (s *IndexShipper) Append(tableName, userID string, ls labels.Labels, fp model.Fingerprint, chk index.ChunkMeta) error {
+	if s.headManager == nil {
+		return errReadOnly
+	}
+	return s.headManager.Append(tableName, userID, ls, fp, chk)
+}
+
+// ForIndexes calls fn for each index of the table for the user, be it in memory, built locally or downloaded.
+func (s *IndexShipper) ForIndexes(ctx context.Context, tableName, userID string, fn func(index.Index) error) error {
+	if s.headManager != nil {
+		if err := s.headManager.ForIndexes(tableName, userID, fn); err != nil {
+			return err
+		}
+	}
+
+	if s.downloadsManager != nil {
+		return s.downloadsManager.ForEach(ctx, tableName, userID, func(indexFile downloads.IndexFile) error {
+			idx, ok := indexFile.(index.Index)
+			if !ok {
+				return fmt.Errorf("unexpected index file %s", indexFile.Path())
+			}
+			return fn(idx)
+		})
+	}
+
+	return nil
+}
+
+func (s *IndexShipper) Stop() {
+	s.stopOnce.Do(func() {
+		if s.headManager != nil {
+			s.headManager.Stop()
+		}
+		if s.downloadsManager != nil {
+			s.downloadsManager.Stop()
+		}
+	})
+}
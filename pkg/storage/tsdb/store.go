@@ -0,0 +1,315 @@
+package tsdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/pkg/querier/astmapper"
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/storage/chunk/cache"
+	"github.com/grafana/loki/pkg/storage/tsdb/index"
+	util_log "github.com/grafana/loki/pkg/util/log"
+	"github.com/grafana/loki/pkg/util/spanlogger"
+	"github.com/grafana/loki/pkg/util/validation"
+)
+
+var errDeletionNotSupported = errors.New("deletion is not supported by the tsdb index")
+
+// store is a chunk.Store for a period using the TSDB index.
+// Chunks are written to the chunks storage as usual while their refs are written to the index managed by the IndexShipper.
+type store struct {
+	indexShipper *IndexShipper
+	schemaCfg    chunk.SchemaConfig
+	periodCfg    chunk.PeriodConfig
+	limits       chunk.StoreLimits
+	chunks       chunk.Client
+	chunksCache  cache.Cache
+	fetcher      *chunk.Fetcher
+}
+
+// NewStore creates a chunk.Store for the period, using the TSDB index.
+func NewStore(indexShipper *IndexShipper, storeCfg chunk.StoreConfig, periodCfg chunk.PeriodConfig, chunks chunk.Client, limits chunk.StoreLimits, chunksCache cache.Cache) (chunk.Store, error) {
+	schemaCfg := chunk.SchemaConfig{Configs: []chunk.PeriodConfig{periodCfg}}
+	fetcher, err := chunk.NewChunkFetcher(chunksCache, false, schemaCfg, chunks,
+		storeCfg.ChunkCacheConfig.AsyncCacheWriteBackConcurrency, storeCfg.ChunkCacheConfig.AsyncCacheWriteBackBufferSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &store{
+		indexShipper: indexShipper,
+		schemaCfg:    schemaCfg,
+		periodCfg:    periodCfg,
+		limits:       limits,
+		chunks:       chunks,
+		chunksCache:  chunksCache,
+		fetcher:      fetcher,
+	}, nil
+}
+
+func (s *store) Put(ctx context.Context, chunks []chunk.Chunk) error {
+	for _, c := range chunks {
+		if err := s.PutOne(ctx, c.From, c.Through, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *store) PutOne(ctx context.Context, from, through model.Time, c chunk.Chunk) error {
+	log, ctx := spanlogger.New(ctx, "TSDBStore.PutOne")
+	defer log.Finish()
+
+	encoded, err := c.Encoded()
+	if err != nil {
+		return err
+	}
+
+	// If this chunk is in cache it must already be in the storage so we don't need to write it again.
+	found, _, _, _ := s.chunksCache.Fetch(ctx, []string{s.schemaCfg.ExternalKey(c)})
+	if len(found) == 0 {
+		if err := s.chunks.PutChunks(ctx, []chunk.Chunk{c}); err != nil {
+			return err
+		}
+		if cacheErr := s.fetcher.WriteBackCache(ctx, []chunk.Chunk{c}); cacheErr != nil {
+			level.Warn(log).Log("msg", "could not store chunks in chunk cache", "err", cacheErr)
+		}
+	}
+
+	chk := index.ChunkMeta{
+		Checksum: c.Checksum,
+		MinTime:  int64(c.From),
+		MaxTime:  int64(c.Through),
+		Size:     uint32(len(encoded)),
+	}
+	for _, tableName := range s.tablesFor(from, through) {
+		if err := s.indexShipper.Append(tableName, c.UserID, c.Metric, c.Fingerprint, chk); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *store) Get(ctx context.Context, userID string, from, through model.Time, matchers ...*labels.Matcher) ([]chunk.Chunk, error) {
+	log, ctx := spanlogger.New(ctx, "TSDBStore.Get")
+	defer log.Span.Finish()
+
+	chks, fetchers, err := s.GetChunkRefs(ctx, userID, from, through, matchers...)
+	if err != nil {
+		return nil, err
+	}
+	if len(chks) == 0 || len(chks[0]) == 0 {
+		return nil, nil
+	}
+
+	chunks := chks[0]
+	// Protect ourselves against OOMing.
+	maxChunksPerQuery := s.limits.MaxChunksPerQueryFromStore(userID)
+	if maxChunksPerQuery > 0 && len(chunks) > maxChunksPerQuery {
+		err := chunk.QueryError(fmt.Sprintf("Query %v fetched too many chunks (%d > %d)", matchers, len(chunks), maxChunksPerQuery))
+		level.Error(log).Log("err", err)
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(chunks))
+	for _, c := range chunks {
+		keys = append(keys, s.schemaCfg.ExternalKey(c))
+	}
+	allChunks, err := fetchers[0].FetchChunks(ctx, chunks, keys)
+	if err != nil {
+		level.Error(log).Log("msg", "FetchChunks", "err", err)
+		return nil, err
+	}
+
+	// inject artificial __cortex_shard__ labels if present in the query. GetChunkRefs guarantees any chunk refs match the shard.
+	shard, _, err := astmapper.ShardFromMatchers(matchers)
+	if err != nil {
+		return nil, err
+	}
+	if shard != nil {
+		for i := range allChunks {
+			allChunks[i].Metric = labels.NewBuilder(allChunks[i].Metric).Set(astmapper.ShardLabel, shard.String()).Labels()
+		}
+	}
+
+	return allChunks, nil
+}
+
+func (s *store) GetChunkRefs(ctx context.Context, userID string, from, through model.Time, matchers ...*labels.Matcher) ([][]chunk.Chunk, []*chunk.Fetcher, error) {
+	log, ctx := spanlogger.New(ctx, "TSDBStore.GetChunkRefs")
+	defer log.Span.Finish()
+
+	shortcut, err := s.validateQueryTimeRange(ctx, userID, &from, &through)
+	if err != nil || shortcut {
+		return nil, nil, err
+	}
+
+	shard, shardIdx, err := astmapper.ShardFromMatchers(matchers)
+	if err != nil {
+		return nil, nil, err
+	}
+	if shard != nil {
+		matchers = append(append([]*labels.Matcher{}, matchers[:shardIdx]...), matchers[shardIdx+1:]...)
+	}
+
+	var (
+		chunks []chunk.Chunk
+		seen   = map[string]struct{}{}
+	)
+	for _, tableName := range s.tablesFor(from, through) {
+		err := s.indexShipper.ForIndexes(ctx, tableName, userID, func(idx index.Index) error {
+			refs, err := idx.GetChunkRefs(ctx, userID, from, through, shard, matchers...)
+			if err != nil {
+				return err
+			}
+
+			for _, ref := range refs {
+				c := chunk.Chunk{
+					UserID:      ref.User,
+					Fingerprint: ref.Fingerprint,
+					From:        ref.Start,
+					Through:     ref.End,
+					Metric:      ref.Labels,
+					ChecksumSet: true,
+					Checksum:    ref.Checksum,
+				}
+
+				// the same chunk is found in the index files of each table it overlaps, as well as in the files built by each replica.
+				key := s.schemaCfg.ExternalKey(c)
+				if _, ok := seen[key]; ok {
+					continue
+				}
+				seen[key] = struct{}{}
+				chunks = append(chunks, c)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	level.Debug(log).Log("chunks", len(chunks))
+	return [][]chunk.Chunk{chunks}, []*chunk.Fetcher{s.fetcher}, nil
+}
+
+func (s *store) LabelValuesForMetricName(ctx context.Context, userID string, from, through model.Time, metricName string, labelName string, matchers ...*labels.Matcher) ([]string, error) {
+	log, ctx := spanlogger.New(ctx, "TSDBStore.LabelValuesForMetricName")
+	defer log.Span.Finish()
+
+	shortcut, err := s.validateQueryTimeRange(ctx, userID, &from, &through)
+	if err != nil || shortcut {
+		return nil, err
+	}
+
+	matchers = append([]*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, metricName)}, matchers...)
+	return s.collectStrings(ctx, userID, from, through, func(idx index.Index) ([]string, error) {
+		return idx.LabelValues(ctx, userID, from, through, labelName, matchers...)
+	})
+}
+
+func (s *store) LabelNamesForMetricName(ctx context.Context, userID string, from, through model.Time, metricName string) ([]string, error) {
+	log, ctx := spanlogger.New(ctx, "TSDBStore.LabelNamesForMetricName")
+	defer log.Span.Finish()
+
+	shortcut, err := s.validateQueryTimeRange(ctx, userID, &from, &through)
+	if err != nil || shortcut {
+		return nil, err
+	}
+
+	matcher := labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, metricName)
+	return s.collectStrings(ctx, userID, from, through, func(idx index.Index) ([]string, error) {
+		return idx.LabelNames(ctx, userID, from, through, matcher)
+	})
+}
+
+// collectStrings returns the sorted union of the strings returned by fn for each index overlapping the time range.
+func (s *store) collectStrings(ctx context.Context, userID string, from, through model.Time, fn func(index.Index) ([]string, error)) ([]string, error) {
+	set := map[string]struct{}{}
+	for _, tableName := range s.tablesFor(from, through) {
+		err := s.indexShipper.ForIndexes(ctx, tableName, userID, func(idx index.Index) error {
+			values, err := fn(idx)
+			if err != nil {
+				return err
+			}
+			for _, v := range values {
+				set[v] = struct{}{}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result := make([]string, 0, len(set))
+	for v := range set {
+		result = append(result, v)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+func (s *store) GetChunkFetcher(_ model.Time) *chunk.Fetcher {
+	return s.fetcher
+}
+
+func (s *store) DeleteChunk(_ context.Context, _, _ model.Time, _, _ string, _ labels.Labels, _ *model.Interval) error {
+	return errDeletionNotSupported
+}
+
+func (s *store) DeleteSeriesIDs(_ context.Context, _, _ model.Time, _ string, _ labels.Labels) error {
+	return errDeletionNotSupported
+}
+
+func (s *store) Stop() {
+	s.fetcher.Stop()
+	s.indexShipper.Stop()
+}
+
+// tablesFor returns the names of the index tables overlapping the time range.
+func (s *store) tablesFor(from, through model.Time) []string {
+	period := int64(s.periodCfg.IndexTables.Period / time.Second)
+	if period == 0 {
+		return []string{s.periodCfg.IndexTables.Prefix}
+	}
+
+	var tables []string
+	for i := from.Unix() / period; i <= through.Unix()/period; i++ {
+		tables = append(tables, s.periodCfg.IndexTables.TableFor(model.TimeFromUnix(i*period)))
+	}
+	return tables
+}
+
+func (s *store) validateQueryTimeRange(ctx context.Context, userID string, from *model.Time, through *model.Time) (bool, error) {
+	if *through < *from {
+		return false, chunk.QueryError(fmt.Sprintf("invalid query, through < from (%s < %s)", through, from))
+	}
+
+	maxQueryLength := s.limits.MaxQueryLength(userID)
+	if maxQueryLength > 0 && (*through).Sub(*from) > maxQueryLength {
+		return false, chunk.QueryError(fmt.Sprintf(validation.ErrQueryTooLong, (*through).Sub(*from), maxQueryLength))
+	}
+
+	now := model.Now()
+	if from.After(now) {
+		// time-span start is in future ... regard as legal
+		level.Info(util_log.WithContext(ctx, util_log.Logger)).Log("msg", "whole timerange in future, yield empty resultset", "through", through, "from", from, "now", now)
+		return true, nil
+	}
+
+	if through.After(now.Add(5 * time.Minute)) {
+		// time-span end is in future ... regard as legal
+		*through = now
+	}
+
+	return false, nil
+}
@@ -0,0 +1,148 @@
+package tsdb
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/pkg/storage/tsdb/index"
+	util_log "github.com/grafana/loki/pkg/util/log"
+)
+
+const walSegmentSuffix = ".wal"
+
+// walRecord is a chunk added to the index of a table for a user.
+type walRecord struct {
+	Table       string            `json:"table"`
+	User        string            `json:"user"`
+	Labels      labels.Labels     `json:"labels"`
+	Fingerprint model.Fingerprint `json:"fingerprint"`
+	Chunk       index.ChunkMeta   `json:"chunk"`
+}
+
+// walSegment holds the records added to the heads since they were last rotated,
+// so that the heads can be rebuilt if the process stops before building them into index files.
+// The records are written by one goroutine at a time, while the segment can be synced concurrently.
+type walSegment struct {
+	path string
+	f    *os.File
+
+	// written is the number of records written, synced the number of records synced to disk.
+	written uint64
+	syncMtx sync.Mutex
+	synced  uint64
+}
+
+func newWALSegment(dir, name string) (*walSegment, error) {
+	path := filepath.Join(dir, name+walSegmentSuffix)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return nil, err
+	}
+
+	return &walSegment{
+		path: path,
+		f:    f,
+	}, nil
+}
+
+// write writes the record to the segment, without syncing it to disk.
+// It returns the number of records written so far, for syncing up to the record.
+func (s *walSegment) write(rec walRecord) (uint64, error) {
+	buf, err := json.Marshal(rec)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := s.f.Write(append(buf, '\n')); err != nil {
+		return 0, err
+	}
+	return atomic.AddUint64(&s.written, 1), nil
+}
+
+// sync syncs the segment to disk up to the n-th record written, at least.
+// The concurrent calls share a single fsync, which syncs all the records written before it starts.
+func (s *walSegment) sync(n uint64) error {
+	s.syncMtx.Lock()
+	defer s.syncMtx.Unlock()
+
+	if s.synced >= n {
+		return nil
+	}
+	written := atomic.LoadUint64(&s.written)
+	if err := s.f.Sync(); err != nil {
+		return err
+	}
+	s.synced = written
+	return nil
+}
+
+// Close syncs the records written to disk and closes the segment.
+func (s *walSegment) Close() error {
+	if err := s.sync(atomic.LoadUint64(&s.written)); err != nil {
+		_ = s.f.Close()
+		return err
+	}
+	return s.f.Close()
+}
+
+// listWALSegments returns the paths of the segments in dir, from the oldest to the newest.
+func listWALSegments(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var segments []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), walSegmentSuffix) {
+			continue
+		}
+		segments = append(segments, filepath.Join(dir, entry.Name()))
+	}
+	// segments are named after the time at which they were created, which are all of the same length.
+	sort.Strings(segments)
+	return segments, nil
+}
+
+// replayWALSegment calls fn for each record of the segment.
+// A partially written or corrupted record, which happens when the process or the machine stops while writing it, and
+// the rest of the segment after it are ignored.
+func replayWALSegment(path string, fn func(walRecord)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			if len(line) > 0 {
+				level.Warn(util_log.Logger).Log("msg", "ignoring partially written record at the end of the wal segment", "path", path)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var rec walRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			level.Warn(util_log.Logger).Log("msg", "ignoring corrupted record and the rest of the wal segment", "path", path, "err", err)
+			return nil
+		}
+		fn(rec)
+	}
+}
@@ -44,9 +44,27 @@ var queryTests = []struct {
 				}
 			],
 			"stats" : {
+				"cache": {
+					"chunk": {
+						"entriesRequested": 0,
+						"entriesFound": 0
+					},
+					"index": {
+						"entriesRequested": 0,
+						"entriesFound": 0
+					},
+					"result": {
+						"entriesRequested": 0,
+						"entriesFound": 0
+					}
+				},
 				"ingester" : {
 					"store": {
 						"chunksDownloadTime": 0,
+						"index": {
+							"totalQueries": 0,
+							"lookupTime": 0
+						},
 						"totalChunksRef": 0,
 						"totalChunksDownloaded": 0,
 						"chunk" :{
@@ -66,6 +84,10 @@ var queryTests = []struct {
 				"querier": {
 					"store": {
 						"chunksDownloadTime": 0,
+						"index": {
+							"totalQueries": 0,
+							"lookupTime": 0
+						},
 						"totalChunksRef": 0,
 						"totalChunksDownloaded": 0,
 						"chunk" :{
@@ -84,7 +106,9 @@ var queryTests = []struct {
 					"linesProcessedPerSecond": 0,
 					"queueTime": 0,
 					"totalBytesProcessed":0,
-					"totalLinesProcessed":0
+					"totalLinesProcessed":0,
+					"splits": 0,
+					"shards": 0
 				}
 			}
 		}`,
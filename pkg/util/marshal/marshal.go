@@ -46,6 +46,17 @@ func WriteLabelResponseJSON(l logproto.LabelResponse, w io.Writer) error {
 	return jsoniter.NewEncoder(w).Encode(v1Response)
 }
 
+// WriteQueryPlanResponseJSON marshals a loghttp.QueryPlan to v1 loghttp JSON and then
+// writes it to the provided io.Writer.
+func WriteQueryPlanResponseJSON(p loghttp.QueryPlan, w io.Writer) error {
+	v1Response := loghttp.QueryPlanResponse{
+		Status: "success",
+		Data:   p,
+	}
+
+	return jsoniter.NewEncoder(w).Encode(v1Response)
+}
+
 // WebsocketWriter knows how to write message to a websocket connection.
 type WebsocketWriter interface {
 	WriteMessage(int, []byte) error
@@ -50,9 +50,27 @@ var queryTests = []struct {
 					}
 				],
 				"stats" : {
+					"cache": {
+						"chunk": {
+							"entriesRequested": 0,
+							"entriesFound": 0
+						},
+						"index": {
+							"entriesRequested": 0,
+							"entriesFound": 0
+						},
+						"result": {
+							"entriesRequested": 0,
+							"entriesFound": 0
+						}
+					},
 					"ingester" : {
 						"store": {
 							"chunksDownloadTime": 0,
+							"index": {
+								"totalQueries": 0,
+								"lookupTime": 0
+							},
 							"totalChunksRef": 0,
 							"totalChunksDownloaded": 0,
 							"chunk" :{
@@ -72,6 +90,10 @@ var queryTests = []struct {
 					"querier": {
 						"store": {
 							"chunksDownloadTime": 0,
+							"index": {
+								"totalQueries": 0,
+								"lookupTime": 0
+							},
 							"totalChunksRef": 0,
 							"totalChunksDownloaded": 0,
 							"chunk" :{
@@ -90,7 +112,9 @@ var queryTests = []struct {
 						"linesProcessedPerSecond": 0,
 						"queueTime": 0,
 						"totalBytesProcessed":0,
-						"totalLinesProcessed":0
+						"totalLinesProcessed":0,
+						"splits": 0,
+						"shards": 0
 					}
 				}
 			}
@@ -158,9 +182,27 @@ var queryTests = []struct {
 				}
 			  ],
 			  "stats" : {
+				"cache": {
+					"chunk": {
+						"entriesRequested": 0,
+						"entriesFound": 0
+					},
+					"index": {
+						"entriesRequested": 0,
+						"entriesFound": 0
+					},
+					"result": {
+						"entriesRequested": 0,
+						"entriesFound": 0
+					}
+				},
 				"ingester" : {
 					"store": {
 						"chunksDownloadTime": 0,
+						"index": {
+							"totalQueries": 0,
+							"lookupTime": 0
+						},
 						"totalChunksRef": 0,
 						"totalChunksDownloaded": 0,
 						"chunk" :{
@@ -180,6 +222,10 @@ var queryTests = []struct {
 				"querier": {
 					"store": {
 						"chunksDownloadTime": 0,
+						"index": {
+							"totalQueries": 0,
+							"lookupTime": 0
+						},
 						"totalChunksRef": 0,
 						"totalChunksDownloaded": 0,
 						"chunk" :{
@@ -198,7 +244,9 @@ var queryTests = []struct {
 					"linesProcessedPerSecond": 0,
 					"queueTime": 0,
 					"totalBytesProcessed":0,
-					"totalLinesProcessed":0
+					"totalLinesProcessed":0,
+					"splits": 0,
+					"shards": 0
 				}
 			  }
 			},
@@ -283,9 +331,27 @@ var queryTests = []struct {
 				}
 			  ],
 			  "stats" : {
+				"cache": {
+					"chunk": {
+						"entriesRequested": 0,
+						"entriesFound": 0
+					},
+					"index": {
+						"entriesRequested": 0,
+						"entriesFound": 0
+					},
+					"result": {
+						"entriesRequested": 0,
+						"entriesFound": 0
+					}
+				},
 				"ingester" : {
 					"store": {
 						"chunksDownloadTime": 0,
+						"index": {
+							"totalQueries": 0,
+							"lookupTime": 0
+						},
 						"totalChunksRef": 0,
 						"totalChunksDownloaded": 0,
 						"chunk" :{
@@ -305,6 +371,10 @@ var queryTests = []struct {
 				"querier": {
 					"store": {
 						"chunksDownloadTime": 0,
+						"index": {
+							"totalQueries": 0,
+							"lookupTime": 0
+						},
 						"totalChunksRef": 0,
 						"totalChunksDownloaded": 0,
 						"chunk" :{
@@ -323,7 +393,9 @@ var queryTests = []struct {
 					"linesProcessedPerSecond": 0,
 					"queueTime": 0,
 					"totalBytesProcessed":0,
-					"totalLinesProcessed":0
+					"totalLinesProcessed":0,
+					"splits": 0,
+					"shards": 0
 				}
 			  }
 			},
@@ -395,6 +467,26 @@ var tailTests = []struct {
 			]
 		}`,
 	},
+	{
+		legacy.TailResponse{
+			Checkpoints: []legacy.TailCheckpoint{
+				{
+					Timestamp: time.Unix(0, 123456789032345),
+					Labels:    "{test=\"test\"}",
+				},
+			},
+		},
+		`{
+			"checkpoints": [
+				{
+					"timestamp": "123456789032345",
+					"labels": {
+						"test": "test"
+					}
+				}
+			]
+		}`,
+	},
 }
 
 func Test_WriteQueryResponseJSON(t *testing.T) {
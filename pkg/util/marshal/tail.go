@@ -12,6 +12,9 @@ func NewTailResponse(r legacy.TailResponse) (loghttp.TailResponse, error) {
 		Streams:        make([]loghttp.Stream, len(r.Streams)),
 		DroppedStreams: make([]loghttp.DroppedStream, len(r.DroppedEntries)),
 	}
+	if len(r.Checkpoints) > 0 {
+		ret.Checkpoints = make([]loghttp.TailCheckpoint, len(r.Checkpoints))
+	}
 
 	for i, s := range r.Streams {
 		ret.Streams[i], err = NewStream(s)
@@ -28,6 +31,17 @@ func NewTailResponse(r legacy.TailResponse) (loghttp.TailResponse, error) {
 		}
 	}
 
+	for i, c := range r.Checkpoints {
+		l, err := NewLabelSet(c.Labels)
+		if err != nil {
+			return loghttp.TailResponse{}, err
+		}
+		ret.Checkpoints[i] = loghttp.TailCheckpoint{
+			Timestamp: c.Timestamp,
+			Labels:    l,
+		}
+	}
+
 	return ret, nil
 }
 
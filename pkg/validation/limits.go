@@ -111,6 +111,10 @@ type Limits struct {
 	RetentionPeriod model.Duration    `yaml:"retention_period" json:"retention_period"`
 	StreamRetention []StreamRetention `yaml:"retention_stream,omitempty" json:"retention_stream,omitempty"`
 
+	// Per tenant retention rules managed through the compactor API.
+	RetentionRulesMaxCount  int            `yaml:"retention_rules_max_count" json:"retention_rules_max_count"`
+	RetentionRulesMaxPeriod model.Duration `yaml:"retention_rules_max_period" json:"retention_rules_max_period"`
+
 	// Config for overrides, convenient if it goes here.
 	PerTenantOverrideConfig string         `yaml:"per_tenant_override_config" json:"per_tenant_override_config"`
 	PerTenantOverridePeriod model.Duration `yaml:"per_tenant_override_period" json:"per_tenant_override_period"`
@@ -181,6 +185,9 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.StringVar(&l.PerTenantOverrideConfig, "limits.per-user-override-config", "", "File name of per-user overrides.")
 	_ = l.RetentionPeriod.Set("744h")
 	f.Var(&l.RetentionPeriod, "store.retention", "How long before chunks will be deleted from the store. (requires compactor retention enabled).")
+	f.IntVar(&l.RetentionRulesMaxCount, "store.retention-rules-max-count", 0, "Maximum number of per-stream retention rules a tenant can define through the compactor retention rules API. 0 to disable the API for the tenant.")
+	_ = l.RetentionRulesMaxPeriod.Set("0s")
+	f.Var(&l.RetentionRulesMaxPeriod, "store.retention-rules-max-period", "Maximum retention period a tenant can set in a retention rule defined through the compactor retention rules API. 0 means the rules can not exceed the tenant retention period, or are not capped when the tenant retention period is 0.")
 
 	_ = l.PerTenantOverridePeriod.Set("10s")
 	f.Var(&l.PerTenantOverridePeriod, "limits.per-user-override-period", "Period with this to reload the overrides.")
@@ -514,6 +521,21 @@ func (o *Overrides) StreamRetention(userID string) []StreamRetention {
 	return o.getOverridesForUser(userID).StreamRetention
 }
 
+// RetentionRulesMaxCount returns the maximum number of retention rules a given user can define through the API.
+func (o *Overrides) RetentionRulesMaxCount(userID string) int {
+	return o.getOverridesForUser(userID).RetentionRulesMaxCount
+}
+
+// RetentionRulesMaxPeriod returns the maximum period of retention rules a given user can define through the API.
+// When not set, the rules are capped to the retention period of the user, 0 meaning no cap.
+func (o *Overrides) RetentionRulesMaxPeriod(userID string) time.Duration {
+	overrides := o.getOverridesForUser(userID)
+	if overrides.RetentionRulesMaxPeriod == 0 {
+		return time.Duration(overrides.RetentionPeriod)
+	}
+	return time.Duration(overrides.RetentionRulesMaxPeriod)
+}
+
 func (o *Overrides) UnorderedWrites(userID string) bool {
 	return o.getOverridesForUser(userID).UnorderedWrites
 }
@@ -32,6 +32,7 @@ type ProxyConfig struct {
 	UseRelativeError               bool
 	PassThroughNonRegisteredRoutes bool
 	SkipRecentSamples              time.Duration
+	ShadowSamplePercentage         float64
 }
 
 func (cfg *ProxyConfig) RegisterFlags(f *flag.FlagSet) {
@@ -43,6 +44,7 @@ func (cfg *ProxyConfig) RegisterFlags(f *flag.FlagSet) {
 	f.Float64Var(&cfg.ValueComparisonTolerance, "proxy.value-comparison-tolerance", 0.000001, "The tolerance to apply when comparing floating point values in the responses. 0 to disable tolerance and require exact match (not recommended).")
 	f.BoolVar(&cfg.UseRelativeError, "proxy.compare-use-relative-error", false, "Use relative error tolerance when comparing floating point values.")
 	f.DurationVar(&cfg.SkipRecentSamples, "proxy.compare-skip-recent-samples", 60*time.Second, "The window from now to skip comparing samples. 0 to disable.")
+	f.Float64Var(&cfg.ShadowSamplePercentage, "proxy.shadow-sample-percentage", 100, "The percentage of requests also sent to the secondary backends, between 0 and 100. The other requests are only sent to the preferred backend. Requires a preferred backend.")
 	f.BoolVar(&cfg.PassThroughNonRegisteredRoutes, "proxy.passthrough-non-registered-routes", false, "Passthrough requests for non-registered routes to preferred backend.")
 }
 
@@ -59,6 +61,7 @@ type Proxy struct {
 	logger   log.Logger
 	metrics  *ProxyMetrics
 	routes   []Route
+	report   *ComparisonReport
 
 	// The HTTP server used to run the proxy service.
 	srv         *http.Server
@@ -77,11 +80,16 @@ func NewProxy(cfg ProxyConfig, logger log.Logger, routes []Route, registerer pro
 		return nil, fmt.Errorf("when enabling passthrough for non-registered routes -backend.preferred flag must be set to hostname of backend where those requests needs to be passed")
 	}
 
+	if cfg.ShadowSamplePercentage < 0 || cfg.ShadowSamplePercentage > 100 {
+		return nil, fmt.Errorf("-proxy.shadow-sample-percentage must be between 0 and 100")
+	}
+
 	p := &Proxy{
 		cfg:     cfg,
 		logger:  logger,
 		metrics: NewProxyMetrics(registerer),
 		routes:  routes,
+		report:  NewComparisonReport(),
 	}
 
 	// Parse the backend endpoints (comma separated).
@@ -159,13 +167,18 @@ func (p *Proxy) Start() error {
 		w.WriteHeader(http.StatusOK)
 	}))
 
+	// Responses comparison report endpoint.
+	if p.cfg.CompareResponses {
+		router.Path("/report").Methods("GET").Handler(p.report)
+	}
+
 	// register routes
 	for _, route := range p.routes {
 		var comparator ResponsesComparator
 		if p.cfg.CompareResponses {
 			comparator = route.ResponseComparator
 		}
-		router.Path(route.Path).Methods(route.Methods...).Handler(NewProxyEndpoint(p.backends, route.RouteName, p.metrics, p.logger, comparator))
+		router.Path(route.Path).Methods(route.Methods...).Handler(NewProxyEndpoint(p.backends, route.RouteName, p.metrics, p.logger, comparator, p.cfg.ShadowSamplePercentage, p.report))
 	}
 
 	if p.cfg.PassThroughNonRegisteredRoutes {
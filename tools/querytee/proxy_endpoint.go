@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math/rand"
 	"net/http"
 	"strconv"
 	"sync"
@@ -25,6 +26,10 @@ type ProxyEndpoint struct {
 	metrics    *ProxyMetrics
 	logger     log.Logger
 	comparator ResponsesComparator
+	report     *ComparisonReport
+
+	// The percentage of requests sent to the non preferred backends.
+	shadowSamplePercentage float64
 
 	// Whether for this endpoint there's a preferred backend configured.
 	hasPreferredBackend bool
@@ -33,7 +38,7 @@ type ProxyEndpoint struct {
 	routeName string
 }
 
-func NewProxyEndpoint(backends []*ProxyBackend, routeName string, metrics *ProxyMetrics, logger log.Logger, comparator ResponsesComparator) *ProxyEndpoint {
+func NewProxyEndpoint(backends []*ProxyBackend, routeName string, metrics *ProxyMetrics, logger log.Logger, comparator ResponsesComparator, shadowSamplePercentage float64, report *ComparisonReport) *ProxyEndpoint {
 	hasPreferredBackend := false
 	for _, backend := range backends {
 		if backend.preferred {
@@ -43,12 +48,14 @@ func NewProxyEndpoint(backends []*ProxyBackend, routeName string, metrics *Proxy
 	}
 
 	return &ProxyEndpoint{
-		backends:            backends,
-		routeName:           routeName,
-		metrics:             metrics,
-		logger:              logger,
-		comparator:          comparator,
-		hasPreferredBackend: hasPreferredBackend,
+		backends:               backends,
+		routeName:              routeName,
+		metrics:                metrics,
+		logger:                 logger,
+		comparator:             comparator,
+		report:                 report,
+		shadowSamplePercentage: shadowSamplePercentage,
+		hasPreferredBackend:    hasPreferredBackend,
 	}
 }
 
@@ -101,8 +108,18 @@ func (p *ProxyEndpoint) executeBackendRequests(r *http.Request, resCh chan *back
 
 	level.Debug(p.logger).Log("msg", "Received request", "path", r.URL.Path, "query", query)
 
-	wg.Add(len(p.backends))
-	for _, b := range p.backends {
+	// Only a sample of the requests is shadowed to the non preferred backends.
+	backends := p.backends
+	if p.hasPreferredBackend && len(backends) > 1 {
+		if p.shadowed() {
+			p.metrics.requestsShadowedTotal.WithLabelValues(r.Method, p.routeName).Inc()
+		} else {
+			backends = p.preferredBackends()
+		}
+	}
+
+	wg.Add(len(backends))
+	for _, b := range backends {
 		b := b
 
 		go func() {
@@ -150,7 +167,7 @@ func (p *ProxyEndpoint) executeBackendRequests(r *http.Request, resCh chan *back
 	close(resCh)
 
 	// Compare responses.
-	if p.comparator != nil {
+	if p.comparator != nil && len(responses) == 2 {
 		expectedResponse := responses[0]
 		actualResponse := responses[1]
 		if responses[1].backend.preferred {
@@ -166,7 +183,32 @@ func (p *ProxyEndpoint) executeBackendRequests(r *http.Request, resCh chan *back
 		}
 
 		p.metrics.responsesComparedTotal.WithLabelValues(p.routeName, result).Inc()
+		if p.report != nil {
+			p.report.Record(p.routeName, queryParam(r), err)
+		}
+	}
+}
+
+// queryParam returns the query of the request, either from the URL or the parsed form.
+func queryParam(r *http.Request) string {
+	if r.Form != nil {
+		return r.Form.Get("query")
+	}
+	return r.URL.Query().Get("query")
+}
+
+// shadowed returns whether the request should also be sent to the non preferred backends.
+func (p *ProxyEndpoint) shadowed() bool {
+	return p.shadowSamplePercentage >= 100 || rand.Float64()*100 < p.shadowSamplePercentage
+}
+
+func (p *ProxyEndpoint) preferredBackends() []*ProxyBackend {
+	for _, b := range p.backends {
+		if b.preferred {
+			return []*ProxyBackend{b}
+		}
 	}
+	return p.backends
 }
 
 func (p *ProxyEndpoint) waitBackendResponseForDownstream(resCh chan *backendResponse) *backendResponse {
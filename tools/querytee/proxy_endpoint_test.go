@@ -12,6 +12,7 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/atomic"
@@ -95,7 +96,7 @@ func Test_ProxyEndpoint_waitBackendResponseForDownstream(t *testing.T) {
 		testData := testData
 
 		t.Run(testName, func(t *testing.T) {
-			endpoint := NewProxyEndpoint(testData.backends, "test", NewProxyMetrics(nil), log.NewNopLogger(), nil)
+			endpoint := NewProxyEndpoint(testData.backends, "test", NewProxyMetrics(nil), log.NewNopLogger(), nil, 100, nil)
 
 			// Send the responses from a dedicated goroutine.
 			resCh := make(chan *backendResponse)
@@ -139,7 +140,7 @@ func Test_ProxyEndpoint_Requests(t *testing.T) {
 		NewProxyBackend("backend-1", backendURL1, time.Second, true),
 		NewProxyBackend("backend-2", backendURL2, time.Second, false),
 	}
-	endpoint := NewProxyEndpoint(backends, "test", NewProxyMetrics(nil), log.NewNopLogger(), nil)
+	endpoint := NewProxyEndpoint(backends, "test", NewProxyMetrics(nil), log.NewNopLogger(), nil, 100, nil)
 
 	for _, tc := range []struct {
 		name    string
@@ -222,6 +223,83 @@ func Test_ProxyEndpoint_Requests(t *testing.T) {
 	}
 }
 
+type compareFunc func(expected, actual []byte) error
+
+func (f compareFunc) Compare(expected, actual []byte) error {
+	return f(expected, actual)
+}
+
+func Test_ProxyEndpoint_ShadowSampling(t *testing.T) {
+	var preferredCount, secondaryCount atomic.Uint64
+
+	preferred := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		preferredCount.Inc()
+		_, _ = w.Write([]byte("expected"))
+	}))
+	defer preferred.Close()
+	preferredURL, err := url.Parse(preferred.URL)
+	require.NoError(t, err)
+
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secondaryCount.Inc()
+		_, _ = w.Write([]byte("actual"))
+	}))
+	defer secondary.Close()
+	secondaryURL, err := url.Parse(secondary.URL)
+	require.NoError(t, err)
+
+	comparator := compareFunc(func(expected, actual []byte) error {
+		if string(expected) != string(actual) {
+			return errors.Errorf("expected %s but got %s", expected, actual)
+		}
+		return nil
+	})
+
+	for _, tc := range []struct {
+		name              string
+		percentage        float64
+		noPreferred       bool
+		expectedSecondary uint64
+		expectedShadowed  float64
+		expectedCompared  int64
+	}{
+		{name: "none sampled", percentage: 0, expectedSecondary: 0, expectedShadowed: 0, expectedCompared: 0},
+		{name: "all sampled", percentage: 100, expectedSecondary: 1, expectedShadowed: 1, expectedCompared: 1},
+		// Without a preferred backend the requests are always sent to all the backends.
+		{name: "no preferred backend", percentage: 0, noPreferred: true, expectedSecondary: 1, expectedShadowed: 0, expectedCompared: 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			preferredCount.Store(0)
+			secondaryCount.Store(0)
+			backends := []*ProxyBackend{
+				NewProxyBackend("backend-1", preferredURL, time.Second, !tc.noPreferred),
+				NewProxyBackend("backend-2", secondaryURL, time.Second, false),
+			}
+			metrics := NewProxyMetrics(nil)
+			report := NewComparisonReport()
+			endpoint := NewProxyEndpoint(backends, "test", metrics, log.NewNopLogger(), comparator, tc.percentage, report)
+
+			r, err := http.NewRequest("GET", `http://test/loki/api/v1/query?query={app="foo"}`, nil)
+			require.NoError(t, err)
+			endpoint.executeBackendRequests(r, make(chan *backendResponse, len(backends)))
+
+			require.Equal(t, uint64(1), preferredCount.Load())
+			require.Equal(t, tc.expectedSecondary, secondaryCount.Load())
+			require.Equal(t, tc.expectedShadowed, testutil.ToFloat64(metrics.requestsShadowedTotal.WithLabelValues("GET", "test")))
+			res := report.Report()
+			require.Equal(t, tc.expectedCompared, res.Compared)
+			require.Equal(t, tc.expectedCompared, res.Mismatches)
+			if tc.expectedCompared > 0 {
+				require.Equal(t, `{app="foo"}`, res.Queries[0].Query)
+				require.NotNil(t, res.Queries[0].LastMismatch)
+			}
+			if !tc.noPreferred && tc.expectedCompared > 0 {
+				require.Equal(t, "expected expected but got actual", res.Queries[0].LastError)
+			}
+		})
+	}
+}
+
 func Test_backendResponse_succeeded(t *testing.T) {
 	tests := map[string]struct {
 		resStatus int
@@ -154,10 +154,11 @@ func Test_Proxy_RequestsForwarding(t *testing.T) {
 
 			// Start the proxy.
 			cfg := ProxyConfig{
-				BackendEndpoints:   strings.Join(backendURLs, ","),
-				PreferredBackend:   strconv.Itoa(testData.preferredBackendIdx),
-				ServerServicePort:  0,
-				BackendReadTimeout: time.Second,
+				BackendEndpoints:       strings.Join(backendURLs, ","),
+				PreferredBackend:       strconv.Itoa(testData.preferredBackendIdx),
+				ServerServicePort:      0,
+				BackendReadTimeout:     time.Second,
+				ShadowSamplePercentage: 100,
 			}
 
 			if len(backendURLs) == 2 {
@@ -311,6 +312,7 @@ func TestProxy_Passthrough(t *testing.T) {
 				ServerServicePort:              0,
 				BackendReadTimeout:             time.Second,
 				PassThroughNonRegisteredRoutes: true,
+				ShadowSamplePercentage:         100,
 			}
 
 			p, err := NewProxy(cfg, log.NewNopLogger(), testRoutes, nil)
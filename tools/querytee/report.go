@@ -0,0 +1,133 @@
+package querytee
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// maxReportedQueries is the maximum number of query fingerprints tracked by the
+// comparison report, to bound its memory usage.
+const maxReportedQueries = 1000
+
+// ComparisonReport summarises the results of the responses comparison by query fingerprint.
+type ComparisonReport struct {
+	mtx     sync.Mutex
+	started time.Time
+	queries map[string]*QueryComparison
+	dropped int64
+}
+
+// QueryComparison is the summary of the comparisons of a single query fingerprint.
+type QueryComparison struct {
+	Fingerprint  string     `json:"fingerprint"`
+	Route        string     `json:"route"`
+	Query        string     `json:"query"`
+	Compared     int64      `json:"compared"`
+	Mismatches   int64      `json:"mismatches"`
+	LastError    string     `json:"lastError,omitempty"`
+	LastMismatch *time.Time `json:"lastMismatch,omitempty"`
+}
+
+// ComparisonReportResponse is the response of the report endpoint.
+type ComparisonReportResponse struct {
+	Since      time.Time          `json:"since"`
+	Compared   int64              `json:"compared"`
+	Mismatches int64              `json:"mismatches"`
+	Dropped    int64              `json:"dropped"`
+	Queries    []*QueryComparison `json:"queries"`
+}
+
+func NewComparisonReport() *ComparisonReport {
+	return &ComparisonReport{
+		started: time.Now(),
+		queries: map[string]*QueryComparison{},
+	}
+}
+
+// Record records the result of a comparison for the given route and query.
+func (r *ComparisonReport) Record(route, query string, err error) {
+	fp := queryFingerprint(route, query)
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	q, ok := r.queries[fp]
+	if !ok {
+		if len(r.queries) >= maxReportedQueries {
+			r.dropped++
+			return
+		}
+		q = &QueryComparison{Fingerprint: fp, Route: route, Query: query}
+		r.queries[fp] = q
+	}
+
+	q.Compared++
+	if err != nil {
+		q.Mismatches++
+		now := time.Now()
+		q.LastError = err.Error()
+		q.LastMismatch = &now
+	}
+}
+
+// Report returns a snapshot of the report, with the queries having the most mismatches first.
+func (r *ComparisonReport) Report() ComparisonReportResponse {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	res := ComparisonReportResponse{
+		Since:   r.started,
+		Dropped: r.dropped,
+		Queries: make([]*QueryComparison, 0, len(r.queries)),
+	}
+	for _, q := range r.queries {
+		c := *q
+		res.Compared += c.Compared
+		res.Mismatches += c.Mismatches
+		res.Queries = append(res.Queries, &c)
+	}
+
+	sort.Slice(res.Queries, func(i, j int) bool {
+		if res.Queries[i].Mismatches != res.Queries[j].Mismatches {
+			return res.Queries[i].Mismatches > res.Queries[j].Mismatches
+		}
+		if res.Queries[i].Compared != res.Queries[j].Compared {
+			return res.Queries[i].Compared > res.Queries[j].Compared
+		}
+		return res.Queries[i].Fingerprint < res.Queries[j].Fingerprint
+	})
+	return res
+}
+
+// ServeHTTP serves the report as JSON. Queries without mismatches are
+// omitted unless the all parameter is set.
+func (r *ComparisonReport) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	res := r.Report()
+	if req.URL.Query().Get("all") != "true" {
+		queries := res.Queries[:0]
+		for _, q := range res.Queries {
+			if q.Mismatches > 0 {
+				queries = append(queries, q)
+			}
+		}
+		res.Queries = queries
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// queryFingerprint identifies a query regardless of its time range and of
+// the whitespaces in it, so that the same query run over time is reported once.
+func queryFingerprint(route, query string) string {
+	return fmt.Sprintf("%016x", xxhash.Sum64String(route+"\xff"+strings.Join(strings.Fields(query), " ")))
+}
@@ -0,0 +1,67 @@
+package querytee
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestComparisonReport(t *testing.T) {
+	report := NewComparisonReport()
+	report.Record("api_v1_query_range", `{app="foo"}`, nil)
+	report.Record("api_v1_query_range", `{app="bar"}`, errors.New("expected 1 streams but got 0"))
+	report.Record("api_v1_query_range", "{app=\"bar\"}\n  |= \"error\"", nil)
+	report.Record("api_v1_query_range", `{app="bar"} |= "error"`, errors.New("expected 2 values for stream {app=\"bar\"} but got 1"))
+	report.Record("api_v1_query", `{app="bar"} |= "error"`, nil)
+
+	res := report.Report()
+	require.Equal(t, int64(5), res.Compared)
+	require.Equal(t, int64(2), res.Mismatches)
+	require.Len(t, res.Queries, 4)
+
+	// The queries with the most mismatches come first, and queries only
+	// differing by their whitespaces share the same fingerprint.
+	require.Equal(t, "api_v1_query_range", res.Queries[0].Route)
+	require.Equal(t, "{app=\"bar\"}\n  |= \"error\"", res.Queries[0].Query)
+	require.Equal(t, int64(2), res.Queries[0].Compared)
+	require.Equal(t, int64(1), res.Queries[0].Mismatches)
+	require.Equal(t, "expected 2 values for stream {app=\"bar\"} but got 1", res.Queries[0].LastError)
+	require.Equal(t, `{app="bar"}`, res.Queries[1].Query)
+	require.Equal(t, int64(1), res.Queries[1].Mismatches)
+	require.Equal(t, int64(0), res.Queries[2].Mismatches)
+
+	w := httptest.NewRecorder()
+	report.ServeHTTP(w, httptest.NewRequest("GET", "/report", nil))
+	var served ComparisonReportResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &served))
+	require.Equal(t, int64(5), served.Compared)
+	require.Len(t, served.Queries, 2)
+
+	w = httptest.NewRecorder()
+	report.ServeHTTP(w, httptest.NewRequest("GET", "/report?all=true", nil))
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &served))
+	require.Len(t, served.Queries, 4)
+
+	// The queries which never mismatched have no last mismatch.
+	var raw struct {
+		Queries []map[string]interface{} `json:"queries"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &raw))
+	require.Contains(t, raw.Queries[0], "lastMismatch")
+	require.NotContains(t, raw.Queries[3], "lastMismatch")
+}
+
+func TestComparisonReport_MaxQueries(t *testing.T) {
+	report := NewComparisonReport()
+	for i := 0; i < maxReportedQueries+10; i++ {
+		report.Record("api_v1_query", fmt.Sprintf(`{app="%d"}`, i), nil)
+	}
+
+	res := report.Report()
+	require.Len(t, res.Queries, maxReportedQueries)
+	require.Equal(t, int64(10), res.Dropped)
+}